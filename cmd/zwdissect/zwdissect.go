@@ -0,0 +1,110 @@
+// Command zwdissect prints annotated ZWave serial API frames of recorded
+// traces or hex dumps, to read field logs.
+//
+// Usage:
+//
+//	zwdissect [-long-range] [file ...]
+//
+// Files are read from stdin if none are given. Every file is either a trace
+// recorded with the trace package, or a hex dump with a frame or a part of a
+// frame per line. Hex dump lines starting with > are written to the
+// controller, and lines starting with < are read from the controller. Text
+// after a # is ignored:
+//
+//	> 01 0a 00 13 02 03 25 01 ff 25 0b 12 # ZWSendData
+//	< 06
+//
+// Every frame is printed with its direction, MessageType and decoded fields.
+// Command class frames of ApplicationCommand and ZWSendData are decoded with
+// the node report decoders, and the commandclass codecs of the highest version
+// which decodes them.
+//
+// Node IDs are 8 bit, or 16 bit with -long-range, until the trace has a
+// successful SerialAPISetup SetNodeIDType request, or the controller restarts.
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"flag"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"io"
+	"os"
+)
+
+// run dissects the files, or stdin if there are none
+func run(paths []string, stdin io.Reader, out io.Writer, nodeIDType uint8) error {
+	if len(paths) == 0 {
+		return dissectInput(stdin, out, nodeIDType)
+	}
+
+	for i, path := range paths {
+		if len(paths) > 1 {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "# %s\n", path)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+
+		err = dissectInput(file, out, nodeIDType)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// dissectInput dissects all records of a trace or hex dump. Records read
+// before an error are still dissected.
+func dissectInput(r io.Reader, out io.Writer, nodeIDType uint8) error {
+	records, err := readRecords(r)
+
+	dissector := dissector{out: out, nodeIDType: nodeIDType}
+	for _, record := range records {
+		dissector.dissect(record)
+	}
+
+	return err
+}
+
+func main() {
+	longRange := flag.Bool("long-range", false,
+		"Decode 16 bit node IDs of Long Range controllers from the start")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: zwdissect [-long-range] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	nodeIDType := message.NodeIDType8Bit
+	if *longRange {
+		nodeIDType = message.NodeIDType16Bit
+	}
+
+	if err := run(flag.Args(), os.Stdin, os.Stdout, nodeIDType); err != nil {
+		fmt.Fprintf(os.Stderr, "zwdissect: %v\n", err)
+		os.Exit(1)
+	}
+}
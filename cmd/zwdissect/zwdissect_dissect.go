@@ -0,0 +1,267 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"github.com/cybojanek/gozwave/trace"
+	"io"
+	"strings"
+)
+
+// Indentation of the command class lines of a frame
+const detailIndent = "    "
+
+// dissector prints the frames of records. The bytes of each direction are
+// parsed separately, since frames of both directions may be interleaved.
+type dissector struct {
+	out               io.Writer
+	nodeIDType        uint8                    // Node ID type of frames, set by the trace
+	pendingNodeIDType uint8                    // Node ID type of a SetNodeIDType request, or 0
+	parsers           map[uint8]*packet.Parser // Parser of each direction
+	inFrame           map[uint8]bool           // Parser of the direction is within a frame
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// dissect parses the bytes of a record, and prints every frame
+func (dissector *dissector) dissect(record *trace.Record) {
+	if dissector.parsers == nil {
+		dissector.parsers = make(map[uint8]*packet.Parser)
+		dissector.inFrame = make(map[uint8]bool)
+	}
+
+	parser := dissector.parsers[record.Direction]
+	if parser == nil {
+		parser = &packet.Parser{}
+		dissector.parsers[record.Direction] = parser
+	}
+
+	for _, b := range record.Data {
+		// The host terminates frames with a newline
+		if b == '\n' && !dissector.inFrame[record.Direction] {
+			continue
+		}
+
+		p, err := parser.Parse(b)
+		dissector.inFrame[record.Direction] = p == nil && err == nil
+
+		if err != nil {
+			dissector.print(record, fmt.Sprintf("ERROR %v", err), nil)
+		} else if p != nil {
+			summary, details := dissector.describe(record.Direction, p)
+			dissector.print(record, summary, details)
+		}
+	}
+}
+
+// print a line of a frame, followed by its indented details
+func (dissector *dissector) print(record *trace.Record, summary string, details []string) {
+	var prefix string
+	if !record.Time.IsZero() {
+		prefix = record.Time.Format("15:04:05.000000") + " "
+	}
+
+	switch record.Direction {
+	case trace.DirectionWrite:
+		prefix += "->"
+	case trace.DirectionRead:
+		prefix += "<-"
+	default:
+		prefix += "??"
+	}
+
+	fmt.Fprintf(dissector.out, "%s %s\n", prefix, summary)
+	for _, detail := range details {
+		fmt.Fprintf(dissector.out, "%s%s\n", detailIndent, detail)
+	}
+}
+
+// describe a frame with a summary, and details of its command class frame
+func (dissector *dissector) describe(direction uint8, p *packet.Packet) (string, []string) {
+	switch p.Preamble {
+	case packet.PacketPreambleACK:
+		return "ACK", nil
+	case packet.PacketPreambleNAK:
+		return "NAK", nil
+	case packet.PacketPreambleCAN:
+		return "CAN", nil
+	}
+
+	summary := "Request " + message.MessageTypeName(p.MessageType)
+	if p.PacketType == packet.PacketTypeResponse {
+		summary = "Response " + message.MessageTypeName(p.MessageType)
+	}
+
+	decoded, err := dissector.decode(direction, p)
+	if err != nil {
+		return summary + " body: " + formatBytes(p.Body),
+			[]string{fmt.Sprintf("error: %v", err)}
+	}
+
+	// Follow the node ID type of the controller
+	switch decoded := decoded.(type) {
+	case *message.SerialAPISetupArgs:
+		// The node ID type changes after a successful response
+		if decoded.Command == message.SerialAPISetupCommandSetNodeIDType {
+			dissector.pendingNodeIDType = decoded.NodeIDType
+		}
+	case *message.SerialAPISetup:
+		if decoded.Command == message.SerialAPISetupCommandSetNodeIDType {
+			if decoded.Success && dissector.pendingNodeIDType != 0 {
+				dissector.nodeIDType = dissector.pendingNodeIDType
+			}
+			dissector.pendingNodeIDType = 0
+		}
+	case *message.SerialAPIStarted:
+		// The controller uses 8 bit node IDs after it starts
+		dissector.nodeIDType = message.NodeIDType8Bit
+		dissector.pendingNodeIDType = 0
+	}
+
+	switch decoded := decoded.(type) {
+	case *message.ZWSendDataArgs:
+		summary += fmt.Sprintf(" node: %d transmit options: 0x%02x", decoded.NodeID,
+			decoded.TransmitOptions)
+		if decoded.CallbackID != 0 {
+			summary += fmt.Sprintf(" callback: 0x%02x", decoded.CallbackID)
+		}
+		frame := append([]uint8{decoded.CommandClass}, decoded.Payload...)
+		return summary, describeCommand(decoded.NodeID, frame)
+	case *message.ApplicationCommand:
+		summary += fmt.Sprintf(" node: %d status: 0x%02x", decoded.NodeID, decoded.Status)
+		return summary, describeCommand(decoded.NodeID, decoded.Body)
+	case *message.Unknown:
+		return summary + " body: " + formatBytes(decoded.Body), nil
+	default:
+		return summary + " " + formatFields(decoded), nil
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// decode a frame of the direction. Requests written by the host are decoded
+// as requests. Requests of an unknown direction are decoded as written, if
+// possible.
+func (dissector *dissector) decode(direction uint8, p *packet.Packet) (interface{}, error) {
+	if p.PacketType != packet.PacketTypeRequest || direction == trace.DirectionRead {
+		return message.Decode(p, dissector.nodeIDType)
+	}
+
+	decoded, err := message.DecodeRequest(p, dissector.nodeIDType)
+	if direction == trace.DirectionWrite {
+		return decoded, err
+	}
+
+	if _, ok := decoded.(*message.Unknown); ok || err != nil {
+		return message.Decode(p, dissector.nodeIDType)
+	}
+	return decoded, nil
+}
+
+// describeCommand decodes a command class frame with the commandclass codecs,
+// and the node report decoders
+func describeCommand(nodeID uint16, frame []uint8) []string {
+	if len(frame) < 1 {
+		return []string{"command class: none"}
+	}
+
+	line := "command class: " + commandClassName(frame[0])
+	if len(frame) < 2 {
+		return []string{line}
+	}
+	line += fmt.Sprintf(" command: 0x%02x", frame[1])
+
+	var details []string
+	command, err := decodeCommand(frame)
+	if command != nil {
+		line += " " + command.Name()
+	}
+	switch {
+	case err != nil:
+		details = append(details, fmt.Sprintf("error: %v", err),
+			"data: "+formatBytes(frame[2:]))
+	case command != nil:
+		details = append(details, "fields: "+formatFields(command))
+	case len(frame) > 2:
+		details = append(details, "data: "+formatBytes(frame[2:]))
+	}
+
+	data := node.ApplicationCommandData{NodeID: nodeID}
+	data.Command.ClassID = frame[0]
+	data.Command.ID = frame[1]
+	data.Command.Data = frame[2:]
+
+	report, err := node.DecodeReport(node.MakeNode(nodeID, nil), &data)
+	if err != nil {
+		details = append(details, fmt.Sprintf("report error: %v", err))
+	} else if report != nil {
+		details = append(details, "report: "+formatFields(report.Value))
+	}
+
+	return append([]string{line}, details...)
+}
+
+// decodeCommand decodes a command class frame with the highest version of the
+// command, or the highest lower version which decodes it. Returns a nil
+// command if the command is unknown, or the error of the highest version if no
+// version decodes it.
+func decodeCommand(frame []uint8) (commandclass.Command, error) {
+	highest := commandclass.NewCommand(frame[0], frame[1], 0)
+	if highest == nil {
+		return nil, nil
+	}
+
+	err := highest.Decode(frame[2:])
+	if err == nil {
+		return highest, nil
+	}
+
+	for version := int(highest.Version()) - 1; version > 0; version-- {
+		command := commandclass.NewCommand(frame[0], frame[1], uint8(version))
+		if command != nil && command.Decode(frame[2:]) == nil {
+			return command, nil
+		}
+	}
+
+	return highest, err
+}
+
+// commandClassName returns the name and ID of a command class
+func commandClassName(commandClass uint8) string {
+	if name, ok := node.CommandClassNames[commandClass]; ok {
+		return fmt.Sprintf("%s (0x%02x)", name, commandClass)
+	}
+	return fmt.Sprintf("0x%02x", commandClass)
+}
+
+// formatFields formats the field names and values of a decoded value
+func formatFields(value interface{}) string {
+	return strings.TrimPrefix(fmt.Sprintf("%+v", value), "&")
+}
+
+// formatBytes formats bytes as hex
+func formatBytes(b []uint8) string {
+	if len(b) == 0 {
+		return "[]"
+	}
+	return fmt.Sprintf("[% x]", b)
+}
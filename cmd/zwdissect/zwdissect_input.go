@@ -0,0 +1,108 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/cybojanek/gozwave/trace"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Direction of hex dump lines without a > or < prefix
+const directionUnknown uint8 = 0x00
+
+// readRecords reads a trace, or a hex dump if the input has no trace header
+func readRecords(r io.Reader) ([]*trace.Record, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := trace.NewReader(bytes.NewReader(data)); err == nil {
+		return trace.ReadAll(bytes.NewReader(data))
+	}
+
+	return parseHexDump(data)
+}
+
+// parseHexDump parses every line of a hex dump into a record without a Time
+func parseHexDump(data []uint8) ([]*trace.Record, error) {
+	var records []*trace.Record
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		record, err := parseHexLine(scanner.Text())
+		if err != nil {
+			return records, fmt.Errorf("Line %d: %v", line, err)
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// parseHexLine parses a line of hex bytes, which are separated by spaces,
+// commas or colons, and may have a 0x prefix. Returns nil for empty lines.
+func parseHexLine(line string) (*trace.Record, error) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.TrimSpace(line)
+
+	record := trace.Record{Direction: directionUnknown}
+	switch {
+	case strings.HasPrefix(line, ">"):
+		record.Direction = trace.DirectionWrite
+		line = line[1:]
+	case strings.HasPrefix(line, "<"):
+		record.Direction = trace.DirectionRead
+		line = line[1:]
+	}
+
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ',' || r == ':'
+	})
+
+	for _, field := range fields {
+		digits := strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		if len(digits) == 1 {
+			digits = "0" + digits
+		}
+
+		b, err := hex.DecodeString(digits)
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("Bad hex: %q", field)
+		}
+		record.Data = append(record.Data, b...)
+	}
+
+	if len(record.Data) == 0 {
+		if record.Direction != directionUnknown {
+			return nil, fmt.Errorf("Missing bytes after direction")
+		}
+		return nil, nil
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/trace"
+	"strings"
+	"testing"
+)
+
+const testHexDump = `# Turn on node 2
+> 01 0a 00 13 02 03 25 01 ff 25 0b 12
+< 06
+< 01 04 01 13 01 e8
+> 06
+< 01 07 00 13 0b 00 00 02 e2
+> 06
+
+# Frames split across lines, in other formats
+< 0x01,0x09,0x00,0x04,0x00,0x02
+< 03:80:03:55:25
+01 08 00 13 02 02 25 02 25 e6
+01 04 01 13 01 e8
+< 01 03 00 13 ff
+`
+
+func TestParseHexLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		direction uint8
+		data      []uint8
+	}{
+		{"> 06", trace.DirectionWrite, []uint8{0x06}},
+		{"<0x01 0x3 0a", trace.DirectionRead, []uint8{0x01, 0x03, 0x0a}},
+		{"  01:02,0304 # comment", directionUnknown, []uint8{0x01, 0x02, 0x03, 0x04}},
+	}
+
+	for _, test := range tests {
+		record, err := parseHexLine(test.line)
+		if err != nil {
+			t.Errorf("Expected nil error for %q: %v", test.line, err)
+			continue
+		}
+		if record.Direction != test.direction || !bytes.Equal(record.Data, test.data) {
+			t.Errorf("Expected %q to be 0x%02x %v got 0x%02x %v", test.line,
+				test.direction, test.data, record.Direction, record.Data)
+		}
+	}
+
+	for _, line := range []string{"", "  ", "# comment"} {
+		if record, err := parseHexLine(line); record != nil || err != nil {
+			t.Errorf("Expected nil record: %v and nil error: %v for %q", record, err, line)
+		}
+	}
+
+	for _, line := range []string{">", "< # comment", "0x", "123", "zz", "01 0x0g"} {
+		if _, err := parseHexLine(line); err == nil {
+			t.Errorf("Expected non nil error for %q", line)
+		}
+	}
+}
+
+func TestDissectHexDump(t *testing.T) {
+	var out bytes.Buffer
+	if err := dissectInput(strings.NewReader(testHexDump), &out, message.NodeIDType8Bit); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	expected := `-> Request ZWSendData node: 2 transmit options: 0x25 callback: 0x0b
+    command class: BinarySwitch (0x25) command: 0x01 SWITCH_BINARY_SET
+    fields: {SwitchValue:255}
+<- ACK
+<- Response ZWSendData body: [01]
+-> ACK
+<- Request ZWSendData {CallbackID:11 Status:0 TransmitTime:2}
+-> ACK
+<- Request ApplicationCommand node: 2 status: 0x00
+    command class: Battery (0x80) command: 0x03 BATTERY_REPORT
+    fields: {BatteryLevel:85}
+    report: {Level:85 Low:false}
+?? Request ZWSendData node: 2 transmit options: 0x25
+    command class: BinarySwitch (0x25) command: 0x02 SWITCH_BINARY_GET
+    fields: {}
+?? Response ZWSendData body: [01]
+`
+	actual := out.String()
+	if i := strings.Index(actual, "<- ERROR "); i < 0 {
+		t.Errorf("Expected a checksum error: %s", actual)
+	} else {
+		actual = actual[:i]
+	}
+
+	if actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	if err := dissectInput(strings.NewReader("06\nxx\n"), &out, message.NodeIDType8Bit); err == nil ||
+		!strings.HasPrefix(err.Error(), "Line 2:") {
+		t.Errorf("Expected a Line 2 error: %v", err)
+	}
+}
+
+func TestDissectTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := trace.NewWriter(&buffer)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// 16 bit node ID 0x0101, in a frame split across records
+	records := []struct {
+		direction uint8
+		data      []uint8
+	}{
+		{trace.DirectionWrite, []uint8{0x15, '\n'}},
+		{trace.DirectionRead, []uint8{0x01, 0x0a, 0x00, 0x04, 0x00, 0x01, 0x01}},
+		{trace.DirectionWrite, []uint8{0x06, '\n'}},
+		{trace.DirectionRead, []uint8{0x03, 0x25, 0x03, 0x00, 0xd4}},
+	}
+	for _, record := range records {
+		if err := writer.Record(record.direction, record.data); err != nil {
+			t.Fatalf("Expected nil error: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := dissectInput(&buffer, &out, message.NodeIDType16Bit); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	expected := []string{
+		"-> NAK",
+		"-> ACK",
+		"<- Request ApplicationCommand node: 257 status: 0x00",
+		"    command class: BinarySwitch (0x25) command: 0x03 SWITCH_BINARY_REPORT",
+		"    fields: {Value:0}",
+		"    report: {On:false Value:0}",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines got %d: %v", len(expected), len(lines), lines)
+	}
+
+	// Trace lines start with the time of the record
+	for i, line := range lines {
+		if strings.HasPrefix(expected[i], " ") {
+			if line != expected[i] {
+				t.Errorf("Expected %q got %q", expected[i], line)
+			}
+		} else if fields := strings.SplitN(line, " ", 2); len(fields) != 2 ||
+			len(fields[0]) != len("15:04:05.000000") || fields[1] != expected[i] {
+			t.Errorf("Expected time and %q got %q", expected[i], line)
+		}
+	}
+}
+
+func TestDissectNodeIDType(t *testing.T) {
+	// Switch to 16 bit node IDs, then restart in 8 bit node IDs
+	const hexDump = `> 01 05 00 0b 80 02 73
+< 01 05 01 0b 80 01 71
+< 01 0a 00 04 00 01 01 03 25 03 00 d4
+< 01 09 00 0a 00 00 00 02 01 00 ff
+< 01 0b 00 04 00 02 05 32 02 01 22 12 f6
+`
+	var out bytes.Buffer
+	if err := dissectInput(strings.NewReader(hexDump), &out, message.NodeIDType8Bit); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	expected := `-> Request SerialAPISetup {Command:128 TxStatusReport:false RFRegion:0 PowerLevel:{Normal:0 Measured0dBm:0} NodeIDType:2}
+<- Response SerialAPISetup {Command:128 Success:true RFRegion:0 PowerLevel:{Normal:0 Measured0dBm:0} MaxPayloadSize:0}
+<- Request ApplicationCommand node: 257 status: 0x00
+    command class: BinarySwitch (0x25) command: 0x03 SWITCH_BINARY_REPORT
+    fields: {Value:0}
+    report: {On:false Value:0}
+<- Request SerialAPIStarted {WakeUpReason:0 WatchdogStarted:false Listening:false DeviceClass:{Generic:2 Specific:1} CommandClasses:[]}
+<- Request ApplicationCommand node: 2 status: 0x00
+    command class: Meter (0x32) command: 0x02 METER_REPORT
+    error: Bad MeterReportV3 Data length: 3 < 4
+    data: [01 22 12]
+    report error: Bad MeterReportV3 Data length: 3 < 4
+`
+	if actual := out.String(); actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
@@ -0,0 +1,124 @@
+// Command zwgen generates typed encoders and decoders of Z-Wave commands from
+// the public Z-Wave command class XML definitions.
+//
+// Usage:
+//
+//	zwgen -spec ZWave_custom_cmd_classes.xml -out commandclass_generated.go \
+//	    -test commandclass_generated_test.go [-package commandclass] \
+//	    [-classes 0x20,0x25]
+//
+// Every version of every command becomes a struct named after the command and
+// version, like SwitchBinarySetV1, with Encode and Decode methods. The test
+// output lists a sample of every command for round trip tests.
+//
+// The specification is the unmodified ZWave_custom_cmd_classes.xml published
+// with the Z-Wave specification. Parameters which can't be decoded are printed
+// as warnings, and kept as a Raw byte slice.
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parseClasses parses a comma separated list of command class IDs
+func parseClasses(value string) (map[uint8]bool, error) {
+	classes := make(map[uint8]bool)
+	if value == "" {
+		return classes, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		key, err := strconv.ParseUint(strings.TrimSpace(item), 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("Bad command class: %q", item)
+		}
+		classes[uint8(key)] = true
+	}
+
+	return classes, nil
+}
+
+// run the generator
+func run(specPath string, pkg string, outPath string, testPath string,
+	classList string) error {
+	classes, err := parseClasses(classList)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(specPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	spec, warnings, err := parseSpec(file, classes)
+	if err != nil {
+		return fmt.Errorf("%s: %v", specPath, err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "zwgen: %s: warning: %s\n", specPath, warning)
+	}
+
+	source := filepath.Base(specPath)
+
+	code, err := generateCode(pkg, source, spec)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(outPath, code, 0644); err != nil {
+		return err
+	}
+
+	if testPath == "" {
+		return nil
+	}
+
+	test, err := generateTest(pkg, source, spec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(testPath, test, 0644)
+}
+
+func main() {
+	specPath := flag.String("spec", "", "Z-Wave command class XML definitions")
+	pkg := flag.String("package", "commandclass", "Package name of generated code")
+	outPath := flag.String("out", "", "Output path of generated code")
+	testPath := flag.String("test", "", "Output path of generated tests")
+	classList := flag.String("classes", "",
+		"Comma separated command class IDs to generate, default all")
+	flag.Parse()
+
+	if *specPath == "" || *outPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*specPath, *pkg, *outPath, *testPath, *classList); err != nil {
+		fmt.Fprintf(os.Stderr, "zwgen: %v\n", err)
+		os.Exit(1)
+	}
+}
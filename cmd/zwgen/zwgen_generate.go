@@ -0,0 +1,565 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// Header of generated files, recognized by go tooling
+const generatedHeader = "// Code generated by zwgen from %s. DO NOT EDIT.\n\n"
+
+// generator accumulates generated source code
+type generator struct {
+	buf bytes.Buffer
+}
+
+// printf appends formatted source code
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// format returns the gofmt formatted source code
+func (g *generator) format() ([]uint8, error) {
+	source, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to format generated code: %v", err)
+	}
+	return source, nil
+}
+
+// lowerFirst returns name with a lower case first letter
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// lengthVar returns the name of the local variable holding the decoded length
+// of p
+func lengthVar(p *param) string {
+	if p.LengthField != nil {
+		return "length" + p.LengthParam.GoName + p.LengthField.GoName
+	}
+	return "length" + p.LengthParam.GoName
+}
+
+// goType returns the Go type of a non STRUCT_BYTE parameter
+func goType(p *param) string {
+	switch p.Type {
+	case paramTypeWord:
+		return "uint16"
+	case paramTypeBit24, paramTypeDWord:
+		return "uint32"
+	case paramTypeVariant, paramTypeBitmask, paramTypeArray, paramTypeEnumArray,
+		paramTypeRaw:
+		return "[]uint8"
+	case paramTypeVariantGroup:
+		return "[]" + p.GroupGoName
+	}
+	return "uint8"
+}
+
+// isByte reports if the parameter is a single byte value
+func isByte(p *param) bool {
+	switch p.Type {
+	case paramTypeByte, paramTypeConst, paramTypeEnum, paramTypeMultiArray:
+		return true
+	}
+	return false
+}
+
+// description returns the human readable description of a command
+func description(cmd *command) string {
+	if cmd.Help != "" {
+		return cmd.Help
+	}
+	return cmd.Name
+}
+
+// codec describes the generated Encode and Decode methods of a command, or the
+// encode and decode methods of a variant group element
+type codec struct {
+	GoName    string   // Go type name
+	Params    []*param // Parameters in order
+	Required  int      // Number of required parameters
+	Payload   string   // Initial payload
+	ErrReturn string   // Return statement prefix of decode errors
+	OkReturn  string   // Return statement of a successful decode
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// generateCode returns the source code of the command codecs
+func generateCode(pkg string, source string, classes []*commandClass) ([]uint8, error) {
+	g := &generator{}
+	g.printf(generatedHeader, source)
+	g.printf("package %s\n\nimport \"fmt\"\n\n", pkg)
+
+	// One constant per command class name, even with several versions
+	g.printf("// Command classes\nconst (\n")
+	seen := make(map[string]bool)
+	for _, class := range classes {
+		if seen[class.GoName] {
+			continue
+		}
+		if len(seen) == 0 {
+			g.printf("CommandClass%s uint8 = 0x%02x\n", class.GoName, class.Key)
+		} else {
+			g.printf("CommandClass%s = 0x%02x\n", class.GoName, class.Key)
+		}
+		seen[class.GoName] = true
+	}
+	g.printf(")\n\n")
+
+	for _, class := range classes {
+		for _, cmd := range class.Commands {
+			g.generateCommand(cmd)
+		}
+	}
+
+	g.printf("// commands lists every generated command\n")
+	g.printf("var commands = []commandInfo{\n")
+	for _, class := range classes {
+		for _, cmd := range class.Commands {
+			g.printf("{CommandClass%s, %d, 0x%02x, func() Command { return &%s{} }},\n",
+				class.GoName, class.Version, cmd.Key, cmd.GoName)
+		}
+	}
+	g.printf("}\n")
+
+	return g.format()
+}
+
+// generateConstants emits the constants of the parameters
+func (g *generator) generateConstants(goName string, params []*param) {
+	for _, p := range params {
+		if len(p.Consts) == 0 {
+			continue
+		}
+		g.printf("// %s values of %s\nconst (\n", p.Name, goName)
+		for i, c := range p.Consts {
+			if i == 0 {
+				g.printf("%s uint8 = 0x%02x\n", c.GoName, c.Value)
+			} else {
+				g.printf("%s = 0x%02x\n", c.GoName, c.Value)
+			}
+		}
+		g.printf(")\n\n")
+	}
+}
+
+// generateFields emits the struct fields of the parameters
+func (g *generator) generateFields(params []*param) {
+	for _, p := range params {
+		switch {
+		case p.Type == paramTypeStructByte:
+			for _, f := range p.Fields {
+				if f.Derived() {
+					continue
+				}
+				if f.Flag {
+					g.printf("%s bool\n", f.GoName)
+				} else {
+					g.printf("%s uint8\n", f.GoName)
+				}
+			}
+		case p.Type == paramTypeRaw:
+			g.printf("// Parameters which are not decoded, up to the end of the command\n")
+			g.printf("%s %s\n", p.GoName, goType(p))
+		case !p.Derived():
+			g.printf("%s %s\n", p.GoName, goType(p))
+		}
+	}
+}
+
+// generateGroups emits the element types of the variant groups of a command
+func (g *generator) generateGroups(cmd *command) {
+	for _, p := range cmd.Params {
+		if p.Type != paramTypeVariantGroup {
+			continue
+		}
+
+		g.generateConstants(p.GroupGoName, p.Group)
+
+		g.printf("// %s is an element of the %s group of %s\n", p.GroupGoName,
+			p.Name, cmd.GoName)
+		g.printf("type %s struct {\n", p.GroupGoName)
+		g.generateFields(p.Group)
+		g.printf("}\n\n")
+
+		group := &codec{GoName: p.GroupGoName, Params: p.Group,
+			Required: len(p.Group), Payload: "[]uint8{}",
+			ErrReturn: "return 0, ", OkReturn: "return offset, nil"}
+		recv := "func (command *" + p.GroupGoName + ")"
+
+		g.printf("// encode returns the payload of the element\n")
+		g.printf("%s encode() ([]uint8, error) {\n", recv)
+		g.generateEncode(group)
+		g.printf("// decode the element, and return its length\n")
+		g.printf("%s decode(data []uint8) (int, error) {\n", recv)
+		g.generateDecode(group)
+	}
+}
+
+// generateCommand emits the type, constants and codec of a command
+func (g *generator) generateCommand(cmd *command) {
+	g.printf("%s\n\n", strings.Repeat("/", 80))
+
+	g.generateConstants(cmd.GoName, cmd.Params)
+	g.generateGroups(cmd)
+
+	g.printf("// %s is the %s command, version %d\n", cmd.GoName,
+		description(cmd), cmd.Class.Version)
+	g.printf("type %s struct {\n", cmd.GoName)
+	g.generateFields(cmd.Params)
+	g.printf("}\n\n")
+
+	recv := "func (command *" + cmd.GoName + ")"
+	g.printf("// CommandClass returns the command class ID\n")
+	g.printf("%s CommandClass() uint8 {\nreturn CommandClass%s\n}\n\n",
+		recv, cmd.Class.GoName)
+	g.printf("// CommandID returns the command ID\n")
+	g.printf("%s CommandID() uint8 {\nreturn 0x%02x\n}\n\n", recv, cmd.Key)
+	g.printf("// Version returns the command class version\n")
+	g.printf("%s Version() uint8 {\nreturn %d\n}\n\n", recv, cmd.Class.Version)
+	g.printf("// Name returns the specification name of the command\n")
+	g.printf("%s Name() string {\nreturn %q\n}\n\n", recv, cmd.Name)
+
+	commandCodec := &codec{GoName: cmd.GoName, Params: cmd.Params,
+		Required: cmd.Required, Payload: fmt.Sprintf("[]uint8{0x%02x}", cmd.Key),
+		ErrReturn: "return ", OkReturn: "return nil"}
+
+	g.printf("// Encode returns the payload of the command, starting with the command ID\n")
+	g.printf("%s Encode() ([]uint8, error) {\n", recv)
+	g.generateEncode(commandCodec)
+	g.printf("// Decode the command parameters, which follow the command ID\n")
+	g.printf("%s Decode(data []uint8) error {\n", recv)
+	g.generateDecode(commandCodec)
+}
+
+// generateEncode emits the body of an encode method
+func (g *generator) generateEncode(c *codec) {
+	// Validate values, which do not fit into their encoding
+	for _, p := range c.Params {
+		switch p.Type {
+		case paramTypeBit24:
+			g.printf("if command.%s > 0xffffff {\n", p.GoName)
+			g.printf("return nil, fmt.Errorf(\"Bad %s %s: 0x%%x > 0xffffff\", command.%s)\n}\n",
+				c.GoName, p.GoName, p.GoName)
+		case paramTypeArray, paramTypeEnumArray:
+			if p.Length == 0 {
+				break
+			}
+			g.printf("if len(command.%s) != %d {\n", p.GoName, p.Length)
+			g.printf("return nil, fmt.Errorf(\"Bad %s %s length: %%d != %d\", len(command.%s))\n}\n",
+				c.GoName, p.GoName, p.Length, p.GoName)
+		case paramTypeStructByte:
+			for _, f := range p.Fields {
+				if f.Flag || f.Derived() || f.Mask>>f.Shift == 0xff {
+					continue
+				}
+				g.printf("if command.%s > 0x%02x {\n", f.GoName, f.Mask>>f.Shift)
+				g.printf("return nil, fmt.Errorf(\"Bad %s %s: 0x%%02x > 0x%02x\", command.%s)\n}\n",
+					c.GoName, f.GoName, f.Mask>>f.Shift, f.GoName)
+			}
+		}
+
+		// Values of a parameter ending at a marker can't contain the marker
+		if p.EndMarker != nil {
+			g.printf("for _, value := range command.%s {\n", p.GoName)
+			g.printf("if value == 0x%02x {\n", p.EndMarker.Value)
+			g.printf("return nil, fmt.Errorf(\"Bad %s %s: contains marker 0x%02x\")\n}\n}\n",
+				c.GoName, p.GoName, p.EndMarker.Value)
+		}
+
+		var lengthOf []*param
+		var max uint8
+		if len(p.LengthOf) > 0 {
+			lengthOf, max = p.LengthOf, 0xff
+		}
+		for _, f := range p.Fields {
+			if f.Derived() {
+				lengthOf, max = f.LengthOf, f.Mask>>f.Shift
+			}
+		}
+		if len(lengthOf) == 0 {
+			continue
+		}
+
+		first := lengthOf[0].GoName
+		g.printf("if len(command.%s) > 0x%02x {\n", first, max)
+		g.printf("return nil, fmt.Errorf(\"Bad %s %s length: %%d > %d\", len(command.%s))\n}\n",
+			c.GoName, first, max, first)
+		for _, other := range lengthOf[1:] {
+			g.printf("if len(command.%s) != len(command.%s) {\n", other.GoName, first)
+			g.printf("return nil, fmt.Errorf(\"Bad %s %s length: %%d != %%d\", len(command.%s), len(command.%s))\n}\n",
+				c.GoName, other.GoName, other.GoName, first)
+		}
+	}
+
+	g.printf("payload := %s\n", c.Payload)
+	for _, p := range c.Params {
+		switch {
+		case p.Type == paramTypeStructByte:
+			name := "field" + p.GoName
+			g.printf("%s := uint8(0)\n", name)
+			for _, f := range p.Fields {
+				switch {
+				case f.Derived() && f.Shift == 0:
+					g.printf("%s |= uint8(len(command.%s))\n", name,
+						f.LengthOf[0].GoName)
+				case f.Derived():
+					g.printf("%s |= uint8(len(command.%s)) << %d\n", name,
+						f.LengthOf[0].GoName, f.Shift)
+				case f.Flag:
+					g.printf("if command.%s {\n%s |= 0x%02x\n}\n", f.GoName, name, f.Mask)
+				case f.Shift == 0:
+					g.printf("%s |= command.%s\n", name, f.GoName)
+				default:
+					g.printf("%s |= command.%s << %d\n", name, f.GoName, f.Shift)
+				}
+			}
+			g.printf("payload = append(payload, %s)\n", name)
+		case p.Type == paramTypeMarker:
+			g.printf("payload = append(payload, 0x%02x)\n", p.Value)
+		case isByte(p):
+			if p.Derived() {
+				g.printf("payload = append(payload, uint8(len(command.%s)))\n",
+					p.LengthOf[0].GoName)
+			} else {
+				g.printf("payload = append(payload, command.%s)\n", p.GoName)
+			}
+		case p.Type == paramTypeWord:
+			g.printf("payload = append(payload, uint8(command.%s>>8), uint8(command.%s))\n",
+				p.GoName, p.GoName)
+		case p.Type == paramTypeBit24:
+			g.printf("payload = append(payload, uint8(command.%s>>16), uint8(command.%s>>8), uint8(command.%s))\n",
+				p.GoName, p.GoName, p.GoName)
+		case p.Type == paramTypeDWord:
+			g.printf("payload = append(payload, uint8(command.%s>>24), uint8(command.%s>>16), uint8(command.%s>>8), uint8(command.%s))\n",
+				p.GoName, p.GoName, p.GoName, p.GoName)
+		case p.Type == paramTypeVariantGroup:
+			g.printf("for _, element := range command.%s {\n", p.GoName)
+			g.printf("encoded, err := element.encode()\n")
+			g.printf("if err != nil {\nreturn nil, err\n}\n")
+			g.printf("payload = append(payload, encoded...)\n}\n")
+		default:
+			g.printf("payload = append(payload, command.%s...)\n", p.GoName)
+		}
+	}
+	g.printf("return payload, nil\n}\n\n")
+}
+
+// generateDecode emits the body of a decode method. Bytes after the known
+// parameters are ignored, and parameters added by newer versions are left
+// zero when the data ends before them.
+func (g *generator) generateDecode(c *codec) {
+	if len(c.Params) == 0 {
+		g.printf("%s\n}\n\n", c.OkReturn)
+		return
+	}
+
+	if c.Required < len(c.Params) {
+		g.printf("*command = %s{}\n", c.GoName)
+	}
+	g.printf("offset := 0\n")
+
+	need := func(length string) {
+		g.printf("if len(data) < offset+%s {\n", length)
+		g.printf("%sfmt.Errorf(\"Bad %s Data length: %%d < %%d\", len(data), offset+%s)\n}\n",
+			c.ErrReturn, c.GoName, length)
+	}
+
+	for i, p := range c.Params {
+		if i >= c.Required {
+			g.printf("if offset == len(data) {\n%s\n}\n", c.OkReturn)
+		}
+
+		switch {
+		case p.Type == paramTypeStructByte:
+			need("1")
+			for _, f := range p.Fields {
+				value := fmt.Sprintf("data[offset]&0x%02x", f.Mask)
+				if f.Shift != 0 {
+					value = fmt.Sprintf("(%s)>>%d", value, f.Shift)
+				}
+				switch {
+				case f.Derived():
+					g.printf("length%s%s := int(%s)\n", p.GoName, f.GoName, value)
+				case f.Flag:
+					g.printf("command.%s = data[offset]&0x%02x != 0\n", f.GoName, f.Mask)
+				default:
+					g.printf("command.%s = %s\n", f.GoName, value)
+				}
+			}
+			g.printf("offset++\n")
+		case p.Type == paramTypeMarker:
+			need("1")
+			g.printf("offset++\n")
+		case isByte(p):
+			need("1")
+			if p.Derived() {
+				g.printf("length%s := int(data[offset])\n", p.GoName)
+			} else {
+				g.printf("command.%s = data[offset]\n", p.GoName)
+			}
+			g.printf("offset++\n")
+		case p.Type == paramTypeWord:
+			need("2")
+			g.printf("command.%s = uint16(data[offset])<<8 | uint16(data[offset+1])\n", p.GoName)
+			g.printf("offset += 2\n")
+		case p.Type == paramTypeBit24:
+			need("3")
+			g.printf("command.%s = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])\n", p.GoName)
+			g.printf("offset += 3\n")
+		case p.Type == paramTypeDWord:
+			need("4")
+			g.printf("command.%s = uint32(data[offset])<<24 | uint32(data[offset+1])<<16 | uint32(data[offset+2])<<8 | uint32(data[offset+3])\n", p.GoName)
+			g.printf("offset += 4\n")
+		case p.Type == paramTypeVariantGroup:
+			g.printf("command.%s = nil\n", p.GoName)
+			if p.Rest {
+				g.printf("for offset < len(data) {\n")
+			} else {
+				g.printf("for i := 0; i < %s; i++ {\n", lengthVar(p))
+			}
+			g.printf("var element %s\n", p.GroupGoName)
+			g.printf("length, err := element.decode(data[offset:])\n")
+			g.printf("if err != nil {\n%serr\n}\n", c.ErrReturn)
+			g.printf("command.%s = append(command.%s, element)\n", p.GoName, p.GoName)
+			g.printf("offset += length\n}\n")
+		default:
+			var length string
+			switch {
+			case p.EndMarker != nil:
+				length = "length" + p.GoName
+				g.printf("%s := 0\n", length)
+				g.printf("for offset+%s < len(data) && data[offset+%s] != 0x%02x {\n",
+					length, length, p.EndMarker.Value)
+				g.printf("%s++\n}\n", length)
+			case p.Rest:
+				length = "len(data) - offset"
+			case p.LengthParam != nil:
+				length = lengthVar(p)
+			default:
+				length = fmt.Sprintf("%d", p.Length)
+			}
+			if !p.Rest {
+				need(length)
+			}
+			g.printf("command.%s = nil\n", p.GoName)
+			g.printf("if %s > 0 {\n", length)
+			g.printf("command.%s = append([]uint8(nil), data[offset:offset+%s]...)\n}\n",
+				p.GoName, length)
+			g.printf("offset += %s\n", length)
+		}
+	}
+
+	g.printf("%s\n}\n\n", c.OkReturn)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// sampleBytes returns deterministic sample data of the given length
+func sampleBytes(length int) string {
+	if length == 0 {
+		return "nil"
+	}
+
+	var values []string
+	for i := 0; i < length; i++ {
+		values = append(values, fmt.Sprintf("0x%02x", i+1))
+	}
+	return "[]uint8{" + strings.Join(values, ", ") + "}"
+}
+
+// sampleLength returns the length of sample data of a variable parameter
+func sampleLength(p *param) int {
+	switch {
+	case p.Length > 0:
+		return p.Length
+	case p.LengthParam == nil:
+		return 2
+	}
+
+	max := int(p.LengthMask >> p.LengthShift)
+	if max > 2 {
+		return 2
+	}
+	return max
+}
+
+// sampleValues returns the struct literal fields of sample parameter values
+func sampleValues(params []*param) string {
+	var values []string
+	for _, p := range params {
+		switch {
+		case p.Type == paramTypeStructByte:
+			for _, f := range p.Fields {
+				switch {
+				case f.Derived():
+				case f.Flag:
+					values = append(values, fmt.Sprintf("%s: true", f.GoName))
+				default:
+					values = append(values, fmt.Sprintf("%s: 0x%02x", f.GoName,
+						0xa5&(f.Mask>>f.Shift)))
+				}
+			}
+		case p.Derived():
+		case p.Type == paramTypeWord:
+			values = append(values, fmt.Sprintf("%s: 0xa55a", p.GoName))
+		case p.Type == paramTypeBit24:
+			values = append(values, fmt.Sprintf("%s: 0xa55aa5", p.GoName))
+		case p.Type == paramTypeDWord:
+			values = append(values, fmt.Sprintf("%s: 0xa55aa55a", p.GoName))
+		case p.Type == paramTypeVariantGroup:
+			length := sampleLength(p)
+			if length > 0 {
+				element := "{" + sampleValues(p.Group) + "}"
+				elements := strings.Repeat(element+", ", length-1) + element
+				values = append(values, fmt.Sprintf("%s: []%s{%s}", p.GoName,
+					p.GroupGoName, elements))
+			}
+		case goType(p) == "[]uint8":
+			values = append(values, fmt.Sprintf("%s: %s", p.GoName,
+				sampleBytes(sampleLength(p))))
+		default:
+			values = append(values, fmt.Sprintf("%s: 0xa5", p.GoName))
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// generateTest returns the source code of round trip tests of every command
+func generateTest(pkg string, source string, classes []*commandClass) ([]uint8, error) {
+	g := &generator{}
+	g.printf(generatedHeader, source)
+	g.printf("package %s\n\n", pkg)
+
+	g.printf("// roundTripCommands has a sample of every generated command\n")
+	g.printf("var roundTripCommands = []Command{\n")
+	for _, class := range classes {
+		for _, cmd := range class.Commands {
+			g.printf("&%s{%s},\n", cmd.GoName, sampleValues(cmd.Params))
+		}
+	}
+	g.printf("}\n")
+
+	return g.format()
+}
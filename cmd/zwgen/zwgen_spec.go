@@ -0,0 +1,867 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NOTE: Parameters which can't be described by the supported subset of the
+//       Z-Wave command class XML format, like unknown types or unresolvable
+//       length references, are not decoded: they and all following
+//       parameters of the command become a single Raw byte slice, so that
+//       every command of every class is still generated
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Parameter types
+const (
+	paramTypeByte         = "BYTE"
+	paramTypeWord         = "WORD"
+	paramTypeBit24        = "BIT_24"
+	paramTypeDWord        = "DWORD"
+	paramTypeConst        = "CONST"
+	paramTypeEnum         = "ENUM"
+	paramTypeMultiArray   = "MULTI_ARRAY"
+	paramTypeStructByte   = "STRUCT_BYTE"
+	paramTypeVariant      = "VARIANT"
+	paramTypeArray        = "ARRAY"
+	paramTypeEnumArray    = "ENUM_ARRAY"
+	paramTypeBitmask      = "BITMASK"
+	paramTypeMarker       = "MARKER"
+	paramTypeVariantGroup = "VARIANT_GROUP" // variant_group element
+	paramTypeRaw          = "RAW"           // Parameters which are not decoded
+)
+
+// Methods of generated commands, which parameters can't be named after
+var commandMethods = []string{"CommandClass", "CommandID", "Version", "Name",
+	"Encode", "Decode"}
+
+// Parameter offset meaning the parameter extends to the end of the command
+const paramOffsetRest = 0xff
+
+// xmlParam is a param element of a command or variant group
+type xmlParam struct {
+	Key    string `xml:"key,attr"`
+	Name   string `xml:"name,attr"`
+	Type   string `xml:"type,attr"`
+	Consts []struct {
+		FlagName string `xml:"flagname,attr"`
+		FlagMask string `xml:"flagmask,attr"`
+	} `xml:"const"`
+	Enums []struct {
+		Key  string `xml:"key,attr"`
+		Name string `xml:"name,attr"`
+	} `xml:"enum"`
+	BitFields []struct {
+		FieldName string `xml:"fieldname,attr"`
+		FieldMask string `xml:"fieldmask,attr"`
+		Shifter   string `xml:"shifter,attr"`
+	} `xml:"bitfield"`
+	FieldEnums []struct {
+		FieldName string `xml:"fieldname,attr"`
+		FieldMask string `xml:"fieldmask,attr"`
+		Shifter   string `xml:"shifter,attr"`
+	} `xml:"fieldenum"`
+	BitFlags []struct {
+		FlagName string `xml:"flagname,attr"`
+		FlagMask string `xml:"flagmask,attr"`
+	} `xml:"bitflag"`
+	Variant []struct {
+		ParamOffs  string `xml:"paramoffs,attr"`
+		SizeMask   string `xml:"sizemask,attr"`
+		SizeOffs   string `xml:"sizeoffs,attr"`
+		SizeChange string `xml:"sizechange,attr"`
+	} `xml:"variant"`
+	Array []struct {
+		Len string `xml:"len,attr"`
+	} `xml:"arrayattrib"`
+	ArrayLen []struct {
+		ParamOffs string `xml:"paramoffs,attr"`
+		LenMask   string `xml:"lenmask,attr"`
+		LenOffs   string `xml:"lenoffs,attr"`
+	} `xml:"arraylen"`
+	Bitmask []struct {
+		ParamOffs string `xml:"paramoffs,attr"`
+		LenMask   string `xml:"lenmask,attr"`
+		LenOffs   string `xml:"lenoffs,attr"`
+	} `xml:"bitmask"`
+}
+
+// xmlVariantGroup is a repeated group of parameters of a command
+type xmlVariantGroup struct {
+	Key            string     `xml:"key,attr"`
+	Name           string     `xml:"name,attr"`
+	ParamOffs      string     `xml:"paramOffs,attr"`
+	ParamOffsLower string     `xml:"paramoffs,attr"`
+	SizeMask       string     `xml:"sizemask,attr"`
+	SizeOffs       string     `xml:"sizeoffs,attr"`
+	Params         []xmlParam `xml:"param"`
+}
+
+// xmlCommand is a cmd element of a command class
+type xmlCommand struct {
+	Key           string            `xml:"key,attr"`
+	Name          string            `xml:"name,attr"`
+	Help          string            `xml:"help,attr"`
+	Params        []xmlParam        `xml:"param"`
+	VariantGroups []xmlVariantGroup `xml:"variant_group"`
+}
+
+// xmlClasses is the root of the Z-Wave command class definitions
+type xmlClasses struct {
+	Classes []struct {
+		Key      string       `xml:"key,attr"`
+		Version  string       `xml:"version,attr"`
+		Name     string       `xml:"name,attr"`
+		Help     string       `xml:"help,attr"`
+		Commands []xmlCommand `xml:"cmd"`
+	} `xml:"cmd_class"`
+}
+
+// commandClass is one version of a command class
+type commandClass struct {
+	Key      uint8
+	Version  uint8
+	Name     string
+	GoName   string
+	Help     string
+	Commands []*command
+}
+
+// command of a command class version
+type command struct {
+	Class  *commandClass
+	Key    uint8
+	Name   string
+	GoName string
+	Help   string
+	Params []*param
+
+	// Number of parameters present in every version of the command. Later
+	// parameters were added by newer versions, or follow a MARKER, and are
+	// optional when decoding.
+	Required int
+}
+
+// param of a command or variant group
+type param struct {
+	Key    uint8
+	Name   string
+	GoName string
+	Type   string
+	Consts []*constant
+	Fields []*field
+
+	// Length of VARIANT, BITMASK, ARRAY and ENUM_ARRAY parameters, or number
+	// of elements of VARIANT_GROUP parameters
+	Length      int    // Fixed length for ARRAY
+	Rest        bool   // Parameter extends to the end of the command
+	EndMarker   *param // MARKER ending a Rest parameter, or nil
+	LengthParam *param // Parameter holding the length
+	LengthField *field // Field of LengthParam holding the length, or nil
+	LengthMask  uint8
+	LengthShift uint
+
+	// Parameters whose length is stored in this BYTE parameter
+	LengthOf []*param
+
+	// Value of a MARKER
+	Value uint8
+
+	// Parameters and Go type name of each element of a VARIANT_GROUP
+	Group       []*param
+	GroupGoName string
+}
+
+// field of a STRUCT_BYTE parameter
+type field struct {
+	Name   string
+	GoName string
+	Mask   uint8
+	Shift  uint
+	Flag   bool
+
+	// Parameters whose length is stored in this field
+	LengthOf []*param
+}
+
+// constant value of a CONST or ENUM parameter
+type constant struct {
+	Name   string // Go name of the value within the parameter
+	GoName string // Package level Go name
+	Value  uint8
+}
+
+// Derived reports if the value of the parameter is computed from the length of
+// other parameters, or is a fixed MARKER
+func (p *param) Derived() bool {
+	return len(p.LengthOf) > 0 || p.Type == paramTypeMarker
+}
+
+// Derived reports if the value of the field is computed from the length of
+// other parameters
+func (f *field) Derived() bool {
+	return len(f.LengthOf) > 0
+}
+
+// paramError is an error of the parameter at Index of a command
+type paramError struct {
+	Index int
+	Err   error
+}
+
+func (err *paramError) Error() string {
+	return err.Err.Error()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// parseUint8 parses a decimal or 0x prefixed hexadecimal attribute
+func parseUint8(name string, value string) (uint8, error) {
+	if value == "" {
+		return 0, fmt.Errorf("Missing %s", name)
+	}
+
+	parsed, err := strconv.ParseUint(value, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("Bad %s: %q", name, value)
+	}
+
+	return uint8(parsed), nil
+}
+
+// parseShift parses an optional bit shift attribute
+func parseShift(name string, value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	shift, err := parseUint8(name, value)
+	if err != nil {
+		return 0, err
+	}
+
+	if shift > 7 {
+		return 0, fmt.Errorf("Bad %s: %d > 7", name, shift)
+	}
+
+	return uint(shift), nil
+}
+
+// Words kept upper case in Go identifiers
+var initialisms = map[string]bool{"ID": true}
+
+// goName converts a specification name like "SWITCH_BINARY_SET" or
+// "Sensor Value" into an exported Go identifier. Upper case words are title
+// cased, other words keep their case.
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var result string
+	for _, word := range words {
+		if word == strings.ToUpper(word) && !initialisms[word] {
+			word = strings.ToLower(word)
+		}
+		result += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	if len(result) > 0 && unicode.IsDigit(rune(result[0])) {
+		result = "X" + result
+	}
+
+	return result
+}
+
+// classGoName converts a command class name like "COMMAND_CLASS_SWITCH_BINARY"
+// into a Go identifier like "SwitchBinary"
+func classGoName(name string) string {
+	return goName(strings.TrimPrefix(name, "COMMAND_CLASS_"))
+}
+
+// trimVersion removes the "_V2" like suffix, which the specification appends to
+// names of newer versions
+func trimVersion(name string, version uint8) string {
+	return strings.TrimSuffix(name, fmt.Sprintf("_V%d", version))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// names hands out identifiers, which are unique within a scope
+type names map[string]bool
+
+// unique returns name, or name with the lowest number suffix that is not used
+// yet, and marks it as used
+func (used names) unique(name string) string {
+	result := name
+	for i := 2; used[result]; i++ {
+		result = fmt.Sprintf("%s%d", name, i)
+	}
+	used[result] = true
+	return result
+}
+
+// charsetReader converts ISO-8859-1 encoded definitions to UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "latin-1":
+	default:
+		return nil, fmt.Errorf("Unsupported charset: %q", charset)
+	}
+
+	data, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return strings.NewReader(string(runes)), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// parseSpec reads the command class definitions from r. If classes is not
+// empty, only command classes with those keys are kept. Returns warnings of
+// parameters, which are not decoded.
+func parseSpec(r io.Reader, classes map[uint8]bool) ([]*commandClass, []string, error) {
+	var spec xmlClasses
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, nil, fmt.Errorf("Failed to decode specification: %v", err)
+	}
+
+	var result []*commandClass
+	var warnings []string
+	used := make(names)
+	classKeys := make(map[string]uint8)
+
+	for _, xc := range spec.Classes {
+		key, err := parseUint8("cmd_class key", xc.Key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Command class %q: %v", xc.Name, err)
+		}
+
+		if len(classes) > 0 && !classes[key] {
+			continue
+		}
+
+		version, err := parseUint8("cmd_class version", xc.Version)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Command class %q: %v", xc.Name, err)
+		}
+
+		class := &commandClass{Key: key, Version: version, Name: xc.Name,
+			GoName: classGoName(trimVersion(xc.Name, version)), Help: xc.Help}
+		if class.GoName == "" {
+			return nil, nil, fmt.Errorf("Command class 0x%02x: missing name", key)
+		}
+		if other, ok := classKeys[class.GoName]; ok && other != key {
+			return nil, nil, fmt.Errorf("Command class %q: keys 0x%02x and 0x%02x",
+				xc.Name, other, key)
+		}
+		classKeys[class.GoName] = key
+
+		for i := range xc.Commands {
+			cmd, warning, err := parseCommand(class, &xc.Commands[i], used)
+			if err != nil {
+				return nil, nil, err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			class.Commands = append(class.Commands, cmd)
+		}
+
+		result = append(result, class)
+	}
+
+	setRequired(result)
+	return result, warnings, nil
+}
+
+// paramItem is a param or variant_group element of a command
+type paramItem struct {
+	Key    int // Key, or 0x100 if it is bad
+	KeyErr error
+	Param  *xmlParam
+	Group  *xmlVariantGroup
+}
+
+// name returns the name of the element
+func (item *paramItem) name() string {
+	if item.Group != nil {
+		return item.Group.Name
+	}
+	return item.Param.Name
+}
+
+// parseCommand parses a command. The first parameter, which can't be
+// described, and all following parameters are replaced by a Raw parameter,
+// and reported as a warning. used has the package level identifiers.
+func parseCommand(class *commandClass, xcmd *xmlCommand, used names) (*command, string, error) {
+	key, err := parseUint8("cmd key", xcmd.Key)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s V%d %q: %v", class.Name, class.Version,
+			xcmd.Name, err)
+	}
+
+	goName := fmt.Sprintf("%sV%d", goName(trimVersion(xcmd.Name, class.Version)),
+		class.Version)
+	if used[goName] {
+		return nil, "", fmt.Errorf("Duplicate command: %s", goName)
+	}
+
+	// Parameters and variant groups, ordered by key
+	var items []paramItem
+	for i := range xcmd.Params {
+		items = append(items, paramItem{Param: &xcmd.Params[i]})
+	}
+	for i := range xcmd.VariantGroups {
+		items = append(items, paramItem{Group: &xcmd.VariantGroups[i]})
+	}
+	for i := range items {
+		item := &items[i]
+		keyText := ""
+		if item.Group != nil {
+			keyText = item.Group.Key
+		} else {
+			keyText = item.Param.Key
+		}
+		parsed, err := parseUint8("param key", keyText)
+		item.Key, item.KeyErr = int(parsed), err
+		if err != nil {
+			item.Key = 0x100
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+
+	var warning string
+	for limit := len(items); ; {
+		cmd := &command{Class: class, Key: key, Name: xcmd.Name, GoName: goName,
+			Help: xcmd.Help}
+
+		err := cmd.build(items[:limit], limit < len(items))
+		if err == nil {
+			cmd.setNames(used)
+			return cmd, warning, nil
+		}
+
+		// Not decoding a parameter can't fail
+		index := err.(*paramError).Index
+		warning = fmt.Sprintf("%s %q: %v, not decoding it", goName,
+			items[index].name(), err)
+		limit = index
+	}
+}
+
+// build the parameters of the command from the items, followed by a Raw
+// parameter if raw is true
+func (cmd *command) build(items []paramItem, raw bool) error {
+	cmd.Params = nil
+	for i := range items {
+		item := &items[i]
+		if item.KeyErr != nil {
+			return &paramError{Index: i, Err: item.KeyErr}
+		}
+
+		var p *param
+		var err error
+		if item.Group != nil {
+			p, err = parseGroup(uint8(item.Key), item.Group)
+		} else {
+			p, err = parseParam(uint8(item.Key), item.Param)
+		}
+		if err != nil {
+			return &paramError{Index: i, Err: err}
+		}
+
+		cmd.Params = append(cmd.Params, p)
+	}
+
+	if raw {
+		cmd.Params = append(cmd.Params, &param{Key: paramOffsetRest, Name: "Raw",
+			GoName: "Raw", Type: paramTypeRaw, Rest: true})
+	}
+
+	return checkParams(cmd.Params, false)
+}
+
+// setNames sets the package level names of the command constants and variant
+// group types
+func (cmd *command) setNames(used names) {
+	used[cmd.GoName] = true
+
+	var setParamNames func(params []*param, prefix string)
+	setParamNames = func(params []*param, prefix string) {
+		for _, p := range params {
+			for _, c := range p.Consts {
+				c.GoName = used.unique(prefix + p.GoName + c.Name)
+			}
+			if p.Type == paramTypeVariantGroup {
+				p.GroupGoName = used.unique(prefix + p.GoName)
+				setParamNames(p.Group, p.GroupGoName)
+			}
+		}
+	}
+	setParamNames(cmd.Params, cmd.GoName)
+}
+
+// parseParam parses a param element
+func parseParam(key uint8, xp *xmlParam) (*param, error) {
+	p := &param{Key: key, Name: xp.Name, GoName: goName(xp.Name), Type: xp.Type}
+	if p.GoName == "" {
+		p.GoName = fmt.Sprintf("Param%d", key)
+	}
+
+	var err error
+	switch p.Type {
+	case paramTypeByte, paramTypeWord, paramTypeBit24, paramTypeDWord:
+	case paramTypeConst:
+		for _, xconst := range xp.Consts {
+			value, err := parseUint8("flagmask", xconst.FlagMask)
+			if err != nil {
+				return nil, err
+			}
+			p.Consts = append(p.Consts, &constant{Name: goName(xconst.FlagName),
+				Value: value})
+		}
+
+	case paramTypeEnum, paramTypeMultiArray, paramTypeEnumArray:
+		for _, xenum := range xp.Enums {
+			value, err := parseUint8("enum key", xenum.Key)
+			if err != nil {
+				return nil, err
+			}
+			p.Consts = append(p.Consts, &constant{Name: goName(xenum.Name),
+				Value: value})
+		}
+
+		if p.Type == paramTypeEnumArray {
+			// Without a length, the values extend to the end of the command
+			p.Rest = true
+			if len(xp.Array) > 0 {
+				if err = p.setArrayLength(xp.Array[0].Len); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+	case paramTypeMarker:
+		if len(xp.Consts) > 0 {
+			if p.Value, err = parseUint8("flagmask", xp.Consts[0].FlagMask); err != nil {
+				return nil, err
+			}
+		}
+
+	case paramTypeStructByte:
+		for _, xf := range xp.BitFields {
+			f := &field{Name: xf.FieldName, GoName: goName(xf.FieldName)}
+			if f.Mask, err = parseUint8("fieldmask", xf.FieldMask); err != nil {
+				return nil, err
+			}
+			if f.Shift, err = parseShift("shifter", xf.Shifter); err != nil {
+				return nil, err
+			}
+			p.Fields = append(p.Fields, f)
+		}
+		for _, xf := range xp.FieldEnums {
+			f := &field{Name: xf.FieldName, GoName: goName(xf.FieldName)}
+			if f.Mask, err = parseUint8("fieldmask", xf.FieldMask); err != nil {
+				return nil, err
+			}
+			if f.Shift, err = parseShift("shifter", xf.Shifter); err != nil {
+				return nil, err
+			}
+			p.Fields = append(p.Fields, f)
+		}
+		for _, xf := range xp.BitFlags {
+			f := &field{Name: xf.FlagName, GoName: goName(xf.FlagName), Flag: true}
+			if f.Mask, err = parseUint8("flagmask", xf.FlagMask); err != nil {
+				return nil, err
+			}
+			p.Fields = append(p.Fields, f)
+		}
+
+		// Fields are encoded from the least significant bit
+		sort.SliceStable(p.Fields, func(i, j int) bool {
+			return p.Fields[i].Mask < p.Fields[j].Mask
+		})
+
+		var mask uint8
+		for _, f := range p.Fields {
+			if f.GoName == "" {
+				f.GoName = fmt.Sprintf("Field%02X", f.Mask)
+			}
+			if f.Mask == 0 || (f.Mask>>f.Shift)<<f.Shift != f.Mask {
+				return nil, fmt.Errorf("bad mask 0x%02x for %q", f.Mask, f.Name)
+			}
+			if f.Flag && f.Mask&(f.Mask-1) != 0 {
+				return nil, fmt.Errorf("bad flag mask 0x%02x for %q", f.Mask, f.Name)
+			}
+			if mask&f.Mask != 0 {
+				return nil, fmt.Errorf("overlapping mask 0x%02x for %q", f.Mask, f.Name)
+			}
+			mask |= f.Mask
+		}
+
+	case paramTypeVariant:
+		if len(xp.Variant) != 1 {
+			return nil, fmt.Errorf("expected one variant")
+		}
+		if change := xp.Variant[0].SizeChange; change != "" && change != "0" {
+			return nil, fmt.Errorf("unsupported sizechange %q", change)
+		}
+		if err = p.setLength(xp.Variant[0].ParamOffs, xp.Variant[0].SizeMask,
+			xp.Variant[0].SizeOffs); err != nil {
+			return nil, err
+		}
+
+	case paramTypeBitmask:
+		if len(xp.Bitmask) != 1 {
+			return nil, fmt.Errorf("expected one bitmask")
+		}
+		if err = p.setLength(xp.Bitmask[0].ParamOffs, xp.Bitmask[0].LenMask,
+			xp.Bitmask[0].LenOffs); err != nil {
+			return nil, err
+		}
+
+	case paramTypeArray:
+		switch {
+		case len(xp.Array) == 1 && len(xp.ArrayLen) == 0:
+			if err = p.setArrayLength(xp.Array[0].Len); err != nil {
+				return nil, err
+			}
+		case len(xp.ArrayLen) == 1:
+			if err = p.setLength(xp.ArrayLen[0].ParamOffs, xp.ArrayLen[0].LenMask,
+				xp.ArrayLen[0].LenOffs); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("expected one arrayattrib or arraylen")
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %q", xp.Type)
+	}
+
+	return p, nil
+}
+
+// parseGroup parses a variant_group element
+func parseGroup(key uint8, xg *xmlVariantGroup) (*param, error) {
+	p := &param{Key: key, Name: xg.Name, GoName: goName(xg.Name),
+		Type: paramTypeVariantGroup}
+	if p.GoName == "" {
+		p.GoName = fmt.Sprintf("Group%d", key)
+	}
+
+	offset := xg.ParamOffs
+	if offset == "" {
+		offset = xg.ParamOffsLower
+	}
+	if err := p.setLength(offset, xg.SizeMask, xg.SizeOffs); err != nil {
+		return nil, err
+	}
+
+	for i := range xg.Params {
+		xp := &xg.Params[i]
+		memberKey, err := parseUint8("param key", xp.Key)
+		if err != nil {
+			return nil, err
+		}
+		member, err := parseParam(memberKey, xp)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", xp.Name, err)
+		}
+		p.Group = append(p.Group, member)
+	}
+	if len(p.Group) == 0 {
+		return nil, fmt.Errorf("empty variant group")
+	}
+
+	sort.SliceStable(p.Group, func(i, j int) bool { return p.Group[i].Key < p.Group[j].Key })
+	if err := checkParams(p.Group, true); err != nil {
+		return nil, fmt.Errorf("%q: %v", p.Group[err.(*paramError).Index].Name, err)
+	}
+
+	return p, nil
+}
+
+// setRequired sets the number of required parameters of every command to the
+// number of parameters of its version with the fewest parameters, and before
+// the first MARKER
+func setRequired(classes []*commandClass) {
+	required := make(map[uint16]int)
+	for _, class := range classes {
+		for _, cmd := range class.Commands {
+			key := uint16(class.Key)<<8 | uint16(cmd.Key)
+			if count, ok := required[key]; !ok || len(cmd.Params) < count {
+				required[key] = len(cmd.Params)
+			}
+		}
+	}
+
+	for _, class := range classes {
+		for _, cmd := range class.Commands {
+			cmd.Required = required[uint16(class.Key)<<8|uint16(cmd.Key)]
+			for i, p := range cmd.Params {
+				if p.Type == paramTypeMarker && i < cmd.Required {
+					cmd.Required = i
+				}
+			}
+		}
+	}
+}
+
+// setLength of a VARIANT, BITMASK, ARRAY or VARIANT_GROUP parameter. The
+// length parameter is looked up later, because it is identified by key.
+func (p *param) setLength(offset string, mask string, shift string) error {
+	key, err := parseUint8("paramoffs", offset)
+	if err != nil {
+		return err
+	}
+
+	if key == paramOffsetRest {
+		p.Rest = true
+		return nil
+	}
+
+	if p.LengthMask, err = parseUint8("size mask", mask); err != nil {
+		return err
+	}
+
+	if p.LengthShift, err = parseShift("size shift", shift); err != nil {
+		return err
+	}
+
+	// Temporary placeholder, resolved in checkParams
+	p.LengthParam = &param{Key: key}
+	return nil
+}
+
+// setArrayLength sets the fixed length of an ARRAY or ENUM_ARRAY parameter, or
+// extends it to the end of the command for paramOffsetRest
+func (p *param) setArrayLength(length string) error {
+	parsed, err := parseUint8("len", length)
+	if err != nil || parsed == 0 {
+		return fmt.Errorf("bad array length %q", length)
+	}
+
+	if parsed == paramOffsetRest {
+		p.Rest = true
+		return nil
+	}
+
+	p.Rest = false
+	p.Length = int(parsed)
+	return nil
+}
+
+// checkParams resolves parameter length references, validates the parameters
+// of a command or variant group, and makes their names unique. Returns a
+// *paramError.
+func checkParams(params []*param, group bool) error {
+	fail := func(index int, format string, args ...interface{}) error {
+		return &paramError{Index: index, Err: fmt.Errorf(format, args...)}
+	}
+
+	byKey := make(map[uint8]*param)
+	for i, p := range params {
+		if byKey[p.Key] != nil {
+			return fail(i, "duplicate param key 0x%02x", p.Key)
+		}
+		byKey[p.Key] = p
+
+		if group && (p.Rest || p.Type == paramTypeMarker) {
+			return fail(i, "variable length %s in a variant group", p.Type)
+		}
+
+		if p.Rest {
+			switch {
+			case i == len(params)-1:
+			case params[i+1].Type == paramTypeMarker && goType(p) == "[]uint8":
+				p.EndMarker = params[i+1]
+			default:
+				return fail(i, "only the last parameter, or one before a MARKER, "+
+					"can extend to the end")
+			}
+		}
+
+		if p.LengthParam == nil {
+			continue
+		}
+
+		source := byKey[p.LengthParam.Key]
+		if source == nil {
+			return fail(i, "length parameter 0x%02x is not before it", p.LengthParam.Key)
+		}
+		p.LengthParam = source
+
+		switch source.Type {
+		case paramTypeByte:
+			if p.LengthMask == 0 {
+				p.LengthMask = 0xff
+			}
+			if p.LengthMask != 0xff || p.LengthShift != 0 {
+				return fail(i, "partial BYTE length is not supported")
+			}
+			source.LengthOf = append(source.LengthOf, p)
+
+		case paramTypeStructByte:
+			for _, f := range source.Fields {
+				if f.Mask == p.LengthMask && f.Shift == p.LengthShift && !f.Flag {
+					p.LengthField = f
+				}
+			}
+			if p.LengthField == nil {
+				return fail(i, "no field with mask 0x%02x in %q", p.LengthMask,
+					source.Name)
+			}
+			p.LengthField.LengthOf = append(p.LengthField.LengthOf, p)
+
+		default:
+			return fail(i, "unsupported length parameter type %q", source.Type)
+		}
+	}
+
+	// Names of struct fields, and of local variables of the codec
+	used := make(names)
+	if !group {
+		for _, method := range commandMethods {
+			used[method] = true
+		}
+	}
+	for _, p := range params {
+		p.GoName = used.unique(p.GoName)
+		for _, f := range p.Fields {
+			f.GoName = used.unique(f.GoName)
+		}
+	}
+
+	return nil
+}
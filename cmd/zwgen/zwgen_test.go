@@ -0,0 +1,482 @@
+package main
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSpec = `<?xml version="1.0" encoding="utf-8"?>
+<zw_classes>
+  <cmd_class key="0x31" version="5" name="COMMAND_CLASS_SENSOR_MULTILEVEL" help="Command Class Sensor Multilevel">
+    <cmd key="0x05" name="SENSOR_MULTILEVEL_REPORT" help="Sensor Multilevel Report">
+      <param key="0x01" name="Level" type="STRUCT_BYTE">
+        <bitfield key="0x00" fieldname="Size" fieldmask="0x07" shifter="0" />
+        <bitfield key="0x01" fieldname="Scale" fieldmask="0x18" shifter="3" />
+        <bitfield key="0x02" fieldname="Precision" fieldmask="0xE0" shifter="5" />
+      </param>
+      <param key="0x00" name="Sensor Type" type="BYTE" />
+      <param key="0x02" name="Sensor Value" type="VARIANT">
+        <variant paramoffs="1" sizemask="0x07" sizeoffs="0" />
+      </param>
+    </cmd>
+  </cmd_class>
+  <cmd_class key="0x25" version="1" name="COMMAND_CLASS_SWITCH_BINARY" help="Command Class Switch Binary">
+    <cmd key="0x01" name="SWITCH_BINARY_SET" help="Switch Binary Set">
+      <param key="0x00" name="Switch Value" type="CONST">
+        <const key="0x00" flagname="off/disable" flagmask="0x00" />
+        <const key="0x01" flagname="on/enable" flagmask="0xFF" />
+      </param>
+    </cmd>
+  </cmd_class>
+</zw_classes>`
+
+// featureSpec has parameter types, which are not in testSpec
+const featureSpec = `<?xml version="1.0" encoding="utf-8"?>
+<zw_classes>
+  <cmd_class key="0x8E" version="2" name="COMMAND_CLASS_MULTI_CHANNEL_ASSOCIATION_V2" help="Command Class Multi Channel Association">
+    <cmd key="0x03" name="MULTI_CHANNEL_ASSOCIATION_REPORT_V2" help="Multi Channel Association Report">
+      <param key="0x00" name="Grouping Identifier" type="BYTE" />
+      <param key="0x01" name="Max Nodes Supported" type="BYTE" />
+      <param key="0x02" name="Reports to Follow" type="BYTE" />
+      <param key="0x03" name="Node ID" type="ARRAY">
+        <arrayattrib key="0x00" len="255" is_ascii="false" />
+      </param>
+      <param key="0x04" name="Marker" type="MARKER">
+        <const key="0x00" flagname="MULTI_CHANNEL_ASSOCIATION_REPORT_MARKER_V2" flagmask="0x00" />
+      </param>
+      <variant_group key="0x05" name="vg" paramOffs="255" sizemask="0x00" sizeoffs="0">
+        <param key="0x00" name="Multi Channel Node ID" type="BYTE" />
+        <param key="0x01" name="Properties1" type="STRUCT_BYTE">
+          <bitfield key="0x00" fieldname="End Point" fieldmask="0x7F" shifter="0" />
+          <bitflag key="0x01" flagname="Bit Address" flagmask="0x80" />
+        </param>
+      </variant_group>
+    </cmd>
+  </cmd_class>
+  <cmd_class key="0x40" version="1" name="COMMAND_CLASS_THERMOSTAT_MODE" help="Command Class Thermostat Mode">
+    <cmd key="0x01" name="THERMOSTAT_MODE_SET" help="Thermostat Mode Set">
+      <param key="0x00" name="Mode" type="ENUM">
+        <enum key="0x00" name="Off" />
+        <enum key="0x01" name="Heat" />
+      </param>
+    </cmd>
+    <cmd key="0x05" name="THERMOSTAT_MODE_SUPPORTED_REPORT" help="Thermostat Mode Supported Report">
+      <param key="0x00" name="Mode" type="ENUM_ARRAY">
+        <enum key="0x00" name="Off" />
+        <enum key="0x01" name="Heat" />
+      </param>
+    </cmd>
+  </cmd_class>
+  <cmd_class key="0xF0" version="1" name="COMMAND_CLASS_TEST" help="Command Class Test">
+    <cmd key="0x01" name="TEST_REPORT" help="Test Report">
+      <param key="0x00" name="Number of Items" type="BYTE" />
+      <variant_group key="0x01" name="Item" paramOffs="0" sizemask="0xFF" sizeoffs="0">
+        <param key="0x00" name="Properties1" type="STRUCT_BYTE">
+          <bitfield key="0x00" fieldname="Size" fieldmask="0x07" shifter="0" />
+          <bitfield key="0x01" fieldname="Type" fieldmask="0xF8" shifter="3" />
+        </param>
+        <param key="0x01" name="Value" type="VARIANT">
+          <variant paramoffs="0" sizemask="0x07" sizeoffs="0" />
+        </param>
+        <param key="0x02" name="Mode" type="ENUM">
+          <enum key="0x00" name="Off" />
+        </param>
+      </variant_group>
+      <param key="0x02" name="Word" type="WORD" />
+    </cmd>
+    <cmd key="0x02" name="TEST_SET" help="Test Set">
+      <param key="0x00" name="Value" type="BYTE" />
+      <param key="0x01" name="Float" type="FLOAT" />
+    </cmd>
+  </cmd_class>
+</zw_classes>`
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"SWITCH_BINARY_SET": "SwitchBinarySet",
+		"Sensor Value":      "SensorValue",
+		"Up/ Down":          "UpDown",
+		"on/enable":         "OnEnable",
+		"Scale Bits 10":     "ScaleBits10",
+		"10 bits":           "X10Bits",
+		"ZWave Alarm Type":  "ZWaveAlarmType",
+		"NodeID":            "NodeID",
+		"Source Node ID":    "SourceNodeID",
+	}
+
+	for name, expected := range tests {
+		if actual := goName(name); actual != expected {
+			t.Fatalf("%q: %q != %q", name, actual, expected)
+		}
+	}
+
+	if actual := classGoName("COMMAND_CLASS_SWITCH_BINARY"); actual != "SwitchBinary" {
+		t.Fatalf("%q != SwitchBinary", actual)
+	}
+}
+
+func TestParseSpec(t *testing.T) {
+	classes, warnings, err := parseSpec(strings.NewReader(testSpec), nil)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if len(classes) != 2 {
+		t.Fatalf("Bad number of classes: %d != 2", len(classes))
+	}
+
+	if len(warnings) != 0 {
+		t.Fatalf("Unexpected warnings: %v", warnings)
+	}
+
+	report := classes[0].Commands[0]
+	if report.GoName != "SensorMultilevelReportV5" {
+		t.Fatalf("Bad name: %s", report.GoName)
+	}
+
+	// Parameters are ordered by key
+	if report.Params[0].GoName != "SensorType" || report.Params[1].GoName != "Level" {
+		t.Fatalf("Bad parameter order: %s %s", report.Params[0].GoName,
+			report.Params[1].GoName)
+	}
+
+	value := report.Params[2]
+	if value.LengthParam != report.Params[1] ||
+		value.LengthField != report.Params[1].Fields[0] ||
+		!report.Params[1].Fields[0].Derived() {
+		t.Fatalf("Bad length reference")
+	}
+
+	constants := classes[1].Commands[0].Params[0].Consts
+	if len(constants) != 2 || constants[1].GoName != "SwitchBinarySetV1SwitchValueOnEnable" ||
+		constants[1].Value != 0xff {
+		t.Fatalf("Bad constants: %+v", constants)
+	}
+
+	if report.Required != 3 {
+		t.Fatalf("Bad number of required parameters: %d != 3", report.Required)
+	}
+
+	// Parameters added by newer versions are optional
+	if classes, _, err = parseSpec(strings.NewReader(`<zw_classes>
+	  <cmd_class key="0x20" version="2" name="COMMAND_CLASS_BASIC">
+	    <cmd key="0x03" name="BASIC_REPORT">
+	      <param key="0x00" name="Current Value" type="BYTE"/>
+	      <param key="0x01" name="Target Value" type="BYTE"/>
+	    </cmd>
+	  </cmd_class>
+	  <cmd_class key="0x20" version="1" name="COMMAND_CLASS_BASIC">
+	    <cmd key="0x03" name="BASIC_REPORT"><param key="0x00" name="Value" type="BYTE"/></cmd>
+	  </cmd_class>
+	</zw_classes>`), nil); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	} else if classes[0].Commands[0].Required != 1 || classes[1].Commands[0].Required != 1 {
+		t.Fatalf("Bad number of required parameters: %d %d",
+			classes[0].Commands[0].Required, classes[1].Commands[0].Required)
+	}
+
+	// Filter by command class
+	if classes, _, err = parseSpec(strings.NewReader(testSpec),
+		map[uint8]bool{0x25: true}); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	} else if len(classes) != 1 || classes[0].Key != 0x25 {
+		t.Fatalf("Bad filtered classes: %d", len(classes))
+	}
+
+	// Names are made unique, and can't be method names
+	if classes, _, err = parseSpec(strings.NewReader(`<zw_classes>
+	  <cmd_class key="0x01" version="1" name="COMMAND_CLASS_X">
+	    <cmd key="0x01" name="X_SET">
+	      <param key="0x00" name="A" type="BYTE"/>
+	      <param key="0x01" name="A" type="WORD"/>
+	      <param key="0x02" name="Version" type="BYTE"/>
+	    </cmd>
+	  </cmd_class>
+	</zw_classes>`), nil); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	} else if params := classes[0].Commands[0].Params; params[0].GoName != "A" ||
+		params[1].GoName != "A2" || params[2].GoName != "Version2" {
+		t.Fatalf("Bad names: %s %s %s", params[0].GoName, params[1].GoName,
+			params[2].GoName)
+	}
+}
+
+func TestParseSpecFeatures(t *testing.T) {
+	classes, warnings, err := parseSpec(strings.NewReader(featureSpec), nil)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	// FLOAT is not supported
+	if len(warnings) != 1 || classes[2].Commands[1].Params[1].Type != paramTypeRaw {
+		t.Fatalf("Bad warnings: %v", warnings)
+	}
+
+	// Version suffixes are removed from names
+	if classes[0].GoName != "MultiChannelAssociation" {
+		t.Fatalf("Bad class name: %s", classes[0].GoName)
+	}
+
+	// ARRAY extending to a MARKER, followed by a variant group
+	report := classes[0].Commands[0]
+	if len(report.Params) != 6 {
+		t.Fatalf("Bad number of parameters: %d != 6", len(report.Params))
+	}
+
+	nodeID, marker, group := report.Params[3], report.Params[4], report.Params[5]
+	if !nodeID.Rest || nodeID.EndMarker != marker || marker.Value != 0x00 {
+		t.Fatalf("Bad marker: %+v %+v", nodeID, marker)
+	}
+
+	if group.Type != paramTypeVariantGroup || !group.Rest ||
+		group.GroupGoName != "MultiChannelAssociationReportV2Vg" ||
+		len(group.Group) != 2 || goType(group) != "[]MultiChannelAssociationReportV2Vg" {
+		t.Fatalf("Bad variant group: %+v", group)
+	}
+
+	// Parameters after a MARKER are optional
+	if report.Required != 4 {
+		t.Fatalf("Bad number of required parameters: %d != 4", report.Required)
+	}
+
+	// ENUM values and ENUM_ARRAY to the end of the command
+	mode := classes[1].Commands[0].Params[0]
+	if len(mode.Consts) != 2 || mode.Consts[1].GoName != "ThermostatModeSetV1ModeHeat" ||
+		mode.Consts[1].Value != 0x01 {
+		t.Fatalf("Bad enum constants: %+v", mode.Consts)
+	}
+
+	supported := classes[1].Commands[1].Params[0]
+	if !supported.Rest || goType(supported) != "[]uint8" {
+		t.Fatalf("Bad enum array: %+v", supported)
+	}
+
+	// Variant group with a length parameter
+	items := classes[2].Commands[0].Params[1]
+	if items.LengthParam != classes[2].Commands[0].Params[0] || items.Rest {
+		t.Fatalf("Bad variant group length: %+v", items)
+	}
+
+	// ISO-8859-1 encoded definitions
+	if classes, _, err = parseSpec(strings.NewReader("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>"+
+		"<zw_classes><cmd_class key=\"0x01\" version=\"1\" name=\"COMMAND_CLASS_X\" help=\"\xb0C\"/></zw_classes>"),
+		nil); err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	} else if classes[0].Help != "\u00b0C" {
+		t.Fatalf("Bad help: %q", classes[0].Help)
+	}
+}
+
+func TestParseSpecRaw(t *testing.T) {
+	tests := []struct {
+		params string
+		count  int // Parameters before Raw
+	}{
+		// Unsupported parameter type
+		{`<param key="0x00" name="A" type="BYTE"/>
+		  <param key="0x01" name="B" type="FLOAT"/>
+		  <param key="0x02" name="C" type="BYTE"/>`, 1},
+		// Length parameter after the variant
+		{`<param key="0x00" name="A" type="VARIANT"><variant paramoffs="1" sizemask="0xFF"/></param>
+		  <param key="0x01" name="B" type="BYTE"/>`, 0},
+		// Unsupported variant size change
+		{`<param key="0x00" name="A" type="BYTE"/>
+		  <param key="0x01" name="B" type="VARIANT"><variant paramoffs="0" sizemask="0xFF" sizechange="1"/></param>`, 1},
+		// Overlapping fields
+		{`<param key="0x00" name="A" type="STRUCT_BYTE">
+		    <bitfield fieldname="B" fieldmask="0x07" shifter="0"/>
+		    <bitfield fieldname="C" fieldmask="0x0C" shifter="2"/>
+		  </param>`, 0},
+		// Rest of frame parameter is not last
+		{`<param key="0x00" name="A" type="BITMASK"><bitmask paramoffs="255"/></param>
+		  <param key="0x01" name="B" type="BYTE"/>`, 0},
+		// Variable length parameter in a variant group
+		{`<param key="0x00" name="A" type="BYTE"/>
+		  <variant_group key="0x01" name="B" paramOffs="0" sizemask="0xFF">
+		    <param key="0x00" name="C" type="BITMASK"><bitmask paramoffs="255"/></param>
+		  </variant_group>`, 1},
+	}
+
+	for _, test := range tests {
+		classes, warnings, err := parseSpec(strings.NewReader(`<zw_classes>
+		  <cmd_class key="0x01" version="1" name="COMMAND_CLASS_X">
+		    <cmd key="0x01" name="X_SET">`+test.params+`</cmd>
+		  </cmd_class></zw_classes>`), nil)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", test.params, err)
+		}
+
+		if len(warnings) != 1 {
+			t.Fatalf("Bad warnings for %s: %v", test.params, warnings)
+		}
+
+		params := classes[0].Commands[0].Params
+		if len(params) != test.count+1 || params[test.count].Type != paramTypeRaw ||
+			params[test.count].GoName != "Raw" || !params[test.count].Rest {
+			t.Fatalf("Bad parameters for %s: %d", test.params, len(params))
+		}
+	}
+}
+
+func TestParseSpecErrors(t *testing.T) {
+	specs := []string{
+		// Not XML
+		`<zw_classes`,
+		// Bad key
+		`<zw_classes><cmd_class key="0x100" version="1" name="COMMAND_CLASS_X"/></zw_classes>`,
+		// Bad command key
+		`<zw_classes><cmd_class key="0x01" version="1" name="COMMAND_CLASS_X">
+		   <cmd key="0x100" name="X_SET"/>
+		 </cmd_class></zw_classes>`,
+		// Duplicate command
+		`<zw_classes><cmd_class key="0x01" version="1" name="COMMAND_CLASS_X">
+		   <cmd key="0x01" name="X_SET"/><cmd key="0x02" name="X_SET"/>
+		 </cmd_class></zw_classes>`,
+		// Command class name with two keys
+		`<zw_classes><cmd_class key="0x01" version="1" name="COMMAND_CLASS_X"/>
+		 <cmd_class key="0x02" version="2" name="COMMAND_CLASS_X"/></zw_classes>`,
+	}
+
+	for _, spec := range specs {
+		if _, _, err := parseSpec(strings.NewReader(spec), nil); err == nil {
+			t.Fatalf("Expected error for: %s", spec)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	directory, err := ioutil.TempDir("", "zwgen")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(directory)
+
+	specPath := filepath.Join(directory, "spec.xml")
+	outPath := filepath.Join(directory, "out.go")
+	testPath := filepath.Join(directory, "out_test.go")
+
+	if err = ioutil.WriteFile(specPath, []byte(testSpec), 0600); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	if err = run(specPath, "commandclass", outPath, testPath, ""); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	for _, path := range []string{outPath, testPath} {
+		source, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+
+		if !bytes.HasPrefix(source, []byte("// Code generated by zwgen from spec.xml. DO NOT EDIT.")) {
+			t.Fatalf("%s: missing generated header", path)
+		}
+
+		if _, err = parser.ParseFile(token.NewFileSet(), path, source, 0); err != nil {
+			t.Fatalf("%s: failed to parse: %v", path, err)
+		}
+	}
+
+	source, _ := ioutil.ReadFile(outPath)
+	for _, expected := range []string{
+		"type SensorMultilevelReportV5 struct",
+		"SwitchBinarySetV1SwitchValueOnEnable",
+		"lengthLevelSize := int(data[offset] & 0x07)",
+		"{CommandClassSwitchBinary, 1, 0x01, func() Command { return &SwitchBinarySetV1{} }}",
+	} {
+		if !bytes.Contains(source, []byte(expected)) {
+			t.Fatalf("Missing %q in generated code", expected)
+		}
+	}
+
+	// Only the minimum length is checked
+	if bytes.Contains(source, []byte("offset != len(data)")) {
+		t.Fatalf("Unexpected exact length check in generated code")
+	}
+
+	// The size field is derived from the variant length
+	if bytes.Contains(source, []byte("\tSize uint8")) {
+		t.Fatalf("Unexpected Size field in generated code")
+	}
+
+	if err = run(specPath, "commandclass", outPath, "", "0x100"); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
+
+func TestGenerateRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skipf("Skipping go test of generated code")
+	}
+
+	directory, err := ioutil.TempDir("", "zwgen")
+	if err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	defer os.RemoveAll(directory)
+
+	// The commandclass package tests, with the feature classes added to its
+	// definitions
+	subset, err := ioutil.ReadFile(filepath.Join("testdata", "zwave_cmd_classes_subset.xml"))
+	if err != nil {
+		t.Fatalf("Failed to read spec: %v", err)
+	}
+	features := featureSpec[strings.Index(featureSpec, "<zw_classes>")+len("<zw_classes>"):]
+	spec := strings.Replace(string(subset), "</zw_classes>", features, 1)
+
+	specPath := filepath.Join(directory, "spec.xml")
+	if err = ioutil.WriteFile(specPath, []byte(spec), 0600); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	for _, name := range []string{"commandclass.go", "commandclass_test.go"} {
+		source, err := ioutil.ReadFile(filepath.Join("..", "..", "commandclass", name))
+		if err != nil {
+			t.Fatalf("Failed to read: %v", err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(directory, name), source, 0600); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(directory, "go.mod"),
+		[]byte("module commandclass\n"), 0600); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	if err = run(specPath, "commandclass", filepath.Join(directory, "out.go"),
+		filepath.Join(directory, "out_test.go"), ""); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	command := exec.Command("go", "test", "-vet=off", ".")
+	command.Dir = directory
+	command.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=")
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("Generated code failed: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,2984 @@
+// Code generated by zwgen from zwave_cmd_classes_subset.xml. DO NOT EDIT.
+
+package commandclass
+
+import "fmt"
+
+// Command classes
+const (
+	CommandClassBasic            uint8 = 0x20
+	CommandClassSwitchBinary           = 0x25
+	CommandClassSwitchMultilevel       = 0x26
+	CommandClassSensorBinary           = 0x30
+	CommandClassSensorMultilevel       = 0x31
+	CommandClassMeter                  = 0x32
+	CommandClassAlarm                  = 0x71
+	CommandClassBattery                = 0x80
+	CommandClassWakeUp                 = 0x84
+)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicSetV1 is the Basic Set command, version 1
+type BasicSetV1 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *BasicSetV1) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicSetV1) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *BasicSetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *BasicSetV1) Name() string {
+	return "BASIC_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicSetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicSetV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicSetV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicGetV1 is the Basic Get command, version 1
+type BasicGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *BasicGetV1) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicGetV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *BasicGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *BasicGetV1) Name() string {
+	return "BASIC_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicReportV1 is the Basic Report command, version 1
+type BasicReportV1 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *BasicReportV1) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicReportV1) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *BasicReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *BasicReportV1) Name() string {
+	return "BASIC_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicSetV2 is the Basic Set command, version 2
+type BasicSetV2 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *BasicSetV2) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicSetV2) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *BasicSetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *BasicSetV2) Name() string {
+	return "BASIC_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicSetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicSetV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicSetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicGetV2 is the Basic Get command, version 2
+type BasicGetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *BasicGetV2) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicGetV2) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *BasicGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *BasicGetV2) Name() string {
+	return "BASIC_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicGetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicReportV2 is the Basic Report command, version 2
+type BasicReportV2 struct {
+	CurrentValue uint8
+	TargetValue  uint8
+	Duration     uint8
+}
+
+// CommandClass returns the command class ID
+func (command *BasicReportV2) CommandClass() uint8 {
+	return CommandClassBasic
+}
+
+// CommandID returns the command ID
+func (command *BasicReportV2) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *BasicReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *BasicReportV2) Name() string {
+	return "BASIC_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BasicReportV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.CurrentValue)
+	payload = append(payload, command.TargetValue)
+	payload = append(payload, command.Duration)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BasicReportV2) Decode(data []uint8) error {
+	*command = BasicReportV2{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.CurrentValue = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.TargetValue = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BasicReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Duration = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Switch Value values of SwitchBinarySetV1
+const (
+	SwitchBinarySetV1SwitchValueOffDisable uint8 = 0x00
+	SwitchBinarySetV1SwitchValueOnEnable         = 0xff
+)
+
+// SwitchBinarySetV1 is the Switch Binary Set command, version 1
+type SwitchBinarySetV1 struct {
+	SwitchValue uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchBinarySetV1) CommandClass() uint8 {
+	return CommandClassSwitchBinary
+}
+
+// CommandID returns the command ID
+func (command *SwitchBinarySetV1) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *SwitchBinarySetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchBinarySetV1) Name() string {
+	return "SWITCH_BINARY_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchBinarySetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	payload = append(payload, command.SwitchValue)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchBinarySetV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchBinarySetV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SwitchValue = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchBinaryGetV1 is the Switch Binary Get command, version 1
+type SwitchBinaryGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchBinaryGetV1) CommandClass() uint8 {
+	return CommandClassSwitchBinary
+}
+
+// CommandID returns the command ID
+func (command *SwitchBinaryGetV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *SwitchBinaryGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchBinaryGetV1) Name() string {
+	return "SWITCH_BINARY_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchBinaryGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchBinaryGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Value values of SwitchBinaryReportV1
+const (
+	SwitchBinaryReportV1ValueOffDisable uint8 = 0x00
+	SwitchBinaryReportV1ValueOnEnable         = 0xff
+)
+
+// SwitchBinaryReportV1 is the Switch Binary Report command, version 1
+type SwitchBinaryReportV1 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchBinaryReportV1) CommandClass() uint8 {
+	return CommandClassSwitchBinary
+}
+
+// CommandID returns the command ID
+func (command *SwitchBinaryReportV1) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *SwitchBinaryReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchBinaryReportV1) Name() string {
+	return "SWITCH_BINARY_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchBinaryReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchBinaryReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchBinaryReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchMultilevelSetV1 is the Switch Multilevel Set command, version 1
+type SwitchMultilevelSetV1 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchMultilevelSetV1) CommandClass() uint8 {
+	return CommandClassSwitchMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SwitchMultilevelSetV1) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *SwitchMultilevelSetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchMultilevelSetV1) Name() string {
+	return "SWITCH_MULTILEVEL_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchMultilevelSetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchMultilevelSetV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchMultilevelSetV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchMultilevelGetV1 is the Switch Multilevel Get command, version 1
+type SwitchMultilevelGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchMultilevelGetV1) CommandClass() uint8 {
+	return CommandClassSwitchMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SwitchMultilevelGetV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *SwitchMultilevelGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchMultilevelGetV1) Name() string {
+	return "SWITCH_MULTILEVEL_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchMultilevelGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchMultilevelGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchMultilevelReportV1 is the Switch Multilevel Report command, version 1
+type SwitchMultilevelReportV1 struct {
+	Value uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchMultilevelReportV1) CommandClass() uint8 {
+	return CommandClassSwitchMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SwitchMultilevelReportV1) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *SwitchMultilevelReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchMultilevelReportV1) Name() string {
+	return "SWITCH_MULTILEVEL_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchMultilevelReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.Value)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchMultilevelReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchMultilevelReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Value = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchMultilevelStartLevelChangeV1 is the Switch Multilevel Start Level Change command, version 1
+type SwitchMultilevelStartLevelChangeV1 struct {
+	Reserved1        uint8
+	IgnoreStartLevel bool
+	Reserved2        bool
+	UpDown           bool
+	StartLevel       uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchMultilevelStartLevelChangeV1) CommandClass() uint8 {
+	return CommandClassSwitchMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SwitchMultilevelStartLevelChangeV1) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *SwitchMultilevelStartLevelChangeV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchMultilevelStartLevelChangeV1) Name() string {
+	return "SWITCH_MULTILEVEL_START_LEVEL_CHANGE"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchMultilevelStartLevelChangeV1) Encode() ([]uint8, error) {
+	if command.Reserved1 > 0x1f {
+		return nil, fmt.Errorf("Bad SwitchMultilevelStartLevelChangeV1 Reserved1: 0x%02x > 0x1f", command.Reserved1)
+	}
+	payload := []uint8{0x04}
+	fieldLevel := uint8(0)
+	fieldLevel |= command.Reserved1
+	if command.IgnoreStartLevel {
+		fieldLevel |= 0x20
+	}
+	if command.Reserved2 {
+		fieldLevel |= 0x40
+	}
+	if command.UpDown {
+		fieldLevel |= 0x80
+	}
+	payload = append(payload, fieldLevel)
+	payload = append(payload, command.StartLevel)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchMultilevelStartLevelChangeV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchMultilevelStartLevelChangeV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Reserved1 = data[offset] & 0x1f
+	command.IgnoreStartLevel = data[offset]&0x20 != 0
+	command.Reserved2 = data[offset]&0x40 != 0
+	command.UpDown = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SwitchMultilevelStartLevelChangeV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.StartLevel = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SwitchMultilevelStopLevelChangeV1 is the Switch Multilevel Stop Level Change command, version 1
+type SwitchMultilevelStopLevelChangeV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SwitchMultilevelStopLevelChangeV1) CommandClass() uint8 {
+	return CommandClassSwitchMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SwitchMultilevelStopLevelChangeV1) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *SwitchMultilevelStopLevelChangeV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SwitchMultilevelStopLevelChangeV1) Name() string {
+	return "SWITCH_MULTILEVEL_STOP_LEVEL_CHANGE"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SwitchMultilevelStopLevelChangeV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x05}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SwitchMultilevelStopLevelChangeV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorBinaryGetV1 is the Sensor Binary Get command, version 1
+type SensorBinaryGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinaryGetV1) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinaryGetV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *SensorBinaryGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinaryGetV1) Name() string {
+	return "SENSOR_BINARY_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinaryGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinaryGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Sensor Value values of SensorBinaryReportV1
+const (
+	SensorBinaryReportV1SensorValueIdle            uint8 = 0x00
+	SensorBinaryReportV1SensorValueDetectedAnEvent       = 0xff
+)
+
+// SensorBinaryReportV1 is the Sensor Binary Report command, version 1
+type SensorBinaryReportV1 struct {
+	SensorValue uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinaryReportV1) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinaryReportV1) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *SensorBinaryReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinaryReportV1) Name() string {
+	return "SENSOR_BINARY_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinaryReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.SensorValue)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinaryReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorBinaryReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorValue = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorBinarySupportedGetSensorV2 is the Sensor Binary Supported Get Sensor command, version 2
+type SensorBinarySupportedGetSensorV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinarySupportedGetSensorV2) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinarySupportedGetSensorV2) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *SensorBinarySupportedGetSensorV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinarySupportedGetSensorV2) Name() string {
+	return "SENSOR_BINARY_SUPPORTED_GET_SENSOR"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinarySupportedGetSensorV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinarySupportedGetSensorV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorBinaryGetV2 is the Sensor Binary Get command, version 2
+type SensorBinaryGetV2 struct {
+	SensorType uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinaryGetV2) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinaryGetV2) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *SensorBinaryGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinaryGetV2) Name() string {
+	return "SENSOR_BINARY_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinaryGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	payload = append(payload, command.SensorType)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinaryGetV2) Decode(data []uint8) error {
+	*command = SensorBinaryGetV2{}
+	offset := 0
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorBinaryGetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Sensor Value values of SensorBinaryReportV2
+const (
+	SensorBinaryReportV2SensorValueIdle            uint8 = 0x00
+	SensorBinaryReportV2SensorValueDetectedAnEvent       = 0xff
+)
+
+// SensorBinaryReportV2 is the Sensor Binary Report command, version 2
+type SensorBinaryReportV2 struct {
+	SensorValue uint8
+	SensorType  uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinaryReportV2) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinaryReportV2) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *SensorBinaryReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinaryReportV2) Name() string {
+	return "SENSOR_BINARY_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinaryReportV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.SensorValue)
+	payload = append(payload, command.SensorType)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinaryReportV2) Decode(data []uint8) error {
+	*command = SensorBinaryReportV2{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorBinaryReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorValue = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorBinaryReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorBinarySupportedSensorReportV2 is the Sensor Binary Supported Sensor Report command, version 2
+type SensorBinarySupportedSensorReportV2 struct {
+	BitMask []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorBinarySupportedSensorReportV2) CommandClass() uint8 {
+	return CommandClassSensorBinary
+}
+
+// CommandID returns the command ID
+func (command *SensorBinarySupportedSensorReportV2) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *SensorBinarySupportedSensorReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *SensorBinarySupportedSensorReportV2) Name() string {
+	return "SENSOR_BINARY_SUPPORTED_SENSOR_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorBinarySupportedSensorReportV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x04}
+	payload = append(payload, command.BitMask...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorBinarySupportedSensorReportV2) Decode(data []uint8) error {
+	offset := 0
+	command.BitMask = nil
+	if len(data)-offset > 0 {
+		command.BitMask = append([]uint8(nil), data[offset:offset+len(data)-offset]...)
+	}
+	offset += len(data) - offset
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelSupportedGetSensorV5 is the Sensor Multilevel Supported Get Sensor command, version 5
+type SensorMultilevelSupportedGetSensorV5 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelSupportedGetSensorV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelSupportedGetSensorV5) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelSupportedGetSensorV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelSupportedGetSensorV5) Name() string {
+	return "SENSOR_MULTILEVEL_SUPPORTED_GET_SENSOR"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelSupportedGetSensorV5) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelSupportedGetSensorV5) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelSupportedSensorReportV5 is the Sensor Multilevel Supported Sensor Report command, version 5
+type SensorMultilevelSupportedSensorReportV5 struct {
+	BitMask []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelSupportedSensorReportV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelSupportedSensorReportV5) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelSupportedSensorReportV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelSupportedSensorReportV5) Name() string {
+	return "SENSOR_MULTILEVEL_SUPPORTED_SENSOR_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelSupportedSensorReportV5) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	payload = append(payload, command.BitMask...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelSupportedSensorReportV5) Decode(data []uint8) error {
+	offset := 0
+	command.BitMask = nil
+	if len(data)-offset > 0 {
+		command.BitMask = append([]uint8(nil), data[offset:offset+len(data)-offset]...)
+	}
+	offset += len(data) - offset
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelSupportedGetScaleV5 is the Sensor Multilevel Supported Get Scale command, version 5
+type SensorMultilevelSupportedGetScaleV5 struct {
+	SensorType uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelSupportedGetScaleV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelSupportedGetScaleV5) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelSupportedGetScaleV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelSupportedGetScaleV5) Name() string {
+	return "SENSOR_MULTILEVEL_SUPPORTED_GET_SCALE"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelSupportedGetScaleV5) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.SensorType)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelSupportedGetScaleV5) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelSupportedGetScaleV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelGetV5 is the Sensor Multilevel Get command, version 5
+type SensorMultilevelGetV5 struct {
+	SensorType uint8
+	Reserved1  uint8
+	Scale      uint8
+	Reserved2  uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelGetV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelGetV5) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelGetV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelGetV5) Name() string {
+	return "SENSOR_MULTILEVEL_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelGetV5) Encode() ([]uint8, error) {
+	if command.Reserved1 > 0x07 {
+		return nil, fmt.Errorf("Bad SensorMultilevelGetV5 Reserved1: 0x%02x > 0x07", command.Reserved1)
+	}
+	if command.Scale > 0x03 {
+		return nil, fmt.Errorf("Bad SensorMultilevelGetV5 Scale: 0x%02x > 0x03", command.Scale)
+	}
+	if command.Reserved2 > 0x07 {
+		return nil, fmt.Errorf("Bad SensorMultilevelGetV5 Reserved2: 0x%02x > 0x07", command.Reserved2)
+	}
+	payload := []uint8{0x04}
+	payload = append(payload, command.SensorType)
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.Reserved1
+	fieldProperties1 |= command.Scale << 3
+	fieldProperties1 |= command.Reserved2 << 5
+	payload = append(payload, fieldProperties1)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelGetV5) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelGetV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelGetV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Reserved1 = data[offset] & 0x07
+	command.Scale = (data[offset] & 0x18) >> 3
+	command.Reserved2 = (data[offset] & 0xe0) >> 5
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelReportV5 is the Sensor Multilevel Report command, version 5
+type SensorMultilevelReportV5 struct {
+	SensorType  uint8
+	Scale       uint8
+	Precision   uint8
+	SensorValue []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelReportV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelReportV5) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelReportV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelReportV5) Name() string {
+	return "SENSOR_MULTILEVEL_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelReportV5) Encode() ([]uint8, error) {
+	if command.Scale > 0x03 {
+		return nil, fmt.Errorf("Bad SensorMultilevelReportV5 Scale: 0x%02x > 0x03", command.Scale)
+	}
+	if command.Precision > 0x07 {
+		return nil, fmt.Errorf("Bad SensorMultilevelReportV5 Precision: 0x%02x > 0x07", command.Precision)
+	}
+	if len(command.SensorValue) > 0x07 {
+		return nil, fmt.Errorf("Bad SensorMultilevelReportV5 SensorValue length: %d > 7", len(command.SensorValue))
+	}
+	payload := []uint8{0x05}
+	payload = append(payload, command.SensorType)
+	fieldLevel := uint8(0)
+	fieldLevel |= uint8(len(command.SensorValue))
+	fieldLevel |= command.Scale << 3
+	fieldLevel |= command.Precision << 5
+	payload = append(payload, fieldLevel)
+	payload = append(payload, command.SensorValue...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelReportV5) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelReportV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelReportV5 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthLevelSize := int(data[offset] & 0x07)
+	command.Scale = (data[offset] & 0x18) >> 3
+	command.Precision = (data[offset] & 0xe0) >> 5
+	offset++
+	if len(data) < offset+lengthLevelSize {
+		return fmt.Errorf("Bad SensorMultilevelReportV5 Data length: %d < %d", len(data), offset+lengthLevelSize)
+	}
+	command.SensorValue = nil
+	if lengthLevelSize > 0 {
+		command.SensorValue = append([]uint8(nil), data[offset:offset+lengthLevelSize]...)
+	}
+	offset += lengthLevelSize
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SensorMultilevelSupportedScaleReportV5 is the Sensor Multilevel Supported Scale Report command, version 5
+type SensorMultilevelSupportedScaleReportV5 struct {
+	SensorType   uint8
+	ScaleBitMask uint8
+	Reserved2    uint8
+}
+
+// CommandClass returns the command class ID
+func (command *SensorMultilevelSupportedScaleReportV5) CommandClass() uint8 {
+	return CommandClassSensorMultilevel
+}
+
+// CommandID returns the command ID
+func (command *SensorMultilevelSupportedScaleReportV5) CommandID() uint8 {
+	return 0x06
+}
+
+// Version returns the command class version
+func (command *SensorMultilevelSupportedScaleReportV5) Version() uint8 {
+	return 5
+}
+
+// Name returns the specification name of the command
+func (command *SensorMultilevelSupportedScaleReportV5) Name() string {
+	return "SENSOR_MULTILEVEL_SUPPORTED_SCALE_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *SensorMultilevelSupportedScaleReportV5) Encode() ([]uint8, error) {
+	if command.ScaleBitMask > 0x0f {
+		return nil, fmt.Errorf("Bad SensorMultilevelSupportedScaleReportV5 ScaleBitMask: 0x%02x > 0x0f", command.ScaleBitMask)
+	}
+	if command.Reserved2 > 0x0f {
+		return nil, fmt.Errorf("Bad SensorMultilevelSupportedScaleReportV5 Reserved2: 0x%02x > 0x0f", command.Reserved2)
+	}
+	payload := []uint8{0x06}
+	payload = append(payload, command.SensorType)
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.ScaleBitMask
+	fieldProperties1 |= command.Reserved2 << 4
+	payload = append(payload, fieldProperties1)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *SensorMultilevelSupportedScaleReportV5) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelSupportedScaleReportV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.SensorType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad SensorMultilevelSupportedScaleReportV5 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ScaleBitMask = data[offset] & 0x0f
+	command.Reserved2 = (data[offset] & 0xf0) >> 4
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterGetV1 is the Meter Get command, version 1
+type MeterGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *MeterGetV1) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterGetV1) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *MeterGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *MeterGetV1) Name() string {
+	return "METER_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x01}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterReportV1 is the Meter Report command, version 1
+type MeterReportV1 struct {
+	MeterType  uint8
+	Scale      uint8
+	Precision  uint8
+	MeterValue []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterReportV1) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterReportV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *MeterReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *MeterReportV1) Name() string {
+	return "METER_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterReportV1) Encode() ([]uint8, error) {
+	if command.Scale > 0x03 {
+		return nil, fmt.Errorf("Bad MeterReportV1 Scale: 0x%02x > 0x03", command.Scale)
+	}
+	if command.Precision > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV1 Precision: 0x%02x > 0x07", command.Precision)
+	}
+	if len(command.MeterValue) > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV1 MeterValue length: %d > 7", len(command.MeterValue))
+	}
+	payload := []uint8{0x02}
+	payload = append(payload, command.MeterType)
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= uint8(len(command.MeterValue))
+	fieldProperties1 |= command.Scale << 3
+	fieldProperties1 |= command.Precision << 5
+	payload = append(payload, fieldProperties1)
+	payload = append(payload, command.MeterValue...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.MeterType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthProperties1Size := int(data[offset] & 0x07)
+	command.Scale = (data[offset] & 0x18) >> 3
+	command.Precision = (data[offset] & 0xe0) >> 5
+	offset++
+	if len(data) < offset+lengthProperties1Size {
+		return fmt.Errorf("Bad MeterReportV1 Data length: %d < %d", len(data), offset+lengthProperties1Size)
+	}
+	command.MeterValue = nil
+	if lengthProperties1Size > 0 {
+		command.MeterValue = append([]uint8(nil), data[offset:offset+lengthProperties1Size]...)
+	}
+	offset += lengthProperties1Size
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterGetV2 is the Meter Get command, version 2
+type MeterGetV2 struct {
+	Reserved  uint8
+	Scale     uint8
+	Reserved2 uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterGetV2) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterGetV2) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *MeterGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *MeterGetV2) Name() string {
+	return "METER_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterGetV2) Encode() ([]uint8, error) {
+	if command.Reserved > 0x07 {
+		return nil, fmt.Errorf("Bad MeterGetV2 Reserved: 0x%02x > 0x07", command.Reserved)
+	}
+	if command.Scale > 0x03 {
+		return nil, fmt.Errorf("Bad MeterGetV2 Scale: 0x%02x > 0x03", command.Scale)
+	}
+	if command.Reserved2 > 0x07 {
+		return nil, fmt.Errorf("Bad MeterGetV2 Reserved2: 0x%02x > 0x07", command.Reserved2)
+	}
+	payload := []uint8{0x01}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.Reserved
+	fieldProperties1 |= command.Scale << 3
+	fieldProperties1 |= command.Reserved2 << 5
+	payload = append(payload, fieldProperties1)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterGetV2) Decode(data []uint8) error {
+	*command = MeterGetV2{}
+	offset := 0
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterGetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Reserved = data[offset] & 0x07
+	command.Scale = (data[offset] & 0x18) >> 3
+	command.Reserved2 = (data[offset] & 0xe0) >> 5
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterReportV2 is the Meter Report command, version 2
+type MeterReportV2 struct {
+	MeterType          uint8
+	RateType           uint8
+	Reserved           bool
+	Scale              uint8
+	Precision          uint8
+	MeterValue         []uint8
+	DeltaTime          uint16
+	PreviousMeterValue []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterReportV2) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterReportV2) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *MeterReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *MeterReportV2) Name() string {
+	return "METER_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterReportV2) Encode() ([]uint8, error) {
+	if command.MeterType > 0x1f {
+		return nil, fmt.Errorf("Bad MeterReportV2 MeterType: 0x%02x > 0x1f", command.MeterType)
+	}
+	if command.RateType > 0x03 {
+		return nil, fmt.Errorf("Bad MeterReportV2 RateType: 0x%02x > 0x03", command.RateType)
+	}
+	if command.Scale > 0x03 {
+		return nil, fmt.Errorf("Bad MeterReportV2 Scale: 0x%02x > 0x03", command.Scale)
+	}
+	if command.Precision > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV2 Precision: 0x%02x > 0x07", command.Precision)
+	}
+	if len(command.MeterValue) > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV2 MeterValue length: %d > 7", len(command.MeterValue))
+	}
+	if len(command.PreviousMeterValue) != len(command.MeterValue) {
+		return nil, fmt.Errorf("Bad MeterReportV2 PreviousMeterValue length: %d != %d", len(command.PreviousMeterValue), len(command.MeterValue))
+	}
+	payload := []uint8{0x02}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.MeterType
+	fieldProperties1 |= command.RateType << 5
+	if command.Reserved {
+		fieldProperties1 |= 0x80
+	}
+	payload = append(payload, fieldProperties1)
+	fieldProperties2 := uint8(0)
+	fieldProperties2 |= uint8(len(command.MeterValue))
+	fieldProperties2 |= command.Scale << 3
+	fieldProperties2 |= command.Precision << 5
+	payload = append(payload, fieldProperties2)
+	payload = append(payload, command.MeterValue...)
+	payload = append(payload, uint8(command.DeltaTime>>8), uint8(command.DeltaTime))
+	payload = append(payload, command.PreviousMeterValue...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterReportV2) Decode(data []uint8) error {
+	*command = MeterReportV2{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.MeterType = data[offset] & 0x1f
+	command.RateType = (data[offset] & 0x60) >> 5
+	command.Reserved = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthProperties2Size := int(data[offset] & 0x07)
+	command.Scale = (data[offset] & 0x18) >> 3
+	command.Precision = (data[offset] & 0xe0) >> 5
+	offset++
+	if len(data) < offset+lengthProperties2Size {
+		return fmt.Errorf("Bad MeterReportV2 Data length: %d < %d", len(data), offset+lengthProperties2Size)
+	}
+	command.MeterValue = nil
+	if lengthProperties2Size > 0 {
+		command.MeterValue = append([]uint8(nil), data[offset:offset+lengthProperties2Size]...)
+	}
+	offset += lengthProperties2Size
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+2 {
+		return fmt.Errorf("Bad MeterReportV2 Data length: %d < %d", len(data), offset+2)
+	}
+	command.DeltaTime = uint16(data[offset])<<8 | uint16(data[offset+1])
+	offset += 2
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+lengthProperties2Size {
+		return fmt.Errorf("Bad MeterReportV2 Data length: %d < %d", len(data), offset+lengthProperties2Size)
+	}
+	command.PreviousMeterValue = nil
+	if lengthProperties2Size > 0 {
+		command.PreviousMeterValue = append([]uint8(nil), data[offset:offset+lengthProperties2Size]...)
+	}
+	offset += lengthProperties2Size
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterSupportedGetV2 is the Meter Supported Get command, version 2
+type MeterSupportedGetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *MeterSupportedGetV2) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterSupportedGetV2) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *MeterSupportedGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *MeterSupportedGetV2) Name() string {
+	return "METER_SUPPORTED_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterSupportedGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterSupportedGetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterSupportedReportV2 is the Meter Supported Report command, version 2
+type MeterSupportedReportV2 struct {
+	MeterType      uint8
+	Reserved       uint8
+	MeterReset     bool
+	ScaleSupported uint8
+	Reserved2      uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterSupportedReportV2) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterSupportedReportV2) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *MeterSupportedReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *MeterSupportedReportV2) Name() string {
+	return "METER_SUPPORTED_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterSupportedReportV2) Encode() ([]uint8, error) {
+	if command.MeterType > 0x1f {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV2 MeterType: 0x%02x > 0x1f", command.MeterType)
+	}
+	if command.Reserved > 0x03 {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV2 Reserved: 0x%02x > 0x03", command.Reserved)
+	}
+	if command.ScaleSupported > 0x0f {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV2 ScaleSupported: 0x%02x > 0x0f", command.ScaleSupported)
+	}
+	if command.Reserved2 > 0x0f {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV2 Reserved2: 0x%02x > 0x0f", command.Reserved2)
+	}
+	payload := []uint8{0x04}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.MeterType
+	fieldProperties1 |= command.Reserved << 5
+	if command.MeterReset {
+		fieldProperties1 |= 0x80
+	}
+	payload = append(payload, fieldProperties1)
+	fieldProperties2 := uint8(0)
+	fieldProperties2 |= command.ScaleSupported
+	fieldProperties2 |= command.Reserved2 << 4
+	payload = append(payload, fieldProperties2)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterSupportedReportV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterSupportedReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.MeterType = data[offset] & 0x1f
+	command.Reserved = (data[offset] & 0x60) >> 5
+	command.MeterReset = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterSupportedReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ScaleSupported = data[offset] & 0x0f
+	command.Reserved2 = (data[offset] & 0xf0) >> 4
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterResetV2 is the Meter Reset command, version 2
+type MeterResetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *MeterResetV2) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterResetV2) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *MeterResetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *MeterResetV2) Name() string {
+	return "METER_RESET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterResetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x05}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterResetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterGetV3 is the Meter Get command, version 3
+type MeterGetV3 struct {
+	Reserved  uint8
+	Scale     uint8
+	Reserved2 uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterGetV3) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterGetV3) CommandID() uint8 {
+	return 0x01
+}
+
+// Version returns the command class version
+func (command *MeterGetV3) Version() uint8 {
+	return 3
+}
+
+// Name returns the specification name of the command
+func (command *MeterGetV3) Name() string {
+	return "METER_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterGetV3) Encode() ([]uint8, error) {
+	if command.Reserved > 0x07 {
+		return nil, fmt.Errorf("Bad MeterGetV3 Reserved: 0x%02x > 0x07", command.Reserved)
+	}
+	if command.Scale > 0x07 {
+		return nil, fmt.Errorf("Bad MeterGetV3 Scale: 0x%02x > 0x07", command.Scale)
+	}
+	if command.Reserved2 > 0x03 {
+		return nil, fmt.Errorf("Bad MeterGetV3 Reserved2: 0x%02x > 0x03", command.Reserved2)
+	}
+	payload := []uint8{0x01}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.Reserved
+	fieldProperties1 |= command.Scale << 3
+	fieldProperties1 |= command.Reserved2 << 6
+	payload = append(payload, fieldProperties1)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterGetV3) Decode(data []uint8) error {
+	*command = MeterGetV3{}
+	offset := 0
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterGetV3 Data length: %d < %d", len(data), offset+1)
+	}
+	command.Reserved = data[offset] & 0x07
+	command.Scale = (data[offset] & 0x38) >> 3
+	command.Reserved2 = (data[offset] & 0xc0) >> 6
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterReportV3 is the Meter Report command, version 3
+type MeterReportV3 struct {
+	MeterType          uint8
+	RateType           uint8
+	ScaleBit2          bool
+	ScaleBits10        uint8
+	Precision          uint8
+	MeterValue         []uint8
+	DeltaTime          uint16
+	PreviousMeterValue []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterReportV3) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterReportV3) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *MeterReportV3) Version() uint8 {
+	return 3
+}
+
+// Name returns the specification name of the command
+func (command *MeterReportV3) Name() string {
+	return "METER_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterReportV3) Encode() ([]uint8, error) {
+	if command.MeterType > 0x1f {
+		return nil, fmt.Errorf("Bad MeterReportV3 MeterType: 0x%02x > 0x1f", command.MeterType)
+	}
+	if command.RateType > 0x03 {
+		return nil, fmt.Errorf("Bad MeterReportV3 RateType: 0x%02x > 0x03", command.RateType)
+	}
+	if command.ScaleBits10 > 0x03 {
+		return nil, fmt.Errorf("Bad MeterReportV3 ScaleBits10: 0x%02x > 0x03", command.ScaleBits10)
+	}
+	if command.Precision > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV3 Precision: 0x%02x > 0x07", command.Precision)
+	}
+	if len(command.MeterValue) > 0x07 {
+		return nil, fmt.Errorf("Bad MeterReportV3 MeterValue length: %d > 7", len(command.MeterValue))
+	}
+	if len(command.PreviousMeterValue) != len(command.MeterValue) {
+		return nil, fmt.Errorf("Bad MeterReportV3 PreviousMeterValue length: %d != %d", len(command.PreviousMeterValue), len(command.MeterValue))
+	}
+	payload := []uint8{0x02}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.MeterType
+	fieldProperties1 |= command.RateType << 5
+	if command.ScaleBit2 {
+		fieldProperties1 |= 0x80
+	}
+	payload = append(payload, fieldProperties1)
+	fieldProperties2 := uint8(0)
+	fieldProperties2 |= uint8(len(command.MeterValue))
+	fieldProperties2 |= command.ScaleBits10 << 3
+	fieldProperties2 |= command.Precision << 5
+	payload = append(payload, fieldProperties2)
+	payload = append(payload, command.MeterValue...)
+	payload = append(payload, uint8(command.DeltaTime>>8), uint8(command.DeltaTime))
+	payload = append(payload, command.PreviousMeterValue...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterReportV3) Decode(data []uint8) error {
+	*command = MeterReportV3{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV3 Data length: %d < %d", len(data), offset+1)
+	}
+	command.MeterType = data[offset] & 0x1f
+	command.RateType = (data[offset] & 0x60) >> 5
+	command.ScaleBit2 = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterReportV3 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthProperties2Size := int(data[offset] & 0x07)
+	command.ScaleBits10 = (data[offset] & 0x18) >> 3
+	command.Precision = (data[offset] & 0xe0) >> 5
+	offset++
+	if len(data) < offset+lengthProperties2Size {
+		return fmt.Errorf("Bad MeterReportV3 Data length: %d < %d", len(data), offset+lengthProperties2Size)
+	}
+	command.MeterValue = nil
+	if lengthProperties2Size > 0 {
+		command.MeterValue = append([]uint8(nil), data[offset:offset+lengthProperties2Size]...)
+	}
+	offset += lengthProperties2Size
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+2 {
+		return fmt.Errorf("Bad MeterReportV3 Data length: %d < %d", len(data), offset+2)
+	}
+	command.DeltaTime = uint16(data[offset])<<8 | uint16(data[offset+1])
+	offset += 2
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+lengthProperties2Size {
+		return fmt.Errorf("Bad MeterReportV3 Data length: %d < %d", len(data), offset+lengthProperties2Size)
+	}
+	command.PreviousMeterValue = nil
+	if lengthProperties2Size > 0 {
+		command.PreviousMeterValue = append([]uint8(nil), data[offset:offset+lengthProperties2Size]...)
+	}
+	offset += lengthProperties2Size
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterSupportedGetV3 is the Meter Supported Get command, version 3
+type MeterSupportedGetV3 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *MeterSupportedGetV3) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterSupportedGetV3) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *MeterSupportedGetV3) Version() uint8 {
+	return 3
+}
+
+// Name returns the specification name of the command
+func (command *MeterSupportedGetV3) Name() string {
+	return "METER_SUPPORTED_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterSupportedGetV3) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterSupportedGetV3) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterSupportedReportV3 is the Meter Supported Report command, version 3
+type MeterSupportedReportV3 struct {
+	MeterType      uint8
+	Reserved       uint8
+	MeterReset     bool
+	ScaleSupported uint8
+}
+
+// CommandClass returns the command class ID
+func (command *MeterSupportedReportV3) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterSupportedReportV3) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *MeterSupportedReportV3) Version() uint8 {
+	return 3
+}
+
+// Name returns the specification name of the command
+func (command *MeterSupportedReportV3) Name() string {
+	return "METER_SUPPORTED_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterSupportedReportV3) Encode() ([]uint8, error) {
+	if command.MeterType > 0x1f {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV3 MeterType: 0x%02x > 0x1f", command.MeterType)
+	}
+	if command.Reserved > 0x03 {
+		return nil, fmt.Errorf("Bad MeterSupportedReportV3 Reserved: 0x%02x > 0x03", command.Reserved)
+	}
+	payload := []uint8{0x04}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= command.MeterType
+	fieldProperties1 |= command.Reserved << 5
+	if command.MeterReset {
+		fieldProperties1 |= 0x80
+	}
+	payload = append(payload, fieldProperties1)
+	fieldProperties2 := uint8(0)
+	fieldProperties2 |= command.ScaleSupported
+	payload = append(payload, fieldProperties2)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterSupportedReportV3) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterSupportedReportV3 Data length: %d < %d", len(data), offset+1)
+	}
+	command.MeterType = data[offset] & 0x1f
+	command.Reserved = (data[offset] & 0x60) >> 5
+	command.MeterReset = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad MeterSupportedReportV3 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ScaleSupported = data[offset] & 0xff
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MeterResetV3 is the Meter Reset command, version 3
+type MeterResetV3 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *MeterResetV3) CommandClass() uint8 {
+	return CommandClassMeter
+}
+
+// CommandID returns the command ID
+func (command *MeterResetV3) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *MeterResetV3) Version() uint8 {
+	return 3
+}
+
+// Name returns the specification name of the command
+func (command *MeterResetV3) Name() string {
+	return "METER_RESET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *MeterResetV3) Encode() ([]uint8, error) {
+	payload := []uint8{0x05}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *MeterResetV3) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmGetV1 is the Alarm Get command, version 1
+type AlarmGetV1 struct {
+	AlarmType uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmGetV1) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmGetV1) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *AlarmGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *AlarmGetV1) Name() string {
+	return "ALARM_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x04}
+	payload = append(payload, command.AlarmType)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmGetV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmGetV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmType = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmReportV1 is the Alarm Report command, version 1
+type AlarmReportV1 struct {
+	AlarmType  uint8
+	AlarmLevel uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmReportV1) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmReportV1) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *AlarmReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *AlarmReportV1) Name() string {
+	return "ALARM_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x05}
+	payload = append(payload, command.AlarmType)
+	payload = append(payload, command.AlarmLevel)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmLevel = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmGetV2 is the Alarm Get command, version 2
+type AlarmGetV2 struct {
+	AlarmType      uint8
+	ZWaveAlarmType uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmGetV2) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmGetV2) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *AlarmGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *AlarmGetV2) Name() string {
+	return "ALARM_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x04}
+	payload = append(payload, command.AlarmType)
+	payload = append(payload, command.ZWaveAlarmType)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmGetV2) Decode(data []uint8) error {
+	*command = AlarmGetV2{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmGetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmType = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmGetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmType = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmReportV2 is the Alarm Report command, version 2
+type AlarmReportV2 struct {
+	AlarmType             uint8
+	AlarmLevel            uint8
+	ZensorNetSourceNodeID uint8
+	ZWaveAlarmStatus      uint8
+	ZWaveAlarmType        uint8
+	ZWaveAlarmEvent       uint8
+	EventParameter        []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmReportV2) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmReportV2) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *AlarmReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *AlarmReportV2) Name() string {
+	return "ALARM_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmReportV2) Encode() ([]uint8, error) {
+	if len(command.EventParameter) > 0xff {
+		return nil, fmt.Errorf("Bad AlarmReportV2 EventParameter length: %d > 255", len(command.EventParameter))
+	}
+	payload := []uint8{0x05}
+	payload = append(payload, command.AlarmType)
+	payload = append(payload, command.AlarmLevel)
+	payload = append(payload, command.ZensorNetSourceNodeID)
+	payload = append(payload, command.ZWaveAlarmStatus)
+	payload = append(payload, command.ZWaveAlarmType)
+	payload = append(payload, command.ZWaveAlarmEvent)
+	payload = append(payload, uint8(len(command.EventParameter)))
+	payload = append(payload, command.EventParameter...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmReportV2) Decode(data []uint8) error {
+	*command = AlarmReportV2{}
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.AlarmLevel = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZensorNetSourceNodeID = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmStatus = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmType = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmEvent = data[offset]
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthNumberOfEventParameters := int(data[offset])
+	offset++
+	if offset == len(data) {
+		return nil
+	}
+	if len(data) < offset+lengthNumberOfEventParameters {
+		return fmt.Errorf("Bad AlarmReportV2 Data length: %d < %d", len(data), offset+lengthNumberOfEventParameters)
+	}
+	command.EventParameter = nil
+	if lengthNumberOfEventParameters > 0 {
+		command.EventParameter = append([]uint8(nil), data[offset:offset+lengthNumberOfEventParameters]...)
+	}
+	offset += lengthNumberOfEventParameters
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ZWave Alarm Status values of AlarmSetV2
+const (
+	AlarmSetV2ZWaveAlarmStatusOff uint8 = 0x00
+	AlarmSetV2ZWaveAlarmStatusOn        = 0xff
+)
+
+// AlarmSetV2 is the Alarm Set command, version 2
+type AlarmSetV2 struct {
+	ZWaveAlarmType   uint8
+	ZWaveAlarmStatus uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmSetV2) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmSetV2) CommandID() uint8 {
+	return 0x06
+}
+
+// Version returns the command class version
+func (command *AlarmSetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *AlarmSetV2) Name() string {
+	return "ALARM_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmSetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x06}
+	payload = append(payload, command.ZWaveAlarmType)
+	payload = append(payload, command.ZWaveAlarmStatus)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmSetV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmSetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmType = data[offset]
+	offset++
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmSetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.ZWaveAlarmStatus = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmTypeSupportedGetV2 is the Alarm Type Supported Get command, version 2
+type AlarmTypeSupportedGetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmTypeSupportedGetV2) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmTypeSupportedGetV2) CommandID() uint8 {
+	return 0x07
+}
+
+// Version returns the command class version
+func (command *AlarmTypeSupportedGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *AlarmTypeSupportedGetV2) Name() string {
+	return "ALARM_TYPE_SUPPORTED_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmTypeSupportedGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x07}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmTypeSupportedGetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmTypeSupportedReportV2 is the Alarm Type Supported Report command, version 2
+type AlarmTypeSupportedReportV2 struct {
+	Reserved uint8
+	V1Alarm  bool
+	BitMask  []uint8
+}
+
+// CommandClass returns the command class ID
+func (command *AlarmTypeSupportedReportV2) CommandClass() uint8 {
+	return CommandClassAlarm
+}
+
+// CommandID returns the command ID
+func (command *AlarmTypeSupportedReportV2) CommandID() uint8 {
+	return 0x08
+}
+
+// Version returns the command class version
+func (command *AlarmTypeSupportedReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *AlarmTypeSupportedReportV2) Name() string {
+	return "ALARM_TYPE_SUPPORTED_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *AlarmTypeSupportedReportV2) Encode() ([]uint8, error) {
+	if command.Reserved > 0x03 {
+		return nil, fmt.Errorf("Bad AlarmTypeSupportedReportV2 Reserved: 0x%02x > 0x03", command.Reserved)
+	}
+	if len(command.BitMask) > 0x1f {
+		return nil, fmt.Errorf("Bad AlarmTypeSupportedReportV2 BitMask length: %d > 31", len(command.BitMask))
+	}
+	payload := []uint8{0x08}
+	fieldProperties1 := uint8(0)
+	fieldProperties1 |= uint8(len(command.BitMask))
+	fieldProperties1 |= command.Reserved << 5
+	if command.V1Alarm {
+		fieldProperties1 |= 0x80
+	}
+	payload = append(payload, fieldProperties1)
+	payload = append(payload, command.BitMask...)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *AlarmTypeSupportedReportV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad AlarmTypeSupportedReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	lengthProperties1NumberOfBitMasks := int(data[offset] & 0x1f)
+	command.Reserved = (data[offset] & 0x60) >> 5
+	command.V1Alarm = data[offset]&0x80 != 0
+	offset++
+	if len(data) < offset+lengthProperties1NumberOfBitMasks {
+		return fmt.Errorf("Bad AlarmTypeSupportedReportV2 Data length: %d < %d", len(data), offset+lengthProperties1NumberOfBitMasks)
+	}
+	command.BitMask = nil
+	if lengthProperties1NumberOfBitMasks > 0 {
+		command.BitMask = append([]uint8(nil), data[offset:offset+lengthProperties1NumberOfBitMasks]...)
+	}
+	offset += lengthProperties1NumberOfBitMasks
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BatteryGetV1 is the Battery Get command, version 1
+type BatteryGetV1 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *BatteryGetV1) CommandClass() uint8 {
+	return CommandClassBattery
+}
+
+// CommandID returns the command ID
+func (command *BatteryGetV1) CommandID() uint8 {
+	return 0x02
+}
+
+// Version returns the command class version
+func (command *BatteryGetV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *BatteryGetV1) Name() string {
+	return "BATTERY_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BatteryGetV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x02}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BatteryGetV1) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BatteryReportV1 is the Battery Report command, version 1
+type BatteryReportV1 struct {
+	BatteryLevel uint8
+}
+
+// CommandClass returns the command class ID
+func (command *BatteryReportV1) CommandClass() uint8 {
+	return CommandClassBattery
+}
+
+// CommandID returns the command ID
+func (command *BatteryReportV1) CommandID() uint8 {
+	return 0x03
+}
+
+// Version returns the command class version
+func (command *BatteryReportV1) Version() uint8 {
+	return 1
+}
+
+// Name returns the specification name of the command
+func (command *BatteryReportV1) Name() string {
+	return "BATTERY_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *BatteryReportV1) Encode() ([]uint8, error) {
+	payload := []uint8{0x03}
+	payload = append(payload, command.BatteryLevel)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *BatteryReportV1) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad BatteryReportV1 Data length: %d < %d", len(data), offset+1)
+	}
+	command.BatteryLevel = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpIntervalSetV2 is the Wake Up Interval Set command, version 2
+type WakeUpIntervalSetV2 struct {
+	Seconds uint32
+	NodeID  uint8
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpIntervalSetV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpIntervalSetV2) CommandID() uint8 {
+	return 0x04
+}
+
+// Version returns the command class version
+func (command *WakeUpIntervalSetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpIntervalSetV2) Name() string {
+	return "WAKE_UP_INTERVAL_SET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpIntervalSetV2) Encode() ([]uint8, error) {
+	if command.Seconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalSetV2 Seconds: 0x%x > 0xffffff", command.Seconds)
+	}
+	payload := []uint8{0x04}
+	payload = append(payload, uint8(command.Seconds>>16), uint8(command.Seconds>>8), uint8(command.Seconds))
+	payload = append(payload, command.NodeID)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpIntervalSetV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalSetV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.Seconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad WakeUpIntervalSetV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.NodeID = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpIntervalGetV2 is the Wake Up Interval Get command, version 2
+type WakeUpIntervalGetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpIntervalGetV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpIntervalGetV2) CommandID() uint8 {
+	return 0x05
+}
+
+// Version returns the command class version
+func (command *WakeUpIntervalGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpIntervalGetV2) Name() string {
+	return "WAKE_UP_INTERVAL_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpIntervalGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x05}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpIntervalGetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpIntervalReportV2 is the Wake Up Interval Report command, version 2
+type WakeUpIntervalReportV2 struct {
+	Seconds uint32
+	NodeID  uint8
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpIntervalReportV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpIntervalReportV2) CommandID() uint8 {
+	return 0x06
+}
+
+// Version returns the command class version
+func (command *WakeUpIntervalReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpIntervalReportV2) Name() string {
+	return "WAKE_UP_INTERVAL_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpIntervalReportV2) Encode() ([]uint8, error) {
+	if command.Seconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalReportV2 Seconds: 0x%x > 0xffffff", command.Seconds)
+	}
+	payload := []uint8{0x06}
+	payload = append(payload, uint8(command.Seconds>>16), uint8(command.Seconds>>8), uint8(command.Seconds))
+	payload = append(payload, command.NodeID)
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpIntervalReportV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalReportV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.Seconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	if len(data) < offset+1 {
+		return fmt.Errorf("Bad WakeUpIntervalReportV2 Data length: %d < %d", len(data), offset+1)
+	}
+	command.NodeID = data[offset]
+	offset++
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpNotificationV2 is the Wake Up Notification command, version 2
+type WakeUpNotificationV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpNotificationV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpNotificationV2) CommandID() uint8 {
+	return 0x07
+}
+
+// Version returns the command class version
+func (command *WakeUpNotificationV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpNotificationV2) Name() string {
+	return "WAKE_UP_NOTIFICATION"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpNotificationV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x07}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpNotificationV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpNoMoreInformationV2 is the Wake Up No More Information command, version 2
+type WakeUpNoMoreInformationV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpNoMoreInformationV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpNoMoreInformationV2) CommandID() uint8 {
+	return 0x08
+}
+
+// Version returns the command class version
+func (command *WakeUpNoMoreInformationV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpNoMoreInformationV2) Name() string {
+	return "WAKE_UP_NO_MORE_INFORMATION"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpNoMoreInformationV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x08}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpNoMoreInformationV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpIntervalCapabilitiesGetV2 is the Wake Up Interval Capabilities Get command, version 2
+type WakeUpIntervalCapabilitiesGetV2 struct {
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpIntervalCapabilitiesGetV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpIntervalCapabilitiesGetV2) CommandID() uint8 {
+	return 0x09
+}
+
+// Version returns the command class version
+func (command *WakeUpIntervalCapabilitiesGetV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpIntervalCapabilitiesGetV2) Name() string {
+	return "WAKE_UP_INTERVAL_CAPABILITIES_GET"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpIntervalCapabilitiesGetV2) Encode() ([]uint8, error) {
+	payload := []uint8{0x09}
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpIntervalCapabilitiesGetV2) Decode(data []uint8) error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpIntervalCapabilitiesReportV2 is the Wake Up Interval Capabilities Report command, version 2
+type WakeUpIntervalCapabilitiesReportV2 struct {
+	MinimumWakeUpIntervalSeconds uint32
+	MaximumWakeUpIntervalSeconds uint32
+	DefaultWakeUpIntervalSeconds uint32
+	WakeUpIntervalStepSeconds    uint32
+}
+
+// CommandClass returns the command class ID
+func (command *WakeUpIntervalCapabilitiesReportV2) CommandClass() uint8 {
+	return CommandClassWakeUp
+}
+
+// CommandID returns the command ID
+func (command *WakeUpIntervalCapabilitiesReportV2) CommandID() uint8 {
+	return 0x0a
+}
+
+// Version returns the command class version
+func (command *WakeUpIntervalCapabilitiesReportV2) Version() uint8 {
+	return 2
+}
+
+// Name returns the specification name of the command
+func (command *WakeUpIntervalCapabilitiesReportV2) Name() string {
+	return "WAKE_UP_INTERVAL_CAPABILITIES_REPORT"
+}
+
+// Encode returns the payload of the command, starting with the command ID
+func (command *WakeUpIntervalCapabilitiesReportV2) Encode() ([]uint8, error) {
+	if command.MinimumWakeUpIntervalSeconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 MinimumWakeUpIntervalSeconds: 0x%x > 0xffffff", command.MinimumWakeUpIntervalSeconds)
+	}
+	if command.MaximumWakeUpIntervalSeconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 MaximumWakeUpIntervalSeconds: 0x%x > 0xffffff", command.MaximumWakeUpIntervalSeconds)
+	}
+	if command.DefaultWakeUpIntervalSeconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 DefaultWakeUpIntervalSeconds: 0x%x > 0xffffff", command.DefaultWakeUpIntervalSeconds)
+	}
+	if command.WakeUpIntervalStepSeconds > 0xffffff {
+		return nil, fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 WakeUpIntervalStepSeconds: 0x%x > 0xffffff", command.WakeUpIntervalStepSeconds)
+	}
+	payload := []uint8{0x0a}
+	payload = append(payload, uint8(command.MinimumWakeUpIntervalSeconds>>16), uint8(command.MinimumWakeUpIntervalSeconds>>8), uint8(command.MinimumWakeUpIntervalSeconds))
+	payload = append(payload, uint8(command.MaximumWakeUpIntervalSeconds>>16), uint8(command.MaximumWakeUpIntervalSeconds>>8), uint8(command.MaximumWakeUpIntervalSeconds))
+	payload = append(payload, uint8(command.DefaultWakeUpIntervalSeconds>>16), uint8(command.DefaultWakeUpIntervalSeconds>>8), uint8(command.DefaultWakeUpIntervalSeconds))
+	payload = append(payload, uint8(command.WakeUpIntervalStepSeconds>>16), uint8(command.WakeUpIntervalStepSeconds>>8), uint8(command.WakeUpIntervalStepSeconds))
+	return payload, nil
+}
+
+// Decode the command parameters, which follow the command ID
+func (command *WakeUpIntervalCapabilitiesReportV2) Decode(data []uint8) error {
+	offset := 0
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.MinimumWakeUpIntervalSeconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.MaximumWakeUpIntervalSeconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.DefaultWakeUpIntervalSeconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	if len(data) < offset+3 {
+		return fmt.Errorf("Bad WakeUpIntervalCapabilitiesReportV2 Data length: %d < %d", len(data), offset+3)
+	}
+	command.WakeUpIntervalStepSeconds = uint32(data[offset])<<16 | uint32(data[offset+1])<<8 | uint32(data[offset+2])
+	offset += 3
+	return nil
+}
+
+// commands lists every generated command
+var commands = []commandInfo{
+	{CommandClassBasic, 1, 0x01, func() Command { return &BasicSetV1{} }},
+	{CommandClassBasic, 1, 0x02, func() Command { return &BasicGetV1{} }},
+	{CommandClassBasic, 1, 0x03, func() Command { return &BasicReportV1{} }},
+	{CommandClassBasic, 2, 0x01, func() Command { return &BasicSetV2{} }},
+	{CommandClassBasic, 2, 0x02, func() Command { return &BasicGetV2{} }},
+	{CommandClassBasic, 2, 0x03, func() Command { return &BasicReportV2{} }},
+	{CommandClassSwitchBinary, 1, 0x01, func() Command { return &SwitchBinarySetV1{} }},
+	{CommandClassSwitchBinary, 1, 0x02, func() Command { return &SwitchBinaryGetV1{} }},
+	{CommandClassSwitchBinary, 1, 0x03, func() Command { return &SwitchBinaryReportV1{} }},
+	{CommandClassSwitchMultilevel, 1, 0x01, func() Command { return &SwitchMultilevelSetV1{} }},
+	{CommandClassSwitchMultilevel, 1, 0x02, func() Command { return &SwitchMultilevelGetV1{} }},
+	{CommandClassSwitchMultilevel, 1, 0x03, func() Command { return &SwitchMultilevelReportV1{} }},
+	{CommandClassSwitchMultilevel, 1, 0x04, func() Command { return &SwitchMultilevelStartLevelChangeV1{} }},
+	{CommandClassSwitchMultilevel, 1, 0x05, func() Command { return &SwitchMultilevelStopLevelChangeV1{} }},
+	{CommandClassSensorBinary, 1, 0x02, func() Command { return &SensorBinaryGetV1{} }},
+	{CommandClassSensorBinary, 1, 0x03, func() Command { return &SensorBinaryReportV1{} }},
+	{CommandClassSensorBinary, 2, 0x01, func() Command { return &SensorBinarySupportedGetSensorV2{} }},
+	{CommandClassSensorBinary, 2, 0x02, func() Command { return &SensorBinaryGetV2{} }},
+	{CommandClassSensorBinary, 2, 0x03, func() Command { return &SensorBinaryReportV2{} }},
+	{CommandClassSensorBinary, 2, 0x04, func() Command { return &SensorBinarySupportedSensorReportV2{} }},
+	{CommandClassSensorMultilevel, 5, 0x01, func() Command { return &SensorMultilevelSupportedGetSensorV5{} }},
+	{CommandClassSensorMultilevel, 5, 0x02, func() Command { return &SensorMultilevelSupportedSensorReportV5{} }},
+	{CommandClassSensorMultilevel, 5, 0x03, func() Command { return &SensorMultilevelSupportedGetScaleV5{} }},
+	{CommandClassSensorMultilevel, 5, 0x04, func() Command { return &SensorMultilevelGetV5{} }},
+	{CommandClassSensorMultilevel, 5, 0x05, func() Command { return &SensorMultilevelReportV5{} }},
+	{CommandClassSensorMultilevel, 5, 0x06, func() Command { return &SensorMultilevelSupportedScaleReportV5{} }},
+	{CommandClassMeter, 1, 0x01, func() Command { return &MeterGetV1{} }},
+	{CommandClassMeter, 1, 0x02, func() Command { return &MeterReportV1{} }},
+	{CommandClassMeter, 2, 0x01, func() Command { return &MeterGetV2{} }},
+	{CommandClassMeter, 2, 0x02, func() Command { return &MeterReportV2{} }},
+	{CommandClassMeter, 2, 0x03, func() Command { return &MeterSupportedGetV2{} }},
+	{CommandClassMeter, 2, 0x04, func() Command { return &MeterSupportedReportV2{} }},
+	{CommandClassMeter, 2, 0x05, func() Command { return &MeterResetV2{} }},
+	{CommandClassMeter, 3, 0x01, func() Command { return &MeterGetV3{} }},
+	{CommandClassMeter, 3, 0x02, func() Command { return &MeterReportV3{} }},
+	{CommandClassMeter, 3, 0x03, func() Command { return &MeterSupportedGetV3{} }},
+	{CommandClassMeter, 3, 0x04, func() Command { return &MeterSupportedReportV3{} }},
+	{CommandClassMeter, 3, 0x05, func() Command { return &MeterResetV3{} }},
+	{CommandClassAlarm, 1, 0x04, func() Command { return &AlarmGetV1{} }},
+	{CommandClassAlarm, 1, 0x05, func() Command { return &AlarmReportV1{} }},
+	{CommandClassAlarm, 2, 0x04, func() Command { return &AlarmGetV2{} }},
+	{CommandClassAlarm, 2, 0x05, func() Command { return &AlarmReportV2{} }},
+	{CommandClassAlarm, 2, 0x06, func() Command { return &AlarmSetV2{} }},
+	{CommandClassAlarm, 2, 0x07, func() Command { return &AlarmTypeSupportedGetV2{} }},
+	{CommandClassAlarm, 2, 0x08, func() Command { return &AlarmTypeSupportedReportV2{} }},
+	{CommandClassBattery, 1, 0x02, func() Command { return &BatteryGetV1{} }},
+	{CommandClassBattery, 1, 0x03, func() Command { return &BatteryReportV1{} }},
+	{CommandClassWakeUp, 2, 0x04, func() Command { return &WakeUpIntervalSetV2{} }},
+	{CommandClassWakeUp, 2, 0x05, func() Command { return &WakeUpIntervalGetV2{} }},
+	{CommandClassWakeUp, 2, 0x06, func() Command { return &WakeUpIntervalReportV2{} }},
+	{CommandClassWakeUp, 2, 0x07, func() Command { return &WakeUpNotificationV2{} }},
+	{CommandClassWakeUp, 2, 0x08, func() Command { return &WakeUpNoMoreInformationV2{} }},
+	{CommandClassWakeUp, 2, 0x09, func() Command { return &WakeUpIntervalCapabilitiesGetV2{} }},
+	{CommandClassWakeUp, 2, 0x0a, func() Command { return &WakeUpIntervalCapabilitiesReportV2{} }},
+}
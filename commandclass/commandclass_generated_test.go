@@ -0,0 +1,61 @@
+// Code generated by zwgen from zwave_cmd_classes_subset.xml. DO NOT EDIT.
+
+package commandclass
+
+// roundTripCommands has a sample of every generated command
+var roundTripCommands = []Command{
+	&BasicSetV1{Value: 0xa5},
+	&BasicGetV1{},
+	&BasicReportV1{Value: 0xa5},
+	&BasicSetV2{Value: 0xa5},
+	&BasicGetV2{},
+	&BasicReportV2{CurrentValue: 0xa5, TargetValue: 0xa5, Duration: 0xa5},
+	&SwitchBinarySetV1{SwitchValue: 0xa5},
+	&SwitchBinaryGetV1{},
+	&SwitchBinaryReportV1{Value: 0xa5},
+	&SwitchMultilevelSetV1{Value: 0xa5},
+	&SwitchMultilevelGetV1{},
+	&SwitchMultilevelReportV1{Value: 0xa5},
+	&SwitchMultilevelStartLevelChangeV1{Reserved1: 0x05, IgnoreStartLevel: true, Reserved2: true, UpDown: true, StartLevel: 0xa5},
+	&SwitchMultilevelStopLevelChangeV1{},
+	&SensorBinaryGetV1{},
+	&SensorBinaryReportV1{SensorValue: 0xa5},
+	&SensorBinarySupportedGetSensorV2{},
+	&SensorBinaryGetV2{SensorType: 0xa5},
+	&SensorBinaryReportV2{SensorValue: 0xa5, SensorType: 0xa5},
+	&SensorBinarySupportedSensorReportV2{BitMask: []uint8{0x01, 0x02}},
+	&SensorMultilevelSupportedGetSensorV5{},
+	&SensorMultilevelSupportedSensorReportV5{BitMask: []uint8{0x01, 0x02}},
+	&SensorMultilevelSupportedGetScaleV5{SensorType: 0xa5},
+	&SensorMultilevelGetV5{SensorType: 0xa5, Reserved1: 0x05, Scale: 0x01, Reserved2: 0x05},
+	&SensorMultilevelReportV5{SensorType: 0xa5, Scale: 0x01, Precision: 0x05, SensorValue: []uint8{0x01, 0x02}},
+	&SensorMultilevelSupportedScaleReportV5{SensorType: 0xa5, ScaleBitMask: 0x05, Reserved2: 0x05},
+	&MeterGetV1{},
+	&MeterReportV1{MeterType: 0xa5, Scale: 0x01, Precision: 0x05, MeterValue: []uint8{0x01, 0x02}},
+	&MeterGetV2{Reserved: 0x05, Scale: 0x01, Reserved2: 0x05},
+	&MeterReportV2{MeterType: 0x05, RateType: 0x01, Reserved: true, Scale: 0x01, Precision: 0x05, MeterValue: []uint8{0x01, 0x02}, DeltaTime: 0xa55a, PreviousMeterValue: []uint8{0x01, 0x02}},
+	&MeterSupportedGetV2{},
+	&MeterSupportedReportV2{MeterType: 0x05, Reserved: 0x01, MeterReset: true, ScaleSupported: 0x05, Reserved2: 0x05},
+	&MeterResetV2{},
+	&MeterGetV3{Reserved: 0x05, Scale: 0x05, Reserved2: 0x01},
+	&MeterReportV3{MeterType: 0x05, RateType: 0x01, ScaleBit2: true, ScaleBits10: 0x01, Precision: 0x05, MeterValue: []uint8{0x01, 0x02}, DeltaTime: 0xa55a, PreviousMeterValue: []uint8{0x01, 0x02}},
+	&MeterSupportedGetV3{},
+	&MeterSupportedReportV3{MeterType: 0x05, Reserved: 0x01, MeterReset: true, ScaleSupported: 0xa5},
+	&MeterResetV3{},
+	&AlarmGetV1{AlarmType: 0xa5},
+	&AlarmReportV1{AlarmType: 0xa5, AlarmLevel: 0xa5},
+	&AlarmGetV2{AlarmType: 0xa5, ZWaveAlarmType: 0xa5},
+	&AlarmReportV2{AlarmType: 0xa5, AlarmLevel: 0xa5, ZensorNetSourceNodeID: 0xa5, ZWaveAlarmStatus: 0xa5, ZWaveAlarmType: 0xa5, ZWaveAlarmEvent: 0xa5, EventParameter: []uint8{0x01, 0x02}},
+	&AlarmSetV2{ZWaveAlarmType: 0xa5, ZWaveAlarmStatus: 0xa5},
+	&AlarmTypeSupportedGetV2{},
+	&AlarmTypeSupportedReportV2{Reserved: 0x01, V1Alarm: true, BitMask: []uint8{0x01, 0x02}},
+	&BatteryGetV1{},
+	&BatteryReportV1{BatteryLevel: 0xa5},
+	&WakeUpIntervalSetV2{Seconds: 0xa55aa5, NodeID: 0xa5},
+	&WakeUpIntervalGetV2{},
+	&WakeUpIntervalReportV2{Seconds: 0xa55aa5, NodeID: 0xa5},
+	&WakeUpNotificationV2{},
+	&WakeUpNoMoreInformationV2{},
+	&WakeUpIntervalCapabilitiesGetV2{},
+	&WakeUpIntervalCapabilitiesReportV2{MinimumWakeUpIntervalSeconds: 0xa55aa5, MaximumWakeUpIntervalSeconds: 0xa55aa5, DefaultWakeUpIntervalSeconds: 0xa55aa5, WakeUpIntervalStepSeconds: 0xa55aa5},
+}
@@ -0,0 +1,263 @@
+package commandclass
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// endsWithGroup reports if the last parameter of the command is a variant group
+func endsWithGroup(command Command) bool {
+	value := reflect.ValueOf(command).Elem()
+	if value.NumField() == 0 {
+		return false
+	}
+
+	last := value.Field(value.NumField() - 1).Type()
+	return last.Kind() == reflect.Slice && last.Elem().Kind() == reflect.Struct
+}
+
+func TestRoundTrip(t *testing.T) {
+	if len(roundTripCommands) != len(commands) {
+		t.Fatalf("Missing round trip samples: %d != %d",
+			len(roundTripCommands), len(commands))
+	}
+
+	for _, command := range roundTripCommands {
+		payload, err := command.Encode()
+		if err != nil {
+			t.Fatalf("%T: failed to encode: %v", command, err)
+		}
+
+		if payload[0] != command.CommandID() {
+			t.Fatalf("%T: bad command ID: 0x%02x != 0x%02x", command,
+				payload[0], command.CommandID())
+		}
+
+		decoded, err := DecodeCommand(command.CommandClass(), command.Version(),
+			payload)
+		if err != nil {
+			t.Fatalf("%T: failed to decode %x: %v", command, payload, err)
+		}
+
+		if !reflect.DeepEqual(command, decoded) {
+			t.Fatalf("%T: round trip mismatch: %+v != %+v", command, command,
+				decoded)
+		}
+
+		// Trailing bytes are ignored, unless the last parameter extends to
+		// the end of the command. A partial variant group element is an
+		// error.
+		extended := append(append([]uint8(nil), payload...), 0x00)
+		if decoded, err = DecodeCommand(command.CommandClass(),
+			command.Version(), extended); err != nil && !endsWithGroup(command) {
+			t.Fatalf("%T: failed to decode %x: %v", command, extended, err)
+		}
+
+		if err == nil && !reflect.DeepEqual(command, decoded) {
+			if encoded, err := decoded.Encode(); err != nil ||
+				!bytes.Equal(encoded, extended) {
+				t.Fatalf("%T: extended mismatch: %+v != %+v", command, command,
+					decoded)
+			}
+		}
+
+		// Truncated data must fail to decode, or encode to the same data
+		// followed by the optional parameters it was missing
+		if len(payload) < 2 {
+			continue
+		}
+
+		data := payload[1 : len(payload)-1]
+		if decoded, err = DecodeCommand(command.CommandClass(),
+			command.Version(), append([]uint8{payload[0]}, data...)); err != nil {
+			continue
+		}
+
+		encoded, err := decoded.Encode()
+		if err != nil || !bytes.HasPrefix(encoded[1:], data) {
+			t.Fatalf("%T: accepted %x but encoded %x, %v", command, data,
+				encoded, err)
+		}
+	}
+}
+
+func TestNewCommand(t *testing.T) {
+	if command := NewCommand(CommandClassBasic, 0x03, 0); command == nil {
+		t.Fatalf("Expected command")
+	} else if command.Version() != 2 {
+		t.Fatalf("Expected highest version: %d != 2", command.Version())
+	}
+
+	if command := NewCommand(CommandClassBasic, 0x03, 1); command == nil {
+		t.Fatalf("Expected command")
+	} else if _, ok := command.(*BasicReportV1); !ok {
+		t.Fatalf("Bad command type: %T", command)
+	}
+
+	if command := NewCommand(CommandClassBasic, 0x03, 9); command != nil {
+		t.Fatalf("Unexpected command: %T", command)
+	}
+
+	if command := NewCommand(0xff, 0x03, 0); command != nil {
+		t.Fatalf("Unexpected command: %T", command)
+	}
+}
+
+func TestDecodeCommand(t *testing.T) {
+	command, err := DecodeCommand(CommandClassSensorMultilevel, 0,
+		[]uint8{0x05, 0x01, 0x22, 0x00, 0xd7})
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	report, ok := command.(*SensorMultilevelReportV5)
+	if !ok {
+		t.Fatalf("Bad command type: %T", command)
+	}
+
+	if report.SensorType != 0x01 || report.Precision != 1 || report.Scale != 0 ||
+		!bytes.Equal(report.SensorValue, []uint8{0x00, 0xd7}) {
+		t.Fatalf("Bad report: %+v", report)
+	}
+
+	// Size says 2 bytes, but only 1 is present
+	if _, err = DecodeCommand(CommandClassSensorMultilevel, 0,
+		[]uint8{0x05, 0x01, 0x22, 0x00}); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err = DecodeCommand(CommandClassBasic, 0, nil); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err = DecodeCommand(CommandClassBasic, 0, []uint8{0x7f}); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
+
+func TestDecodeVersions(t *testing.T) {
+	tests := []struct {
+		commandClass uint8
+		version      uint8
+		payload      []uint8
+		expected     Command
+	}{
+		// Version 1 and 2 Meter Reports without Delta Time
+		{CommandClassMeter, 0, []uint8{0x02, 0x01, 0x21, 0x12},
+			&MeterReportV3{MeterType: 0x01, Precision: 0x01,
+				MeterValue: []uint8{0x12}}},
+		{CommandClassMeter, 2, []uint8{0x02, 0x21, 0x22, 0x12, 0x34},
+			&MeterReportV2{MeterType: 0x01, RateType: 0x01, Precision: 0x01,
+				MeterValue: []uint8{0x12, 0x34}}},
+		// Delta Time of 0 without Previous Meter Value
+		{CommandClassMeter, 3, []uint8{0x02, 0x21, 0x21, 0x12, 0x00, 0x00},
+			&MeterReportV3{MeterType: 0x01, RateType: 0x01, Precision: 0x01,
+				MeterValue: []uint8{0x12}}},
+		// Version 1 Alarm Report
+		{CommandClassAlarm, 2, []uint8{0x05, 0x01, 0xff},
+			&AlarmReportV2{AlarmType: 0x01, AlarmLevel: 0xff}},
+		// Version 2 Alarm Report decoded as version 1
+		{CommandClassAlarm, 1, []uint8{0x05, 0x01, 0xff, 0x00, 0xff, 0x06, 0x02, 0x00},
+			&AlarmReportV1{AlarmType: 0x01, AlarmLevel: 0xff}},
+		// Sensor Multilevel Report with a trailing byte
+		{CommandClassSensorMultilevel, 0, []uint8{0x05, 0x01, 0x01, 0xd7, 0x00},
+			&SensorMultilevelReportV5{SensorType: 0x01, SensorValue: []uint8{0xd7}}},
+		// Version 1 Basic Report
+		{CommandClassBasic, 2, []uint8{0x03, 0xff},
+			&BasicReportV2{CurrentValue: 0xff}},
+		// Version 1 Sensor Binary Get has no sensor type
+		{CommandClassSensorBinary, 2, []uint8{0x02},
+			&SensorBinaryGetV2{}},
+	}
+
+	for _, test := range tests {
+		command, err := DecodeCommand(test.commandClass, test.version,
+			test.payload)
+		if err != nil {
+			t.Fatalf("%x: failed to decode: %v", test.payload, err)
+		}
+
+		if !reflect.DeepEqual(command, test.expected) {
+			t.Fatalf("%x: %+v != %+v", test.payload, command, test.expected)
+		}
+	}
+
+	// Optional parameters are cleared when decoding into a used command
+	report := &MeterReportV3{DeltaTime: 0x0102, PreviousMeterValue: []uint8{0x01}}
+	if err := report.Decode([]uint8{0x01, 0x21, 0x12}); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	} else if report.DeltaTime != 0 || report.PreviousMeterValue != nil {
+		t.Fatalf("Bad report: %+v", report)
+	}
+
+	// A partial optional parameter is an error
+	if _, err := DecodeCommand(CommandClassMeter, 3,
+		[]uint8{0x02, 0x01, 0x21, 0x12, 0x00}); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	commands := []Command{
+		&WakeUpIntervalSetV2{Seconds: 0x1000000},
+		&SensorMultilevelGetV5{Scale: 0x04},
+		&MeterReportV3{MeterValue: []uint8{0x01}, PreviousMeterValue: []uint8{0x01, 0x02}},
+		&MeterReportV3{MeterValue: make([]uint8, 8), PreviousMeterValue: make([]uint8, 8)},
+		&AlarmTypeSupportedReportV2{BitMask: make([]uint8, 32)},
+	}
+
+	for _, command := range commands {
+		if payload, err := command.Encode(); err == nil {
+			t.Fatalf("%T: expected error, got %x", command, payload)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		command  Command
+		expected []uint8
+	}{
+		{&SwitchBinarySetV1{SwitchValue: SwitchBinarySetV1SwitchValueOnEnable},
+			[]uint8{0x01, 0xff}},
+		{&AlarmSetV2{ZWaveAlarmType: 0x01, ZWaveAlarmStatus: AlarmSetV2ZWaveAlarmStatusOn},
+			[]uint8{0x06, 0x01, 0xff}},
+		{&WakeUpIntervalSetV2{Seconds: 3600, NodeID: 0x01},
+			[]uint8{0x04, 0x00, 0x0e, 0x10, 0x01}},
+		{&MeterReportV3{MeterType: 0x01, RateType: 0x01, ScaleBits10: 0x02,
+			Precision: 0x03, MeterValue: []uint8{0x00, 0x00, 0x12, 0x34},
+			DeltaTime: 0x0102, PreviousMeterValue: []uint8{0x00, 0x00, 0x12, 0x00}},
+			[]uint8{0x02, 0x21, 0x74, 0x00, 0x00, 0x12, 0x34, 0x01, 0x02,
+				0x00, 0x00, 0x12, 0x00}},
+		{&SwitchMultilevelStartLevelChangeV1{UpDown: true, IgnoreStartLevel: true},
+			[]uint8{0x04, 0xa0, 0x00}},
+	}
+
+	for _, test := range tests {
+		payload, err := test.command.Encode()
+		if err != nil {
+			t.Fatalf("%T: failed to encode: %v", test.command, err)
+		}
+
+		if !bytes.Equal(payload, test.expected) {
+			t.Fatalf("%T: %x != %x", test.command, payload, test.expected)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 // Package controller reads and writes packets to a ZWave USB Serial Controller.
 // All public methods are goroutine safe. The same controller instance can be
 // opened and closed multiple times. Closing the controller will invalidate all
-// ongoing requests and drop all buffered responses.
+// ongoing requests and drop all buffered responses. An open controller
+// reconnects to the device after fatal serial errors, such as the USB device
+// being unplugged.
 package controller
 
 /*
@@ -25,33 +27,71 @@ import (
 	"fmt"
 	"github.com/cybojanek/gozwave/message"
 	"github.com/cybojanek/gozwave/packet"
+	"github.com/cybojanek/gozwave/trace"
 	"github.com/tarm/serial"
+	"io"
 	"log"
 	"math/rand"
 	"sync"
 	"time"
 )
 
+// ErrClosed is returned for requests invalidated by Close
+var ErrClosed = errors.New("Controller closed")
+
+// ErrDisconnected is returned for requests invalidated by a serial device
+// error, or issued while reconnecting
+var ErrDisconnected = errors.New("Controller disconnected")
+
+// errHungUp is the fatal read error of a serial device, which keeps returning
+// io.EOF without waiting for the read timeout
+var errHungUp = errors.New("Serial device hung up")
+
+// Connection State
+const (
+	ConnectionStateConnected    uint8 = 0x01 // Reconnected to the serial device
+	ConnectionStateDisconnected       = 0x02 // Serial device failed
+	ConnectionStateReconnecting       = 0x03 // Attempting to reopen the serial device
+)
+
 // Controller processes a ZWave packet and returns a response
 type Controller interface {
 	DoRequest(request *packet.Packet) (*packet.Packet, error)
 }
 
+// Port is a connection to the controller. Read returns io.EOF after a timeout
+// without data. A Port which keeps returning io.EOF without waiting, like a
+// hung up tty, is treated as failed.
+type Port interface {
+	io.ReadWriteCloser
+	Flush() error
+}
+
 // SerialController information and state
 type SerialController struct {
-	DevicePath   string // Path USB serial device
-	DebugLogging bool   // Toggle DEBUG logging
-
-	lastCallbackID   uint8                   // Next ZWSendData callback id
-	mutex            sync.Mutex              // SerialController mutex
-	callbackChannel  chan *packet.Packet     // Callback channel
-	serial           *serial.Port            // Serial port connection
-	responses        chan *packet.Packet     // Channel for packets read from serial
-	requests         chan *controllerRequest // Channel for outgoing requests
-	stopResponses    chan int                // Exit signal channel for doResponses
-	stopRequests     chan int                // Exit signal channel for doRequests
-	stoppedResponses chan int                // Exit confirmation channel for doResponses
-	stoppedRequests  chan int                // Exit confirmation channel for doRequests
+	DevicePath   string               // Path USB serial device
+	DebugLogging bool                 // Toggle DEBUG logging
+	OpenPort     func() (Port, error) // Opens the connection instead of DevicePath, can be nil
+	Trace        *trace.Writer        // Records all bytes to and from the controller, can be nil
+
+	lastCallbackID    uint8                   // Next ZWSendData callback id
+	openMutex         sync.Mutex              // Serializes Open and Close
+	mutex             sync.Mutex              // SerialController mutex
+	opened            bool                    // Open and not yet Closed
+	callbackMutex     sync.Mutex              // Callback, state channel and node ID type mutex
+	callbackChannel   chan *packet.Packet     // Callback channel
+	stateChannel      chan uint8              // Connection state channel
+	nodeIDType        uint8                   // Node ID type of request frames
+	serial            Port                    // Serial port connection, nil while disconnected
+	responses         chan *packet.Packet     // Channel for packets read from serial
+	requests          chan *controllerRequest // Channel for outgoing requests
+	fatal             chan error              // Fatal serial errors for doSupervise
+	stopResponses     chan int                // Exit signal channel for doResponses
+	stopRequests      chan error              // Exit signal channel for doRequests, with request error
+	stopSupervisor    chan int                // Exit signal channel for doSupervise
+	stoppedResponses  chan int                // Exit confirmation channel for doResponses
+	stoppedRequests   chan int                // Exit confirmation channel for doRequests
+	stoppedSupervisor chan int                // Exit confirmation channel for doSupervise
 }
 
 // A request to the controller. Used only within serial constroller
@@ -62,6 +102,71 @@ type controllerRequest struct {
 	Chan     chan int       // Channel to notify on request completion
 }
 
+// getRequestFlow returns the flow of the request. Requests of a fixed body
+// length, which already end with a callback id of 0, do not get a callback.
+func getRequestFlow(request *packet.Packet, nodeIDType uint8) message.RequestFlow {
+	codec, _ := message.LookupCodec(request.MessageType)
+	if codec.Flow == nil {
+		return message.RequestFlow{Response: true}
+	}
+	flow := *codec.Flow
+
+	bodyLength := flow.BodyLength
+	if bodyLength > 0 && nodeIDType == message.NodeIDType16Bit {
+		bodyLength++
+	}
+	if bodyLength > 0 && len(request.Body) == bodyLength+1 &&
+		request.Body[bodyLength] == 0x00 {
+		return message.RequestFlow{Response: true}
+	}
+
+	return flow
+}
+
+// checkSendDataRequest checks that a ZWSendData or ZWSendDataMulti request
+// is well formed, and does not already contain a callback id
+func checkSendDataRequest(request *packet.Packet, nodeIDType uint8) error {
+	var name string
+	var lengthIndex int
+	body := request.Body
+
+	switch request.MessageType {
+	case message.MessageTypeZWSendData:
+		// Body: | NODE_ID | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
+		//       | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
+		// NODE_ID is two bytes in the 16 bit node ID type
+		name = "ZWSendData"
+		lengthIndex = 1
+		if nodeIDType == message.NodeIDType16Bit {
+			lengthIndex = 2
+		}
+
+	case message.MessageTypeZWSendDataMulti:
+		// Body: | NUMBER_OF_NODES | NODE_IDS | LENGTH_OF_PAYLOAD + 1 |
+		//       | COMMAND_CLASS | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
+		name = "ZWSendDataMulti"
+		if len(body) > 0 {
+			lengthIndex = 1 + int(body[0])
+		}
+
+	default:
+		return nil
+	}
+
+	if len(body) < lengthIndex+3 {
+		return fmt.Errorf("%s request is too small", name)
+	}
+	payloadLength := int(body[lengthIndex])
+	if payloadLength+lengthIndex+3 == len(body) {
+		// Callback id is last byte - error, not allowed
+		return fmt.Errorf("Specifying a custom %s CallbackID is not allowed", name)
+	} else if payloadLength+lengthIndex+2 != len(body) {
+		return fmt.Errorf("%s request has unexpected length", name)
+	}
+
+	return nil
+}
+
 // TODO: check constraints on this
 const callbackIDMin = 0x0a + 1
 const callbackIDMax = 0x7f
@@ -81,6 +186,20 @@ const responseTimeout = (10 * time.Second)
 // Serial port read timeout for non-blocking mode
 const serialPortReadTimeout = (1 * time.Second)
 
+// Reads returning io.EOF sooner than this did not wait for the read timeout
+const immediateEOFTime = (5 * time.Millisecond)
+
+// Number of consecutive immediate io.EOF reads, after which the serial device
+// is considered hung up
+const maxImmediateEOFCount = 10
+
+// Minimum and maximum time to wait between reconnect attempts
+const reconnectMinBackOff = (1 * time.Second)
+const reconnectMaxBackOff = (30 * time.Second)
+
+// Time for the controller to restart after a soft reset
+const softResetDelay = (1500 * time.Millisecond)
+
 var ackBytes = []uint8{packet.PacketPreambleACK, '\n'}
 var nakBytes = []uint8{packet.PacketPreambleNAK, '\n'}
 
@@ -89,11 +208,21 @@ var nakBytes = []uint8{packet.PacketPreambleNAK, '\n'}
 // isOpen is an private function that does not acquire the controller mutex.
 // NOTE: not goroutine safe, caller must hold controller.mutex
 func (controller *SerialController) isOpen() bool {
+	return controller.opened
+}
+
+// isConnected is an private function that does not acquire the controller
+// mutex.
+// NOTE: not goroutine safe, caller must hold controller.mutex
+func (controller *SerialController) isConnected() bool {
 	return controller.serial != nil
 }
 
 // Open controller. goroutine safe.
 func (controller *SerialController) Open() error {
+	controller.openMutex.Lock()
+	defer controller.openMutex.Unlock()
+
 	controller.mutex.Lock()
 	defer controller.mutex.Unlock()
 
@@ -101,84 +230,268 @@ func (controller *SerialController) Open() error {
 		return nil
 	}
 
-	c := &serial.Config{Name: controller.DevicePath, Baud: 115200,
-		ReadTimeout: serialPortReadTimeout}
-
-	s, err := serial.OpenPort(c)
-	if err != nil {
-		return err
-	}
-	controller.serial = s
-
-	controller.serial.Flush()
-
 	if controller.responses == nil {
 		controller.responses = make(chan *packet.Packet)
 		// 1 to avoid deadlock on closed submit
 		controller.requests = make(chan *controllerRequest, 1)
-		controller.stopRequests = make(chan int)
+		controller.fatal = make(chan error, 1)
+		controller.stopRequests = make(chan error)
 		controller.stoppedRequests = make(chan int)
-		controller.stopResponses = make(chan int)
+		// 1 since doResponses might be blocked on controller.responses
+		controller.stopResponses = make(chan int, 1)
 		controller.stoppedResponses = make(chan int)
+		controller.stopSupervisor = make(chan int)
+		controller.stoppedSupervisor = make(chan int)
 	}
 
 	// On startup choose a random starting callbackID
 	rand.Seed(time.Now().Unix())
 	controller.lastCallbackID = uint8(rand.Int31n(callbackIDMax-callbackIDMin+1) + callbackIDMin)
 
-	go controller.doRequests()
-	go controller.doResponses()
+	if err := controller.start(); err != nil {
+		return err
+	}
+
+	controller.opened = true
+	go controller.doSupervise()
 
 	return nil
 }
 
 // Close controller. goroutine safe.
 func (controller *SerialController) Close() error {
+	controller.openMutex.Lock()
+	defer controller.openMutex.Unlock()
+
+	controller.mutex.Lock()
+	if !controller.isOpen() {
+		controller.mutex.Unlock()
+		return nil
+	}
+	// Reject new requests
+	controller.opened = false
+	controller.mutex.Unlock()
+
+	// doSupervise acquires the mutex while reconnecting, so stop it first
+	controller.stopSupervisor <- 0
+	<-controller.stoppedSupervisor
+
 	controller.mutex.Lock()
 	defer controller.mutex.Unlock()
 
-	if !controller.isOpen() {
+	if !controller.isConnected() {
 		return nil
 	}
 
+	return controller.stop(ErrClosed)
+}
+
+// start opens the serial device and starts doRequests and doResponses
+// NOTE: not goroutine safe, caller must hold controller.mutex
+func (controller *SerialController) start() error {
+	s, err := controller.openPort()
+	if err != nil {
+		return err
+	}
+	controller.serial = s
+
+	controller.serial.Flush()
+
+	go controller.doRequests()
+	go controller.doResponses()
+
+	return nil
+}
+
+// openPort opens the connection to the controller, wrapped to record a trace
+func (controller *SerialController) openPort() (Port, error) {
+	var port Port
+
+	if controller.OpenPort != nil {
+		var err error
+		if port, err = controller.OpenPort(); err != nil {
+			return nil, err
+		}
+	} else {
+		c := &serial.Config{Name: controller.DevicePath, Baud: 115200,
+			ReadTimeout: serialPortReadTimeout}
+
+		s, err := serial.OpenPort(c)
+		if err != nil {
+			return nil, err
+		}
+		port = s
+	}
+
+	if controller.Trace != nil {
+		port = &tracePort{Port: port, trace: controller.Trace}
+	}
+
+	return port, nil
+}
+
+// stop doRequests and doResponses, fail all pending requests with the
+// requestErr, and close the serial device
+// NOTE: not goroutine safe, caller must hold controller.mutex
+func (controller *SerialController) stop(requestErr error) error {
 	// doRequests will always stop if triggered with stopRequqests
-	controller.stopRequests <- 0
+	controller.stopRequests <- requestErr
 	<-controller.stoppedRequests
 
-	// doResponses might block on sending to controller.responses, so don't
-	// check for stoppedResponses until after purging all channels
+	// doResponses might block on sending to controller.responses, so purge
+	// all requests and responses until it exits
 	controller.stopResponses <- 0
 
-	// Purge all requests and responses
 loop:
 	for {
 		select {
 
 		case request := <-controller.requests:
-			request.Err = fmt.Errorf("Controller closed")
+			request.Err = requestErr
 			request.Chan <- 0
 
 		case <-controller.responses:
 			// Pass and drop
 
-		default:
+		case <-controller.stoppedResponses:
 			break loop
 		}
 	}
 
-	// Now safe to expect response
-	<-controller.stoppedResponses
-
 	// Close after doReponses exits
 	err := controller.serial.Close()
 
 	controller.serial = nil
 
+	// Drop fatal errors of the stopped serial device
+	select {
+	case <-controller.fatal:
+	default:
+	}
+
 	return err
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// Reopen the serial device after fatal errors, until Close
+func (controller *SerialController) doSupervise() {
+	for {
+		select {
+
+		case err := <-controller.fatal:
+			log.Printf("ERROR doSupervise serial device failed: %v", err)
+			if !controller.reconnect() {
+				controller.stoppedSupervisor <- 0
+				return
+			}
+
+		case <-controller.stopSupervisor:
+			controller.stoppedSupervisor <- 0
+			return
+		}
+	}
+}
+
+// reconnect closes the failed serial device, and reopens it with back off.
+// Returns false if stopped before reconnecting.
+// Assumptions: called only from doSupervise
+func (controller *SerialController) reconnect() bool {
+	controller.mutex.Lock()
+	if controller.isConnected() {
+		if err := controller.stop(ErrDisconnected); err != nil {
+			log.Printf("ERROR reconnect close error: %v", err)
+		}
+	}
+	controller.mutex.Unlock()
+
+	controller.sendState(ConnectionStateDisconnected)
+
+	for backOff := reconnectMinBackOff; ; {
+		select {
+		case <-time.After(backOff):
+		case <-controller.stopSupervisor:
+			return false
+		}
+
+		controller.sendState(ConnectionStateReconnecting)
+
+		controller.mutex.Lock()
+		err := controller.start()
+		controller.mutex.Unlock()
+
+		if err == nil {
+			break
+		}
+
+		if backOff *= 2; backOff > reconnectMaxBackOff {
+			backOff = reconnectMaxBackOff
+		}
+		log.Printf("ERROR reconnect to %s failed, retrying in %v: %v",
+			controller.DevicePath, backOff, err)
+	}
+
+	// The controller might have been power cycled, or be in the middle of a
+	// request, so start from a known state. Errors are fatal serial errors,
+	// which are handled by doSupervise. The request retries for a long time
+	// without a reply, so Close does not wait for it, and fails it instead.
+	softReset := make(chan error, 1)
+	go func() {
+		_, err := controller.DoRequest(message.SerialAPISoftResetRequest())
+		softReset <- err
+	}()
+
+	select {
+	case err := <-softReset:
+		if err != nil {
+			log.Printf("ERROR reconnect soft reset error: %v", err)
+			break
+		}
+
+		select {
+		case <-time.After(softResetDelay):
+		case <-controller.stopSupervisor:
+			return false
+		}
+
+	case <-controller.stopSupervisor:
+		return false
+	}
+
+	log.Printf("INFO reconnect to %s done", controller.DevicePath)
+	controller.sendState(ConnectionStateConnected)
+
+	return true
+}
+
+// signalFatal notifies doSupervise of a fatal serial error, without blocking
+// if a notification is already pending
+func (controller *SerialController) signalFatal(err error) {
+	select {
+	case controller.fatal <- err:
+	default:
+	}
+}
+
+// sendState sends a connection state to the state channel, without blocking
+// NOTE: goroutine safe, acquires controller callback lock
+func (controller *SerialController) sendState(state uint8) {
+	controller.callbackMutex.Lock()
+	defer controller.callbackMutex.Unlock()
+
+	if controller.stateChannel == nil {
+		return
+	}
+
+	select {
+	case controller.stateChannel <- state:
+	default:
+		log.Printf("ERROR sendState dropped connection state: 0x%02x", state)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 // DoRequest issues a request and awaits a response. goroutine safe.
 func (controller *SerialController) DoRequest(request *packet.Packet) (*packet.Packet, error) {
 	if request.Preamble != packet.PacketPreambleSOF {
@@ -196,6 +509,9 @@ func (controller *SerialController) DoRequest(request *packet.Packet) (*packet.P
 	if !controller.isOpen() {
 		controller.mutex.Unlock()
 		return nil, fmt.Errorf("Controller is not open")
+	} else if !controller.isConnected() {
+		controller.mutex.Unlock()
+		return nil, ErrDisconnected
 	}
 	controller.mutex.Unlock()
 
@@ -205,7 +521,12 @@ func (controller *SerialController) DoRequest(request *packet.Packet) (*packet.P
 	controller.requests <- &controllerRequest
 
 	controller.mutex.Lock()
-	if !controller.isOpen() {
+	if !controller.isConnected() {
+		requestErr := ErrDisconnected
+		if !controller.isOpen() {
+			requestErr = ErrClosed
+		}
+
 		// Error on all requests, ours might be there too
 		// NOTE: this will only loop indefinitely if there is an unending
 		//       stream of new requests...
@@ -214,7 +535,7 @@ func (controller *SerialController) DoRequest(request *packet.Packet) (*packet.P
 			select {
 
 			case request := <-controller.requests:
-				request.Err = fmt.Errorf("Controller closed")
+				request.Err = requestErr
 				request.Chan <- 0
 
 			default:
@@ -232,12 +553,31 @@ func (controller *SerialController) DoRequest(request *packet.Packet) (*packet.P
 // SetCallbackChannel set the channel to the callback list, can be null.
 // goroutine safe.
 func (controller *SerialController) SetCallbackChannel(channel chan *packet.Packet) {
-	controller.mutex.Lock()
-	defer controller.mutex.Unlock()
+	controller.callbackMutex.Lock()
+	defer controller.callbackMutex.Unlock()
 
 	controller.callbackChannel = channel
 }
 
+// SetStateChannel set the channel for ConnectionState changes, can be null.
+// States are dropped if the channel is full, so it should be buffered.
+// goroutine safe.
+func (controller *SerialController) SetStateChannel(channel chan uint8) {
+	controller.callbackMutex.Lock()
+	defer controller.callbackMutex.Unlock()
+
+	controller.stateChannel = channel
+}
+
+// SetNodeIDType sets the node ID type of request frames, after the controller
+// was switched to it. goroutine safe.
+func (controller *SerialController) SetNodeIDType(nodeIDType uint8) {
+	controller.callbackMutex.Lock()
+	defer controller.callbackMutex.Unlock()
+
+	controller.nodeIDType = nodeIDType
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 // Read from serial device and forward parsed packets to controller.responses
@@ -246,10 +586,33 @@ func (controller *SerialController) doResponses() {
 	parser := packet.Parser{}
 	buffer := make([]byte, 512)
 
+	// Consecutive reads which returned io.EOF without waiting
+	immediateEOFCount := 0
+
 	for {
-		// Read blocking with timeout
-		// FIXME: currently error ignored following serial library guidelines
-		if n, _ := controller.serial.Read(buffer); n > 0 {
+		// Read blocking with timeout, which is reported as io.EOF
+		start := time.Now()
+		n, err := controller.serial.Read(buffer)
+		if err == io.EOF && n == 0 && time.Since(start) < immediateEOFTime {
+			// A hung up tty returns io.EOF on every read, without waiting
+			if immediateEOFCount++; immediateEOFCount >= maxImmediateEOFCount {
+				err = errHungUp
+			}
+		} else {
+			immediateEOFCount = 0
+		}
+
+		if err != nil && err != io.EOF {
+			log.Printf("ERROR doResponses read error: %v", err)
+			controller.signalFatal(err)
+
+			// Wait for doSupervise to stop us
+			<-controller.stopResponses
+			controller.stoppedResponses <- 0
+			return
+		}
+
+		if n > 0 {
 			// Log received bytes
 			if controller.DebugLogging {
 				log.Printf("DEBUG doResponses bytes: %v", buffer[0:n])
@@ -287,7 +650,7 @@ func (controller *SerialController) doResponses() {
 
 ////////////////////////////////////////////////////////////////////////////////
 
-// Write all bytes to the serial device
+// Write all bytes to the serial device. Errors are fatal.
 // Assumptions: called only from doRequests
 func (controller *SerialController) writeFully(b []byte) error {
 	written := 0
@@ -295,6 +658,7 @@ func (controller *SerialController) writeFully(b []byte) error {
 		n, err := controller.serial.Write(b[written:])
 		if err != nil {
 			log.Printf("ERROR writeFully error: %v", err)
+			controller.signalFatal(err)
 			return err
 		}
 		written += n
@@ -316,10 +680,10 @@ func (controller *SerialController) getZWaveCallbackID() uint8 {
 }
 
 // sendToCallback sends a packet to a callback channel
-// NOTE: goroutine safe, acquires controller lock
+// NOTE: goroutine safe, acquires controller callback lock
 func (controller *SerialController) sendToCallback(packet *packet.Packet) {
-	controller.mutex.Lock()
-	defer controller.mutex.Unlock()
+	controller.callbackMutex.Lock()
+	defer controller.callbackMutex.Unlock()
 
 	// NOTE: extract to local variable to not refernce controller in goroutine
 	channel := controller.callbackChannel
@@ -380,36 +744,34 @@ func (controller *SerialController) doRequests() {
 				log.Printf("DEBUG doRequests request Packet: %v", request.Request)
 			}
 
-			// For ZWSendData, we need to inspect and potentially inject a
+			// For requests with callbacks, we need to inspect and inject a
 			// random callback id. This is ugly, since we're mixing protocol
 			// layers, but at least we can transparently handle this.
+			controller.callbackMutex.Lock()
+			nodeIDType := controller.nodeIDType
+			controller.callbackMutex.Unlock()
+
+			flow := getRequestFlow(request.Request, nodeIDType)
 			var callbackID uint8
-			if request.Request.MessageType == message.MessageTypeZWSendData {
+			if flow.CallbackID {
 				callbackID = controller.getZWaveCallbackID()
 
-				body := request.Request.Body
-				// Body: | NODE_ID | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
-				//       | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
-				if len(body) < 4 {
-					request.Err = fmt.Errorf("ZWSendData request is too small")
+				if err := checkSendDataRequest(request.Request, nodeIDType); err != nil {
+					request.Err = err
 					request.Chan <- 0
 					break
 				}
-				payloadLength := body[1]
-				if int(payloadLength)+4 == len(body) {
-					// Callback id is last byte - error, not allowed
-					request.Err = fmt.Errorf("Specifying a custom ZWSendData " +
-						"CallbackID is not allowed")
-					request.Chan <- 0
-					break
-				} else if int(payloadLength)+3 == len(body) {
-					// No callback id
-					request.Request.Body = append(request.Request.Body, callbackID)
+
+				// Callback id is the last byte, unless the flow places it
+				// before the rest of the body
+				body := request.Request.Body
+				if flow.CallbackAt > 0 && flow.CallbackAt < len(body) {
+					body = append(body[:flow.CallbackAt:flow.CallbackAt],
+						append([]uint8{callbackID}, body[flow.CallbackAt:]...)...)
 				} else {
-					request.Err = fmt.Errorf("ZWSendData request has unexpected length")
-					request.Chan <- 0
-					break
+					body = append(body, callbackID)
 				}
+				request.Request.Body = body
 
 				if controller.DebugLogging {
 					log.Printf("DEBUG doRequests request modified Packet: %v", request.Request)
@@ -434,8 +796,7 @@ func (controller *SerialController) doRequests() {
 				// Write Packet
 				if resend {
 					if err := controller.writeFully(requestBytes); err != nil {
-						request.Err = fmt.Errorf("Failed to write bytes to serial device %v", err)
-						request.Chan <- 0
+						request.Err = ErrDisconnected
 						break
 					}
 					resend = false
@@ -499,8 +860,8 @@ func (controller *SerialController) doRequests() {
 					attempt++
 					resend = true
 
-				case <-controller.stopRequests:
-					request.Err = fmt.Errorf("Controller closed")
+				case err := <-controller.stopRequests:
+					request.Err = err
 					request.Chan <- 0
 					controller.stoppedRequests <- 0
 					return
@@ -509,14 +870,21 @@ func (controller *SerialController) doRequests() {
 
 			// Check if we got an ACK
 			if !gotACK {
-				request.Err = errors.New("Failed to send request")
+				if request.Err == nil {
+					request.Err = errors.New("Failed to send request")
+				}
+				request.Chan <- 0
+				break
+			}
+
+			// Some requests are complete with just the ACK
+			if !flow.Response && !flow.Callback {
 				request.Chan <- 0
 				break
 			}
 
-			responseCount := 0
-		wait_for_response:
 			// Await response
+			gotCallbackResponse := false
 			gotResponse := false
 			for attempt := 0; attempt < maxResponseRetryCount && !gotResponse; {
 
@@ -560,48 +928,58 @@ func (controller *SerialController) doRequests() {
 							continue
 						}
 
-						if response.MessageType == message.MessageTypeZWSendData {
-							if responseCount == 0 {
-								// This is the first ZWSendData response
-								if len(response.Body) != 1 {
-									log.Printf("ERROR doRequests request response "+
-										"ZWSendData reply too long: %d", len(response.Body))
-									// Try to route it anyways
-									controller.sendToCallback(response)
-									attempt++
-									continue
-								} else if response.Body[0] != 1 {
-									log.Printf("ERROR doRequests request response "+
-										"ZWSendData reply not TransmitCompleteOK (0x00): 0x%02x",
-										response.Body[0])
-									attempt++
-									continue
-								} else {
-									// This is just the 1 byte response confirming
-									// our request. We need to wait for one more
-									// response with the final data
-									attempt = 0
-									responseCount = 1
-									goto wait_for_response
-								}
+						if flow.Callback && flow.Response && !gotCallbackResponse {
+							// This is the first response
+							if response.PacketType != packet.PacketTypeResponse ||
+								len(response.Body) != 1 {
+								log.Printf("ERROR doRequests request response "+
+									"MessageType 0x%02x unexpected reply: %v",
+									response.MessageType, response)
+								// Try to route it anyways
+								controller.sendToCallback(response)
+								attempt++
+								continue
+							} else if response.Body[0] == 0 {
+								// The controller will not send a callback
+								request.Err = fmt.Errorf("MessageType 0x%02x request "+
+									"rejected by controller", response.MessageType)
+								gotResponse = true
+								request.Chan <- 0
+								continue
 							} else {
-								// Check for matching callback id
-								if len(response.Body) != 4 {
-									log.Printf("ERROR doRequests request response "+
-										"ZWSendData reply not == 4: 0x%02x", len(response.Body))
-									attempt++
-									continue
-								} else if actualCallbackID := response.Body[0]; actualCallbackID != callbackID {
-									// FIXME: better checking
-									log.Printf("ERROR doRequests request response "+
-										"ZWSendData reply callback mismatch 0x%02x != 0x%02x",
-										actualCallbackID, callbackID)
-									attempt++
-									controller.sendToCallback(response)
-									continue
-								} else {
-									// It matches! Nothing to do, fall through
+								// This is just the 1 byte response confirming
+								// our request. We need to wait for one more
+								// response with the final data
+								attempt = 0
+								gotCallbackResponse = true
+								continue
+							}
+						} else if flow.Callback {
+							// Check for matching callback id
+							if len(response.Body) < 1 {
+								log.Printf("ERROR doRequests request response "+
+									"MessageType 0x%02x callback too short: %d",
+									response.MessageType, len(response.Body))
+								attempt++
+								continue
+							} else if actualCallbackID := response.Body[0]; actualCallbackID != callbackID {
+								// FIXME: better checking
+								log.Printf("ERROR doRequests request response "+
+									"MessageType 0x%02x callback mismatch 0x%02x != 0x%02x",
+									response.MessageType, actualCallbackID, callbackID)
+								attempt++
+								controller.sendToCallback(response)
+								continue
+							} else if len(response.Body) > 1 && flow.IsIntermediate(response.Body[1]) {
+								// Keep waiting for the final callback
+								if controller.DebugLogging {
+									log.Printf("DEBUG doRequests request response "+
+										"intermediate callback: %v", response)
 								}
+								attempt = 0
+								continue
+							} else {
+								// It matches! Nothing to do, fall through
 							}
 						}
 
@@ -633,8 +1011,8 @@ func (controller *SerialController) doRequests() {
 				case <-time.After(responseTimeout):
 					attempt++
 
-				case <-controller.stopRequests:
-					request.Err = fmt.Errorf("Controller closed")
+				case err := <-controller.stopRequests:
+					request.Err = err
 					request.Chan <- 0
 					controller.stoppedRequests <- 0
 					return
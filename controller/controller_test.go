@@ -17,7 +17,13 @@ limitations under the License.
 */
 
 import (
+	"bytes"
+	"errors"
 	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"github.com/cybojanek/gozwave/trace"
+	"io"
+	"sync"
 	"testing"
 	"time"
 )
@@ -142,3 +148,437 @@ func TestControllerCallback(t *testing.T) {
 
 	// TODO: add more tests
 }
+
+// testPacketBytes returns the bytes of a SOF packet
+func testPacketBytes(t *testing.T, packetType uint8, messageType uint8, body ...uint8) []uint8 {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packetType, MessageType: messageType, Body: body}
+	if err := p.Update(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	b, err := p.Bytes()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	return b
+}
+
+func TestControllerReplay(t *testing.T) {
+	// Recorded callback id, which is never assigned by the controller
+	const recordedCallbackID = callbackIDMin - 1
+
+	initDataRequest, err := message.SerialAPIGetInitDataRequest().Bytes()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	initDataResponse := testPacketBytes(t, packet.PacketTypeResponse,
+		message.MessageTypeSerialAPIGetInitData, 0x05, 0x08, 0x1d, 0x01)
+
+	sendDataPacket, err := message.ZWSendDataRequest(2, message.NodeIDType8Bit,
+		0x25, []uint8{0x02}, 0x25, recordedCallbackID)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	sendDataRequest, err := sendDataPacket.Bytes()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	sendDataResponse := testPacketBytes(t, packet.PacketTypeResponse,
+		message.MessageTypeZWSendData, 0x01)
+	sendDataCallback := testPacketBytes(t, packet.PacketTypeRequest,
+		message.MessageTypeZWSendData, recordedCallbackID, 0x00)
+
+	replay := trace.NewReplay([]*trace.Record{
+		{Direction: trace.DirectionWrite, Data: nakBytes},
+		{Direction: trace.DirectionWrite, Data: append(initDataRequest, '\n')},
+		{Direction: trace.DirectionRead, Data: []uint8{packet.PacketPreambleACK}},
+		{Direction: trace.DirectionRead, Data: initDataResponse},
+		{Direction: trace.DirectionWrite, Data: ackBytes},
+		{Direction: trace.DirectionWrite, Data: append(sendDataRequest, '\n')},
+		{Direction: trace.DirectionRead, Data: []uint8{packet.PacketPreambleACK}},
+		{Direction: trace.DirectionRead, Data: sendDataResponse},
+		{Direction: trace.DirectionWrite, Data: ackBytes},
+		{Direction: trace.DirectionRead, Data: sendDataCallback},
+		{Direction: trace.DirectionWrite, Data: ackBytes},
+	})
+
+	var buffer bytes.Buffer
+	traceWriter, err := trace.NewWriter(&buffer)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	controller := SerialController{Trace: traceWriter,
+		OpenPort: func() (Port, error) { return replay, nil }}
+
+	if err := controller.Open(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	defer func() {
+		if err := controller.Close(); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+	}()
+
+	response, err := controller.DoRequest(message.SerialAPIGetInitDataRequest())
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if actual, _ := response.Bytes(); !bytes.Equal(actual, initDataResponse) {
+		t.Errorf("Expected response %v got %v", initDataResponse, actual)
+	}
+
+	sendDataPacket, err = message.ZWSendDataRequest(2, message.NodeIDType8Bit,
+		0x25, []uint8{0x02}, 0x25, 0)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	response, err = controller.DoRequest(sendDataPacket)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if response.MessageType != message.MessageTypeZWSendData ||
+		len(response.Body) != 2 || response.Body[0] < callbackIDMin {
+		t.Errorf("Expected ZWSendData callback with a replayed callback id: %v", response)
+	}
+
+	select {
+	case <-replay.Done():
+	case <-time.After(time.Second):
+		t.Errorf("Expected replay done")
+	}
+
+	// The trace records the callback id written by the controller
+	records, err := trace.ReadAll(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	var written []uint8
+	for _, record := range records {
+		if record.Direction == trace.DirectionWrite {
+			written = append(written, record.Data...)
+		}
+	}
+	if len(response.Body) > 0 {
+		sendDataPacket, _ = message.ZWSendDataRequest(2, message.NodeIDType8Bit,
+			0x25, []uint8{0x02}, 0x25, response.Body[0])
+		sendDataRequest, _ = sendDataPacket.Bytes()
+		if !bytes.Contains(written, sendDataRequest) {
+			t.Errorf("Expected trace writes %v to contain %v", written, sendDataRequest)
+		}
+	}
+}
+
+// testPort emulates a controller, which ACKs requests and replies with
+// configured responses. Reads and writes fail once after failRead and
+// failWrite. After hangUp, reads return io.EOF immediately, like a hung up tty.
+type testPort struct {
+	t         *testing.T
+	mutex     sync.Mutex
+	parser    packet.Parser
+	inFrame   bool
+	pending   []uint8           // Bytes to be read
+	changed   chan struct{}     // Closed and replaced on changes
+	requests  []*packet.Packet  // Received requests
+	responses map[uint8][]uint8 // Response body of each MessageType
+	noACK     map[uint8]bool    // MessageTypes which are not ACKed
+	readErr   error             // Error of the next Read
+	writeErr  error             // Error of the next Write
+	hungUp    bool              // Reads return io.EOF without waiting
+	opens     int               // Number of opens
+}
+
+func newTestPort(t *testing.T) *testPort {
+	return &testPort{t: t, changed: make(chan struct{}),
+		responses: make(map[uint8][]uint8), noACK: make(map[uint8]bool)}
+}
+
+// open counts opens of the port
+func (port *testPort) open() (Port, error) {
+	port.mutex.Lock()
+	defer port.mutex.Unlock()
+
+	port.opens++
+	return port, nil
+}
+
+// update wakes up waiting readers
+// NOTE: not goroutine safe, caller must hold port.mutex
+func (port *testPort) update() {
+	close(port.changed)
+	port.changed = make(chan struct{})
+}
+
+// failRead fails the next Read with the error
+func (port *testPort) failRead(err error) {
+	port.mutex.Lock()
+	defer port.mutex.Unlock()
+
+	port.readErr = err
+	port.update()
+}
+
+// failWrite fails the next Write with the error
+func (port *testPort) failWrite(err error) {
+	port.mutex.Lock()
+	defer port.mutex.Unlock()
+
+	port.writeErr = err
+}
+
+// hangUp makes reads return io.EOF without waiting, until hungUp is false
+func (port *testPort) hangUp(hungUp bool) {
+	port.mutex.Lock()
+	defer port.mutex.Unlock()
+
+	port.hungUp = hungUp
+	port.update()
+}
+
+// waitRequests waits for count requests of the MessageType
+func (port *testPort) waitRequests(messageType uint8, count int) {
+	timeout := time.After(5 * time.Second)
+	for {
+		port.mutex.Lock()
+		n := 0
+		for _, request := range port.requests {
+			if request.MessageType == messageType {
+				n++
+			}
+		}
+		changed := port.changed
+		port.mutex.Unlock()
+
+		if n >= count {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			port.t.Fatalf("Timed out waiting for %d requests of 0x%02x", count, messageType)
+		}
+	}
+}
+
+func (port *testPort) Read(b []byte) (int, error) {
+	timeout := time.After(10 * time.Millisecond)
+	for {
+		port.mutex.Lock()
+		if err := port.readErr; err != nil {
+			port.readErr = nil
+			port.mutex.Unlock()
+			return 0, err
+		}
+		if port.hungUp {
+			port.mutex.Unlock()
+			return 0, io.EOF
+		}
+		if len(port.pending) > 0 {
+			n := copy(b, port.pending)
+			port.pending = port.pending[n:]
+			port.mutex.Unlock()
+			return n, nil
+		}
+		changed := port.changed
+		port.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return 0, io.EOF
+		}
+	}
+}
+
+func (port *testPort) Write(b []byte) (int, error) {
+	port.mutex.Lock()
+	defer port.mutex.Unlock()
+
+	if err := port.writeErr; err != nil {
+		port.writeErr = nil
+		return 0, err
+	}
+
+	for _, x := range b {
+		if x == '\n' && !port.inFrame {
+			continue
+		}
+
+		p, err := port.parser.Parse(x)
+		port.inFrame = p == nil && err == nil
+		if err != nil {
+			port.t.Errorf("Expected nil error: %v", err)
+		}
+		if p == nil || p.Preamble != packet.PacketPreambleSOF {
+			continue
+		}
+
+		port.requests = append(port.requests, p)
+		if !port.noACK[p.MessageType] {
+			port.pending = append(port.pending, packet.PacketPreambleACK)
+		}
+		if body, ok := port.responses[p.MessageType]; ok {
+			port.pending = append(port.pending, testPacketBytes(port.t,
+				packet.PacketTypeResponse, p.MessageType, body...)...)
+		}
+		port.update()
+	}
+
+	return len(b), nil
+}
+
+func (port *testPort) Flush() error {
+	return nil
+}
+
+func (port *testPort) Close() error {
+	return nil
+}
+
+// expectStates waits for the connection states
+func expectStates(t *testing.T, states chan uint8, expected ...uint8) {
+	for _, state := range expected {
+		select {
+		case actual := <-states:
+			if actual != state {
+				t.Errorf("Expected state 0x%02x got 0x%02x", state, actual)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for state 0x%02x", state)
+		}
+	}
+}
+
+func TestControllerReconnect(t *testing.T) {
+	port := newTestPort(t)
+	port.responses[message.MessageTypeSerialAPIGetInitData] = []uint8{0x05, 0x08}
+
+	controller := SerialController{OpenPort: port.open}
+	states := make(chan uint8, 8)
+	controller.SetStateChannel(states)
+
+	if err := controller.Open(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	defer func() {
+		if err := controller.Close(); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+	}()
+
+	// A request awaiting its response fails on a read error
+	request, err := message.ZWGetRandomRequest(8)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	result := make(chan error, 1)
+	go func() {
+		_, err := controller.DoRequest(request)
+		result <- err
+	}()
+	port.waitRequests(message.MessageTypeZWGetRandom, 1)
+
+	port.failRead(errors.New("Device unplugged"))
+	select {
+	case err := <-result:
+		if err != ErrDisconnected {
+			t.Errorf("Expected ErrDisconnected: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for request error")
+	}
+
+	expectStates(t, states, ConnectionStateDisconnected, ConnectionStateReconnecting,
+		ConnectionStateConnected)
+	port.waitRequests(message.MessageTypeSerialAPISoftReset, 1)
+
+	// Link recovered
+	if _, err := controller.DoRequest(message.SerialAPIGetInitDataRequest()); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	// A write error fails the request, and reconnects again
+	port.failWrite(errors.New("Device unplugged"))
+	if _, err := controller.DoRequest(message.SerialAPIGetInitDataRequest()); err != ErrDisconnected {
+		t.Errorf("Expected ErrDisconnected: %v", err)
+	}
+
+	expectStates(t, states, ConnectionStateDisconnected, ConnectionStateReconnecting,
+		ConnectionStateConnected)
+	port.waitRequests(message.MessageTypeSerialAPISoftReset, 2)
+
+	if _, err := controller.DoRequest(message.SerialAPIGetInitDataRequest()); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	port.mutex.Lock()
+	opens := port.opens
+	port.mutex.Unlock()
+	if opens != 3 {
+		t.Errorf("Expected 3 opens got %d", opens)
+	}
+}
+
+func TestControllerHangUp(t *testing.T) {
+	port := newTestPort(t)
+
+	controller := SerialController{OpenPort: port.open}
+	states := make(chan uint8, 8)
+	controller.SetStateChannel(states)
+
+	if err := controller.Open(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	defer func() {
+		if err := controller.Close(); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+	}()
+
+	// Idle network, so only the reads notice the hang up
+	port.hangUp(true)
+	expectStates(t, states, ConnectionStateDisconnected)
+	port.hangUp(false)
+
+	expectStates(t, states, ConnectionStateReconnecting, ConnectionStateConnected)
+
+	port.mutex.Lock()
+	opens := port.opens
+	port.mutex.Unlock()
+	if opens != 2 {
+		t.Errorf("Expected 2 opens got %d", opens)
+	}
+}
+
+func TestControllerCloseWhileReconnecting(t *testing.T) {
+	port := newTestPort(t)
+	// The soft reset is retried for a long time
+	port.noACK[message.MessageTypeSerialAPISoftReset] = true
+
+	controller := SerialController{OpenPort: port.open}
+	if err := controller.Open(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	port.failRead(errors.New("Device unplugged"))
+	port.waitRequests(message.MessageTypeSerialAPISoftReset, 1)
+
+	start := time.Now()
+	if err := controller.Close(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Close to abort the reconnect, took %v", elapsed)
+	}
+
+	if _, err := controller.DoRequest(message.SerialAPIGetInitDataRequest()); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+}
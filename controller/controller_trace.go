@@ -0,0 +1,50 @@
+package controller
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"github.com/cybojanek/gozwave/trace"
+	"log"
+)
+
+// tracePort records all bytes read from and written to a Port
+type tracePort struct {
+	Port
+	trace *trace.Writer
+}
+
+// Read from the port, and record the bytes
+func (port *tracePort) Read(b []byte) (int, error) {
+	n, err := port.Port.Read(b)
+	if n > 0 {
+		if traceErr := port.trace.Record(trace.DirectionRead, b[:n]); traceErr != nil {
+			log.Printf("ERROR tracePort read record error: %v", traceErr)
+		}
+	}
+	return n, err
+}
+
+// Write to the port, and record the written bytes
+func (port *tracePort) Write(b []byte) (int, error) {
+	n, err := port.Port.Write(b)
+	if n > 0 {
+		if traceErr := port.trace.Record(trace.DirectionWrite, b[:n]); traceErr != nil {
+			log.Printf("ERROR tracePort write record error: %v", traceErr)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,237 @@
+// Package database holds manufacturer and product specific device information:
+// configuration parameter metadata, association group labels and quirks.
+// All public methods are goroutine safe.
+package database
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DeviceKey identifies a device by the values reported by the
+// ManufacturerSpecific command class
+type DeviceKey struct {
+	ManufacturerID uint16 `json:"manufacturer_id"`
+	ProductType    uint16 `json:"product_type"`
+	ProductID      uint16 `json:"product_id"`
+}
+
+// Device information
+type Device struct {
+	Key          DeviceKey          `json:"key"`
+	Manufacturer string             `json:"manufacturer,omitempty"`
+	Name         string             `json:"name,omitempty"`
+	Parameters   []Parameter        `json:"parameters,omitempty"`
+	Groups       []AssociationGroup `json:"groups,omitempty"`
+	Quirks       Quirks             `json:"quirks"`
+}
+
+// Parameter information for a Configuration command class parameter
+type Parameter struct {
+	Index   uint8           `json:"index"`
+	Label   string          `json:"label,omitempty"`
+	Help    string          `json:"help,omitempty"`
+	Size    uint8           `json:"size"`    // Size in bytes: 1, 2 or 4
+	Min     int32           `json:"min"`     // Minimum value
+	Max     int32           `json:"max"`     // Maximum value
+	Default int32           `json:"default"` // Factory default value
+	Items   []ParameterItem `json:"items,omitempty"`
+}
+
+// ParameterItem is a named value of a list Parameter
+type ParameterItem struct {
+	Label string `json:"label"`
+	Value int32  `json:"value"`
+}
+
+// AssociationGroup information
+type AssociationGroup struct {
+	Index           uint8  `json:"index"`
+	Label           string `json:"label,omitempty"`
+	MaxAssociations uint8  `json:"max_associations"`
+}
+
+// Quirks of a device that deviate from the specification
+type Quirks struct {
+	CommandClassVersions  map[uint8]uint8 `json:"command_class_versions,omitempty"`  // Forced command class versions
+	IgnoredReports        []Report        `json:"ignored_reports,omitempty"`         // Reports to drop
+	RemovedCommandClasses []uint8         `json:"removed_command_classes,omitempty"` // Advertised, but not supported command classes
+}
+
+// Report identifies a command class report. A Command of 0 matches every
+// command of the command class.
+type Report struct {
+	CommandClass uint8 `json:"command_class"`
+	Command      uint8 `json:"command"`
+}
+
+// Database of devices
+type Database struct {
+	mutex   sync.RWMutex          // Database mutex
+	devices map[DeviceKey]*Device // Devices
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Add a device, replacing any previous device with the same key. goroutine
+// safe.
+func (database *Database) Add(device *Device) {
+	database.mutex.Lock()
+	defer database.mutex.Unlock()
+
+	if database.devices == nil {
+		database.devices = make(map[DeviceKey]*Device)
+	}
+
+	database.devices[device.Key] = device
+}
+
+// Lookup returns the device or nil if doesn't exist. goroutine safe.
+func (database *Database) Lookup(manufacturerID uint16, productType uint16, productID uint16) *Device {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	device, ok := database.devices[DeviceKey{ManufacturerID: manufacturerID,
+		ProductType: productType, ProductID: productID}]
+	if ok {
+		return device
+	}
+	return nil
+}
+
+// Len returns the number of devices. goroutine safe.
+func (database *Database) Len() int {
+	database.mutex.RLock()
+	defer database.mutex.RUnlock()
+
+	return len(database.devices)
+}
+
+// LoadJSON adds all devices from a JSON list of devices. goroutine safe.
+func (database *Database) LoadJSON(reader io.Reader) error {
+	var devices []*Device
+	if err := json.NewDecoder(reader).Decode(&devices); err != nil {
+		return err
+	}
+
+	for i, device := range devices {
+		if err := device.validate(); err != nil {
+			return fmt.Errorf("Bad device %d: %v", i, err)
+		}
+	}
+
+	for _, device := range devices {
+		database.Add(device)
+	}
+
+	return nil
+}
+
+// WriteJSON writes all devices as a JSON list of devices. goroutine safe.
+func (database *Database) WriteJSON(writer io.Writer) error {
+	database.mutex.RLock()
+	devices := make([]*Device, 0, len(database.devices))
+	for _, device := range database.devices {
+		devices = append(devices, device)
+	}
+	database.mutex.RUnlock()
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(devices)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetParameter returns the parameter or nil if doesn't exist
+func (device *Device) GetParameter(index uint8) *Parameter {
+	for i := range device.Parameters {
+		if device.Parameters[i].Index == index {
+			return &device.Parameters[i]
+		}
+	}
+	return nil
+}
+
+// GetGroup returns the association group or nil if doesn't exist
+func (device *Device) GetGroup(index uint8) *AssociationGroup {
+	for i := range device.Groups {
+		if device.Groups[i].Index == index {
+			return &device.Groups[i]
+		}
+	}
+	return nil
+}
+
+// CommandClassVersion returns the forced version of a command class, or false
+// if the version is not forced
+func (device *Device) CommandClassVersion(commandClass uint8) (uint8, bool) {
+	version, ok := device.Quirks.CommandClassVersions[commandClass]
+	return version, ok
+}
+
+// IsReportIgnored checks if the report should be dropped
+func (device *Device) IsReportIgnored(commandClass uint8, command uint8) bool {
+	for _, report := range device.Quirks.IgnoredReports {
+		if report.CommandClass == commandClass &&
+			(report.Command == 0 || report.Command == command) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCommandClassRemoved checks if the device advertises the command class in
+// its node information frame, but does not support it
+func (device *Device) IsCommandClassRemoved(commandClass uint8) bool {
+	for _, x := range device.Quirks.RemovedCommandClasses {
+		if x == commandClass {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the device for inconsistent values
+func (device *Device) validate() error {
+	for _, parameter := range device.Parameters {
+		switch parameter.Size {
+		case 1, 2, 4:
+			// Pass
+		default:
+			return fmt.Errorf("Parameter %d has bad size: %d",
+				parameter.Index, parameter.Size)
+		}
+
+		if parameter.Min > parameter.Max {
+			return fmt.Errorf("Parameter %d has min %d > max %d",
+				parameter.Index, parameter.Min, parameter.Max)
+		}
+	}
+
+	for _, group := range device.Groups {
+		if group.Index == 0 {
+			return fmt.Errorf("Association group index must not be 0")
+		}
+	}
+
+	return nil
+}
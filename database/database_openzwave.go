@@ -0,0 +1,322 @@
+package database
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NOTE: Only the subset of the OpenZWave device configuration format used by
+//       this package is decoded, everything else is silently ignored
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Command classes with device specific information in OpenZWave configs
+const (
+	commandClassConfiguration           uint8 = 0x70
+	commandClassAssociation                   = 0x85
+	commandClassMultiChannelAssociation       = 0x8e
+)
+
+// Name of the OpenZWave product index file
+const openZWaveManufacturerSpecificFileName = "manufacturer_specific.xml"
+
+// openZWaveManufacturerSpecific is the root of manufacturer_specific.xml
+type openZWaveManufacturerSpecific struct {
+	Manufacturers []struct {
+		ID       string `xml:"id,attr"`
+		Name     string `xml:"name,attr"`
+		Products []struct {
+			Type   string `xml:"type,attr"`
+			ID     string `xml:"id,attr"`
+			Name   string `xml:"name,attr"`
+			Config string `xml:"config,attr"`
+		} `xml:"Product"`
+	} `xml:"Manufacturer"`
+}
+
+// openZWaveProduct is the root of a product configuration file
+type openZWaveProduct struct {
+	CommandClasses []struct {
+		ID      string `xml:"id,attr"`
+		Action  string `xml:"action,attr"`
+		Version string `xml:"version,attr"`
+		Values  []struct {
+			Genre string `xml:"genre,attr"`
+			Index string `xml:"index,attr"`
+			Label string `xml:"label,attr"`
+			Size  string `xml:"size,attr"`
+			Min   string `xml:"min,attr"`
+			Max   string `xml:"max,attr"`
+			Value string `xml:"value,attr"`
+			Help  string `xml:"Help"`
+			Items []struct {
+				Label string `xml:"label,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"Item"`
+		} `xml:"Value"`
+		Groups []struct {
+			Index           string `xml:"index,attr"`
+			Label           string `xml:"label,attr"`
+			MaxAssociations string `xml:"max_associations,attr"`
+		} `xml:"Associations>Group"`
+	} `xml:"CommandClass"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// parseOpenZWaveInt parses a decimal or 0x prefixed hexadecimal attribute,
+// with an empty attribute returning the fallback value
+func parseOpenZWaveInt(value string, fallback int64, bitSize int) (int64, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return fallback, nil
+	}
+	return strconv.ParseInt(value, 0, bitSize+1)
+}
+
+// parseOpenZWaveInt32 parses a signed 32 bit attribute, like
+// parseOpenZWaveInt. Unsigned 4 byte values above math.MaxInt32 don't fit the
+// signed Parameter values, and are an error instead of wrapping around.
+func parseOpenZWaveInt32(value string, fallback int32) (int32, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return fallback, nil
+	}
+	x, err := strconv.ParseInt(value, 0, 32)
+	return int32(x), err
+}
+
+// parseOpenZWaveID parses a hexadecimal manufacturer or product attribute
+func parseOpenZWaveID(value string) (uint16, error) {
+	id, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(value), "0x"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("Bad id %q: %v", value, err)
+	}
+	return uint16(id), nil
+}
+
+// LoadOpenZWaveProduct adds a device from an OpenZWave product configuration
+// file. goroutine safe.
+func (database *Database) LoadOpenZWaveProduct(reader io.Reader, key DeviceKey) error {
+	device, err := decodeOpenZWaveProduct(reader, key)
+	if err != nil {
+		return err
+	}
+
+	database.Add(device)
+	return nil
+}
+
+// LoadOpenZWaveDirectory adds all devices listed in the manufacturer_specific.xml
+// file of an OpenZWave config directory. Products without a config file are
+// added without parameters, groups or quirks. goroutine safe.
+func (database *Database) LoadOpenZWaveDirectory(directory string) error {
+	file, err := os.Open(filepath.Join(directory, openZWaveManufacturerSpecificFileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var manufacturerSpecific openZWaveManufacturerSpecific
+	if err := xml.NewDecoder(file).Decode(&manufacturerSpecific); err != nil {
+		return fmt.Errorf("Failed to decode %s: %v",
+			openZWaveManufacturerSpecificFileName, err)
+	}
+
+	var devices []*Device
+	for _, manufacturer := range manufacturerSpecific.Manufacturers {
+		manufacturerID, err := parseOpenZWaveID(manufacturer.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range manufacturer.Products {
+			key := DeviceKey{ManufacturerID: manufacturerID}
+			if key.ProductType, err = parseOpenZWaveID(product.Type); err != nil {
+				return err
+			}
+			if key.ProductID, err = parseOpenZWaveID(product.ID); err != nil {
+				return err
+			}
+
+			device := &Device{Key: key}
+			if len(product.Config) > 0 {
+				if device, err = decodeOpenZWaveProductFile(
+					filepath.Join(directory, product.Config), key); err != nil {
+					return err
+				}
+			}
+			device.Manufacturer = manufacturer.Name
+			device.Name = product.Name
+
+			devices = append(devices, device)
+		}
+	}
+
+	for _, device := range devices {
+		database.Add(device)
+	}
+
+	return nil
+}
+
+// decodeOpenZWaveProductFile decodes a product configuration file
+func decodeOpenZWaveProductFile(path string, key DeviceKey) (*Device, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	device, err := decodeOpenZWaveProduct(file, key)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode %s: %v", path, err)
+	}
+	return device, nil
+}
+
+// decodeOpenZWaveProduct decodes a product configuration
+func decodeOpenZWaveProduct(reader io.Reader, key DeviceKey) (*Device, error) {
+	var product openZWaveProduct
+	if err := xml.NewDecoder(reader).Decode(&product); err != nil {
+		return nil, err
+	}
+
+	device := Device{Key: key}
+
+	for _, commandClass := range product.CommandClasses {
+		id, err := parseOpenZWaveInt(commandClass.ID, 0, 8)
+		if err != nil || id <= 0 || id > 0xff {
+			return nil, fmt.Errorf("Bad CommandClass id: %q", commandClass.ID)
+		}
+		commandClassID := uint8(id)
+
+		// Quirks
+		if commandClass.Action == "remove" {
+			device.Quirks.RemovedCommandClasses = append(
+				device.Quirks.RemovedCommandClasses, commandClassID)
+		}
+
+		if len(commandClass.Version) > 0 {
+			version, err := parseOpenZWaveInt(commandClass.Version, 0, 8)
+			if err != nil || version <= 0 || version > 0xff {
+				return nil, fmt.Errorf("Bad CommandClass 0x%02x version: %q",
+					commandClassID, commandClass.Version)
+			}
+			if device.Quirks.CommandClassVersions == nil {
+				device.Quirks.CommandClassVersions = make(map[uint8]uint8)
+			}
+			device.Quirks.CommandClassVersions[commandClassID] = uint8(version)
+		}
+
+		switch commandClassID {
+		case commandClassConfiguration:
+			for _, value := range commandClass.Values {
+				if len(value.Genre) > 0 && value.Genre != "config" {
+					continue
+				}
+
+				parameter, err := decodeOpenZWaveParameter(value.Index, value.Size,
+					value.Min, value.Max, value.Value)
+				if err != nil {
+					return nil, err
+				}
+				parameter.Label = value.Label
+				parameter.Help = strings.TrimSpace(value.Help)
+
+				for _, item := range value.Items {
+					itemValue, err := parseOpenZWaveInt32(item.Value, 0)
+					if err != nil {
+						return nil, fmt.Errorf("Parameter %d has bad item value: %q",
+							parameter.Index, item.Value)
+					}
+					parameter.Items = append(parameter.Items,
+						ParameterItem{Label: item.Label, Value: itemValue})
+				}
+
+				device.Parameters = append(device.Parameters, *parameter)
+			}
+
+		case commandClassAssociation, commandClassMultiChannelAssociation:
+			for _, group := range commandClass.Groups {
+				index, err := parseOpenZWaveInt(group.Index, 0, 8)
+				if err != nil || index <= 0 || index > 0xff {
+					return nil, fmt.Errorf("Bad Group index: %q", group.Index)
+				}
+				maxAssociations, err := parseOpenZWaveInt(group.MaxAssociations, 0, 8)
+				if err != nil || maxAssociations < 0 || maxAssociations > 0xff {
+					return nil, fmt.Errorf("Bad Group %d max_associations: %q",
+						index, group.MaxAssociations)
+				}
+
+				// Multi Channel Association groups duplicate Association groups
+				if device.GetGroup(uint8(index)) != nil {
+					continue
+				}
+
+				device.Groups = append(device.Groups, AssociationGroup{
+					Index: uint8(index), Label: group.Label,
+					MaxAssociations: uint8(maxAssociations)})
+			}
+		}
+	}
+
+	if err := device.validate(); err != nil {
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+// decodeOpenZWaveParameter decodes the numeric attributes of a configuration
+// value
+func decodeOpenZWaveParameter(index string, size string, min string, max string,
+	value string) (*Parameter, error) {
+
+	parameterIndex, err := parseOpenZWaveInt(index, -1, 8)
+	if err != nil || parameterIndex < 0 || parameterIndex > 0xff {
+		return nil, fmt.Errorf("Bad Value index: %q", index)
+	}
+
+	parameter := Parameter{Index: uint8(parameterIndex)}
+
+	parameterSize, err := parseOpenZWaveInt(size, 1, 8)
+	if err != nil {
+		return nil, fmt.Errorf("Parameter %d has bad size: %q", parameterIndex, size)
+	}
+	parameter.Size = uint8(parameterSize)
+
+	fields := []struct {
+		text  string
+		value *int32
+	}{{min, &parameter.Min}, {max, &parameter.Max}, {value, &parameter.Default}}
+	for _, field := range fields {
+		x, err := parseOpenZWaveInt32(field.text, 0)
+		if err != nil {
+			return nil, fmt.Errorf("Parameter %d has bad value: %q",
+				parameterIndex, field.text)
+		}
+		*field.value = x
+	}
+
+	return &parameter, nil
+}
@@ -0,0 +1,259 @@
+package database
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOpenZWaveProduct = `<?xml version="1.0" encoding="utf-8"?>
+<Product xmlns="https://github.com/OpenZWave/open-zwave">
+  <CommandClass id="32" action="remove" />
+  <CommandClass id="37" version="1" />
+  <CommandClass id="112">
+    <Value type="list" genre="config" instance="1" index="3" label="Night Light" size="1" min="0" max="2" value="1">
+      <Help>LED behavior</Help>
+      <Item label="Off when on" value="0" />
+      <Item label="On when on" value="1" />
+      <Item label="Always off" value="2" />
+    </Value>
+    <Value type="short" genre="config" instance="1" index="10" label="Offset" size="2" min="-100" max="100" value="0" />
+  </CommandClass>
+  <CommandClass id="133">
+    <Associations num_groups="2">
+      <Group index="1" max_associations="5" label="Lifeline" />
+      <Group index="2" max_associations="5" label="Basic Set" />
+    </Associations>
+  </CommandClass>
+</Product>`
+
+const testOpenZWaveManufacturerSpecific = `<?xml version="1.0" encoding="utf-8"?>
+<ManufacturerSpecificData xmlns="https://github.com/OpenZWave/open-zwave">
+  <Manufacturer id="0063" name="GE">
+    <Product type="4952" id="3031" name="12722 On/Off Relay Switch" config="ge/relay.xml" />
+    <Product type="4944" id="3031" name="12724 Dimmer" />
+  </Manufacturer>
+</ManufacturerSpecificData>`
+
+const testJSON = `[{
+  "key": {"manufacturer_id": 99, "product_type": 18770, "product_id": 12337},
+  "name": "Relay",
+  "parameters": [{"index": 3, "size": 1, "min": 0, "max": 2, "default": 1}],
+  "groups": [{"index": 1, "label": "Lifeline", "max_associations": 5}],
+  "quirks": {
+    "command_class_versions": {"37": 1},
+    "ignored_reports": [{"command_class": 32, "command": 3}],
+    "removed_command_classes": [32]
+  }
+}]`
+
+func checkTestDevice(t *testing.T, device *Device) {
+	if device == nil {
+		t.Errorf("Expected non nil device")
+		t.FailNow()
+	}
+
+	parameter := device.GetParameter(3)
+	if parameter == nil {
+		t.Errorf("Expected parameter 3")
+		t.FailNow()
+	}
+	if parameter.Size != 1 || parameter.Min != 0 || parameter.Max != 2 || parameter.Default != 1 {
+		t.Errorf("Unexpected parameter: %+v", parameter)
+	}
+
+	if device.GetParameter(4) != nil {
+		t.Errorf("Expected nil parameter 4")
+	}
+
+	group := device.GetGroup(1)
+	if group == nil || group.Label != "Lifeline" || group.MaxAssociations != 5 {
+		t.Errorf("Unexpected group: %+v", group)
+	}
+
+	if version, ok := device.CommandClassVersion(37); !ok || version != 1 {
+		t.Errorf("Expected forced version 1: %d %v", version, ok)
+	}
+
+	if _, ok := device.CommandClassVersion(38); ok {
+		t.Errorf("Expected no forced version")
+	}
+
+	if !device.IsCommandClassRemoved(32) {
+		t.Errorf("Expected removed command class")
+	}
+
+	if device.IsCommandClassRemoved(37) {
+		t.Errorf("Expected not removed command class")
+	}
+}
+
+func checkTestDeviceReports(t *testing.T, device *Device) {
+	if !device.IsReportIgnored(32, 3) {
+		t.Errorf("Expected ignored report")
+	}
+
+	if device.IsReportIgnored(37, 3) {
+		t.Errorf("Expected not ignored report")
+	}
+}
+
+func TestLoadOpenZWaveProduct(t *testing.T) {
+	database := Database{}
+	key := DeviceKey{ManufacturerID: 0x63, ProductType: 0x4952, ProductID: 0x3031}
+
+	if err := database.LoadOpenZWaveProduct(strings.NewReader(testOpenZWaveProduct), key); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	device := database.Lookup(0x63, 0x4952, 0x3031)
+	checkTestDevice(t, device)
+
+	// Removed command classes are not ignored reports
+	if device.IsReportIgnored(32, 3) {
+		t.Errorf("Expected not ignored report")
+	}
+
+	parameter := device.GetParameter(3)
+	if len(parameter.Items) != 3 || parameter.Items[2].Label != "Always off" ||
+		parameter.Items[2].Value != 2 || parameter.Help != "LED behavior" {
+		t.Errorf("Unexpected parameter: %+v", parameter)
+	}
+
+	if parameter := device.GetParameter(10); parameter == nil || parameter.Min != -100 {
+		t.Errorf("Unexpected parameter: %+v", parameter)
+	}
+
+	if database.Lookup(0x63, 0x4952, 0x3032) != nil {
+		t.Errorf("Expected nil device")
+	}
+}
+
+func TestLoadOpenZWaveProductBad(t *testing.T) {
+	database := Database{}
+
+	for _, product := range []string{
+		`<Product><CommandClass id="0" /></Product>`,
+		`<Product><CommandClass id="37" version="x" /></Product>`,
+		`<Product><CommandClass id="112"><Value index="1" size="3" /></CommandClass></Product>`,
+		`<Product><CommandClass id="112"><Value index="1" min="5" max="1" /></CommandClass></Product>`,
+		`<Product><CommandClass id="112"><Value index="1" size="4" min="0" max="4294967295" /></CommandClass></Product>`,
+		`<Product><CommandClass id="112"><Value index="1" size="4"><Item label="All" value="0xffffffff" /></Value></CommandClass></Product>`,
+		`<Product><CommandClass id="133"><Associations><Group index="0" /></Associations></CommandClass></Product>`,
+		`<Product>`,
+	} {
+		if err := database.LoadOpenZWaveProduct(strings.NewReader(product), DeviceKey{}); err == nil {
+			t.Errorf("Expected non nil error: %s", product)
+		}
+	}
+
+	if database.Len() != 0 {
+		t.Errorf("Expected empty database")
+	}
+}
+
+func TestLoadOpenZWaveDirectory(t *testing.T) {
+	directory, err := ioutil.TempDir("", "gozwave")
+	if err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(directory)
+
+	files := map[string]string{
+		"manufacturer_specific.xml": testOpenZWaveManufacturerSpecific,
+		"ge/relay.xml":              testOpenZWaveProduct,
+	}
+	for name, contents := range files {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+			t.FailNow()
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+			t.FailNow()
+		}
+	}
+
+	database := Database{}
+	if err := database.LoadOpenZWaveDirectory(directory); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	if database.Len() != 2 {
+		t.Errorf("Expected 2 devices got: %d", database.Len())
+	}
+
+	device := database.Lookup(0x63, 0x4952, 0x3031)
+	checkTestDevice(t, device)
+	if device.Manufacturer != "GE" || device.Name != "12722 On/Off Relay Switch" {
+		t.Errorf("Unexpected device names: %+v", device)
+	}
+
+	if device := database.Lookup(0x63, 0x4944, 0x3031); device == nil || device.Name != "12724 Dimmer" {
+		t.Errorf("Unexpected device: %+v", device)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	database := Database{}
+	if err := database.LoadJSON(strings.NewReader(testJSON)); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	checkTestDevice(t, database.Lookup(0x63, 0x4952, 0x3031))
+	checkTestDeviceReports(t, database.Lookup(0x63, 0x4952, 0x3031))
+
+	// Round trip
+	var buffer bytes.Buffer
+	if err := database.WriteJSON(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	other := Database{}
+	if err := other.LoadJSON(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	checkTestDevice(t, other.Lookup(0x63, 0x4952, 0x3031))
+	checkTestDeviceReports(t, other.Lookup(0x63, 0x4952, 0x3031))
+}
+
+func TestLoadJSONBad(t *testing.T) {
+	database := Database{}
+
+	for _, data := range []string{
+		`{}`,
+		`[{"parameters": [{"index": 1, "size": 3}]}]`,
+		`[{"groups": [{"index": 0}]}]`,
+	} {
+		if err := database.LoadJSON(strings.NewReader(data)); err == nil {
+			t.Errorf("Expected non nil error: %s", data)
+		}
+	}
+}
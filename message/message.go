@@ -26,17 +26,58 @@ import (
 
 // Message Type
 const (
-	MessageTypeNone                        uint8 = 0x00
-	MessageTypeSerialAPIGetInitData              = 0x02
-	MessageTypeApplicationCommand                = 0x04
-	MessageTypeZWGetControllerCapabilities       = 0x05
-	MessageTypeSerialAPIGetCapabilities          = 0x07
-	MessageTypeZWSendData                        = 0x13
-	MessageTypeGetVersion                        = 0x15
-	MessageTypeMemoryGetID                       = 0x20
-	MessageTypeZWGetNodeProtocolInfo             = 0x41
-	MessageTypeZWApplicationUpdate               = 0x49
-	MessageTypeZWRequestNodeInfo                 = 0x60
+	MessageTypeNone                         uint8 = 0x00
+	MessageTypeSerialAPIGetInitData               = 0x02
+	MessageTypeApplicationCommand                 = 0x04
+	MessageTypeZWGetControllerCapabilities        = 0x05
+	MessageTypeSerialAPIGetCapabilities           = 0x07
+	MessageTypeSerialAPISoftReset                 = 0x08
+	MessageTypeSerialAPIStarted                   = 0x0a
+	MessageTypeSerialAPISetup                     = 0x0b
+	MessageTypeZWSendData                         = 0x13
+	MessageTypeZWSendDataMulti                    = 0x14
+	MessageTypeGetVersion                         = 0x15
+	MessageTypeZWGetRandom                        = 0x1c
+	MessageTypeMemoryGetID                        = 0x20
+	MessageTypeMemoryGetBuffer                    = 0x23
+	MessageTypeNVMGetID                           = 0x29
+	MessageTypeNVMExtReadLongBuffer               = 0x2a
+	MessageTypeNVMExtWriteLongBuffer              = 0x2b
+	MessageTypeNVMBackupRestore                   = 0x2e
+	MessageTypeZWGetNodeProtocolInfo              = 0x41
+	MessageTypeZWSetDefault                       = 0x42
+	MessageTypeZWNewController                    = 0x43
+	MessageTypeZWAssignReturnRoute                = 0x46
+	MessageTypeZWDeleteReturnRoute                = 0x47
+	MessageTypeZWReplicationCommandComplete       = 0x44
+	MessageTypeZWRequestNodeNeighborUpdate        = 0x48
+	MessageTypeZWApplicationUpdate                = 0x49
+	MessageTypeZWAddNodeToNetwork                 = 0x4a
+	MessageTypeZWControllerChange                 = 0x4d
+	MessageTypeZWSetLearnMode                     = 0x50
+	MessageTypeZWAssignSUCReturnRoute             = 0x51
+	MessageTypeZWRequestNetworkUpdate             = 0x53
+	MessageTypeZWSetSUCNodeID                     = 0x54
+	MessageTypeZWGetSUCNodeID                     = 0x56
+	MessageTypeZWRequestNodeInfo                  = 0x60
+	MessageTypeZWIsFailedNode                     = 0x62
+	MessageTypeZWGetRoutingInfo                   = 0x80
+	MessageTypeSerialAPIGetLRNodes                = 0xda
+)
+
+// Node ID type of the node ID fields in frames. Classic controllers only use
+// 8 bit node IDs, and Long Range controllers can be switched to 16 bit node
+// IDs with SerialAPISetupSetNodeIDTypeRequest.
+const (
+	NodeIDType8Bit  uint8 = 0x01
+	NodeIDType16Bit       = 0x02
+)
+
+// Node ID ranges
+const (
+	MaxClassicNodeID   uint16 = 232
+	MinLongRangeNodeID        = 256
+	MaxLongRangeNodeID        = 4000
 )
 
 // Transmit Option
@@ -48,6 +89,21 @@ const (
 	TransmitOptionExplore         = 0x20
 )
 
+// Frequent Listening wake up period of FLiRS nodes
+const (
+	FrequentListeningNone   uint8 = 0x00
+	FrequentListening250ms        = 0x01
+	FrequentListening1000ms       = 0x02
+)
+
+// Protocol Version
+const (
+	ProtocolVersionUnknown uint8 = 0x00
+	ProtocolVersion2             = 0x01 // 2.0
+	ProtocolVersion4             = 0x02 // 4.2x and 5.0x
+	ProtocolVersion6             = 0x03 // 4.5x and 6.0x
+)
+
 // Transmit Complete
 const (
 	TransmitCompleteOK      uint8 = 0x00
@@ -57,6 +113,76 @@ const (
 	TransmitCompleteNoRoute       = 0x04
 )
 
+// NodeIDBroadcast is the destination of ZWSendData requests to all nodes
+const NodeIDBroadcast uint8 = 0xff
+
+// MaxMulticastNodes is the maximum number of nodes in a ZWSendDataMulti request
+const MaxMulticastNodes = 64
+
+// MaxRandomBytes is the maximum number of bytes in a ZWGetRandom request
+const MaxRandomBytes = 32
+
+// NVMBackupRestore Operation
+const (
+	NVMBackupRestoreOperationOpen  uint8 = 0x00
+	NVMBackupRestoreOperationRead        = 0x01
+	NVMBackupRestoreOperationWrite       = 0x02
+	NVMBackupRestoreOperationClose       = 0x03
+)
+
+// NVMBackupRestore Status
+const (
+	NVMBackupRestoreStatusOK                 uint8 = 0x00
+	NVMBackupRestoreStatusError                    = 0x01
+	NVMBackupRestoreStatusOperationMismatch        = 0x02
+	NVMBackupRestoreStatusOperationDisturbed       = 0x03
+	NVMBackupRestoreStatusEndOfFile                = 0xff
+)
+
+// SerialAPISetup Command
+const (
+	SerialAPISetupCommandUnsupported       uint8 = 0x00
+	SerialAPISetupCommandSetTxStatusReport       = 0x02
+	SerialAPISetupCommandSetPowerLevel           = 0x04
+	SerialAPISetupCommandGetPowerLevel           = 0x08
+	SerialAPISetupCommandGetMaxPayloadSize       = 0x10
+	SerialAPISetupCommandGetRFRegion             = 0x20
+	SerialAPISetupCommandSetRFRegion             = 0x40
+	SerialAPISetupCommandSetNodeIDType           = 0x80
+)
+
+// RF Region
+const (
+	RFRegionEurope              uint8 = 0x00
+	RFRegionUSA                       = 0x01
+	RFRegionAustraliaNewZealand       = 0x02
+	RFRegionHongKong                  = 0x03
+	RFRegionIndia                     = 0x05
+	RFRegionIsrael                    = 0x06
+	RFRegionRussia                    = 0x07
+	RFRegionChina                     = 0x08
+	RFRegionUSALongRange              = 0x09
+	RFRegionJapan                     = 0x20
+	RFRegionKorea                     = 0x21
+	RFRegionUnknown                   = 0xfe
+	RFRegionDefault                   = 0xff
+)
+
+// SerialAPIStarted Wake Up Reason
+const (
+	WakeUpReasonReset                  uint8 = 0x00
+	WakeUpReasonWakeUpTimer                  = 0x01
+	WakeUpReasonWakeUpBeam                   = 0x02
+	WakeUpReasonWatchdogReset                = 0x03
+	WakeUpReasonExternalInterrupt            = 0x04
+	WakeUpReasonPowerUp                      = 0x05
+	WakeUpReasonUSBSuspend                   = 0x06
+	WakeUpReasonSoftwareReset                = 0x07
+	WakeUpReasonEmergencyWatchdogReset       = 0x08
+	WakeUpReasonBrownout                     = 0x09
+	WakeUpReasonUnknown                      = 0xff
+)
+
 // Library Type
 const (
 	LibraryTypeControllerStatic uint8 = 0x01
@@ -73,19 +199,101 @@ const (
 
 // ZWApplicationUpdate Status meaning
 const (
-	ZWApplicationUpdateStateSUCID         uint8 = 0x10
-	ZWApplicationUpdateStateDeleteDone          = 0x20
-	ZWApplicationUpdateStateNewIDAssigned       = 0x40
-	ZWApplicationUpdateStateRoutePending        = 0x80
-	ZWApplicationUpdateStateRequestFailed       = 0x81
-	ZWApplicationUpdateStateRequestDone         = 0x82
-	ZWApplicationUpdateStateReceived            = 0x84
+	ZWApplicationUpdateStateSUCID                      uint8 = 0x10
+	ZWApplicationUpdateStateDeleteDone                       = 0x20
+	ZWApplicationUpdateStateNewIDAssigned                    = 0x40
+	ZWApplicationUpdateStateRoutePending                     = 0x80
+	ZWApplicationUpdateStateRequestFailed                    = 0x81
+	ZWApplicationUpdateStateRequestDone                      = 0x82
+	ZWApplicationUpdateStateReceived                         = 0x84
+	ZWApplicationUpdateStateSmartStartHomeIDReceived         = 0x85 // SmartStart inclusion request
+	ZWApplicationUpdateStateIncludedNodeInfoReceived         = 0x86 // Included SmartStart node powered up
+	ZWApplicationUpdateStateSmartStartHomeIDReceivedLR       = 0x87 // Long Range SmartStart inclusion request
+)
+
+// ZWRequestNodeNeighborUpdate Status meaning
+const (
+	NeighborUpdateStarted uint8 = 0x21
+	NeighborUpdateDone          = 0x22
+	NeighborUpdateFailed        = 0x23
+)
+
+// ZWSetLearnMode Mode
+const (
+	LearnModeDisable              uint8 = 0x00
+	LearnModeClassic                    = 0x01 // Direct range inclusion or exclusion
+	LearnModeNetworkWideInclusion       = 0x02
+	LearnModeNetworkWideExclusion       = 0x03
+)
+
+// ZWSetLearnMode Status
+const (
+	LearnModeStatusStarted uint8 = 0x01
+	LearnModeStatusDone          = 0x06
+	LearnModeStatusFailed        = 0x07
+)
+
+// ZWAddNodeToNetwork Mode
+const (
+	AddNodeStop       uint8 = 0x05
+	AddNodeHomeID           = 0x08 // Include the SmartStart node of a HomeID
+	AddNodeSmartStart       = 0x09 // Listen for SmartStart inclusion requests
+)
+
+// ZWAddNodeToNetwork Mode flags
+const (
+	AddNodeOptionNetworkWide uint8 = 0x40
+	AddNodeOptionHighPower         = 0x80
+)
+
+// ZWAddNodeToNetwork Status
+const (
+	AddNodeStatusLearnReady       uint8 = 0x01
+	AddNodeStatusNodeFound              = 0x02
+	AddNodeStatusAddingSlave            = 0x03
+	AddNodeStatusAddingController       = 0x04
+	AddNodeStatusProtocolDone           = 0x05
+	AddNodeStatusDone                   = 0x06
+	AddNodeStatusFailed                 = 0x07
+)
+
+// ZWControllerChange Mode
+const (
+	ControllerChangeStart      uint8 = 0x02
+	ControllerChangeStop             = 0x05
+	ControllerChangeStopFailed       = 0x06
+)
+
+// ZWControllerChange Status
+const (
+	ControllerChangeStatusLearnReady       uint8 = 0x01
+	ControllerChangeStatusNodeFound              = 0x02
+	ControllerChangeStatusAddingSlave            = 0x03
+	ControllerChangeStatusAddingController       = 0x04
+	ControllerChangeStatusProtocolDone           = 0x05
+	ControllerChangeStatusDone                   = 0x06
+	ControllerChangeStatusFailed                 = 0x07
+)
+
+// ZWSetSUCNodeID Status
+const (
+	SUCStatusSucceeded uint8 = 0x05
+	SUCStatusFailed          = 0x06
+)
+
+// ZWRequestNetworkUpdate Status
+const (
+	NetworkUpdateDone     uint8 = 0x00
+	NetworkUpdateAbort          = 0x01
+	NetworkUpdateWait           = 0x02
+	NetworkUpdateDisabled       = 0x03
+	NetworkUpdateOverflow       = 0x04
 )
 
 // ApplicationCommand information
 type ApplicationCommand struct {
 	Status uint8
-	NodeID uint8
+	NodeID uint16
 	Body   []uint8
 }
 
@@ -98,7 +306,36 @@ type GetVersion struct {
 // MemoryGetID informationZ
 type MemoryGetID struct {
 	HomeID uint32
-	NodeID uint8
+	NodeID uint16
+}
+
+// MemoryGetBuffer information
+type MemoryGetBuffer struct {
+	Data []uint8
+}
+
+// NVMBackupRestore information
+type NVMBackupRestore struct {
+	Status uint8  // One of NVMBackupRestoreStatus
+	Offset uint16 // Offset of the data, or the NVM size for the open operation
+	Data   []uint8
+}
+
+// NVMExtReadLongBuffer information
+type NVMExtReadLongBuffer struct {
+	Data []uint8
+}
+
+// NVMExtWriteLongBuffer information
+type NVMExtWriteLongBuffer struct {
+	Success bool
+}
+
+// NVMGetID information
+type NVMGetID struct {
+	Manufacturer uint8
+	MemoryType   uint8
+	Size         uint32 // Bytes
 }
 
 // SerialAPIGetInitData information
@@ -113,6 +350,13 @@ type SerialAPIGetInitData struct {
 	Nodes []uint8
 }
 
+// SerialAPIGetLRNodes information of one segment of Long Range nodes
+type SerialAPIGetLRNodes struct {
+	More    bool  // More segments follow
+	Segment uint8 // Segment of 1024 node IDs
+	Nodes   []uint16
+}
+
 // SerialAPIGetCapabilities information
 type SerialAPIGetCapabilities struct {
 	Application struct {
@@ -127,13 +371,81 @@ type SerialAPIGetCapabilities struct {
 	MessageTypes []uint8
 }
 
+// SerialAPISetup information. Only the fields of the Command are set.
+type SerialAPISetup struct {
+	Command    uint8 // One of SerialAPISetupCommand
+	Success    bool  // Set commands
+	RFRegion   uint8 // One of RFRegion
+	PowerLevel struct {
+		Normal       int8 // deci dBm
+		Measured0dBm int8 // deci dBm
+	}
+	MaxPayloadSize uint8
+}
+
+// SerialAPIStarted information
+type SerialAPIStarted struct {
+	WakeUpReason    uint8 // One of WakeUpReason
+	WatchdogStarted bool
+	Listening       bool
+	DeviceClass     struct {
+		Generic  uint8
+		Specific uint8
+	}
+	CommandClasses []uint8
+}
+
 // ZWApplicationUpdate information
 type ZWApplicationUpdate struct {
 	Status uint8
-	NodeID uint8
+	NodeID uint16
 	Body   []uint8
 }
 
+// ZWAssignReturnRoute information
+type ZWAssignReturnRoute struct {
+	CallbackID uint8
+	Status     uint8 // One of TransmitComplete
+}
+
+// ZWAssignSUCReturnRoute information
+type ZWAssignSUCReturnRoute struct {
+	CallbackID uint8
+	Status     uint8 // One of TransmitComplete
+}
+
+// ZWAddNodeToNetwork information of a callback
+type ZWAddNodeToNetwork struct {
+	CallbackID uint8
+	Status     uint8  // One of AddNodeStatus
+	NodeID     uint16 // Node being added
+	Body       []uint8
+}
+
+// ZWApplicationUpdateSmartStart information of a SmartStart inclusion request
+type ZWApplicationUpdateSmartStart struct {
+	Status    uint8 // ZWApplicationUpdateStateSmartStartHomeIDReceived or LR
+	NodeID    uint16
+	RxStatus  uint8
+	NWIHomeID uint32 // HomeID derived from the DSK of the node
+	Body      []uint8
+}
+
+// ZWControllerChange information, of ZWControllerChange or ZWNewController
+// callbacks
+type ZWControllerChange struct {
+	CallbackID uint8
+	Status     uint8  // One of ControllerChangeStatus
+	NodeID     uint16 // Other controller
+	Body       []uint8
+}
+
+// ZWDeleteReturnRoute information
+type ZWDeleteReturnRoute struct {
+	CallbackID uint8
+	Status     uint8 // One of TransmitComplete
+}
+
 // ZWGetControllerCapabilities information
 type ZWGetControllerCapabilities struct {
 	Secondary                      bool
@@ -146,7 +458,17 @@ type ZWGetControllerCapabilities struct {
 // ZWGetNodeProtocolInfo information
 type ZWGetNodeProtocolInfo struct {
 	Capabilities struct {
-		Listening bool
+		Listening             bool   // Always listening
+		Routing               bool   // Routes frames for other nodes
+		MaxBaudRate           uint32 // Fastest data rate in bits per second
+		ProtocolVersion       uint8  // One of ProtocolVersion
+		Security              bool   // Supports security
+		Controller            bool   // Is a controller
+		SpecificDeviceClass   bool   // Specific device class is valid
+		RoutingSlave          bool   // Is a routing end node
+		Beaming               bool   // Can wake up FLiRS nodes with a beam
+		FrequentListening     uint8  // One of FrequentListening
+		OptionalFunctionality bool   // Supports more than its device class
 	}
 	DeviceClass struct {
 		Basic    uint8
@@ -155,6 +477,61 @@ type ZWGetNodeProtocolInfo struct {
 	}
 }
 
+// ZWGetSUCNodeID information
+type ZWGetSUCNodeID struct {
+	NodeID uint16 // 0 if there is no SUC
+}
+
+// ZWGetRandom information
+type ZWGetRandom struct {
+	Bytes []uint8
+}
+
+// ZWGetRoutingInfo information
+type ZWGetRoutingInfo struct {
+	Neighbors []uint8
+}
+
+// ZWRequestNetworkUpdate information
+type ZWRequestNetworkUpdate struct {
+	CallbackID uint8
+	Status     uint8 // One of NetworkUpdate
+}
+
+// ZWSetLearnMode information
+type ZWSetLearnMode struct {
+	Accepted bool // Controller entered the learn mode
+}
+
+// ZWSetLearnModeCallback information
+type ZWSetLearnModeCallback struct {
+	CallbackID uint8
+	Status     uint8  // One of LearnModeStatus
+	NodeID     uint16 // Node ID assigned to the controller
+}
+
+// ZWSetSUCNodeID information
+type ZWSetSUCNodeID struct {
+	CallbackID uint8
+	Status     uint8 // One of SUCStatus
+}
+
+// ZWSetDefault information
+type ZWSetDefault struct {
+	CallbackID uint8
+}
+
+// ZWIsFailedNode information
+type ZWIsFailedNode struct {
+	Failed bool // Node is in the failed node list of the controller
+}
+
+// ZWRequestNodeNeighborUpdate information
+type ZWRequestNodeNeighborUpdate struct {
+	CallbackID uint8
+	Status     uint8 // One of NeighborUpdate
+}
+
 // ZWRequestNodeInfo information
 type ZWRequestNodeInfo struct {
 	Status uint8
@@ -167,9 +544,192 @@ type ZWSendData struct {
 	TransmitTime uint16
 }
 
-// IsValidNodeID checks if the nodeID is in the valid range of nodes
-func IsValidNodeID(nodeID uint8) bool {
-	return nodeID > 0 && nodeID < 233
+// ZWSendDataMulti information
+type ZWSendDataMulti struct {
+	CallbackID uint8
+	Status     uint8 // One of TransmitComplete
+}
+
+// The arguments of request packets written by the host, as returned by
+// DecodeRequest. A CallbackID of 0 means the request has no callback id, which
+// the controller appends when it sends the request.
+
+// NoArgs of a request with an empty body
+type NoArgs struct{}
+
+// CallbackArgs of a request with only a callback id
+type CallbackArgs struct {
+	CallbackID uint8
+}
+
+// NodeArgs of a request with only a node ID
+type NodeArgs struct {
+	NodeID uint16
+}
+
+// NodeCallbackArgs of a request with a node ID and a callback id
+type NodeCallbackArgs struct {
+	NodeID     uint16
+	CallbackID uint8
+}
+
+// ModeArgs of a request with a mode and a callback id
+type ModeArgs struct {
+	Mode       uint8
+	CallbackID uint8
+}
+
+// MemoryGetBufferArgs of a MemoryGetBuffer request
+type MemoryGetBufferArgs struct {
+	Offset uint16
+	Length uint8
+}
+
+// NVMBackupRestoreArgs of a NVMBackupRestore request
+type NVMBackupRestoreArgs struct {
+	Operation uint8 // One of NVMBackupRestoreOperation
+	Length    uint8
+	Offset    uint16
+	Data      []uint8 // Write operation
+}
+
+// NVMExtReadLongBufferArgs of a NVMExtReadLongBuffer request
+type NVMExtReadLongBufferArgs struct {
+	Offset uint32 // 24 bits
+	Length uint16
+}
+
+// NVMExtWriteLongBufferArgs of a NVMExtWriteLongBuffer request
+type NVMExtWriteLongBufferArgs struct {
+	Offset uint32 // 24 bits
+	Data   []uint8
+}
+
+// SerialAPIGetLRNodesArgs of a SerialAPIGetLRNodes request
+type SerialAPIGetLRNodesArgs struct {
+	Segment uint8
+}
+
+// SerialAPISetupArgs of a SerialAPISetup request. Only the fields of the
+// Command are set.
+type SerialAPISetupArgs struct {
+	Command        uint8 // One of SerialAPISetupCommand
+	TxStatusReport bool  // SetTxStatusReport
+	RFRegion       uint8 // SetRFRegion, one of RFRegion
+	PowerLevel     struct {
+		Normal       int8 // deci dBm
+		Measured0dBm int8 // deci dBm
+	} // SetPowerLevel
+	NodeIDType uint8 // SetNodeIDType, one of NodeIDType
+}
+
+// ZWAddNodeToNetworkArgs of a ZWAddNodeToNetwork request
+type ZWAddNodeToNetworkArgs struct {
+	Mode       uint8 // One of AddNode, with AddNodeOption flags
+	CallbackID uint8
+	NWIHomeID  uint32 // AddNodeHomeID mode
+	AuthHomeID uint32 // AddNodeHomeID mode
+}
+
+// ZWAssignReturnRouteArgs of a ZWAssignReturnRoute request
+type ZWAssignReturnRouteArgs struct {
+	NodeID            uint16
+	DestinationNodeID uint16
+	CallbackID        uint8
+}
+
+// ZWGetRandomArgs of a ZWGetRandom request
+type ZWGetRandomArgs struct {
+	Count uint8
+}
+
+// ZWGetRoutingInfoArgs of a ZWGetRoutingInfo request
+type ZWGetRoutingInfoArgs struct {
+	NodeID             uint16
+	RemoveBad          bool
+	RemoveNonRepeaters bool
+}
+
+// ZWSetSUCNodeIDArgs of a ZWSetSUCNodeID request. Callback is false if the
+// request ends with a callback id of 0, which asks for no callback.
+type ZWSetSUCNodeIDArgs struct {
+	NodeID          uint16
+	Enable          bool
+	TransmitOptions uint8
+	SIS             bool
+	Callback        bool
+	CallbackID      uint8
+}
+
+// ZWSendDataArgs of a ZWSendData request
+type ZWSendDataArgs struct {
+	NodeID          uint16 // NodeIDBroadcast for all classic nodes
+	CommandClass    uint8
+	Payload         []uint8
+	TransmitOptions uint8
+	CallbackID      uint8
+}
+
+// ZWSendDataMultiArgs of a ZWSendDataMulti request
+type ZWSendDataMultiArgs struct {
+	NodeIDs         []uint8
+	CommandClass    uint8
+	Payload         []uint8
+	TransmitOptions uint8
+	CallbackID      uint8
+}
+
+// IsValidNodeID checks if the nodeID is in the valid range of classic or Long
+// Range nodes
+func IsValidNodeID(nodeID uint16) bool {
+	return IsClassicNodeID(nodeID) || IsLongRangeNodeID(nodeID)
+}
+
+// IsClassicNodeID checks if the nodeID is in the valid range of classic nodes
+func IsClassicNodeID(nodeID uint16) bool {
+	return nodeID > 0 && nodeID <= MaxClassicNodeID
+}
+
+// IsLongRangeNodeID checks if the nodeID is in the valid range of Long Range
+// nodes
+func IsLongRangeNodeID(nodeID uint16) bool {
+	return nodeID >= MinLongRangeNodeID && nodeID <= MaxLongRangeNodeID
+}
+
+// encodeNodeID encodes the nodeID as one or two bytes of the node ID type
+func encodeNodeID(nodeID uint16, nodeIDType uint8) ([]uint8, error) {
+	switch nodeIDType {
+	case NodeIDType8Bit:
+		if nodeID > 0xff {
+			return nil, fmt.Errorf("Node ID does not fit 8 bits: %d", nodeID)
+		}
+		return []uint8{uint8(nodeID)}, nil
+
+	case NodeIDType16Bit:
+		return []uint8{uint8(nodeID >> 8), uint8(nodeID)}, nil
+	}
+
+	return nil, fmt.Errorf("Invalid node ID type: 0x%02x", nodeIDType)
+}
+
+// decodeNodeID decodes the node ID of the node ID type at the start of the
+// body, and returns it with its length in bytes
+func decodeNodeID(body []uint8, nodeIDType uint8) (uint16, int, error) {
+	switch nodeIDType {
+	case NodeIDType8Bit:
+		if len(body) < 1 {
+			return 0, 0, fmt.Errorf("Bad Body length: %d", len(body))
+		}
+		return uint16(body[0]), 1, nil
+
+	case NodeIDType16Bit:
+		if len(body) < 2 {
+			return 0, 0, fmt.Errorf("Bad Body length: %d", len(body))
+		}
+		return binary.BigEndian.Uint16(body), 2, nil
+	}
+
+	return 0, 0, fmt.Errorf("Invalid node ID type: 0x%02x", nodeIDType)
 }
 
 // DecodeDuration duration byte into a time.Duration
@@ -0,0 +1,1792 @@
+package message
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/cybojanek/gozwave/packet"
+	"sync"
+)
+
+// Decoder of a packet into a typed message, like *GetVersion
+type Decoder func(p *packet.Packet, nodeIDType uint8) (interface{}, error)
+
+// Encoder of a typed message, as returned by a Decoder, into a packet
+type Encoder func(message interface{}, nodeIDType uint8) (*packet.Packet, error)
+
+// RequestFlow describes the packets exchanged with the controller for a
+// request of a MessageType
+type RequestFlow struct {
+	CallbackID   bool    // Request body ends with a controller assigned callback id
+	Response     bool    // Controller replies with a response packet
+	Callback     bool    // Controller sends a callback request with the callback id
+	Intermediate []uint8 // Callback statuses to skip while awaiting the final one
+	BodyLength   int     // Fixed request body length with one 8 bit node ID, or 0
+	CallbackAt   int     // Position of the callback id in the request body, or 0 to append
+}
+
+// IsIntermediate checks if the callback status precedes the final callback
+func (flow *RequestFlow) IsIntermediate(status uint8) bool {
+	for _, x := range flow.Intermediate {
+		if x == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Codec of a MessageType. The codecs of this package are listed in
+// builtinCodecs. Any of the functions may be nil, in which case the packet
+// decodes to an *Unknown.
+type Codec struct {
+	Name string
+
+	// Flow of a request, or nil if the controller replies with a single
+	// response packet
+	Flow *RequestFlow
+
+	// DecodeResponse decodes a response packet to a request
+	DecodeResponse Decoder
+
+	// DecodeCallback decodes a request packet sent by the controller: the
+	// callback of a request, or an unsolicited message
+	DecodeCallback Decoder
+
+	// Encode a message returned by DecodeResponse or DecodeCallback
+	Encode Encoder
+
+	// DecodeRequest decodes a request packet written by the host into its
+	// arguments, like *ZWSendDataArgs
+	DecodeRequest Decoder
+
+	// EncodeRequest encodes arguments returned by DecodeRequest
+	EncodeRequest Encoder
+}
+
+// Unknown message, which has no registered decoder
+type Unknown struct {
+	MessageType uint8
+	PacketType  uint8
+	Body        []uint8
+}
+
+// codecs by MessageType, guarded by codecsMutex
+var codecsMutex sync.RWMutex
+var codecs = make(map[uint8]Codec)
+
+// RegisterCodec sets the codec of a MessageType, replacing any previous one.
+// goroutine safe.
+func RegisterCodec(messageType uint8, codec Codec) {
+	codecsMutex.Lock()
+	defer codecsMutex.Unlock()
+
+	codecs[messageType] = codec
+}
+
+// LookupCodec returns the codec of a MessageType. goroutine safe.
+func LookupCodec(messageType uint8) (Codec, bool) {
+	codecsMutex.RLock()
+	defer codecsMutex.RUnlock()
+
+	codec, ok := codecs[messageType]
+	return codec, ok
+}
+
+// builtinCodecs are the codecs of the MessageTypes of this package
+var builtinCodecs = map[uint8]Codec{
+	MessageTypeGetVersion: {
+		Name: "GetVersion",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return GetVersionResponse(p)
+		},
+		Encode:        encodeGetVersion,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeMemoryGetID: {
+		Name: "MemoryGetID",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return MemoryGetIDResponse(p)
+		},
+		Encode:        encodeMemoryGetID,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeMemoryGetBuffer: {
+		Name: "MemoryGetBuffer",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return MemoryGetBufferResponse(p)
+		},
+		Encode:        encodeMemoryGetBuffer,
+		DecodeRequest: decodeMemoryGetBufferArgs,
+		EncodeRequest: encodeMemoryGetBufferArgs,
+	},
+	MessageTypeNVMGetID: {
+		Name: "NVMGetID",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return NVMGetIDResponse(p)
+		},
+		Encode:        encodeNVMGetID,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeNVMExtReadLongBuffer: {
+		Name: "NVMExtReadLongBuffer",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return NVMExtReadLongBufferResponse(p)
+		},
+		Encode:        encodeNVMExtReadLongBuffer,
+		DecodeRequest: decodeNVMExtReadLongBufferArgs,
+		EncodeRequest: encodeNVMExtReadLongBufferArgs,
+	},
+	MessageTypeNVMExtWriteLongBuffer: {
+		Name: "NVMExtWriteLongBuffer",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return NVMExtWriteLongBufferResponse(p)
+		},
+		Encode:        encodeNVMExtWriteLongBuffer,
+		DecodeRequest: decodeNVMExtWriteLongBufferArgs,
+		EncodeRequest: encodeNVMExtWriteLongBufferArgs,
+	},
+	MessageTypeNVMBackupRestore: {
+		Name: "NVMBackupRestore",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return NVMBackupRestoreResponse(p)
+		},
+		Encode:        encodeNVMBackupRestore,
+		DecodeRequest: decodeNVMBackupRestoreArgs,
+		EncodeRequest: encodeNVMBackupRestoreArgs,
+	},
+	MessageTypeSerialAPIGetInitData: {
+		Name: "SerialAPIGetInitData",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return SerialAPIGetInitDataResponse(p)
+		},
+		Encode:        encodeSerialAPIGetInitData,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeSerialAPISoftReset: {
+		Name:          "SerialAPISoftReset",
+		Flow:          &RequestFlow{},
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeSerialAPISetup: {
+		Name: "SerialAPISetup",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return SerialAPISetupResponse(p)
+		},
+		Encode:        encodeSerialAPISetup,
+		DecodeRequest: decodeSerialAPISetupArgs,
+		EncodeRequest: encodeSerialAPISetupArgs,
+	},
+	MessageTypeSerialAPIGetLRNodes: {
+		Name: "SerialAPIGetLRNodes",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return SerialAPIGetLRNodesResponse(p)
+		},
+		Encode:        encodeSerialAPIGetLRNodes,
+		DecodeRequest: decodeSerialAPIGetLRNodesArgs,
+		EncodeRequest: encodeSerialAPIGetLRNodesArgs,
+	},
+	MessageTypeSerialAPIGetCapabilities: {
+		Name: "SerialAPIGetCapabilities",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return SerialAPIGetCapabilitiesResponse(p)
+		},
+		Encode:        encodeSerialAPIGetCapabilities,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeZWGetControllerCapabilities: {
+		Name: "ZWGetControllerCapabilities",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWGetControllerCapabilitiesResponse(p)
+		},
+		Encode:        encodeZWGetControllerCapabilities,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeZWGetRandom: {
+		Name: "ZWGetRandom",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWGetRandomResponse(p)
+		},
+		Encode:        encodeZWGetRandom,
+		DecodeRequest: decodeZWGetRandomArgs,
+		EncodeRequest: encodeZWGetRandomArgs,
+	},
+	MessageTypeZWGetSUCNodeID: {
+		Name: "ZWGetSUCNodeID",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWGetSUCNodeIDResponse(p)
+		},
+		Encode:        encodeZWGetSUCNodeID,
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeZWSetSUCNodeID: {
+		Name: "ZWSetSUCNodeID",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true,
+			BodyLength: 4},
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSetSUCNodeIDResponse(p)
+		},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSetSUCNodeIDResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeZWSetSUCNodeIDArgs,
+		EncodeRequest: encodeZWSetSUCNodeIDArgs,
+	},
+	MessageTypeZWRequestNetworkUpdate: {
+		Name: "ZWRequestNetworkUpdate",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWRequestNetworkUpdateResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeCallbackArgs,
+		EncodeRequest: encodeCallbackArgs,
+	},
+	MessageTypeZWAddNodeToNetwork: {
+		Name: "ZWAddNodeToNetwork",
+		// Callbacks are routed to the callback channel
+		Flow: &RequestFlow{CallbackID: true, CallbackAt: 1},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWAddNodeToNetworkResponse(p, nodeIDType)
+		},
+		Encode:        encodeNodeInfoCallback,
+		DecodeRequest: decodeZWAddNodeToNetworkArgs,
+		EncodeRequest: encodeZWAddNodeToNetworkArgs,
+	},
+	MessageTypeZWControllerChange: {
+		Name: "ZWControllerChange",
+		// Callbacks are routed to the callback channel
+		Flow: &RequestFlow{CallbackID: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWControllerChangeResponse(p, nodeIDType)
+		},
+		Encode:        encodeNodeInfoCallback,
+		DecodeRequest: decodeModeArgs,
+		EncodeRequest: encodeModeArgs,
+	},
+	MessageTypeZWNewController: {
+		Name: "ZWNewController",
+		// Callbacks are routed to the callback channel
+		Flow: &RequestFlow{CallbackID: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWControllerChangeResponse(p, nodeIDType)
+		},
+		Encode:        encodeNodeInfoCallback,
+		DecodeRequest: decodeModeArgs,
+		EncodeRequest: encodeModeArgs,
+	},
+	MessageTypeZWSetLearnMode: {
+		Name: "ZWSetLearnMode",
+		// Callbacks are routed to the callback channel
+		Flow: &RequestFlow{CallbackID: true, Response: true},
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSetLearnModeResponse(p)
+		},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSetLearnModeCallbackResponse(p, nodeIDType)
+		},
+		Encode:        encodeZWSetLearnMode,
+		DecodeRequest: decodeModeArgs,
+		EncodeRequest: encodeModeArgs,
+	},
+	MessageTypeZWReplicationCommandComplete: {
+		Name:          "ZWReplicationCommandComplete",
+		Flow:          &RequestFlow{},
+		DecodeRequest: decodeNoArgs,
+		EncodeRequest: encodeNoArgs,
+	},
+	MessageTypeZWSetDefault: {
+		Name: "ZWSetDefault",
+		Flow: &RequestFlow{CallbackID: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSetDefaultResponse(p)
+		},
+		Encode:        encodeZWSetDefault,
+		DecodeRequest: decodeCallbackArgs,
+		EncodeRequest: encodeCallbackArgs,
+	},
+	MessageTypeZWGetNodeProtocolInfo: {
+		Name: "ZWGetNodeProtocolInfo",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWGetNodeProtocolInfoResponse(p)
+		},
+		Encode:        encodeZWGetNodeProtocolInfo,
+		DecodeRequest: decodeNodeArgs,
+		EncodeRequest: encodeNodeArgs,
+	},
+	MessageTypeZWRequestNodeInfo: {
+		Name: "ZWRequestNodeInfo",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWRequestNodeInfoResponse(p)
+		},
+		Encode:        encodeZWRequestNodeInfo,
+		DecodeRequest: decodeNodeArgs,
+		EncodeRequest: encodeNodeArgs,
+	},
+	MessageTypeZWSendData: {
+		Name: "ZWSendData",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSendDataResponse(p)
+		},
+		Encode:        encodeZWSendData,
+		DecodeRequest: decodeZWSendDataArgs,
+		EncodeRequest: encodeZWSendDataArgs,
+	},
+	MessageTypeZWSendDataMulti: {
+		Name: "ZWSendDataMulti",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWSendDataMultiResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeZWSendDataMultiArgs,
+		EncodeRequest: encodeZWSendDataMultiArgs,
+	},
+	MessageTypeZWAssignReturnRoute: {
+		Name: "ZWAssignReturnRoute",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWAssignReturnRouteResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeZWAssignReturnRouteArgs,
+		EncodeRequest: encodeZWAssignReturnRouteArgs,
+	},
+	MessageTypeZWAssignSUCReturnRoute: {
+		Name: "ZWAssignSUCReturnRoute",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWAssignSUCReturnRouteResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeNodeCallbackArgs,
+		EncodeRequest: encodeNodeCallbackArgs,
+	},
+	MessageTypeZWDeleteReturnRoute: {
+		Name: "ZWDeleteReturnRoute",
+		Flow: &RequestFlow{CallbackID: true, Response: true, Callback: true},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWDeleteReturnRouteResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeNodeCallbackArgs,
+		EncodeRequest: encodeNodeCallbackArgs,
+	},
+	MessageTypeZWIsFailedNode: {
+		Name: "ZWIsFailedNode",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWIsFailedNodeResponse(p)
+		},
+		Encode:        encodeZWIsFailedNode,
+		DecodeRequest: decodeNodeArgs,
+		EncodeRequest: encodeNodeArgs,
+	},
+	MessageTypeZWGetRoutingInfo: {
+		Name: "ZWGetRoutingInfo",
+		DecodeResponse: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWGetRoutingInfoResponse(p)
+		},
+		Encode:        encodeZWGetRoutingInfo,
+		DecodeRequest: decodeZWGetRoutingInfoArgs,
+		EncodeRequest: encodeZWGetRoutingInfoArgs,
+	},
+	MessageTypeZWRequestNodeNeighborUpdate: {
+		Name: "ZWRequestNodeNeighborUpdate",
+		Flow: &RequestFlow{CallbackID: true, Callback: true,
+			Intermediate: []uint8{NeighborUpdateStarted}},
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ZWRequestNodeNeighborUpdateResponse(p)
+		},
+		Encode:        encodeCallbackStatus,
+		DecodeRequest: decodeNodeCallbackArgs,
+		EncodeRequest: encodeNodeCallbackArgs,
+	},
+
+	// Unsolicited requests of the controller
+	MessageTypeApplicationCommand: {
+		Name: "ApplicationCommand",
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return ApplicationCommandResponse(p, nodeIDType)
+		},
+		Encode: encodeApplicationCommand,
+	},
+	MessageTypeSerialAPIStarted: {
+		Name: "SerialAPIStarted",
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return SerialAPIStartedResponse(p)
+		},
+		Encode: encodeSerialAPIStarted,
+	},
+	MessageTypeZWApplicationUpdate: {
+		Name:           "ZWApplicationUpdate",
+		DecodeCallback: decodeZWApplicationUpdate,
+		Encode:         encodeZWApplicationUpdate,
+	},
+}
+
+// init registers the builtinCodecs
+func init() {
+	for messageType, codec := range builtinCodecs {
+		RegisterCodec(messageType, codec)
+	}
+}
+
+// MessageTypeName returns the name of a MessageType, or its hexadecimal value
+// if it is unknown. goroutine safe.
+func MessageTypeName(messageType uint8) string {
+	if codec, ok := LookupCodec(messageType); ok && codec.Name != "" {
+		return codec.Name
+	}
+	return fmt.Sprintf("0x%02x", messageType)
+}
+
+// Decode a packet received from the controller into a typed message, like
+// *ZWSendData, or an *Unknown if there is no decoder for it. Request packets
+// written by the host are decoded with DecodeRequest. goroutine safe.
+func Decode(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	codec, _ := LookupCodec(p.MessageType)
+
+	decoder := codec.DecodeCallback
+	if p.PacketType == packet.PacketTypeResponse {
+		decoder = codec.DecodeResponse
+	}
+
+	return decode(p, nodeIDType, decoder)
+}
+
+// DecodeRequest decodes a request packet written by the host into its
+// arguments, like *ZWSendDataArgs, or an *Unknown if there is no decoder for
+// it. goroutine safe.
+func DecodeRequest(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	if p.PacketType != packet.PacketTypeRequest {
+		return nil, fmt.Errorf("Bad PacketType: 0x%02x", p.PacketType)
+	}
+
+	codec, _ := LookupCodec(p.MessageType)
+	return decode(p, nodeIDType, codec.DecodeRequest)
+}
+
+// decode a packet with the decoder, or into an *Unknown if it is nil
+func decode(p *packet.Packet, nodeIDType uint8, decoder Decoder) (interface{}, error) {
+	if p.Preamble != packet.PacketPreambleSOF {
+		return nil, fmt.Errorf("Bad Preamble: 0x%02x", p.Preamble)
+	}
+
+	if decoder == nil {
+		body := make([]uint8, len(p.Body))
+		copy(body, p.Body)
+		return &Unknown{MessageType: p.MessageType, PacketType: p.PacketType,
+			Body: body}, nil
+	}
+
+	message, err := decoder(p, nodeIDType)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", MessageTypeName(p.MessageType), err)
+	}
+
+	return message, nil
+}
+
+// Encode a typed message of the MessageType, as returned by Decode, into a
+// packet. goroutine safe.
+func Encode(messageType uint8, message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	codec, _ := LookupCodec(messageType)
+	return encode(messageType, message, nodeIDType, codec.Encode)
+}
+
+// EncodeRequest encodes the arguments of a request of the MessageType, as
+// returned by DecodeRequest, into a packet. goroutine safe.
+func EncodeRequest(messageType uint8, args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	codec, _ := LookupCodec(messageType)
+	return encode(messageType, args, nodeIDType, codec.EncodeRequest)
+}
+
+// encode a message with the encoder
+func encode(messageType uint8, message interface{}, nodeIDType uint8,
+	encoder Encoder) (*packet.Packet, error) {
+	if unknown, ok := message.(*Unknown); ok {
+		if unknown.MessageType != messageType {
+			return nil, fmt.Errorf("Bad MessageType: 0x%02x != 0x%02x",
+				unknown.MessageType, messageType)
+		}
+		return codecPacket(unknown.PacketType, messageType, unknown.Body)
+	}
+
+	if encoder == nil {
+		return nil, fmt.Errorf("No encoder for MessageType: %s",
+			MessageTypeName(messageType))
+	}
+
+	p, err := encoder(message, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	p.MessageType = messageType
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// codecPacket creates a packet with the body. The MessageType is set by
+// Encode, because some encoders are shared by several MessageTypes.
+func codecPacket(packetType uint8, messageType uint8, body []uint8) (*packet.Packet, error) {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packetType,
+		MessageType: messageType,
+		Body:        body}
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// badMessage returns the error of an encoder for an unexpected message
+func badMessage(message interface{}) error {
+	return fmt.Errorf("Bad message type: %T", message)
+}
+
+// decodeZWApplicationUpdate decodes a ZWApplicationUpdate callback, which is
+// a SmartStart inclusion request or a plain ZWApplicationUpdate
+func decodeZWApplicationUpdate(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	if IsZWApplicationUpdateSmartStart(p) {
+		return ZWApplicationUpdateSmartStartResponse(p, nodeIDType)
+	}
+	return ZWApplicationUpdateResponse(p, nodeIDType)
+}
+
+// encodeNodeIDBody encodes a body of | HEADER | NODE_ID | LENGTH | DATA |
+func encodeNodeIDBody(header []uint8, nodeID uint16, nodeIDType uint8,
+	data []uint8) ([]uint8, error) {
+	if len(data) > 0xff {
+		return nil, fmt.Errorf("Bad data length: %d > 255", len(data))
+	}
+
+	encodedNodeID, err := encodeNodeID(nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	body := append(append([]uint8(nil), header...), encodedNodeID...)
+	body = append(body, uint8(len(data)))
+	return append(body, data...), nil
+}
+
+// encodeApplicationCommand encodes an *ApplicationCommand
+func encodeApplicationCommand(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	command, ok := message.(*ApplicationCommand)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body, err := encodeNodeIDBody([]uint8{command.Status}, command.NodeID,
+		nodeIDType, command.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeApplicationCommand, body)
+}
+
+// encodeZWApplicationUpdate encodes a *ZWApplicationUpdate or a
+// *ZWApplicationUpdateSmartStart
+func encodeZWApplicationUpdate(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	switch update := message.(type) {
+	case *ZWApplicationUpdate:
+		body, err := encodeNodeIDBody([]uint8{update.Status}, update.NodeID,
+			nodeIDType, update.Body)
+		if err != nil {
+			return nil, err
+		}
+		return codecPacket(packet.PacketTypeRequest, MessageTypeZWApplicationUpdate, body)
+
+	case *ZWApplicationUpdateSmartStart:
+		if len(update.Body) > 0xff {
+			return nil, fmt.Errorf("Bad data length: %d > 255", len(update.Body))
+		}
+		encodedNodeID, err := encodeNodeID(update.NodeID, nodeIDType)
+		if err != nil {
+			return nil, err
+		}
+
+		body := append([]uint8{update.Status}, encodedNodeID...)
+		body = append(body, update.RxStatus, uint8(update.NWIHomeID>>24),
+			uint8(update.NWIHomeID>>16), uint8(update.NWIHomeID>>8),
+			uint8(update.NWIHomeID), uint8(len(update.Body)))
+		body = append(body, update.Body...)
+		return codecPacket(packet.PacketTypeRequest, MessageTypeZWApplicationUpdate, body)
+	}
+
+	return nil, badMessage(message)
+}
+
+// encodeSerialAPIStarted encodes a *SerialAPIStarted
+func encodeSerialAPIStarted(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	started, ok := message.(*SerialAPIStarted)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	if len(started.CommandClasses) > 0xff {
+		return nil, fmt.Errorf("Bad CommandClasses length: %d > 255",
+			len(started.CommandClasses))
+	}
+
+	body := []uint8{started.WakeUpReason, 0x00, 0x00, started.DeviceClass.Generic,
+		started.DeviceClass.Specific, uint8(len(started.CommandClasses))}
+	if started.WatchdogStarted {
+		body[1] = 0x01
+	}
+	if started.Listening {
+		body[2] = 0x80
+	}
+	body = append(body, started.CommandClasses...)
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeSerialAPIStarted, body)
+}
+
+// encodeZWSendData encodes a *ZWSendData callback
+func encodeZWSendData(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	sendData, ok := message.(*ZWSendData)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeZWSendData,
+		[]uint8{sendData.CallbackID, sendData.Status,
+			uint8(sendData.TransmitTime >> 8), uint8(sendData.TransmitTime)})
+}
+
+// encodeCallbackStatus encodes callbacks with a body of callback id and status
+func encodeCallbackStatus(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	var callbackID, status uint8
+
+	switch callback := message.(type) {
+	case *ZWSendDataMulti:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWAssignReturnRoute:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWAssignSUCReturnRoute:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWDeleteReturnRoute:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWRequestNodeNeighborUpdate:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWRequestNetworkUpdate:
+		callbackID, status = callback.CallbackID, callback.Status
+	case *ZWSetSUCNodeID:
+		callbackID, status = callback.CallbackID, callback.Status
+	default:
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone,
+		[]uint8{callbackID, status})
+}
+
+// encodeNodeInfoCallback encodes a *ZWAddNodeToNetwork or *ZWControllerChange
+// callback
+func encodeNodeInfoCallback(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	var header, data []uint8
+	var nodeID uint16
+
+	switch callback := message.(type) {
+	case *ZWAddNodeToNetwork:
+		header = []uint8{callback.CallbackID, callback.Status}
+		nodeID, data = callback.NodeID, callback.Body
+	case *ZWControllerChange:
+		header = []uint8{callback.CallbackID, callback.Status}
+		nodeID, data = callback.NodeID, callback.Body
+	default:
+		return nil, badMessage(message)
+	}
+
+	body, err := encodeNodeIDBody(header, nodeID, nodeIDType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, body)
+}
+
+// encodeZWSetLearnMode encodes a *ZWSetLearnMode response or a
+// *ZWSetLearnModeCallback
+func encodeZWSetLearnMode(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	switch learnMode := message.(type) {
+	case *ZWSetLearnMode:
+		body := []uint8{0x00}
+		if learnMode.Accepted {
+			body[0] = 0x01
+		}
+		return codecPacket(packet.PacketTypeResponse, MessageTypeZWSetLearnMode, body)
+
+	case *ZWSetLearnModeCallback:
+		body, err := encodeNodeIDBody([]uint8{learnMode.CallbackID, learnMode.Status},
+			learnMode.NodeID, nodeIDType, nil)
+		if err != nil {
+			return nil, err
+		}
+		return codecPacket(packet.PacketTypeRequest, MessageTypeZWSetLearnMode, body)
+	}
+
+	return nil, badMessage(message)
+}
+
+// encodeZWSetDefault encodes a *ZWSetDefault callback
+func encodeZWSetDefault(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	setDefault, ok := message.(*ZWSetDefault)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeZWSetDefault,
+		[]uint8{setDefault.CallbackID})
+}
+
+// encodeGetVersion encodes a *GetVersion response
+func encodeGetVersion(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	version, ok := message.(*GetVersion)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body := append([]uint8(version.Info), version.LibraryType)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeGetVersion, body)
+}
+
+// encodeMemoryGetID encodes a *MemoryGetID response
+func encodeMemoryGetID(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	id, ok := message.(*MemoryGetID)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	encodedNodeID, err := encodeNodeID(id.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	body := append([]uint8{uint8(id.HomeID >> 24), uint8(id.HomeID >> 16),
+		uint8(id.HomeID >> 8), uint8(id.HomeID)}, encodedNodeID...)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeMemoryGetID, body)
+}
+
+// encodeZWGetSUCNodeID encodes a *ZWGetSUCNodeID response
+func encodeZWGetSUCNodeID(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	suc, ok := message.(*ZWGetSUCNodeID)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body, err := encodeNodeID(suc.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWGetSUCNodeID, body)
+}
+
+// encodeZWIsFailedNode encodes a *ZWIsFailedNode response
+func encodeZWIsFailedNode(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	failed, ok := message.(*ZWIsFailedNode)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body := []uint8{0x00}
+	if failed.Failed {
+		body[0] = 0x01
+	}
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWIsFailedNode, body)
+}
+
+// encodeNodeBitmask encodes the node IDs into a bitmask of length bytes,
+// whose first bit is the base node ID
+func encodeNodeBitmask(nodeIDs []uint16, base uint16, length int) ([]uint8, error) {
+	bitmask := make([]uint8, length)
+	for _, nodeID := range nodeIDs {
+		if nodeID < base || int(nodeID-base) >= 8*length {
+			return nil, fmt.Errorf("Node ID not in bitmask: %d", nodeID)
+		}
+		bitmask[(nodeID-base)/8] |= 1 << ((nodeID - base) % 8)
+	}
+	return bitmask, nil
+}
+
+// encodeClassicNodeBitmask encodes the classic node IDs into a bitmask of 29
+// bytes
+func encodeClassicNodeBitmask(nodeIDs []uint8) ([]uint8, error) {
+	wide := make([]uint16, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		wide[i] = uint16(nodeID)
+	}
+	return encodeNodeBitmask(wide, 1, 29)
+}
+
+// encodeMemoryGetBuffer encodes a *MemoryGetBuffer response
+func encodeMemoryGetBuffer(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	buffer, ok := message.(*MemoryGetBuffer)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeMemoryGetBuffer,
+		append([]uint8(nil), buffer.Data...))
+}
+
+// encodeNVMGetID encodes a *NVMGetID response
+func encodeNVMGetID(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	id, ok := message.(*NVMGetID)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	// Memory size is the log2 of the number of bytes
+	size := uint8(0)
+	for size < 24 && uint32(1)<<size < id.Size {
+		size++
+	}
+	if uint32(1)<<size != id.Size {
+		return nil, fmt.Errorf("Bad memory size: %d", id.Size)
+	}
+
+	// Body: | READ_TYPE | MANUFACTURER | MEMORY_TYPE | MEMORY_SIZE |
+	return codecPacket(packet.PacketTypeResponse, MessageTypeNVMGetID,
+		[]uint8{0x00, id.Manufacturer, id.MemoryType, size})
+}
+
+// encodeNVMExtReadLongBuffer encodes a *NVMExtReadLongBuffer response
+func encodeNVMExtReadLongBuffer(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	buffer, ok := message.(*NVMExtReadLongBuffer)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeNVMExtReadLongBuffer,
+		append([]uint8(nil), buffer.Data...))
+}
+
+// encodeNVMExtWriteLongBuffer encodes a *NVMExtWriteLongBuffer response
+func encodeNVMExtWriteLongBuffer(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	write, ok := message.(*NVMExtWriteLongBuffer)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body := []uint8{0x00}
+	if write.Success {
+		body[0] = 0x01
+	}
+	return codecPacket(packet.PacketTypeResponse, MessageTypeNVMExtWriteLongBuffer, body)
+}
+
+// encodeNVMBackupRestore encodes a *NVMBackupRestore response
+func encodeNVMBackupRestore(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	backup, ok := message.(*NVMBackupRestore)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	if len(backup.Data) > 0xff {
+		return nil, fmt.Errorf("Bad data length: %d > 255", len(backup.Data))
+	}
+
+	// Body: | STATUS | LENGTH | OFFSET_MSB | OFFSET_LSB | DATA |
+	body := append([]uint8{backup.Status, uint8(len(backup.Data)),
+		uint8(backup.Offset >> 8), uint8(backup.Offset)}, backup.Data...)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeNVMBackupRestore, body)
+}
+
+// encodeSerialAPIGetInitData encodes a *SerialAPIGetInitData response
+func encodeSerialAPIGetInitData(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	initData, ok := message.(*SerialAPIGetInitData)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	capabilities := uint8(0x00)
+	if initData.Capabilities.Slave {
+		capabilities |= 0x1
+	}
+	if initData.Capabilities.TimerSupport {
+		capabilities |= 0x2
+	}
+	if initData.Capabilities.Secondary {
+		capabilities |= 0x4
+	}
+	if initData.Capabilities.StaticUpdate {
+		capabilities |= 0x8
+	}
+
+	bitmask, err := encodeClassicNodeBitmask(initData.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Body: | VERSION | CAPABILITIES | 29 | BITMASK | CHIP_TYPE | CHIP_VERSION |
+	body := append([]uint8{initData.Version, capabilities, 29}, bitmask...)
+	body = append(body, 0x00, 0x00)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeSerialAPIGetInitData, body)
+}
+
+// encodeSerialAPIGetLRNodes encodes a *SerialAPIGetLRNodes response
+func encodeSerialAPIGetLRNodes(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	nodes, ok := message.(*SerialAPIGetLRNodes)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	bitmask, err := encodeNodeBitmask(nodes.Nodes,
+		MinLongRangeNodeID+1024*uint16(nodes.Segment), 128)
+	if err != nil {
+		return nil, err
+	}
+
+	// Body: | MORE | SEGMENT | LENGTH | BITMASK |
+	body := []uint8{0x00, nodes.Segment, uint8(len(bitmask))}
+	if nodes.More {
+		body[0] = 0x01
+	}
+	body = append(body, bitmask...)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeSerialAPIGetLRNodes, body)
+}
+
+// encodeSerialAPIGetCapabilities encodes a *SerialAPIGetCapabilities response
+func encodeSerialAPIGetCapabilities(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	capabilities, ok := message.(*SerialAPIGetCapabilities)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	messageTypes := make([]uint16, len(capabilities.MessageTypes))
+	for i, messageType := range capabilities.MessageTypes {
+		messageTypes[i] = uint16(messageType)
+	}
+	bitmask, err := encodeNodeBitmask(messageTypes, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	body := []uint8{capabilities.Application.Version,
+		capabilities.Application.Revision,
+		uint8(capabilities.Manufacturer >> 8), uint8(capabilities.Manufacturer),
+		uint8(capabilities.Product.Type >> 8), uint8(capabilities.Product.Type),
+		uint8(capabilities.Product.ID >> 8), uint8(capabilities.Product.ID)}
+	body = append(body, bitmask...)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeSerialAPIGetCapabilities, body)
+}
+
+// encodeSerialAPISetup encodes a *SerialAPISetup response
+func encodeSerialAPISetup(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	setup, ok := message.(*SerialAPISetup)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	// Body: | COMMAND | DATA |
+	body := []uint8{setup.Command}
+	switch setup.Command {
+	case SerialAPISetupCommandSetTxStatusReport, SerialAPISetupCommandSetPowerLevel,
+		SerialAPISetupCommandSetRFRegion, SerialAPISetupCommandSetNodeIDType:
+		if setup.Success {
+			body = append(body, 0x01)
+		} else {
+			body = append(body, 0x00)
+		}
+
+	case SerialAPISetupCommandGetRFRegion:
+		body = append(body, setup.RFRegion)
+
+	case SerialAPISetupCommandGetPowerLevel:
+		body = append(body, uint8(setup.PowerLevel.Normal),
+			uint8(setup.PowerLevel.Measured0dBm))
+
+	case SerialAPISetupCommandGetMaxPayloadSize:
+		body = append(body, setup.MaxPayloadSize)
+
+	default:
+		return nil, fmt.Errorf("Unknown SerialAPISetup command: 0x%02x",
+			setup.Command)
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeSerialAPISetup, body)
+}
+
+// encodeZWGetControllerCapabilities encodes a *ZWGetControllerCapabilities
+// response
+func encodeZWGetControllerCapabilities(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	capabilities, ok := message.(*ZWGetControllerCapabilities)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body := []uint8{0x00}
+	if capabilities.Secondary {
+		body[0] |= 0x1
+	}
+	if capabilities.NonStandardHomeID {
+		body[0] |= 0x2
+	}
+	if capabilities.StaticUpdateControllerIDServer {
+		body[0] |= 0x4
+	}
+	if capabilities.WasPrimary {
+		body[0] |= 0x8
+	}
+	if capabilities.StaticUpdateController {
+		body[0] |= 0x10
+	}
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWGetControllerCapabilities, body)
+}
+
+// encodeZWGetNodeProtocolInfo encodes a *ZWGetNodeProtocolInfo response
+func encodeZWGetNodeProtocolInfo(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	info, ok := message.(*ZWGetNodeProtocolInfo)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	// Body: | CAPABILITY | SECURITY | SPEED_EXTENSION | BASIC | GENERIC |
+	//       | SPECIFIC |
+	body := []uint8{info.Capabilities.ProtocolVersion & 0x07, 0x00, 0x00,
+		info.DeviceClass.Basic, info.DeviceClass.Generic, info.DeviceClass.Specific}
+
+	if info.Capabilities.Listening {
+		body[0] |= 0x80
+	}
+	if info.Capabilities.Routing {
+		body[0] |= 0x40
+	}
+
+	// The speed extension adds the faster data rates
+	switch info.Capabilities.MaxBaudRate {
+	case 0:
+	case 9600:
+		body[0] |= 0x08
+	case 40000:
+		body[0] |= 0x10
+	case 100000:
+		body[0] |= 0x10
+		body[2] = 0x01
+	case 200000:
+		body[0] |= 0x10
+		body[2] = 0x02
+	default:
+		return nil, fmt.Errorf("Bad MaxBaudRate: %d", info.Capabilities.MaxBaudRate)
+	}
+
+	switch info.Capabilities.FrequentListening {
+	case FrequentListeningNone:
+	case FrequentListening1000ms:
+		body[1] |= 0x40
+	case FrequentListening250ms:
+		body[1] |= 0x20
+	default:
+		return nil, fmt.Errorf("Bad FrequentListening: 0x%02x",
+			info.Capabilities.FrequentListening)
+	}
+
+	flags := []struct {
+		set bool
+		bit uint8
+	}{
+		{info.Capabilities.OptionalFunctionality, 0x80},
+		{info.Capabilities.Beaming, 0x10},
+		{info.Capabilities.RoutingSlave, 0x08},
+		{info.Capabilities.SpecificDeviceClass, 0x04},
+		{info.Capabilities.Controller, 0x02},
+		{info.Capabilities.Security, 0x01},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			body[1] |= flag.bit
+		}
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWGetNodeProtocolInfo, body)
+}
+
+// encodeZWGetRandom encodes a *ZWGetRandom response
+func encodeZWGetRandom(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	random, ok := message.(*ZWGetRandom)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	if len(random.Bytes) > 0xff {
+		return nil, fmt.Errorf("Bad Bytes length: %d > 255", len(random.Bytes))
+	}
+
+	// Body: | SUCCESS | COUNT | BYTES |
+	body := append([]uint8{0x01, uint8(len(random.Bytes))}, random.Bytes...)
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWGetRandom, body)
+}
+
+// encodeZWGetRoutingInfo encodes a *ZWGetRoutingInfo response
+func encodeZWGetRoutingInfo(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	routing, ok := message.(*ZWGetRoutingInfo)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	bitmask, err := encodeClassicNodeBitmask(routing.Neighbors)
+	if err != nil {
+		return nil, err
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWGetRoutingInfo, bitmask)
+}
+
+// encodeZWRequestNodeInfo encodes a *ZWRequestNodeInfo response
+func encodeZWRequestNodeInfo(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	info, ok := message.(*ZWRequestNodeInfo)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	return codecPacket(packet.PacketTypeResponse, MessageTypeZWRequestNodeInfo,
+		[]uint8{info.Status})
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// callbackIDBody returns the body of an optional callback id, which is absent
+// if it is 0
+func callbackIDBody(callbackID uint8) []uint8 {
+	if callbackID == 0 {
+		return nil
+	}
+	return []uint8{callbackID}
+}
+
+// decodeCallbackID decodes the optional callback id at the end of the body
+func decodeCallbackID(body []uint8) (uint8, error) {
+	switch len(body) {
+	case 0:
+		return 0, nil
+	case 1:
+		return body[0], nil
+	}
+	return 0, fmt.Errorf("Bad Body length: %d", len(body))
+}
+
+// decodeNoArgs decodes a request with an empty body
+func decodeNoArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	if len(p.Body) != 0 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &NoArgs{}, nil
+}
+
+// encodeNoArgs encodes a *NoArgs request
+func encodeNoArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	if _, ok := args.(*NoArgs); !ok {
+		return nil, badMessage(args)
+	}
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, nil)
+}
+
+// decodeCallbackArgs decodes a request with only a callback id
+func decodeCallbackArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	callbackID, err := decodeCallbackID(p.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &CallbackArgs{CallbackID: callbackID}, nil
+}
+
+// encodeCallbackArgs encodes a *CallbackArgs request
+func encodeCallbackArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	callback, ok := args.(*CallbackArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone,
+		callbackIDBody(callback.CallbackID))
+}
+
+// decodeNodeArgs decodes a request with only a node ID
+func decodeNodeArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	if length != len(p.Body) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &NodeArgs{NodeID: nodeID}, nil
+}
+
+// encodeNodeArgs encodes a *NodeArgs request
+func encodeNodeArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	node, ok := args.(*NodeArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+
+	body, err := encodeNodeID(node.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, body)
+}
+
+// decodeNodeCallbackArgs decodes a request with a node ID and a callback id
+func decodeNodeCallbackArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	callbackID, err := decodeCallbackID(p.Body[length:])
+	if err != nil {
+		return nil, err
+	}
+	return &NodeCallbackArgs{NodeID: nodeID, CallbackID: callbackID}, nil
+}
+
+// encodeNodeCallbackArgs encodes a *NodeCallbackArgs request
+func encodeNodeCallbackArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	node, ok := args.(*NodeCallbackArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+
+	body, err := encodeNodeID(node.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, callbackIDBody(node.CallbackID)...)
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, body)
+}
+
+// decodeModeArgs decodes a request with a mode and a callback id
+func decodeModeArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	if len(p.Body) < 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	callbackID, err := decodeCallbackID(p.Body[1:])
+	if err != nil {
+		return nil, err
+	}
+	return &ModeArgs{Mode: p.Body[0], CallbackID: callbackID}, nil
+}
+
+// encodeModeArgs encodes a *ModeArgs request
+func encodeModeArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	mode, ok := args.(*ModeArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone,
+		append([]uint8{mode.Mode}, callbackIDBody(mode.CallbackID)...))
+}
+
+// decodeMemoryGetBufferArgs decodes a MemoryGetBuffer request
+func decodeMemoryGetBufferArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | OFFSET_MSB | OFFSET_LSB | LENGTH |
+	if len(p.Body) != 3 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &MemoryGetBufferArgs{Offset: binary.BigEndian.Uint16(p.Body[0:2]),
+		Length: p.Body[2]}, nil
+}
+
+// encodeMemoryGetBufferArgs encodes a *MemoryGetBufferArgs request
+func encodeMemoryGetBufferArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	buffer, ok := args.(*MemoryGetBufferArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone,
+		[]uint8{uint8(buffer.Offset >> 8), uint8(buffer.Offset), buffer.Length})
+}
+
+// decodeNVMExtReadLongBufferArgs decodes a NVMExtReadLongBuffer request
+func decodeNVMExtReadLongBufferArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | OFFSET (3) | LENGTH (2) |
+	if len(p.Body) != 5 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &NVMExtReadLongBufferArgs{
+		Offset: uint32(p.Body[0])<<16 | uint32(p.Body[1])<<8 | uint32(p.Body[2]),
+		Length: binary.BigEndian.Uint16(p.Body[3:5])}, nil
+}
+
+// encodeNVMExtReadLongBufferArgs encodes a *NVMExtReadLongBufferArgs request
+func encodeNVMExtReadLongBufferArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	read, ok := args.(*NVMExtReadLongBufferArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return NVMExtReadLongBufferRequest(read.Offset, read.Length)
+}
+
+// decodeNVMExtWriteLongBufferArgs decodes a NVMExtWriteLongBuffer request
+func decodeNVMExtWriteLongBufferArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | OFFSET (3) | LENGTH (2) | DATA |
+	if len(p.Body) < 5 || len(p.Body) != 5+int(binary.BigEndian.Uint16(p.Body[3:5])) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &NVMExtWriteLongBufferArgs{
+		Offset: uint32(p.Body[0])<<16 | uint32(p.Body[1])<<8 | uint32(p.Body[2]),
+		Data:   append([]uint8(nil), p.Body[5:]...)}, nil
+}
+
+// encodeNVMExtWriteLongBufferArgs encodes a *NVMExtWriteLongBufferArgs request
+func encodeNVMExtWriteLongBufferArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	write, ok := args.(*NVMExtWriteLongBufferArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	if len(write.Data) > 0xffff {
+		return nil, fmt.Errorf("Bad data length: %d", len(write.Data))
+	}
+	return NVMExtWriteLongBufferRequest(write.Offset, write.Data)
+}
+
+// decodeNVMBackupRestoreArgs decodes a NVMBackupRestore request
+func decodeNVMBackupRestoreArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | OPERATION | LENGTH | OFFSET_MSB | OFFSET_LSB | DATA |
+	if len(p.Body) < 4 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &NVMBackupRestoreArgs{Operation: p.Body[0], Length: p.Body[1],
+		Offset: binary.BigEndian.Uint16(p.Body[2:4]),
+		Data:   append([]uint8(nil), p.Body[4:]...)}, nil
+}
+
+// encodeNVMBackupRestoreArgs encodes a *NVMBackupRestoreArgs request
+func encodeNVMBackupRestoreArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	backup, ok := args.(*NVMBackupRestoreArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return nvmBackupRestoreRequest(backup.Operation, backup.Length, backup.Offset,
+		backup.Data)
+}
+
+// decodeSerialAPISetupArgs decodes a SerialAPISetup request
+func decodeSerialAPISetupArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | COMMAND | ARGS |
+	if len(p.Body) < 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	args := SerialAPISetupArgs{Command: p.Body[0]}
+	length := 1
+	switch args.Command {
+	case SerialAPISetupCommandGetRFRegion, SerialAPISetupCommandGetPowerLevel,
+		SerialAPISetupCommandGetMaxPayloadSize:
+
+	case SerialAPISetupCommandSetTxStatusReport:
+		length = 2
+		if len(p.Body) == length {
+			args.TxStatusReport = p.Body[1] != 0
+		}
+
+	case SerialAPISetupCommandSetRFRegion:
+		length = 2
+		if len(p.Body) == length {
+			args.RFRegion = p.Body[1]
+		}
+
+	case SerialAPISetupCommandSetPowerLevel:
+		length = 3
+		if len(p.Body) == length {
+			args.PowerLevel.Normal = int8(p.Body[1])
+			args.PowerLevel.Measured0dBm = int8(p.Body[2])
+		}
+
+	case SerialAPISetupCommandSetNodeIDType:
+		length = 2
+		if len(p.Body) == length {
+			args.NodeIDType = p.Body[1]
+		}
+
+	default:
+		return nil, fmt.Errorf("Unknown SerialAPISetup command: 0x%02x",
+			args.Command)
+	}
+
+	if len(p.Body) != length {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	return &args, nil
+}
+
+// encodeSerialAPISetupArgs encodes a *SerialAPISetupArgs request
+func encodeSerialAPISetupArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*SerialAPISetupArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	switch args.Command {
+	case SerialAPISetupCommandGetRFRegion, SerialAPISetupCommandGetPowerLevel,
+		SerialAPISetupCommandGetMaxPayloadSize:
+		return serialAPISetupRequest(args.Command), nil
+	case SerialAPISetupCommandSetTxStatusReport:
+		return SerialAPISetupSetTxStatusReportRequest(args.TxStatusReport), nil
+	case SerialAPISetupCommandSetRFRegion:
+		return SerialAPISetupSetRFRegionRequest(args.RFRegion), nil
+	case SerialAPISetupCommandSetPowerLevel:
+		return SerialAPISetupSetPowerLevelRequest(args.PowerLevel.Normal,
+			args.PowerLevel.Measured0dBm), nil
+	case SerialAPISetupCommandSetNodeIDType:
+		return SerialAPISetupSetNodeIDTypeRequest(args.NodeIDType)
+	}
+
+	return nil, fmt.Errorf("Unknown SerialAPISetup command: 0x%02x", args.Command)
+}
+
+// decodeSerialAPIGetLRNodesArgs decodes a SerialAPIGetLRNodes request
+func decodeSerialAPIGetLRNodesArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | SEGMENT |
+	if len(p.Body) != 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &SerialAPIGetLRNodesArgs{Segment: p.Body[0]}, nil
+}
+
+// encodeSerialAPIGetLRNodesArgs encodes a *SerialAPIGetLRNodesArgs request
+func encodeSerialAPIGetLRNodesArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	nodes, ok := args.(*SerialAPIGetLRNodesArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return SerialAPIGetLRNodesRequest(nodes.Segment), nil
+}
+
+// decodeZWGetRandomArgs decodes a ZWGetRandom request
+func decodeZWGetRandomArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | COUNT |
+	if len(p.Body) != 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	return &ZWGetRandomArgs{Count: p.Body[0]}, nil
+}
+
+// encodeZWGetRandomArgs encodes a *ZWGetRandomArgs request
+func encodeZWGetRandomArgs(args interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	random, ok := args.(*ZWGetRandomArgs)
+	if !ok {
+		return nil, badMessage(args)
+	}
+	return ZWGetRandomRequest(random.Count)
+}
+
+// decodeZWSetSUCNodeIDArgs decodes a ZWSetSUCNodeID request
+func decodeZWSetSUCNodeIDArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | NODE_ID | ENABLE | TRANSMIT_OPTIONS | CAPABILITIES | CALLBACK_ID |
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	body := p.Body[length:]
+	if len(body) != 3 && len(body) != 4 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	args := ZWSetSUCNodeIDArgs{NodeID: nodeID, Enable: body[0] != 0,
+		TransmitOptions: body[1], SIS: (body[2] & 0x01) != 0, Callback: true}
+	if len(body) == 4 {
+		args.CallbackID = body[3]
+		args.Callback = args.CallbackID != 0
+	}
+
+	return &args, nil
+}
+
+// encodeZWSetSUCNodeIDArgs encodes a *ZWSetSUCNodeIDArgs request
+func encodeZWSetSUCNodeIDArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWSetSUCNodeIDArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body, err := encodeNodeID(args.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	state := uint8(0x00)
+	if args.Enable {
+		state = 0x01
+	}
+	capabilities := uint8(0x00)
+	if args.SIS {
+		capabilities = 0x01
+	}
+	body = append(body, state, args.TransmitOptions, capabilities)
+	if !args.Callback {
+		body = append(body, 0x00)
+	} else {
+		body = append(body, callbackIDBody(args.CallbackID)...)
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, body)
+}
+
+// decodeZWAddNodeToNetworkArgs decodes a ZWAddNodeToNetwork request
+func decodeZWAddNodeToNetworkArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | MODE | CALLBACK_ID | NWI_HOME_ID | AUTH_HOME_ID |, where the
+	// HomeIDs are only in the AddNodeHomeID mode
+	if len(p.Body) < 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	args := ZWAddNodeToNetworkArgs{Mode: p.Body[0]}
+	body := p.Body[1:]
+	if isAddNodeHomeID(args.Mode) {
+		switch len(body) {
+		case 8:
+		case 9:
+			args.CallbackID = body[0]
+			body = body[1:]
+		default:
+			return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+		}
+		args.NWIHomeID = binary.BigEndian.Uint32(body[0:4])
+		args.AuthHomeID = binary.BigEndian.Uint32(body[4:8])
+		return &args, nil
+	}
+
+	callbackID, err := decodeCallbackID(body)
+	if err != nil {
+		return nil, err
+	}
+	args.CallbackID = callbackID
+
+	return &args, nil
+}
+
+// encodeZWAddNodeToNetworkArgs encodes a *ZWAddNodeToNetworkArgs request
+func encodeZWAddNodeToNetworkArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWAddNodeToNetworkArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body := append([]uint8{args.Mode}, callbackIDBody(args.CallbackID)...)
+	if isAddNodeHomeID(args.Mode) {
+		body = append(body,
+			uint8(args.NWIHomeID>>24), uint8(args.NWIHomeID>>16),
+			uint8(args.NWIHomeID>>8), uint8(args.NWIHomeID),
+			uint8(args.AuthHomeID>>24), uint8(args.AuthHomeID>>16),
+			uint8(args.AuthHomeID>>8), uint8(args.AuthHomeID))
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, body)
+}
+
+// isAddNodeHomeID checks if the ZWAddNodeToNetwork mode, without its option
+// flags, is AddNodeHomeID
+func isAddNodeHomeID(mode uint8) bool {
+	return mode&^(AddNodeOptionNetworkWide|AddNodeOptionHighPower) == AddNodeHomeID
+}
+
+// decodeZWAssignReturnRouteArgs decodes a ZWAssignReturnRoute request
+func decodeZWAssignReturnRouteArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | NODE_ID | DESTINATION_NODE_ID | CALLBACK_ID |
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	destinationNodeID, destinationLength, err := decodeNodeID(p.Body[length:], nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	callbackID, err := decodeCallbackID(p.Body[length+destinationLength:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZWAssignReturnRouteArgs{NodeID: nodeID,
+		DestinationNodeID: destinationNodeID, CallbackID: callbackID}, nil
+}
+
+// encodeZWAssignReturnRouteArgs encodes a *ZWAssignReturnRouteArgs request
+func encodeZWAssignReturnRouteArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWAssignReturnRouteArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	p, err := ZWAssignReturnRouteRequest(args.NodeID, args.DestinationNodeID,
+		nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	p.Body = append(p.Body, callbackIDBody(args.CallbackID)...)
+
+	return p, nil
+}
+
+// decodeZWGetRoutingInfoArgs decodes a ZWGetRoutingInfo request
+func decodeZWGetRoutingInfoArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | NODE_ID | REMOVE_BAD | REMOVE_NON_REPEATERS | FUNC_ID |
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Body) != length+3 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	return &ZWGetRoutingInfoArgs{NodeID: nodeID, RemoveBad: p.Body[length] != 0,
+		RemoveNonRepeaters: p.Body[length+1] != 0}, nil
+}
+
+// encodeZWGetRoutingInfoArgs encodes a *ZWGetRoutingInfoArgs request
+func encodeZWGetRoutingInfoArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWGetRoutingInfoArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+	return ZWGetRoutingInfoRequest(args.NodeID, nodeIDType, args.RemoveBad,
+		args.RemoveNonRepeaters)
+}
+
+// decodeSendDataPayload decodes the | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
+// PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID | end of a ZWSendData or
+// ZWSendDataMulti request body
+func decodeSendDataPayload(body []uint8) (commandClass uint8, payload []uint8,
+	transmitOptions uint8, callbackID uint8, err error) {
+	if len(body) < 1 || body[0] < 1 || len(body) < 2+int(body[0]) {
+		return 0, nil, 0, 0, fmt.Errorf("Bad payload length: %d", len(body))
+	}
+
+	length := int(body[0])
+	commandClass = body[1]
+	payload = append([]uint8(nil), body[2:1+length]...)
+	transmitOptions = body[1+length]
+	callbackID, err = decodeCallbackID(body[2+length:])
+
+	return commandClass, payload, transmitOptions, callbackID, err
+}
+
+// encodeSendDataPayload encodes the end of a ZWSendData or ZWSendDataMulti
+// request body
+func encodeSendDataPayload(commandClass uint8, payload []uint8,
+	transmitOptions uint8, callbackID uint8) ([]uint8, error) {
+	if len(payload) > 0xfe {
+		return nil, fmt.Errorf("Bad payload length: %d", len(payload))
+	}
+
+	body := append([]uint8{1 + uint8(len(payload)), commandClass}, payload...)
+	body = append(body, transmitOptions)
+	return append(body, callbackIDBody(callbackID)...), nil
+}
+
+// decodeZWSendDataArgs decodes a ZWSendData request
+func decodeZWSendDataArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | NODE_ID | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
+	//       | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
+	nodeID, length, err := decodeNodeID(p.Body, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	args := ZWSendDataArgs{NodeID: nodeID}
+	args.CommandClass, args.Payload, args.TransmitOptions, args.CallbackID, err =
+		decodeSendDataPayload(p.Body[length:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &args, nil
+}
+
+// encodeZWSendDataArgs encodes a *ZWSendDataArgs request
+func encodeZWSendDataArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWSendDataArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	body, err := encodeNodeID(args.NodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeSendDataPayload(args.CommandClass, args.Payload,
+		args.TransmitOptions, args.CallbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, append(body, data...))
+}
+
+// decodeZWSendDataMultiArgs decodes a ZWSendDataMulti request
+func decodeZWSendDataMultiArgs(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+	// Body: | NUMBER_OF_NODES | NODE_IDS | LENGTH_OF_PAYLOAD + 1 |
+	//       | COMMAND_CLASS | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
+	if len(p.Body) < 1 || len(p.Body) < 1+int(p.Body[0]) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	count := int(p.Body[0])
+	args := ZWSendDataMultiArgs{NodeIDs: append([]uint8(nil), p.Body[1:1+count]...)}
+
+	var err error
+	args.CommandClass, args.Payload, args.TransmitOptions, args.CallbackID, err =
+		decodeSendDataPayload(p.Body[1+count:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &args, nil
+}
+
+// encodeZWSendDataMultiArgs encodes a *ZWSendDataMultiArgs request
+func encodeZWSendDataMultiArgs(message interface{}, nodeIDType uint8) (*packet.Packet, error) {
+	args, ok := message.(*ZWSendDataMultiArgs)
+	if !ok {
+		return nil, badMessage(message)
+	}
+
+	if len(args.NodeIDs) > MaxMulticastNodes {
+		return nil, fmt.Errorf("Number of nodes %d > %d", len(args.NodeIDs),
+			MaxMulticastNodes)
+	}
+
+	data, err := encodeSendDataPayload(args.CommandClass, args.Payload,
+		args.TransmitOptions, args.CallbackID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := append([]uint8{uint8(len(args.NodeIDs))}, args.NodeIDs...)
+	return codecPacket(packet.PacketTypeRequest, MessageTypeNone, append(body, data...))
+}
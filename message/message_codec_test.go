@@ -0,0 +1,463 @@
+package message
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeEncodeRoundTrip(t *testing.T) {
+	smartStart := &ZWApplicationUpdateSmartStart{
+		Status: ZWApplicationUpdateStateSmartStartHomeIDReceived, NodeID: 0,
+		RxStatus: 0x02, NWIHomeID: 0xc1020304, Body: []uint8{0x04, 0x10, 0x01}}
+	startedMessage := &SerialAPIStarted{WakeUpReason: 0x00, WatchdogStarted: true,
+		Listening: true, CommandClasses: []uint8{0x20, 0x25}}
+	startedMessage.DeviceClass.Generic = 0x02
+	startedMessage.DeviceClass.Specific = 0x07
+	initData := &SerialAPIGetInitData{Version: 0x05, Nodes: []uint8{1, 2, 232}}
+	initData.Capabilities.Secondary = true
+	initData.Capabilities.StaticUpdate = true
+	capabilities := &SerialAPIGetCapabilities{Manufacturer: 0x0086,
+		MessageTypes: []uint8{0x02, 0x13, 0xff}}
+	capabilities.Application.Version = 1
+	capabilities.Application.Revision = 2
+	capabilities.Product.Type = 0x0101
+	capabilities.Product.ID = 0x005a
+	powerLevel := &SerialAPISetup{Command: SerialAPISetupCommandGetPowerLevel}
+	powerLevel.PowerLevel.Normal = -10
+	powerLevel.PowerLevel.Measured0dBm = 3
+	protocolInfo := &ZWGetNodeProtocolInfo{}
+	protocolInfo.Capabilities.Routing = true
+	protocolInfo.Capabilities.MaxBaudRate = 100000
+	protocolInfo.Capabilities.ProtocolVersion = ProtocolVersion6
+	protocolInfo.Capabilities.Beaming = true
+	protocolInfo.Capabilities.FrequentListening = FrequentListening1000ms
+	protocolInfo.Capabilities.SpecificDeviceClass = true
+	protocolInfo.Capabilities.RoutingSlave = true
+	protocolInfo.DeviceClass.Basic = 0x04
+	protocolInfo.DeviceClass.Generic = 0x40
+	protocolInfo.DeviceClass.Specific = 0x03
+
+	tests := []struct {
+		messageType uint8
+		message     interface{}
+		nodeIDType  uint8
+	}{
+		{MessageTypeApplicationCommand, &ApplicationCommand{Status: 0x00, NodeID: 3,
+			Body: []uint8{0x25, 0x03, 0xff}}, NodeIDType8Bit},
+		{MessageTypeApplicationCommand, &ApplicationCommand{Status: 0x00, NodeID: 300,
+			Body: []uint8{0x25, 0x03, 0xff}}, NodeIDType16Bit},
+		{MessageTypeZWApplicationUpdate, &ZWApplicationUpdate{
+			Status: ZWApplicationUpdateStateReceived, NodeID: 5,
+			Body: []uint8{0x04, 0x10, 0x01, 0x25}}, NodeIDType8Bit},
+		{MessageTypeZWApplicationUpdate, smartStart, NodeIDType8Bit},
+		{MessageTypeSerialAPIStarted, startedMessage, NodeIDType8Bit},
+		{MessageTypeZWSendData, &ZWSendData{CallbackID: 0x12,
+			Status: TransmitCompleteNoACK, TransmitTime: 0x0102}, NodeIDType8Bit},
+		{MessageTypeZWSendDataMulti, &ZWSendDataMulti{CallbackID: 0x12,
+			Status: TransmitCompleteOK}, NodeIDType8Bit},
+		{MessageTypeZWAssignReturnRoute, &ZWAssignReturnRoute{CallbackID: 0x01,
+			Status: TransmitCompleteFail}, NodeIDType8Bit},
+		{MessageTypeZWAssignSUCReturnRoute, &ZWAssignSUCReturnRoute{CallbackID: 0x01,
+			Status: TransmitCompleteOK}, NodeIDType8Bit},
+		{MessageTypeZWDeleteReturnRoute, &ZWDeleteReturnRoute{CallbackID: 0x01,
+			Status: TransmitCompleteOK}, NodeIDType8Bit},
+		{MessageTypeZWRequestNodeNeighborUpdate, &ZWRequestNodeNeighborUpdate{
+			CallbackID: 0x01, Status: 0x22}, NodeIDType8Bit},
+		{MessageTypeZWRequestNetworkUpdate, &ZWRequestNetworkUpdate{CallbackID: 0x01,
+			Status: 0x00}, NodeIDType8Bit},
+		{MessageTypeZWSetSUCNodeID, &ZWSetSUCNodeID{CallbackID: 0x01,
+			Status: SUCStatusSucceeded}, NodeIDType8Bit},
+		{MessageTypeZWAddNodeToNetwork, &ZWAddNodeToNetwork{CallbackID: 0x01,
+			Status: AddNodeStatusAddingSlave, NodeID: 7,
+			Body: []uint8{0x04, 0x10, 0x01}}, NodeIDType8Bit},
+		{MessageTypeZWControllerChange, &ZWControllerChange{CallbackID: 0x01,
+			Status: 0x05, NodeID: 1, Body: []uint8{}}, NodeIDType8Bit},
+		{MessageTypeZWNewController, &ZWControllerChange{CallbackID: 0x01,
+			Status: 0x05, NodeID: 1, Body: []uint8{0x02}}, NodeIDType8Bit},
+		{MessageTypeZWSetLearnMode, &ZWSetLearnMode{Accepted: true}, NodeIDType8Bit},
+		{MessageTypeZWSetLearnMode, &ZWSetLearnModeCallback{CallbackID: 0x01,
+			Status: 0x06, NodeID: 300}, NodeIDType16Bit},
+		{MessageTypeZWSetDefault, &ZWSetDefault{CallbackID: 0x01}, NodeIDType8Bit},
+		{MessageTypeGetVersion, &GetVersion{Info: "Z-Wave 4.05\x00",
+			LibraryType: 0x01}, NodeIDType8Bit},
+		{MessageTypeMemoryGetID, &MemoryGetID{HomeID: 0xc0ffee00, NodeID: 1},
+			NodeIDType8Bit},
+		{MessageTypeMemoryGetID, &MemoryGetID{HomeID: 0xc0ffee00, NodeID: 1},
+			NodeIDType16Bit},
+		{MessageTypeZWGetSUCNodeID, &ZWGetSUCNodeID{NodeID: 1}, NodeIDType8Bit},
+		{MessageTypeZWIsFailedNode, &ZWIsFailedNode{Failed: true}, NodeIDType8Bit},
+		{MessageTypeMemoryGetBuffer, &MemoryGetBuffer{Data: []uint8{0x01, 0x02}},
+			NodeIDType8Bit},
+		{MessageTypeNVMGetID, &NVMGetID{Manufacturer: 0xef, MemoryType: 0x40,
+			Size: 1 << 17}, NodeIDType8Bit},
+		{MessageTypeNVMExtReadLongBuffer, &NVMExtReadLongBuffer{
+			Data: []uint8{0x01, 0x02}}, NodeIDType8Bit},
+		{MessageTypeNVMExtWriteLongBuffer, &NVMExtWriteLongBuffer{Success: true},
+			NodeIDType8Bit},
+		{MessageTypeNVMBackupRestore, &NVMBackupRestore{
+			Status: NVMBackupRestoreStatusOK, Offset: 0x0102,
+			Data: []uint8{0x01, 0x02}}, NodeIDType8Bit},
+		{MessageTypeSerialAPIGetInitData, initData, NodeIDType8Bit},
+		{MessageTypeSerialAPIGetLRNodes, &SerialAPIGetLRNodes{More: true,
+			Segment: 1, Nodes: []uint16{1280, 1290, 2303}}, NodeIDType16Bit},
+		{MessageTypeSerialAPIGetCapabilities, capabilities, NodeIDType8Bit},
+		{MessageTypeSerialAPISetup, &SerialAPISetup{
+			Command: SerialAPISetupCommandSetNodeIDType, Success: true},
+			NodeIDType8Bit},
+		{MessageTypeSerialAPISetup, &SerialAPISetup{
+			Command: SerialAPISetupCommandGetRFRegion, RFRegion: RFRegionUSA},
+			NodeIDType8Bit},
+		{MessageTypeSerialAPISetup, powerLevel, NodeIDType8Bit},
+		{MessageTypeSerialAPISetup, &SerialAPISetup{
+			Command: SerialAPISetupCommandGetMaxPayloadSize, MaxPayloadSize: 46},
+			NodeIDType8Bit},
+		{MessageTypeZWGetControllerCapabilities, &ZWGetControllerCapabilities{
+			Secondary: true, WasPrimary: true, StaticUpdateController: true},
+			NodeIDType8Bit},
+		{MessageTypeZWGetNodeProtocolInfo, protocolInfo, NodeIDType8Bit},
+		{MessageTypeZWGetRandom, &ZWGetRandom{Bytes: []uint8{0x01, 0x02, 0x03}},
+			NodeIDType8Bit},
+		{MessageTypeZWGetRoutingInfo, &ZWGetRoutingInfo{
+			Neighbors: []uint8{1, 9, 232}}, NodeIDType8Bit},
+		{MessageTypeZWRequestNodeInfo, &ZWRequestNodeInfo{Status: 0x01},
+			NodeIDType8Bit},
+		{0x77, &Unknown{MessageType: 0x77, PacketType: packet.PacketTypeRequest,
+			Body: []uint8{0x01, 0x02}}, NodeIDType8Bit},
+	}
+
+	for _, test := range tests {
+		p, err := Encode(test.messageType, test.message, test.nodeIDType)
+		if err != nil {
+			t.Fatalf("%T: failed to encode: %v", test.message, err)
+		}
+
+		if p.MessageType != test.messageType {
+			t.Fatalf("%T: bad MessageType: 0x%02x != 0x%02x", test.message,
+				p.MessageType, test.messageType)
+		}
+
+		// Go through the serial encoding, to check the length and checksum
+		bytes, err := p.Bytes()
+		if err != nil {
+			t.Fatalf("%T: failed to get bytes: %v", test.message, err)
+		}
+
+		decoded, err := Decode(parsePacketBytes(t, bytes), test.nodeIDType)
+		if err != nil {
+			t.Fatalf("%T: failed to decode: %v", test.message, err)
+		}
+
+		if !reflect.DeepEqual(decoded, test.message) {
+			t.Fatalf("%T: round trip mismatch: %+v != %+v", test.message, decoded,
+				test.message)
+		}
+	}
+}
+
+func TestDecodeEncodeRequest(t *testing.T) {
+	sendData, err := ZWSendDataRequest(7, NodeIDType8Bit, 0x25, []uint8{0x01, 0xff},
+		TransmitOptionACK, 0x12)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	broadcast, err := ZWSendDataBroadcastRequest(NodeIDType8Bit, 0x20, []uint8{0x02},
+		0x00)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	multi, err := ZWSendDataMultiRequest([]uint8{2, 3}, 0x25, []uint8{0x01, 0x00},
+		TransmitOptionACK)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	protocolInfo, err := ZWGetNodeProtocolInfoRequest(300, NodeIDType16Bit)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	neighborUpdate, err := ZWRequestNodeNeighborUpdateRequest(5, NodeIDType8Bit)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	returnRoute, err := ZWAssignReturnRouteRequest(5, 1, NodeIDType16Bit)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	routingInfo, err := ZWGetRoutingInfoRequest(5, NodeIDType8Bit, true, false)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	setSUC, err := ZWSetSUCNodeIDRequest(1, NodeIDType8Bit, true, true, false)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	learnMode, err := ZWSetLearnModeRequest(LearnModeNetworkWideExclusion)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	random, err := ZWGetRandomRequest(8)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	readLong, err := NVMExtReadLongBufferRequest(0x010203, 0x40)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	writeLong, err := NVMExtWriteLongBufferRequest(0x010203, []uint8{0x01, 0x02})
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	nodeIDType, err := SerialAPISetupSetNodeIDTypeRequest(NodeIDType16Bit)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	powerLevelArgs := &SerialAPISetupArgs{Command: SerialAPISetupCommandSetPowerLevel}
+	powerLevelArgs.PowerLevel.Normal = -10
+	powerLevelArgs.PowerLevel.Measured0dBm = 3
+
+	tests := []struct {
+		request    *packet.Packet
+		args       interface{}
+		nodeIDType uint8
+	}{
+		{GetVersionRequest(), &NoArgs{}, NodeIDType8Bit},
+		{SerialAPISoftResetRequest(), &NoArgs{}, NodeIDType8Bit},
+		{sendData, &ZWSendDataArgs{NodeID: 7, CommandClass: 0x25,
+			Payload: []uint8{0x01, 0xff}, TransmitOptions: TransmitOptionACK,
+			CallbackID: 0x12}, NodeIDType8Bit},
+		{broadcast, &ZWSendDataArgs{NodeID: uint16(NodeIDBroadcast),
+			CommandClass: 0x20, Payload: []uint8{0x02}}, NodeIDType8Bit},
+		{multi, &ZWSendDataMultiArgs{NodeIDs: []uint8{2, 3}, CommandClass: 0x25,
+			Payload: []uint8{0x01, 0x00}, TransmitOptions: TransmitOptionACK},
+			NodeIDType8Bit},
+		{protocolInfo, &NodeArgs{NodeID: 300}, NodeIDType16Bit},
+		{neighborUpdate, &NodeCallbackArgs{NodeID: 5}, NodeIDType8Bit},
+		{returnRoute, &ZWAssignReturnRouteArgs{NodeID: 5, DestinationNodeID: 1},
+			NodeIDType16Bit},
+		{routingInfo, &ZWGetRoutingInfoArgs{NodeID: 5, RemoveBad: true},
+			NodeIDType8Bit},
+		{setSUC, &ZWSetSUCNodeIDArgs{NodeID: 1, Enable: true,
+			TransmitOptions: TransmitOptionLowPower, SIS: true}, NodeIDType8Bit},
+		{learnMode, &ModeArgs{Mode: LearnModeNetworkWideExclusion},
+			NodeIDType8Bit},
+		{ZWSetDefaultRequest(), &CallbackArgs{}, NodeIDType8Bit},
+		{ZWAddNodeToNetworkHomeIDRequest(0xc1020304, 0x01020304),
+			&ZWAddNodeToNetworkArgs{
+				Mode:      AddNodeHomeID | AddNodeOptionHighPower | AddNodeOptionNetworkWide,
+				NWIHomeID: 0xc1020304, AuthHomeID: 0x01020304}, NodeIDType8Bit},
+		{ZWAddNodeToNetworkStopRequest(), &ZWAddNodeToNetworkArgs{Mode: AddNodeStop},
+			NodeIDType8Bit},
+		{random, &ZWGetRandomArgs{Count: 8}, NodeIDType8Bit},
+		{readLong, &NVMExtReadLongBufferArgs{Offset: 0x010203, Length: 0x40},
+			NodeIDType8Bit},
+		{writeLong, &NVMExtWriteLongBufferArgs{Offset: 0x010203,
+			Data: []uint8{0x01, 0x02}}, NodeIDType8Bit},
+		{NVMBackupRestoreReadRequest(0x0102, 0x40), &NVMBackupRestoreArgs{
+			Operation: NVMBackupRestoreOperationRead, Length: 0x40,
+			Offset: 0x0102}, NodeIDType8Bit},
+		{MemoryGetBufferRequest(0x0102, 0x10), &MemoryGetBufferArgs{Offset: 0x0102,
+			Length: 0x10}, NodeIDType8Bit},
+		{nodeIDType, &SerialAPISetupArgs{Command: SerialAPISetupCommandSetNodeIDType,
+			NodeIDType: NodeIDType16Bit}, NodeIDType8Bit},
+		{SerialAPISetupSetPowerLevelRequest(-10, 3), powerLevelArgs, NodeIDType8Bit},
+		{SerialAPISetupGetRFRegionRequest(), &SerialAPISetupArgs{
+			Command: SerialAPISetupCommandGetRFRegion}, NodeIDType8Bit},
+		{SerialAPIGetLRNodesRequest(2), &SerialAPIGetLRNodesArgs{Segment: 2},
+			NodeIDType16Bit},
+	}
+
+	for _, test := range tests {
+		name := MessageTypeName(test.request.MessageType)
+
+		args, err := DecodeRequest(test.request, test.nodeIDType)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", name, err)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Fatalf("%s: bad args: %+v != %+v", name, args, test.args)
+		}
+
+		p, err := EncodeRequest(test.request.MessageType, args, test.nodeIDType)
+		if err != nil {
+			t.Fatalf("%s: failed to encode: %v", name, err)
+		}
+		if !reflect.DeepEqual(p, test.request) {
+			t.Fatalf("%s: round trip mismatch: %+v != %+v", name, p, test.request)
+		}
+	}
+
+	// The controller appends the callback id
+	sendData.Body = append(sendData.Body[:len(sendData.Body):len(sendData.Body)], 0x13)
+	if _, err := DecodeRequest(sendData, NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+	neighborUpdate.Body = append(neighborUpdate.Body, 0x13)
+	args, err := DecodeRequest(neighborUpdate, NodeIDType8Bit)
+	if err != nil || !reflect.DeepEqual(args, &NodeCallbackArgs{NodeID: 5,
+		CallbackID: 0x13}) {
+		t.Fatalf("Bad decode: %+v %v", args, err)
+	}
+
+	// Responses are not requests
+	if _, err := DecodeRequest(&packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeResponse, MessageType: MessageTypeGetVersion},
+		NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
+
+func TestDecodeUnknown(t *testing.T) {
+	// SerialAPISoftReset has a name, but no decoder
+	p := &packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeResponse, MessageType: MessageTypeSerialAPISoftReset,
+		Body: []uint8{0x01}}
+
+	decoded, err := Decode(p, NodeIDType8Bit)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	unknown, ok := decoded.(*Unknown)
+	if !ok {
+		t.Fatalf("Bad decoded type: %T", decoded)
+	}
+
+	if unknown.MessageType != MessageTypeSerialAPISoftReset ||
+		unknown.PacketType != packet.PacketTypeResponse ||
+		!reflect.DeepEqual(unknown.Body, []uint8{0x01}) {
+		t.Fatalf("Bad unknown: %+v", unknown)
+	}
+
+	// The body is a copy
+	p.Body[0] = 0x02
+	if unknown.Body[0] != 0x01 {
+		t.Fatalf("Unknown body is not a copy")
+	}
+
+	// ZWSendData only has a callback decoder
+	p = &packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeResponse, MessageType: MessageTypeZWSendData,
+		Body: []uint8{0x01}}
+	if decoded, err = Decode(p, NodeIDType8Bit); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	} else if _, ok = decoded.(*Unknown); !ok {
+		t.Fatalf("Bad decoded type: %T", decoded)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(&packet.Packet{Preamble: packet.PacketPreambleACK},
+		NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	p := &packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeRequest, MessageType: MessageTypeApplicationCommand,
+		Body: []uint8{0x00, 0x03, 0x05, 0x25}}
+	if decoded, err := Decode(p, NodeIDType8Bit); err == nil || decoded != nil {
+		t.Fatalf("Expected nil and error: %v %v", decoded, err)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	if _, err := Encode(MessageTypeZWSendData, &ZWSendDataMulti{},
+		NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := Encode(MessageTypeZWGetRoutingInfo,
+		&ZWGetRoutingInfo{Neighbors: []uint8{233}}, NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := Encode(0x77, &NoArgs{}, NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := EncodeRequest(MessageTypeGetVersion, &GetVersion{},
+		NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := EncodeRequest(MessageTypeZWSendDataMulti,
+		&ZWSendDataMultiArgs{NodeIDs: make([]uint8, MaxMulticastNodes+1)},
+		NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := Encode(MessageTypeApplicationCommand,
+		&ApplicationCommand{NodeID: 300}, NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+
+	if _, err := Encode(0x01, &Unknown{MessageType: 0x02}, NodeIDType8Bit); err == nil {
+		t.Fatalf("Expected error")
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	const messageType = 0xfe
+
+	if name := MessageTypeName(messageType); name != "0xfe" {
+		t.Fatalf("Bad name: %s", name)
+	}
+
+	RegisterCodec(messageType, Codec{Name: "Test",
+		DecodeCallback: func(p *packet.Packet, nodeIDType uint8) (interface{}, error) {
+			return len(p.Body), nil
+		}})
+	defer func() {
+		codecsMutex.Lock()
+		delete(codecs, messageType)
+		codecsMutex.Unlock()
+	}()
+
+	if name := MessageTypeName(messageType); name != "Test" {
+		t.Fatalf("Bad name: %s", name)
+	}
+
+	decoded, err := Decode(&packet.Packet{Preamble: packet.PacketPreambleSOF,
+		MessageType: messageType, Body: []uint8{0x01, 0x02}}, NodeIDType8Bit)
+	if err != nil || decoded != 2 {
+		t.Fatalf("Bad decode: %v %v", decoded, err)
+	}
+
+	if name := MessageTypeName(MessageTypeZWSendData); name != "ZWSendData" {
+		t.Fatalf("Bad name: %s", name)
+	}
+}
+
+func TestRequestFlow(t *testing.T) {
+	if codec, ok := LookupCodec(MessageTypeGetVersion); !ok || codec.Flow != nil {
+		t.Fatalf("Expected single response flow: %+v", codec.Flow)
+	}
+
+	codec, ok := LookupCodec(MessageTypeZWSendData)
+	if !ok || codec.Flow == nil || !codec.Flow.CallbackID || !codec.Flow.Response ||
+		!codec.Flow.Callback {
+		t.Fatalf("Bad ZWSendData flow: %+v", codec.Flow)
+	}
+
+	codec, ok = LookupCodec(MessageTypeZWRequestNodeNeighborUpdate)
+	if !ok || codec.Flow == nil || codec.Flow.Response ||
+		!codec.Flow.IsIntermediate(NeighborUpdateStarted) ||
+		codec.Flow.IsIntermediate(NeighborUpdateDone) {
+		t.Fatalf("Bad ZWRequestNodeNeighborUpdate flow: %+v", codec.Flow)
+	}
+}
@@ -47,6 +47,135 @@ func MemoryGetIDRequest() *packet.Packet {
 	return &p
 }
 
+// MemoryGetBufferRequest creates a MemoryGetBuffer request packet, which
+// reads length bytes of the application area of the NVM
+func MemoryGetBufferRequest(offset uint16, length uint8) *packet.Packet {
+	// Body: | OFFSET_MSB | OFFSET_LSB | LENGTH |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeMemoryGetBuffer,
+		Body:        []uint8{uint8(offset >> 8), uint8(offset), length}}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// NVMGetIDRequest creates a NVMGetID request packet
+func NVMGetIDRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeNVMGetID}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// NVMExtReadLongBufferRequest creates a NVMExtReadLongBuffer request packet,
+// which reads length bytes of the NVM at the 24 bit offset
+func NVMExtReadLongBufferRequest(offset uint32, length uint16) (*packet.Packet, error) {
+	if offset > 0xffffff {
+		return nil, fmt.Errorf("Invalid offset: 0x%x", offset)
+	}
+
+	// Body: | OFFSET (3) | LENGTH (2) |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeNVMExtReadLongBuffer,
+		Body: []uint8{uint8(offset >> 16), uint8(offset >> 8), uint8(offset),
+			uint8(length >> 8), uint8(length)}}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p, nil
+}
+
+// NVMExtWriteLongBufferRequest creates a NVMExtWriteLongBuffer request packet,
+// which writes the data to the NVM at the 24 bit offset
+func NVMExtWriteLongBufferRequest(offset uint32, data []uint8) (*packet.Packet, error) {
+	if offset > 0xffffff {
+		return nil, fmt.Errorf("Invalid offset: 0x%x", offset)
+	}
+
+	// Body: | OFFSET (3) | LENGTH (2) | DATA |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeNVMExtWriteLongBuffer,
+		Body: append([]uint8{uint8(offset >> 16), uint8(offset >> 8), uint8(offset),
+			uint8(len(data) >> 8), uint8(len(data))}, data...)}
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// nvmBackupRestoreRequest creates a NVMBackupRestore request packet of the
+// operation
+func nvmBackupRestoreRequest(operation uint8, length uint8, offset uint16,
+	data []uint8) (*packet.Packet, error) {
+	// Body: | OPERATION | LENGTH | OFFSET_MSB | OFFSET_LSB | DATA |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeNVMBackupRestore,
+		Body: append([]uint8{operation, length, uint8(offset >> 8), uint8(offset)},
+			data...)}
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// NVMBackupRestoreOpenRequest creates a NVMBackupRestore request packet,
+// which opens the NVM for reading and writing, and stops the radio
+func NVMBackupRestoreOpenRequest() *packet.Packet {
+	p, err := nvmBackupRestoreRequest(NVMBackupRestoreOperationOpen, 0, 0, nil)
+	if err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+	return p
+}
+
+// NVMBackupRestoreReadRequest creates a NVMBackupRestore request packet,
+// which reads length bytes of the NVM at the offset
+func NVMBackupRestoreReadRequest(offset uint16, length uint8) *packet.Packet {
+	p, err := nvmBackupRestoreRequest(NVMBackupRestoreOperationRead, length, offset, nil)
+	if err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+	return p
+}
+
+// NVMBackupRestoreWriteRequest creates a NVMBackupRestore request packet,
+// which writes the data to the NVM at the offset
+func NVMBackupRestoreWriteRequest(offset uint16, data []uint8) (*packet.Packet, error) {
+	if len(data) > 0xff {
+		return nil, fmt.Errorf("Data is too long: %d", len(data))
+	}
+	return nvmBackupRestoreRequest(NVMBackupRestoreOperationWrite, uint8(len(data)),
+		offset, data)
+}
+
+// NVMBackupRestoreCloseRequest creates a NVMBackupRestore request packet,
+// which closes the NVM, and restarts the radio
+func NVMBackupRestoreCloseRequest() *packet.Packet {
+	p, err := nvmBackupRestoreRequest(NVMBackupRestoreOperationClose, 0, 0, nil)
+	if err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+	return p
+}
+
 // SerialAPIGetInitDataRequest creates a SerialAPIGetInitData request packet
 func SerialAPIGetInitDataRequest() *packet.Packet {
 	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
@@ -60,6 +189,103 @@ func SerialAPIGetInitDataRequest() *packet.Packet {
 	return &p
 }
 
+// SerialAPISoftResetRequest creates a SerialAPISoftReset request packet
+func SerialAPISoftResetRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeSerialAPISoftReset}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// serialAPISetupRequest creates a SerialAPISetup request packet of the
+// command
+func serialAPISetupRequest(command uint8, args ...uint8) *packet.Packet {
+	// Body: | COMMAND | ARGS |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeSerialAPISetup,
+		Body:        append([]uint8{command}, args...)}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// SerialAPISetupSetTxStatusReportRequest creates a SerialAPISetup request
+// packet, which enables or disables the transmit report in ZWSendData
+// callbacks
+func SerialAPISetupSetTxStatusReportRequest(enable bool) *packet.Packet {
+	value := uint8(0x00)
+	if enable {
+		value = 0xff
+	}
+	return serialAPISetupRequest(SerialAPISetupCommandSetTxStatusReport, value)
+}
+
+// SerialAPISetupGetRFRegionRequest creates a SerialAPISetup request packet,
+// which gets the RF region
+func SerialAPISetupGetRFRegionRequest() *packet.Packet {
+	return serialAPISetupRequest(SerialAPISetupCommandGetRFRegion)
+}
+
+// SerialAPISetupSetRFRegionRequest creates a SerialAPISetup request packet,
+// which sets the RF region. The region is used after a soft reset.
+func SerialAPISetupSetRFRegionRequest(region uint8) *packet.Packet {
+	return serialAPISetupRequest(SerialAPISetupCommandSetRFRegion, region)
+}
+
+// SerialAPISetupGetPowerLevelRequest creates a SerialAPISetup request packet,
+// which gets the transmit power level
+func SerialAPISetupGetPowerLevelRequest() *packet.Packet {
+	return serialAPISetupRequest(SerialAPISetupCommandGetPowerLevel)
+}
+
+// SerialAPISetupSetPowerLevelRequest creates a SerialAPISetup request packet,
+// which sets the normal transmit power level, and the measured output power
+// at 0 dBm, both in deci dBm
+func SerialAPISetupSetPowerLevelRequest(normal int8, measured0dBm int8) *packet.Packet {
+	return serialAPISetupRequest(SerialAPISetupCommandSetPowerLevel,
+		uint8(normal), uint8(measured0dBm))
+}
+
+// SerialAPISetupSetNodeIDTypeRequest creates a SerialAPISetup request packet,
+// which sets the node ID type of all following frames
+func SerialAPISetupSetNodeIDTypeRequest(nodeIDType uint8) (*packet.Packet, error) {
+	if nodeIDType != NodeIDType8Bit && nodeIDType != NodeIDType16Bit {
+		return nil, fmt.Errorf("Invalid node ID type: 0x%02x", nodeIDType)
+	}
+	return serialAPISetupRequest(SerialAPISetupCommandSetNodeIDType, nodeIDType), nil
+}
+
+// SerialAPIGetLRNodesRequest creates a SerialAPIGetLRNodes request packet,
+// which gets one segment of the Long Range node list
+func SerialAPIGetLRNodesRequest(segment uint8) *packet.Packet {
+	// Body: | SEGMENT |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeSerialAPIGetLRNodes,
+		Body:        []uint8{segment}}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// SerialAPISetupGetMaxPayloadSizeRequest creates a SerialAPISetup request
+// packet, which gets the maximum ZWSendData payload size
+func SerialAPISetupGetMaxPayloadSizeRequest() *packet.Packet {
+	return serialAPISetupRequest(SerialAPISetupCommandGetMaxPayloadSize)
+}
+
 // SerialAPIGetCapabilitiesRequest creates a  SerialAPIGetCapabilities request
 // packet
 func SerialAPIGetCapabilitiesRequest() *packet.Packet {
@@ -88,17 +314,17 @@ func ZWGetControllerCapabilitiesRequest() *packet.Packet {
 	return &p
 }
 
-// ZWGetNodeProtocolInfoRequest creates a ZWGetNodeProtocolInfo
-// request packet
-func ZWGetNodeProtocolInfoRequest(nodeID uint8) (*packet.Packet, error) {
-	if !IsValidNodeID(nodeID) {
-		return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
+// ZWGetRandomRequest creates a ZWGetRandom request packet for count random
+// bytes, which must be in the range of [1, MaxRandomBytes]
+func ZWGetRandomRequest(count uint8) (*packet.Packet, error) {
+	if count < 1 || count > MaxRandomBytes {
+		return nil, fmt.Errorf("Invalid count: %d", count)
 	}
 
 	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
 		PacketType:  packet.PacketTypeRequest,
-		MessageType: MessageTypeZWGetNodeProtocolInfo,
-		Body:        []uint8{nodeID}}
+		MessageType: MessageTypeZWGetRandom,
+		Body:        []uint8{count}}
 
 	if err := p.Update(); err != nil {
 		panic(fmt.Sprintf("This should never fail: %v", err))
@@ -107,17 +333,121 @@ func ZWGetNodeProtocolInfoRequest(nodeID uint8) (*packet.Packet, error) {
 	return &p, nil
 }
 
-// ZWRequestNodeInfoRequest creates a MessageTypeZWRequestNodeInfo
+// ZWGetSUCNodeIDRequest creates a ZWGetSUCNodeID request packet
+func ZWGetSUCNodeIDRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWGetSUCNodeID}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// ZWSetSUCNodeIDRequest creates a ZWSetSUCNodeID request packet, which
+// enables or disables the node as the SUC, and optionally as the SIS. The
+// controller does not send a callback when it sets itself, so callback must
+// be false in that case, otherwise the controller appends the callback id.
+func ZWSetSUCNodeIDRequest(nodeID uint16, nodeIDType uint8, enable bool, sis bool,
+	callback bool) (*packet.Packet, error) {
+	p, err := classicNodeIDRequest(MessageTypeZWSetSUCNodeID, nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Body: | NODE_ID | ENABLE | TRANSMIT_OPTIONS | CAPABILITIES | CALLBACK_ID |
+	state := uint8(0x00)
+	if enable {
+		state = 0x01
+	}
+	capabilities := uint8(0x00)
+	if sis {
+		capabilities = 0x01
+	}
+	p.Body = append(p.Body, state, TransmitOptionLowPower, capabilities)
+	if !callback {
+		p.Body = append(p.Body, 0x00)
+	}
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return p, nil
+}
+
+// ZWRequestNetworkUpdateRequest creates a ZWRequestNetworkUpdate request
+// packet, which gets the network changes from the SUC. The controller appends
+// the callback id.
+func ZWRequestNetworkUpdateRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWRequestNetworkUpdate}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// addNodeToNetworkRequest creates a ZWAddNodeToNetwork request packet
+func addNodeToNetworkRequest(body []uint8) *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWAddNodeToNetwork,
+		Body:        body}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// ZWAddNodeToNetworkSmartStartRequest creates a ZWAddNodeToNetwork request
+// packet, which makes the controller listen for SmartStart inclusion requests.
+// The controller appends the callback id.
+func ZWAddNodeToNetworkSmartStartRequest() *packet.Packet {
+	// Body: | MODE | CALLBACK_ID |
+	return addNodeToNetworkRequest([]uint8{AddNodeSmartStart |
+		AddNodeOptionHighPower | AddNodeOptionNetworkWide})
+}
+
+// ZWAddNodeToNetworkHomeIDRequest creates a ZWAddNodeToNetwork request
+// packet, which includes the SmartStart node with the HomeIDs of its DSK. The
+// controller inserts the callback id after the mode.
+func ZWAddNodeToNetworkHomeIDRequest(nwiHomeID uint32, authHomeID uint32) *packet.Packet {
+	// Body: | MODE | CALLBACK_ID | NWI_HOME_ID | AUTH_HOME_ID |
+	return addNodeToNetworkRequest([]uint8{
+		AddNodeHomeID | AddNodeOptionHighPower | AddNodeOptionNetworkWide,
+		uint8(nwiHomeID >> 24), uint8(nwiHomeID >> 16), uint8(nwiHomeID >> 8),
+		uint8(nwiHomeID),
+		uint8(authHomeID >> 24), uint8(authHomeID >> 16), uint8(authHomeID >> 8),
+		uint8(authHomeID)})
+}
+
+// ZWAddNodeToNetworkStopRequest creates a ZWAddNodeToNetwork request packet,
+// which stops the inclusion. The controller appends the callback id.
+func ZWAddNodeToNetworkStopRequest() *packet.Packet {
+	// Body: | MODE | CALLBACK_ID |
+	return addNodeToNetworkRequest([]uint8{AddNodeStop})
+}
+
+// controllerChangeRequest creates a ZWControllerChange or ZWNewController
 // request packet
-func ZWRequestNodeInfoRequest(nodeID uint8) (*packet.Packet, error) {
-	if !IsValidNodeID(nodeID) {
-		return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
+func controllerChangeRequest(messageType uint8, mode uint8) (*packet.Packet, error) {
+	if mode != ControllerChangeStart && mode != ControllerChangeStop &&
+		mode != ControllerChangeStopFailed {
+		return nil, fmt.Errorf("Invalid mode: 0x%02x", mode)
 	}
 
+	// Body: | MODE | CALLBACK_ID |
 	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
 		PacketType:  packet.PacketTypeRequest,
-		MessageType: MessageTypeZWRequestNodeInfo,
-		Body:        []uint8{nodeID}}
+		MessageType: messageType,
+		Body:        []uint8{mode}}
 
 	if err := p.Update(); err != nil {
 		panic(fmt.Sprintf("This should never fail: %v", err))
@@ -126,13 +456,94 @@ func ZWRequestNodeInfoRequest(nodeID uint8) (*packet.Packet, error) {
 	return &p, nil
 }
 
+// ZWControllerChangeRequest creates a ZWControllerChange request packet, which
+// starts or stops handing the primary controller role to another controller.
+// The controller appends the callback id.
+func ZWControllerChangeRequest(mode uint8) (*packet.Packet, error) {
+	return controllerChangeRequest(MessageTypeZWControllerChange, mode)
+}
+
+// ZWNewControllerRequest creates a ZWNewController request packet, which is
+// the ZWControllerChange request of older controllers. The controller appends
+// the callback id.
+func ZWNewControllerRequest(mode uint8) (*packet.Packet, error) {
+	return controllerChangeRequest(MessageTypeZWNewController, mode)
+}
+
+// ZWSetLearnModeRequest creates a ZWSetLearnMode request packet, which
+// enters or leaves the learn mode, to join or leave another network. The
+// controller appends the callback id.
+func ZWSetLearnModeRequest(mode uint8) (*packet.Packet, error) {
+	if mode > LearnModeNetworkWideExclusion {
+		return nil, fmt.Errorf("Invalid mode: 0x%02x", mode)
+	}
+
+	// Body: | MODE | CALLBACK_ID |
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWSetLearnMode,
+		Body:        []uint8{mode}}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p, nil
+}
+
+// ZWReplicationCommandCompleteRequest creates a ZWReplicationCommandComplete
+// request packet, which acknowledges a received controller replication
+// command
+func ZWReplicationCommandCompleteRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWReplicationCommandComplete}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// ZWSetDefaultRequest creates a ZWSetDefault request packet, which resets the
+// controller to its factory default state. The controller appends the
+// callback id.
+func ZWSetDefaultRequest() *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: MessageTypeZWSetDefault}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p
+}
+
+// ZWGetNodeProtocolInfoRequest creates a ZWGetNodeProtocolInfo
+// request packet
+func ZWGetNodeProtocolInfoRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return nodeIDRequest(MessageTypeZWGetNodeProtocolInfo, nodeID, nodeIDType)
+}
+
+// ZWRequestNodeInfoRequest creates a MessageTypeZWRequestNodeInfo
+// request packet
+func ZWRequestNodeInfoRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return nodeIDRequest(MessageTypeZWRequestNodeInfo, nodeID, nodeIDType)
+}
+
 // ZWSendDataRequest creates a ZWSendData request packet
-func ZWSendDataRequest(nodeID uint8, commandClass uint8, payload []uint8,
-	transmitOptions uint8, callbackID uint8) (*packet.Packet, error) {
+func ZWSendDataRequest(nodeID uint16, nodeIDType uint8, commandClass uint8,
+	payload []uint8, transmitOptions uint8, callbackID uint8) (*packet.Packet, error) {
 
 	if !IsValidNodeID(nodeID) {
 		return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
 	}
+	data, err := encodeNodeID(nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
 
 	p := packet.Packet{}
 	p.Preamble = packet.PacketPreambleSOF
@@ -141,7 +552,8 @@ func ZWSendDataRequest(nodeID uint8, commandClass uint8, payload []uint8,
 
 	// Body: | NODE_ID | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
 	//       | PAYLOAD | TRANSMIT_OPTIONS | CALLBACK_ID |
-	data := []uint8{nodeID, 1 + uint8(len(payload)), commandClass}
+	// NODE_ID is one or two bytes of the node ID type
+	data = append(data, 1+uint8(len(payload)), commandClass)
 	data = append(data, payload...)
 	data = append(data, transmitOptions)
 	// TODO: is this accurate?
@@ -156,3 +568,187 @@ func ZWSendDataRequest(nodeID uint8, commandClass uint8, payload []uint8,
 
 	return &p, nil
 }
+
+// ZWSendDataBroadcastRequest creates a ZWSendData request packet to all
+// classic nodes
+func ZWSendDataBroadcastRequest(nodeIDType uint8, commandClass uint8, payload []uint8,
+	transmitOptions uint8) (*packet.Packet, error) {
+
+	data, err := encodeNodeID(uint16(NodeIDBroadcast), nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	p := packet.Packet{}
+	p.Preamble = packet.PacketPreambleSOF
+	p.PacketType = packet.PacketTypeRequest
+	p.MessageType = MessageTypeZWSendData
+
+	// Body: | NODE_ID | LENGTH_OF_PAYLOAD + 1 | COMMAND_CLASS |
+	//       | PAYLOAD | TRANSMIT_OPTIONS |
+	data = append(data, 1+uint8(len(payload)), commandClass)
+	data = append(data, payload...)
+	data = append(data, transmitOptions)
+	p.Body = data
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// ZWSendDataRequestNodeID returns the destination node ID of a ZWSendData
+// request packet
+func ZWSendDataRequestNodeID(p *packet.Packet, nodeIDType uint8) (uint16, error) {
+	if p.MessageType != MessageTypeZWSendData {
+		return 0, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	nodeID, _, err := decodeNodeID(p.Body, nodeIDType)
+	return nodeID, err
+}
+
+// ZWSendDataMultiRequest creates a ZWSendDataMulti request packet to at most
+// MaxMulticastNodes classic nodes
+func ZWSendDataMultiRequest(nodeIDs []uint8, commandClass uint8, payload []uint8,
+	transmitOptions uint8) (*packet.Packet, error) {
+
+	if len(nodeIDs) == 0 || len(nodeIDs) > MaxMulticastNodes {
+		return nil, fmt.Errorf("Number of nodes %d not in range [1, %d]",
+			len(nodeIDs), MaxMulticastNodes)
+	}
+
+	for _, nodeID := range nodeIDs {
+		if !IsClassicNodeID(uint16(nodeID)) {
+			return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
+		}
+	}
+
+	p := packet.Packet{}
+	p.Preamble = packet.PacketPreambleSOF
+	p.PacketType = packet.PacketTypeRequest
+	p.MessageType = MessageTypeZWSendDataMulti
+
+	// Body: | NUMBER_OF_NODES | NODE_IDS | LENGTH_OF_PAYLOAD + 1 |
+	//       | COMMAND_CLASS | PAYLOAD | TRANSMIT_OPTIONS |
+	data := []uint8{uint8(len(nodeIDs))}
+	data = append(data, nodeIDs...)
+	data = append(data, 1+uint8(len(payload)), commandClass)
+	data = append(data, payload...)
+	data = append(data, transmitOptions)
+	p.Body = data
+
+	if err := p.Update(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// nodeIDRequest creates a request packet with the nodeID as the only body
+// field
+func nodeIDRequest(messageType uint8, nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	if !IsValidNodeID(nodeID) {
+		return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
+	}
+	body, err := encodeNodeID(nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeRequest,
+		MessageType: messageType,
+		Body:        body}
+
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return &p, nil
+}
+
+// classicNodeIDRequest creates a request packet with the nodeID as the only
+// body field, for requests about routes, which Long Range nodes do not have
+func classicNodeIDRequest(messageType uint8, nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	if !IsClassicNodeID(nodeID) {
+		return nil, fmt.Errorf("Invalid classic nodeID: 0x%02x", nodeID)
+	}
+	return nodeIDRequest(messageType, nodeID, nodeIDType)
+}
+
+// ZWAssignReturnRouteRequest creates a ZWAssignReturnRoute request packet,
+// which assigns the node a return route to the destination node. The
+// controller appends the callback id.
+func ZWAssignReturnRouteRequest(nodeID uint16, destinationNodeID uint16,
+	nodeIDType uint8) (*packet.Packet, error) {
+	if !IsClassicNodeID(destinationNodeID) {
+		return nil, fmt.Errorf("Invalid destinationNodeID: 0x%02x", destinationNodeID)
+	}
+
+	p, err := classicNodeIDRequest(MessageTypeZWAssignReturnRoute, nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := encodeNodeID(destinationNodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	p.Body = append(p.Body, destination...)
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return p, nil
+}
+
+// ZWAssignSUCReturnRouteRequest creates a ZWAssignSUCReturnRoute request
+// packet, which assigns the node a return route to the SUC. The controller
+// appends the callback id.
+func ZWAssignSUCReturnRouteRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return classicNodeIDRequest(MessageTypeZWAssignSUCReturnRoute, nodeID, nodeIDType)
+}
+
+// ZWDeleteReturnRouteRequest creates a ZWDeleteReturnRoute request packet,
+// which deletes all return routes of the node. The controller appends the
+// callback id.
+func ZWDeleteReturnRouteRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return classicNodeIDRequest(MessageTypeZWDeleteReturnRoute, nodeID, nodeIDType)
+}
+
+// ZWIsFailedNodeRequest creates a ZWIsFailedNode request packet
+func ZWIsFailedNodeRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return nodeIDRequest(MessageTypeZWIsFailedNode, nodeID, nodeIDType)
+}
+
+// ZWGetRoutingInfoRequest creates a ZWGetRoutingInfo request packet
+func ZWGetRoutingInfoRequest(nodeID uint16, nodeIDType uint8, removeBad bool,
+	removeNonRepeaters bool) (*packet.Packet, error) {
+	p, err := classicNodeIDRequest(MessageTypeZWGetRoutingInfo, nodeID, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Body: | NODE_ID | REMOVE_BAD | REMOVE_NON_REPEATERS | FUNC_ID |
+	flags := []uint8{0x00, 0x00}
+	if removeBad {
+		flags[0] = 0x01
+	}
+	if removeNonRepeaters {
+		flags[1] = 0x01
+	}
+	p.Body = append(p.Body, flags[0], flags[1], 0x00)
+	if err := p.Update(); err != nil {
+		panic(fmt.Sprintf("This should never fail: %v", err))
+	}
+
+	return p, nil
+}
+
+// ZWRequestNodeNeighborUpdateRequest creates a ZWRequestNodeNeighborUpdate
+// request packet. The controller appends the callback id.
+func ZWRequestNodeNeighborUpdateRequest(nodeID uint16, nodeIDType uint8) (*packet.Packet, error) {
+	return classicNodeIDRequest(MessageTypeZWRequestNodeNeighborUpdate, nodeID, nodeIDType)
+}
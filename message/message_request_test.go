@@ -17,8 +17,8 @@ limitations under the License.
 */
 
 import (
-	// "bytes"
-	// "github.com/cybojanek/gozwave/packet"
+	"bytes"
+	"github.com/cybojanek/gozwave/packet"
 	"testing"
 )
 
@@ -28,6 +28,12 @@ func TestSerialAPIGetInitDataRequest(t *testing.T) {
 	}
 }
 
+func TestSerialAPISoftResetRequest(t *testing.T) {
+	if p := SerialAPISoftResetRequest(); p == nil {
+		t.Logf("Expected non nil packet")
+	}
+}
+
 func TestSerialAPIGetCapabilitiesRequest(t *testing.T) {
 	if p := SerialAPIGetCapabilitiesRequest(); p == nil {
 		t.Logf("Expected non nil packet")
@@ -42,8 +48,8 @@ func TestZWGetControllerCapabilitiesRequest(t *testing.T) {
 
 func TestZWGetNodeProtocolInfoRequest(t *testing.T) {
 	for i := 0; i < 0xff; i++ {
-		nodeID := uint8(i)
-		p, err := ZWGetNodeProtocolInfoRequest(nodeID)
+		nodeID := uint16(i)
+		p, err := ZWGetNodeProtocolInfoRequest(nodeID, NodeIDType8Bit)
 		if IsValidNodeID(nodeID) {
 			if p == nil || err != nil {
 				t.Logf("Expected non nil packet and nil error: %v %v", p, err)
@@ -54,4 +60,333 @@ func TestZWGetNodeProtocolInfoRequest(t *testing.T) {
 			}
 		}
 	}
+
+	p, err := ZWGetNodeProtocolInfoRequest(0x0101, NodeIDType16Bit)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x01, 0x01}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	// Long Range nodes don't fit 8 bit node IDs
+	if p, err := ZWGetNodeProtocolInfoRequest(0x0101, NodeIDType8Bit); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+
+	if p, err := ZWGetNodeProtocolInfoRequest(0x05, 0x00); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+}
+
+func TestRouteRequests(t *testing.T) {
+	p, err := ZWAssignReturnRouteRequest(5, 1, NodeIDType8Bit)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	if p.MessageType != MessageTypeZWAssignReturnRoute || len(p.Body) != 2 ||
+		p.Body[0] != 5 || p.Body[1] != 1 {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+
+	if p, err := ZWAssignReturnRouteRequest(5, 0, NodeIDType8Bit); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+
+	p, err = ZWAssignReturnRouteRequest(5, 1, NodeIDType16Bit)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x00, 5, 0x00, 1}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	p, err = ZWGetRoutingInfoRequest(7, NodeIDType8Bit, true, false)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	if p.MessageType != MessageTypeZWGetRoutingInfo || len(p.Body) != 4 ||
+		p.Body[0] != 7 || p.Body[1] != 1 || p.Body[2] != 0 {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+
+	requests := map[uint8]func(uint16, uint8) (*packet.Packet, error){
+		MessageTypeZWAssignSUCReturnRoute:      ZWAssignSUCReturnRouteRequest,
+		MessageTypeZWDeleteReturnRoute:         ZWDeleteReturnRouteRequest,
+		MessageTypeZWRequestNodeNeighborUpdate: ZWRequestNodeNeighborUpdateRequest,
+		MessageTypeZWIsFailedNode:              ZWIsFailedNodeRequest,
+	}
+	for messageType, request := range requests {
+		p, err := request(9, NodeIDType8Bit)
+		if p == nil || err != nil {
+			t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+			continue
+		}
+		if p.MessageType != messageType || len(p.Body) != 1 || p.Body[0] != 9 {
+			t.Errorf("Unexpected packet: %v", p)
+		}
+
+		if p, err := request(9, NodeIDType16Bit); p == nil || err != nil {
+			t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		} else if !bytes.Equal(p.Body, []uint8{0x00, 9}) {
+			t.Errorf("Unexpected Body: %v", p.Body)
+		}
+
+		if p, err := request(0, NodeIDType8Bit); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+
+	// Long Range nodes have no routes
+	for _, request := range []func(uint16, uint8) (*packet.Packet, error){
+		ZWAssignSUCReturnRouteRequest, ZWDeleteReturnRouteRequest,
+		ZWRequestNodeNeighborUpdateRequest} {
+		if p, err := request(0x0101, NodeIDType16Bit); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+
+	if p, err := ZWIsFailedNodeRequest(0x0101, NodeIDType16Bit); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	}
+}
+
+func TestZWSendDataRequest(t *testing.T) {
+	p, err := ZWSendDataRequest(0x0102, NodeIDType16Bit, 0x25, []uint8{0x01, 0xff}, 0x05, 0x07)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	expected := []uint8{0x01, 0x02, 3, 0x25, 0x01, 0xff, 0x05, 0x07}
+	if p.MessageType != MessageTypeZWSendData || !bytes.Equal(expected, p.Body) {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+
+	if nodeID, err := ZWSendDataRequestNodeID(p, NodeIDType16Bit); nodeID != 0x0102 || err != nil {
+		t.Errorf("Unexpected nodeID: %d %v", nodeID, err)
+	}
+
+	p, err = ZWSendDataRequest(0x05, NodeIDType8Bit, 0x25, []uint8{0x01, 0xff}, 0x05, 0x07)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	expected = []uint8{0x05, 3, 0x25, 0x01, 0xff, 0x05, 0x07}
+	if !bytes.Equal(expected, p.Body) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if nodeID, err := ZWSendDataRequestNodeID(p, NodeIDType8Bit); nodeID != 0x05 || err != nil {
+		t.Errorf("Unexpected nodeID: %d %v", nodeID, err)
+	}
+
+	for _, nodeID := range []uint16{0, MaxClassicNodeID + 1, MaxLongRangeNodeID + 1} {
+		if p, err := ZWSendDataRequest(nodeID, NodeIDType16Bit, 0x25, nil, 0x05,
+			0x07); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+
+	if p, err := ZWSendDataRequest(0x0102, NodeIDType8Bit, 0x25, nil, 0x05,
+		0x07); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+}
+
+func TestZWSendDataMultiRequest(t *testing.T) {
+	p, err := ZWSendDataMultiRequest([]uint8{2, 3}, 0x25, []uint8{0x01, 0x00}, 0x05)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	expected := []uint8{2, 2, 3, 3, 0x25, 0x01, 0x00, 0x05}
+	if p.MessageType != MessageTypeZWSendDataMulti || !bytes.Equal(expected, p.Body) {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+
+	tooMany := make([]uint8, MaxMulticastNodes+1)
+	for i := range tooMany {
+		tooMany[i] = uint8(i + 1)
+	}
+	for _, nodeIDs := range [][]uint8{{}, {2, 0}, tooMany} {
+		if p, err := ZWSendDataMultiRequest(nodeIDs, 0x25, nil, 0x05); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+
+	p, err = ZWSendDataBroadcastRequest(NodeIDType8Bit, 0x25, []uint8{0x01, 0xff}, 0x05)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	expected = []uint8{NodeIDBroadcast, 3, 0x25, 0x01, 0xff, 0x05}
+	if p.MessageType != MessageTypeZWSendData || !bytes.Equal(expected, p.Body) {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+
+	p, err = ZWSendDataBroadcastRequest(NodeIDType16Bit, 0x25, []uint8{0x01, 0xff}, 0x05)
+	if p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+		t.FailNow()
+	}
+	expected = []uint8{0x00, NodeIDBroadcast, 3, 0x25, 0x01, 0xff, 0x05}
+	if !bytes.Equal(expected, p.Body) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+}
+
+func TestSerialAPISetupRequests(t *testing.T) {
+	if p := SerialAPISetupSetTxStatusReportRequest(true); !bytes.Equal(p.Body, []uint8{0x02, 0xff}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p := SerialAPISetupSetRFRegionRequest(RFRegionUSA); !bytes.Equal(p.Body, []uint8{0x40, 0x01}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p := SerialAPISetupSetPowerLevelRequest(-10, 33); !bytes.Equal(p.Body, []uint8{0x04, 0xf6, 0x21}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p := SerialAPISetupGetMaxPayloadSizeRequest(); !bytes.Equal(p.Body, []uint8{0x10}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := SerialAPISetupSetNodeIDTypeRequest(NodeIDType16Bit); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x80, 0x02}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := SerialAPISetupSetNodeIDTypeRequest(0x03); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+}
+
+func TestSerialAPIGetLRNodesRequest(t *testing.T) {
+	p := SerialAPIGetLRNodesRequest(0x02)
+	if p.MessageType != MessageTypeSerialAPIGetLRNodes || !bytes.Equal(p.Body, []uint8{0x02}) {
+		t.Errorf("Unexpected packet: %v", p)
+	}
+}
+
+func TestZWGetRandomRequest(t *testing.T) {
+	if p, err := ZWGetRandomRequest(MaxRandomBytes); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{MaxRandomBytes}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	for _, count := range []uint8{0, MaxRandomBytes + 1} {
+		if p, err := ZWGetRandomRequest(count); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+}
+
+func TestNVMRequests(t *testing.T) {
+	if p, err := NVMExtReadLongBufferRequest(0x012345, 0x40); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x01, 0x23, 0x45, 0x00, 0x40}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := NVMExtReadLongBufferRequest(0x1000000, 0x40); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+
+	if p, err := NVMExtWriteLongBufferRequest(0x10, []uint8{0xaa, 0xbb}); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x00, 0x00, 0x10, 0x00, 0x02, 0xaa, 0xbb}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p := NVMBackupRestoreReadRequest(0x0102, 0x30); !bytes.Equal(p.Body,
+		[]uint8{NVMBackupRestoreOperationRead, 0x30, 0x01, 0x02}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := NVMBackupRestoreWriteRequest(0x0102, []uint8{0xaa}); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{NVMBackupRestoreOperationWrite, 0x01, 0x01, 0x02, 0xaa}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	// Too long for a packet
+	if p, err := NVMBackupRestoreWriteRequest(0, make([]uint8, 250)); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+}
+
+func TestZWSetSUCNodeIDRequest(t *testing.T) {
+	if p, err := ZWSetSUCNodeIDRequest(0x01, NodeIDType8Bit, true, true, false); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x01, 0x01, TransmitOptionLowPower, 0x01, 0x00}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := ZWSetSUCNodeIDRequest(0x05, NodeIDType8Bit, false, false, true); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x05, 0x00, TransmitOptionLowPower, 0x00}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := ZWSetSUCNodeIDRequest(0x05, NodeIDType16Bit, false, false, true); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{0x00, 0x05, 0x00, TransmitOptionLowPower, 0x00}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	for _, nodeID := range []uint16{0x00, 0x0101} {
+		if p, err := ZWSetSUCNodeIDRequest(nodeID, NodeIDType16Bit, true, true, true); p != nil || err == nil {
+			t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+		}
+	}
+}
+
+func TestZWAddNodeToNetworkRequest(t *testing.T) {
+	if p := ZWAddNodeToNetworkSmartStartRequest(); !bytes.Equal(p.Body, []uint8{0xc9}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	p := ZWAddNodeToNetworkHomeIDRequest(0xfa5b829a, 0x12e67ea9)
+	if !bytes.Equal(p.Body, []uint8{0xc8, 0xfa, 0x5b, 0x82, 0x9a, 0x12, 0xe6, 0x7e, 0xa9}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p := ZWAddNodeToNetworkStopRequest(); !bytes.Equal(p.Body, []uint8{AddNodeStop}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+}
+
+func TestZWControllerChangeRequest(t *testing.T) {
+	if p, err := ZWControllerChangeRequest(ControllerChangeStart); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if p.MessageType != MessageTypeZWControllerChange ||
+		!bytes.Equal(p.Body, []uint8{ControllerChangeStart}) {
+		t.Errorf("Unexpected packet: %+v", p)
+	}
+
+	if p, err := ZWNewControllerRequest(ControllerChangeStopFailed); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if p.MessageType != MessageTypeZWNewController ||
+		!bytes.Equal(p.Body, []uint8{ControllerChangeStopFailed}) {
+		t.Errorf("Unexpected packet: %+v", p)
+	}
+
+	if p, err := ZWControllerChangeRequest(0x01); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
+}
+
+func TestZWSetLearnModeRequest(t *testing.T) {
+	if p, err := ZWSetLearnModeRequest(LearnModeNetworkWideInclusion); p == nil || err != nil {
+		t.Errorf("Expected non nil packet and nil error: %v %v", p, err)
+	} else if !bytes.Equal(p.Body, []uint8{LearnModeNetworkWideInclusion}) {
+		t.Errorf("Unexpected Body: %v", p.Body)
+	}
+
+	if p, err := ZWSetLearnModeRequest(LearnModeNetworkWideExclusion + 1); p != nil || err == nil {
+		t.Errorf("Expected nil packet and non nil error: %v %v", p, err)
+	}
 }
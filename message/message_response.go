@@ -25,23 +25,34 @@ import (
 
 // ApplicationCommandResponse parses a ApplicationCommand response
 // packet
-func ApplicationCommandResponse(p *packet.Packet) (*ApplicationCommand, error) {
+func ApplicationCommandResponse(p *packet.Packet, nodeIDType uint8) (*ApplicationCommand, error) {
 	if p.MessageType != MessageTypeApplicationCommand {
 		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
 	}
 
-	message := ApplicationCommand{Status: p.Body[0], NodeID: p.Body[1]}
+	// Body: | STATUS | NODE_ID | LENGTH | PAYLOAD |
+	if len(p.Body) < 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	nodeID, nodeIDLength, err := decodeNodeID(p.Body[1:], nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	offset := 1 + nodeIDLength
+	if len(p.Body) < offset+1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := ApplicationCommand{Status: p.Body[0], NodeID: nodeID}
 
 	// This should match up to the rest of the packet length
-	payloadLength := p.Body[2]
-	if len(p.Body)-3 != int(payloadLength) {
+	payloadLength := p.Body[offset]
+	if len(p.Body)-offset-1 != int(payloadLength) {
 		return nil, fmt.Errorf("Bad payloadLength: %d", payloadLength)
 	}
 
-	message.Body = make([]uint8, len(p.Body)-3)
-	for i, x := range p.Body[3:len(p.Body)] {
-		message.Body[i] = x
-	}
+	message.Body = make([]uint8, payloadLength)
+	copy(message.Body, p.Body[offset+1:])
 
 	return &message, nil
 }
@@ -69,17 +80,102 @@ func MemoryGetIDResponse(p *packet.Packet) (*MemoryGetID, error) {
 		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
 	}
 
-	if len(p.Body) != 5 {
-		return nil, fmt.Errorf("Bad Body length: %d != 5", len(p.Body))
-	}
-
+	// Body: | HOME_ID | NODE_ID |, where NODE_ID is two bytes in the 16 bit
+	// node ID type
 	id := MemoryGetID{}
+	switch len(p.Body) {
+	case 5:
+		id.NodeID = uint16(p.Body[4])
+	case 6:
+		id.NodeID = binary.BigEndian.Uint16(p.Body[4:6])
+	default:
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
 	id.HomeID = binary.BigEndian.Uint32(p.Body[0:4])
-	id.NodeID = p.Body[4]
 
 	return &id, nil
 }
 
+// MemoryGetBufferResponse parses a MemoryGetBuffer response packet
+func MemoryGetBufferResponse(p *packet.Packet) (*MemoryGetBuffer, error) {
+	if p.MessageType != MessageTypeMemoryGetBuffer {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	message := MemoryGetBuffer{Data: make([]uint8, len(p.Body))}
+	copy(message.Data, p.Body)
+
+	return &message, nil
+}
+
+// NVMBackupRestoreResponse parses a NVMBackupRestore response packet
+func NVMBackupRestoreResponse(p *packet.Packet) (*NVMBackupRestore, error) {
+	if p.MessageType != MessageTypeNVMBackupRestore {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | STATUS | LENGTH | OFFSET_MSB | OFFSET_LSB | DATA |
+	if len(p.Body) < 4 || len(p.Body) < 4+int(p.Body[1]) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := NVMBackupRestore{Status: p.Body[0],
+		Offset: binary.BigEndian.Uint16(p.Body[2:4]),
+		Data:   make([]uint8, p.Body[1])}
+	copy(message.Data, p.Body[4:])
+
+	return &message, nil
+}
+
+// NVMExtReadLongBufferResponse parses a NVMExtReadLongBuffer response packet
+func NVMExtReadLongBufferResponse(p *packet.Packet) (*NVMExtReadLongBuffer, error) {
+	if p.MessageType != MessageTypeNVMExtReadLongBuffer {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	message := NVMExtReadLongBuffer{Data: make([]uint8, len(p.Body))}
+	copy(message.Data, p.Body)
+
+	return &message, nil
+}
+
+// NVMExtWriteLongBufferResponse parses a NVMExtWriteLongBuffer response packet
+func NVMExtWriteLongBufferResponse(p *packet.Packet) (*NVMExtWriteLongBuffer, error) {
+	if p.MessageType != MessageTypeNVMExtWriteLongBuffer {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	if len(p.Body) != 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := NVMExtWriteLongBuffer{Success: p.Body[0] != 0}
+
+	return &message, nil
+}
+
+// NVMGetIDResponse parses a NVMGetID response packet
+func NVMGetIDResponse(p *packet.Packet) (*NVMGetID, error) {
+	if p.MessageType != MessageTypeNVMGetID {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | READ_TYPE | MANUFACTURER | MEMORY_TYPE | MEMORY_SIZE |
+	if len(p.Body) != 4 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	// Memory size is the log2 of the number of bytes
+	if p.Body[3] > 24 {
+		return nil, fmt.Errorf("Bad memory size: %d", p.Body[3])
+	}
+
+	message := NVMGetID{Manufacturer: p.Body[1], MemoryType: p.Body[2],
+		Size: 1 << p.Body[3]}
+
+	return &message, nil
+}
+
 // SerialAPIGetCapabilitiesResponse parses a SerialAPIGetCapabilities response
 // packet
 func SerialAPIGetCapabilitiesResponse(p *packet.Packet) (*SerialAPIGetCapabilities, error) {
@@ -151,30 +247,131 @@ func SerialAPIGetInitDataResponse(p *packet.Packet) (*SerialAPIGetInitData, erro
 	return &message, nil
 }
 
+// SerialAPISetupResponse parses a SerialAPISetup response packet
+func SerialAPISetupResponse(p *packet.Packet) (*SerialAPISetup, error) {
+	if p.MessageType != MessageTypeSerialAPISetup {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | COMMAND | DATA |
+	if len(p.Body) < 2 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := SerialAPISetup{Command: p.Body[0]}
+
+	switch message.Command {
+	case SerialAPISetupCommandUnsupported:
+		return nil, fmt.Errorf("SerialAPISetup command 0x%02x not supported",
+			p.Body[1])
+
+	case SerialAPISetupCommandSetTxStatusReport, SerialAPISetupCommandSetPowerLevel,
+		SerialAPISetupCommandSetRFRegion, SerialAPISetupCommandSetNodeIDType:
+		message.Success = p.Body[1] != 0
+
+	case SerialAPISetupCommandGetRFRegion:
+		message.RFRegion = p.Body[1]
+
+	case SerialAPISetupCommandGetPowerLevel:
+		if len(p.Body) < 3 {
+			return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+		}
+		message.PowerLevel.Normal = int8(p.Body[1])
+		message.PowerLevel.Measured0dBm = int8(p.Body[2])
+
+	case SerialAPISetupCommandGetMaxPayloadSize:
+		message.MaxPayloadSize = p.Body[1]
+
+	default:
+		return nil, fmt.Errorf("Unknown SerialAPISetup command: 0x%02x",
+			message.Command)
+	}
+
+	return &message, nil
+}
+
+// SerialAPIGetLRNodesResponse parses a SerialAPIGetLRNodes response packet
+func SerialAPIGetLRNodesResponse(p *packet.Packet) (*SerialAPIGetLRNodes, error) {
+	if p.MessageType != MessageTypeSerialAPIGetLRNodes {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | MORE | SEGMENT | LENGTH | BITMASK |
+	if len(p.Body) < 3 || len(p.Body) != 3+int(p.Body[2]) || p.Body[2] > 128 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := SerialAPIGetLRNodes{More: p.Body[0] != 0, Segment: p.Body[1]}
+
+	// Each segment of 128 bytes is a bitmask of 1024 nodes
+	base := MinLongRangeNodeID + 1024*uint16(message.Segment)
+	for i, x := range p.Body[3:] {
+		for bit := uint16(0); bit < 8; bit++ {
+			if x&(1<<bit) != 0 {
+				message.Nodes = append(message.Nodes, base+8*uint16(i)+bit)
+			}
+		}
+	}
+
+	return &message, nil
+}
+
+// SerialAPIStartedResponse parses a SerialAPIStarted request packet, sent by
+// the controller after it starts
+func SerialAPIStartedResponse(p *packet.Packet) (*SerialAPIStarted, error) {
+	if p.MessageType != MessageTypeSerialAPIStarted {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | WAKE_UP_REASON | WATCHDOG_STARTED | DEVICE_OPTION_MASK |
+	//       | GENERIC_TYPE | SPECIFIC_TYPE | LENGTH_OF_COMMAND_CLASSES |
+	//       | COMMAND_CLASSES | (SUPPORTED_PROTOCOLS) |
+	if len(p.Body) < 6 || len(p.Body) < 6+int(p.Body[5]) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := SerialAPIStarted{WakeUpReason: p.Body[0],
+		WatchdogStarted: p.Body[1] != 0,
+		Listening:       (p.Body[2] & 0x80) != 0}
+	message.DeviceClass.Generic = p.Body[3]
+	message.DeviceClass.Specific = p.Body[4]
+	message.CommandClasses = make([]uint8, p.Body[5])
+	copy(message.CommandClasses, p.Body[6:])
+
+	return &message, nil
+}
+
 // ZWApplicationUpdateResponse parses a ZWApplicationUpdate response packet
-func ZWApplicationUpdateResponse(p *packet.Packet) (*ZWApplicationUpdate, error) {
+func ZWApplicationUpdateResponse(p *packet.Packet, nodeIDType uint8) (*ZWApplicationUpdate, error) {
 	if p.MessageType != MessageTypeZWApplicationUpdate {
 		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
 	}
 
-	if len(p.Body) < 3 {
+	// Body: | STATUS | NODE_ID | LENGTH | PAYLOAD |
+	if len(p.Body) < 1 {
+		return nil, fmt.Errorf("Bad length: %d", len(p.Body))
+	}
+	nodeID, nodeIDLength, err := decodeNodeID(p.Body[1:], nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	offset := 1 + nodeIDLength
+	if len(p.Body) < offset+1 {
 		return nil, fmt.Errorf("Bad length: %d", len(p.Body))
 	}
 
 	message := ZWApplicationUpdate{}
 	message.Status = p.Body[0]
-	message.NodeID = p.Body[1]
+	message.NodeID = nodeID
 
 	// This should match up to the rest of the packet length
-	payloadLength := p.Body[2]
-	if len(p.Body)-3 != int(payloadLength) {
+	payloadLength := p.Body[offset]
+	if len(p.Body)-offset-1 != int(payloadLength) {
 		return nil, fmt.Errorf("Bad payloadLength: %d", payloadLength)
 	}
 
-	message.Body = make([]uint8, len(p.Body)-3)
-	for i, x := range p.Body[3:len(p.Body)] {
-		message.Body[i] = x
-	}
+	message.Body = make([]uint8, payloadLength)
+	copy(message.Body, p.Body[offset+1:])
 
 	return &message, nil
 }
@@ -211,11 +408,41 @@ func ZWGetNodeProtocolInfoResponse(p *packet.Packet) (*ZWGetNodeProtocolInfo, er
 		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
 	}
 
+	// Body: | CAPABILITY | SECURITY | SPEED_EXTENSION | BASIC | GENERIC |
+	//       | SPECIFIC |
 	message := ZWGetNodeProtocolInfo{}
 
-	message.Capabilities.Listening = (p.Body[0] & 0x80) != 0
-	// TODO: what is p.Body[1]
-	// TODO: what is p.Body[2]
+	capability := p.Body[0]
+	message.Capabilities.Listening = (capability & 0x80) != 0
+	message.Capabilities.Routing = (capability & 0x40) != 0
+	message.Capabilities.ProtocolVersion = capability & 0x07
+
+	// The speed extension adds the faster data rates
+	switch {
+	case (p.Body[2] & 0x02) != 0:
+		message.Capabilities.MaxBaudRate = 200000
+	case (p.Body[2] & 0x01) != 0:
+		message.Capabilities.MaxBaudRate = 100000
+	case (capability & 0x10) != 0:
+		message.Capabilities.MaxBaudRate = 40000
+	case (capability & 0x08) != 0:
+		message.Capabilities.MaxBaudRate = 9600
+	}
+
+	security := p.Body[1]
+	message.Capabilities.OptionalFunctionality = (security & 0x80) != 0
+	switch {
+	case (security & 0x40) != 0:
+		message.Capabilities.FrequentListening = FrequentListening1000ms
+	case (security & 0x20) != 0:
+		message.Capabilities.FrequentListening = FrequentListening250ms
+	}
+	message.Capabilities.Beaming = (security & 0x10) != 0
+	message.Capabilities.RoutingSlave = (security & 0x08) != 0
+	message.Capabilities.SpecificDeviceClass = (security & 0x04) != 0
+	message.Capabilities.Controller = (security & 0x02) != 0
+	message.Capabilities.Security = (security & 0x01) != 0
+
 	message.DeviceClass.Basic = p.Body[3]
 	message.DeviceClass.Generic = p.Body[4]
 	message.DeviceClass.Specific = p.Body[5]
@@ -223,6 +450,62 @@ func ZWGetNodeProtocolInfoResponse(p *packet.Packet) (*ZWGetNodeProtocolInfo, er
 	return &message, nil
 }
 
+// ZWGetRandomResponse parses a ZWGetRandom response packet
+func ZWGetRandomResponse(p *packet.Packet) (*ZWGetRandom, error) {
+	if p.MessageType != MessageTypeZWGetRandom {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | SUCCESS | COUNT | BYTES |
+	if len(p.Body) < 2 || len(p.Body) != 2+int(p.Body[1]) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	if p.Body[0] == 0 {
+		return nil, fmt.Errorf("ZWGetRandom failed")
+	}
+
+	message := ZWGetRandom{Bytes: make([]uint8, p.Body[1])}
+	copy(message.Bytes, p.Body[2:])
+
+	return &message, nil
+}
+
+// ZWGetSUCNodeIDResponse parses a ZWGetSUCNodeID response packet
+func ZWGetSUCNodeIDResponse(p *packet.Packet) (*ZWGetSUCNodeID, error) {
+	if p.MessageType != MessageTypeZWGetSUCNodeID {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | NODE_ID |, which is two bytes in the 16 bit node ID type
+	message := ZWGetSUCNodeID{}
+	switch len(p.Body) {
+	case 1:
+		message.NodeID = uint16(p.Body[0])
+	case 2:
+		message.NodeID = binary.BigEndian.Uint16(p.Body)
+	default:
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	return &message, nil
+}
+
+// ZWIsFailedNodeResponse parses a ZWIsFailedNode response packet
+func ZWIsFailedNodeResponse(p *packet.Packet) (*ZWIsFailedNode, error) {
+	if p.MessageType != MessageTypeZWIsFailedNode {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	if len(p.Body) != 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := ZWIsFailedNode{Failed: p.Body[0] != 0}
+
+	return &message, nil
+}
+
 // ZWRequestNodeInfoResponse parses a ZWRequestNodeInfo response packet
 func ZWRequestNodeInfoResponse(p *packet.Packet) (*ZWRequestNodeInfo, error) {
 	if p.MessageType != MessageTypeZWRequestNodeInfo {
@@ -245,12 +528,285 @@ func ZWSendDataResponse(p *packet.Packet) (*ZWSendData, error) {
 		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
 	}
 
-	if len(p.Body) != 4 {
+	// Broadcast callbacks, and older controllers, omit the transmit time
+	if len(p.Body) != 2 && len(p.Body) < 4 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := ZWSendData{CallbackID: p.Body[0], Status: p.Body[1]}
+	if len(p.Body) >= 4 {
+		message.TransmitTime = binary.BigEndian.Uint16(p.Body[2:4])
+	}
+
+	return &message, nil
+}
+
+// ZWSendDataMultiResponse parses a ZWSendDataMulti response packet, the second
+// packet with the transmit status
+func ZWSendDataMultiResponse(p *packet.Packet) (*ZWSendDataMulti, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWSendDataMulti)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZWSendDataMulti{CallbackID: callbackID, Status: status}, nil
+}
+
+// callbackStatusResponse parses a callback packet of the MessageType, with a
+// body of callback id and status
+func callbackStatusResponse(p *packet.Packet, messageType uint8) (callbackID uint8, status uint8, err error) {
+	if p.MessageType != messageType {
+		err = fmt.Errorf("Bad MessageType: %d", p.MessageType)
+		return
+	}
+
+	if len(p.Body) < 2 {
+		err = fmt.Errorf("Bad Body length: %d < 2", len(p.Body))
+		return
+	}
+
+	callbackID = p.Body[0]
+	status = p.Body[1]
+	return
+}
+
+// ZWAssignReturnRouteResponse parses a ZWAssignReturnRoute callback packet
+func ZWAssignReturnRouteResponse(p *packet.Packet) (*ZWAssignReturnRoute, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWAssignReturnRoute)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWAssignReturnRoute{CallbackID: callbackID, Status: status}, nil
+}
+
+// ZWAssignSUCReturnRouteResponse parses a ZWAssignSUCReturnRoute callback
+// packet
+func ZWAssignSUCReturnRouteResponse(p *packet.Packet) (*ZWAssignSUCReturnRoute, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWAssignSUCReturnRoute)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWAssignSUCReturnRoute{CallbackID: callbackID, Status: status}, nil
+}
+
+// nodeInfoCallbackResponse parses the fields of a callback packet with node
+// information
+func nodeInfoCallbackResponse(p *packet.Packet, nodeIDType uint8) (callbackID uint8,
+	status uint8, nodeID uint16, body []uint8, err error) {
+	// Body: | CALLBACK_ID | STATUS | NODE_ID | LENGTH | NODE_INFO |
+	if len(p.Body) < 2 {
+		return 0, 0, 0, nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+	nodeID, nodeIDLength, err := decodeNodeID(p.Body[2:], nodeIDType)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	offset := 2 + nodeIDLength
+	if len(p.Body) < offset+1 || len(p.Body) < offset+1+int(p.Body[offset]) {
+		return 0, 0, 0, nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	body = make([]uint8, p.Body[offset])
+	copy(body, p.Body[offset+1:])
+
+	return p.Body[0], p.Body[1], nodeID, body, nil
+}
+
+// ZWAddNodeToNetworkResponse parses a ZWAddNodeToNetwork callback packet
+func ZWAddNodeToNetworkResponse(p *packet.Packet, nodeIDType uint8) (*ZWAddNodeToNetwork, error) {
+	if p.MessageType != MessageTypeZWAddNodeToNetwork {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	message := ZWAddNodeToNetwork{}
+	var err error
+	message.CallbackID, message.Status, message.NodeID, message.Body, err =
+		nodeInfoCallbackResponse(p, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// IsZWApplicationUpdateSmartStart checks if the ZWApplicationUpdate packet is
+// a SmartStart inclusion request, which ZWApplicationUpdateResponse can not
+// parse
+func IsZWApplicationUpdateSmartStart(p *packet.Packet) bool {
+	return p.MessageType == MessageTypeZWApplicationUpdate && len(p.Body) > 0 &&
+		(p.Body[0] == ZWApplicationUpdateStateSmartStartHomeIDReceived ||
+			p.Body[0] == ZWApplicationUpdateStateSmartStartHomeIDReceivedLR)
+}
+
+// ZWApplicationUpdateSmartStartResponse parses a SmartStart inclusion request
+// ZWApplicationUpdate packet
+func ZWApplicationUpdateSmartStartResponse(p *packet.Packet,
+	nodeIDType uint8) (*ZWApplicationUpdateSmartStart, error) {
+	if !IsZWApplicationUpdateSmartStart(p) {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | STATUS | NODE_ID | RX_STATUS | NWI_HOME_ID | LENGTH | NODE_INFO |
+	nodeID, nodeIDLength, err := decodeNodeID(p.Body[1:], nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+	offset := 1 + nodeIDLength
+	if len(p.Body) < offset+6 || len(p.Body) != offset+6+int(p.Body[offset+5]) {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := ZWApplicationUpdateSmartStart{Status: p.Body[0], NodeID: nodeID,
+		RxStatus:  p.Body[offset],
+		NWIHomeID: binary.BigEndian.Uint32(p.Body[offset+1 : offset+5]),
+		Body:      make([]uint8, p.Body[offset+5])}
+	copy(message.Body, p.Body[offset+6:])
+
+	return &message, nil
+}
+
+// ZWControllerChangeResponse parses a ZWControllerChange or ZWNewController
+// callback packet
+func ZWControllerChangeResponse(p *packet.Packet, nodeIDType uint8) (*ZWControllerChange, error) {
+	if p.MessageType != MessageTypeZWControllerChange &&
+		p.MessageType != MessageTypeZWNewController {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	message := ZWControllerChange{}
+	var err error
+	message.CallbackID, message.Status, message.NodeID, message.Body, err =
+		nodeInfoCallbackResponse(p, nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// ZWDeleteReturnRouteResponse parses a ZWDeleteReturnRoute callback packet
+func ZWDeleteReturnRouteResponse(p *packet.Packet) (*ZWDeleteReturnRoute, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWDeleteReturnRoute)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWDeleteReturnRoute{CallbackID: callbackID, Status: status}, nil
+}
+
+// ZWGetRoutingInfoResponse parses a ZWGetRoutingInfo response packet
+func ZWGetRoutingInfoResponse(p *packet.Packet) (*ZWGetRoutingInfo, error) {
+	if p.MessageType != MessageTypeZWGetRoutingInfo {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// 29 * 8 = 232 bits / node ids
+	if len(p.Body) != 29 {
+		return nil, fmt.Errorf("Bad Body length: %d != 29", len(p.Body))
+	}
+
+	message := ZWGetRoutingInfo{Neighbors: []uint8{}}
+	for i, x := range p.Body {
+		for b := uint8(0); b < 8; b++ {
+			if (x & (1 << b)) != 0 {
+				message.Neighbors = append(message.Neighbors, 1+uint8(i)*8+b)
+			}
+		}
+	}
+
+	return &message, nil
+}
+
+// ZWRequestNetworkUpdateResponse parses a ZWRequestNetworkUpdate callback
+// packet
+func ZWRequestNetworkUpdateResponse(p *packet.Packet) (*ZWRequestNetworkUpdate, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWRequestNetworkUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWRequestNetworkUpdate{CallbackID: callbackID, Status: status}, nil
+}
+
+// ZWSetLearnModeResponse parses a ZWSetLearnMode response packet
+func ZWSetLearnModeResponse(p *packet.Packet) (*ZWSetLearnMode, error) {
+	if p.MessageType != MessageTypeZWSetLearnMode {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	if len(p.Body) != 1 {
 		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
 	}
 
-	message := ZWSendData{CallbackID: p.Body[0], Status: p.Body[1],
-		TransmitTime: binary.BigEndian.Uint16(p.Body[2:4])}
+	message := ZWSetLearnMode{Accepted: p.Body[0] != 0}
 
 	return &message, nil
 }
+
+// ZWSetLearnModeCallbackResponse parses a ZWSetLearnMode callback packet
+func ZWSetLearnModeCallbackResponse(p *packet.Packet,
+	nodeIDType uint8) (*ZWSetLearnModeCallback, error) {
+	if p.MessageType != MessageTypeZWSetLearnMode {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | CALLBACK_ID | STATUS | NODE_ID | LENGTH | DATA |
+	if len(p.Body) < 3 {
+		return nil, fmt.Errorf("Bad Body length: %d < 3", len(p.Body))
+	}
+	nodeID, _, err := decodeNodeID(p.Body[2:], nodeIDType)
+	if err != nil {
+		return nil, err
+	}
+
+	message := ZWSetLearnModeCallback{CallbackID: p.Body[0], Status: p.Body[1],
+		NodeID: nodeID}
+
+	return &message, nil
+}
+
+// ZWSetSUCNodeIDResponse parses a ZWSetSUCNodeID callback packet, or the
+// response packet of a request without a callback
+func ZWSetSUCNodeIDResponse(p *packet.Packet) (*ZWSetSUCNodeID, error) {
+	if p.MessageType == MessageTypeZWSetSUCNodeID && p.PacketType == packet.PacketTypeResponse {
+		if len(p.Body) != 1 {
+			return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+		}
+
+		message := ZWSetSUCNodeID{Status: SUCStatusFailed}
+		if p.Body[0] != 0 {
+			message.Status = SUCStatusSucceeded
+		}
+		return &message, nil
+	}
+
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWSetSUCNodeID)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWSetSUCNodeID{CallbackID: callbackID, Status: status}, nil
+}
+
+// ZWSetDefaultResponse parses a ZWSetDefault callback packet
+func ZWSetDefaultResponse(p *packet.Packet) (*ZWSetDefault, error) {
+	if p.MessageType != MessageTypeZWSetDefault {
+		return nil, fmt.Errorf("Bad MessageType: %d", p.MessageType)
+	}
+
+	// Body: | CALLBACK_ID |
+	if len(p.Body) != 1 {
+		return nil, fmt.Errorf("Bad Body length: %d", len(p.Body))
+	}
+
+	message := ZWSetDefault{CallbackID: p.Body[0]}
+
+	return &message, nil
+}
+
+// ZWRequestNodeNeighborUpdateResponse parses a ZWRequestNodeNeighborUpdate
+// callback packet
+func ZWRequestNodeNeighborUpdateResponse(p *packet.Packet) (*ZWRequestNodeNeighborUpdate, error) {
+	callbackID, status, err := callbackStatusResponse(p, MessageTypeZWRequestNodeNeighborUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return &ZWRequestNodeNeighborUpdate{CallbackID: callbackID, Status: status}, nil
+}
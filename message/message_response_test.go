@@ -264,3 +264,461 @@ func TestZWGetControllerCapabilitiesResponse(t *testing.T) {
 	}
 	packet.Body = packet.Body[0 : len(packet.Body)+1]
 }
+
+// Make a packet with a valid checksum
+func makePacket(t *testing.T, packetType uint8, messageType uint8, body []uint8) *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF, PacketType: packetType,
+		MessageType: messageType, Body: body}
+	if err := p.Update(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+	return &p
+}
+
+func TestZWGetRoutingInfoResponse(t *testing.T) {
+	body := make([]uint8, 29)
+	body[0] = 0x03
+	body[28] = 0x80
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetRoutingInfo, body)
+
+	message, err := ZWGetRoutingInfoResponse(p)
+	if message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+		t.FailNow()
+	}
+
+	expectedNeighbors := []uint8{1, 2, 232}
+	if !bytes.Equal(expectedNeighbors, message.Neighbors) {
+		t.Errorf("Expected Neighbors: %v got: %v", expectedNeighbors, message.Neighbors)
+	}
+
+	// Bad BodyLength
+	p.Body = p.Body[0 : len(p.Body)-1]
+	message, err = ZWGetRoutingInfoResponse(p)
+	if message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestRouteCallbackResponses(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeZWRequestNodeNeighborUpdate,
+		[]uint8{0x12, NeighborUpdateDone})
+	update, err := ZWRequestNodeNeighborUpdateResponse(p)
+	if update == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", update, err)
+	} else if update.CallbackID != 0x12 || update.Status != NeighborUpdateDone {
+		t.Errorf("Unexpected message: %+v", update)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWAssignReturnRoute,
+		[]uint8{0x13, TransmitCompleteNoACK})
+	assign, err := ZWAssignReturnRouteResponse(p)
+	if assign == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", assign, err)
+	} else if assign.CallbackID != 0x13 || assign.Status != TransmitCompleteNoACK {
+		t.Errorf("Unexpected message: %+v", assign)
+	}
+
+	// Bad MessageType
+	if message, err := ZWDeleteReturnRouteResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWAssignSUCReturnRoute,
+		[]uint8{0x13})
+	if message, err := ZWAssignSUCReturnRouteResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWSendDataResponses(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeZWSendData,
+		[]uint8{0x12, TransmitCompleteOK, 0x01, 0x02})
+	if message, err := ZWSendDataResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x12 || message.TransmitTime != 0x0102 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Broadcast without transmit time
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSendData,
+		[]uint8{0x12, TransmitCompleteNoACK})
+	if message, err := ZWSendDataResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.Status != TransmitCompleteNoACK || message.TransmitTime != 0 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSendData,
+		[]uint8{0x12, TransmitCompleteOK, 0x01})
+	if message, err := ZWSendDataResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSendDataMulti,
+		[]uint8{0x13, TransmitCompleteOK})
+	if message, err := ZWSendDataMultiResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x13 || message.Status != TransmitCompleteOK {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+}
+
+func TestZWIsFailedNodeResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWIsFailedNode, []uint8{0x01})
+	if message, err := ZWIsFailedNodeResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.Failed {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWIsFailedNode, []uint8{})
+	if message, err := ZWIsFailedNodeResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestSerialAPISetupResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPISetup,
+		[]uint8{SerialAPISetupCommandGetPowerLevel, 0xf6, 0x21})
+	if message, err := SerialAPISetupResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.PowerLevel.Normal != -10 || message.PowerLevel.Measured0dBm != 33 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPISetup,
+		[]uint8{SerialAPISetupCommandSetRFRegion, 0x01})
+	if message, err := SerialAPISetupResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.Success {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Unsupported command
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPISetup,
+		[]uint8{SerialAPISetupCommandUnsupported, SerialAPISetupCommandGetRFRegion})
+	if message, err := SerialAPISetupResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPISetup,
+		[]uint8{SerialAPISetupCommandSetNodeIDType, 0x01})
+	if message, err := SerialAPISetupResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.Success {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPISetup,
+		[]uint8{SerialAPISetupCommandGetPowerLevel, 0x00})
+	if message, err := SerialAPISetupResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestSerialAPIGetLRNodesResponse(t *testing.T) {
+	body := []uint8{0x01, 0x01, 0x02, 0x01, 0x81}
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPIGetLRNodes, body)
+	if message, err := SerialAPIGetLRNodesResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.More || message.Segment != 0x01 || len(message.Nodes) != 3 ||
+		message.Nodes[0] != 1280 || message.Nodes[1] != 1288 || message.Nodes[2] != 1295 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeSerialAPIGetLRNodes, body[:4])
+	if message, err := SerialAPIGetLRNodesResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestLongRangeResponses(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeMemoryGetID,
+		[]uint8{0xfa, 0x5b, 0x82, 0x9a, 0x00, 0x01})
+	if message, err := MemoryGetIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.HomeID != 0xfa5b829a || message.NodeID != 0x01 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeApplicationCommand,
+		[]uint8{0x00, 0x01, 0x02, 0x03, 0x25, 0x03, 0xff})
+	if message, err := ApplicationCommandResponse(p, NodeIDType16Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.NodeID != 0x0102 || !bytes.Equal(message.Body, []uint8{0x25, 0x03, 0xff}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// The same frame has a bad length with 8 bit node IDs
+	if message, err := ApplicationCommandResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSetLearnMode,
+		[]uint8{0x31, LearnModeStatusDone, 0x01, 0x04, 0x00})
+	if message, err := ZWSetLearnModeCallbackResponse(p, NodeIDType16Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.NodeID != 0x0104 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetSUCNodeID, []uint8{0x00, 0x01})
+	if message, err := ZWGetSUCNodeIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.NodeID != 0x01 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+}
+
+func TestSerialAPIStartedResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeSerialAPIStarted,
+		[]uint8{WakeUpReasonSoftwareReset, 0x00, 0x80, 0x02, 0x07, 0x02, 0x5e, 0x86, 0x00})
+	if message, err := SerialAPIStartedResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.WakeUpReason != WakeUpReasonSoftwareReset || !message.Listening ||
+		message.DeviceClass.Generic != 0x02 || message.DeviceClass.Specific != 0x07 ||
+		!bytes.Equal(message.CommandClasses, []uint8{0x5e, 0x86}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeSerialAPIStarted,
+		[]uint8{WakeUpReasonSoftwareReset, 0x00, 0x80, 0x02, 0x07, 0x02, 0x5e})
+	if message, err := SerialAPIStartedResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWGetRandomResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetRandom,
+		[]uint8{0x01, 0x02, 0xab, 0xcd})
+	if message, err := ZWGetRandomResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !bytes.Equal(message.Bytes, []uint8{0xab, 0xcd}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Failed
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetRandom,
+		[]uint8{0x00, 0x00})
+	if message, err := ZWGetRandomResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetRandom,
+		[]uint8{0x01, 0x02, 0xab})
+	if message, err := ZWGetRandomResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestNVMResponses(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeNVMBackupRestore,
+		[]uint8{NVMBackupRestoreStatusOK, 0x02, 0x01, 0x00, 0xaa, 0xbb})
+	if message, err := NVMBackupRestoreResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.Offset != 0x0100 || !bytes.Equal(message.Data, []uint8{0xaa, 0xbb}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeNVMBackupRestore,
+		[]uint8{NVMBackupRestoreStatusOK, 0x02, 0x01, 0x00, 0xaa})
+	if message, err := NVMBackupRestoreResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeNVMGetID,
+		[]uint8{0x00, 0xef, 0x01, 0x0e})
+	if message, err := NVMGetIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.Size != 16384 || message.Manufacturer != 0xef {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeNVMExtWriteLongBuffer,
+		[]uint8{0x01})
+	if message, err := NVMExtWriteLongBufferResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.Success {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+}
+
+func TestZWGetNodeProtocolInfoResponse(t *testing.T) {
+	// FLiRS lock
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetNodeProtocolInfo,
+		[]uint8{0x53, 0xdc, 0x00, 0x04, 0x40, 0x03})
+	if message, err := ZWGetNodeProtocolInfoResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if capabilities := message.Capabilities; capabilities.Listening ||
+		!capabilities.Routing || capabilities.MaxBaudRate != 40000 ||
+		capabilities.ProtocolVersion != ProtocolVersion6 ||
+		capabilities.FrequentListening != FrequentListening1000ms ||
+		!capabilities.Beaming || !capabilities.RoutingSlave ||
+		!capabilities.SpecificDeviceClass || !capabilities.OptionalFunctionality ||
+		capabilities.Controller || capabilities.Security ||
+		message.DeviceClass.Basic != 0x04 || message.DeviceClass.Generic != 0x40 ||
+		message.DeviceClass.Specific != 0x03 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Listening controller
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetNodeProtocolInfo,
+		[]uint8{0xd3, 0x37, 0x01, 0x02, 0x02, 0x01})
+	if message, err := ZWGetNodeProtocolInfoResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if capabilities := message.Capabilities; !capabilities.Listening ||
+		capabilities.MaxBaudRate != 100000 ||
+		capabilities.FrequentListening != FrequentListening250ms ||
+		!capabilities.Controller || !capabilities.Security || capabilities.RoutingSlave {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetNodeProtocolInfo,
+		[]uint8{0xd3, 0x9c, 0x01, 0x04, 0x10})
+	if message, err := ZWGetNodeProtocolInfoResponse(p); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWSetSUCNodeIDResponse(t *testing.T) {
+	// Response without callback
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWSetSUCNodeID, []uint8{0x01})
+	if message, err := ZWSetSUCNodeIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.Status != SUCStatusSucceeded {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Callback
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSetSUCNodeID,
+		[]uint8{0x21, SUCStatusFailed})
+	if message, err := ZWSetSUCNodeIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x21 || message.Status != SUCStatusFailed {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeResponse, MessageTypeZWGetSUCNodeID, []uint8{0x01})
+	if message, err := ZWGetSUCNodeIDResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.NodeID != 0x01 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+}
+
+func TestZWAddNodeToNetworkResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeZWAddNodeToNetwork,
+		[]uint8{0x12, AddNodeStatusAddingSlave, 0x07, 0x03, 0x04, 0x10, 0x01})
+	if message, err := ZWAddNodeToNetworkResponse(p, NodeIDType8Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x12 || message.Status != AddNodeStatusAddingSlave ||
+		message.NodeID != 0x07 || !bytes.Equal(message.Body, []uint8{0x04, 0x10, 0x01}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWAddNodeToNetwork,
+		[]uint8{0x12, AddNodeStatusAddingSlave, 0x07, 0x03, 0x04})
+	if message, err := ZWAddNodeToNetworkResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWApplicationUpdateSmartStartResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeZWApplicationUpdate,
+		[]uint8{ZWApplicationUpdateStateSmartStartHomeIDReceived, 0x00, 0x00,
+			0xfa, 0x5b, 0x82, 0x9a, 0x03, 0x04, 0x10, 0x01})
+	if !IsZWApplicationUpdateSmartStart(p) {
+		t.Errorf("Expected SmartStart update")
+	}
+	if message, err := ZWApplicationUpdateSmartStartResponse(p, NodeIDType8Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.NWIHomeID != 0xfa5b829a ||
+		!bytes.Equal(message.Body, []uint8{0x04, 0x10, 0x01}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWApplicationUpdate,
+		[]uint8{ZWApplicationUpdateStateSmartStartHomeIDReceivedLR, 0x00, 0x00,
+			0xfa, 0x5b, 0x82, 0x9a, 0x03, 0x04, 0x10})
+	if message, err := ZWApplicationUpdateSmartStartResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWApplicationUpdate,
+		[]uint8{ZWApplicationUpdateStateReceived, 0x05, 0x00})
+	if IsZWApplicationUpdateSmartStart(p) {
+		t.Errorf("Unexpected SmartStart update")
+	}
+	if message, err := ZWApplicationUpdateSmartStartResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWControllerChangeResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeRequest, MessageTypeZWControllerChange,
+		[]uint8{0x12, ControllerChangeStatusAddingController, 0x05, 0x03, 0x02, 0x02, 0x01})
+	if message, err := ZWControllerChangeResponse(p, NodeIDType8Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x12 ||
+		message.Status != ControllerChangeStatusAddingController ||
+		message.NodeID != 0x05 || !bytes.Equal(message.Body, []uint8{0x02, 0x02, 0x01}) {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWNewController,
+		[]uint8{0x12, ControllerChangeStatusDone, 0x05, 0x00})
+	if message, err := ZWControllerChangeResponse(p, NodeIDType8Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.Status != ControllerChangeStatusDone || len(message.Body) != 0 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Truncated node info
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWControllerChange,
+		[]uint8{0x12, ControllerChangeStatusAddingController, 0x05, 0x03, 0x02})
+	if message, err := ZWControllerChangeResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSetLearnMode,
+		[]uint8{0x12, ControllerChangeStatusDone, 0x05, 0x00})
+	if message, err := ZWControllerChangeResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
+
+func TestZWSetLearnModeResponse(t *testing.T) {
+	p := makePacket(t, packet.PacketTypeResponse, MessageTypeZWSetLearnMode, []uint8{0x01})
+	if message, err := ZWSetLearnModeResponse(p); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if !message.Accepted {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSetLearnMode,
+		[]uint8{0x31, LearnModeStatusDone, 0x04, 0x00})
+	if message, err := ZWSetLearnModeCallbackResponse(p, NodeIDType8Bit); message == nil || err != nil {
+		t.Errorf("Expected non nil message and nil error: %v %v", message, err)
+	} else if message.CallbackID != 0x31 || message.Status != LearnModeStatusDone ||
+		message.NodeID != 0x04 {
+		t.Errorf("Unexpected message: %+v", message)
+	}
+
+	// Bad BodyLength
+	p = makePacket(t, packet.PacketTypeRequest, MessageTypeZWSetLearnMode,
+		[]uint8{0x31, LearnModeStatusDone})
+	if message, err := ZWSetLearnModeCallbackResponse(p, NodeIDType8Bit); message != nil || err == nil {
+		t.Errorf("Expected nil message and non nil error: %v %v", message, err)
+	}
+}
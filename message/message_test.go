@@ -26,17 +26,27 @@ func TestIsValidNodeID(t *testing.T) {
 		t.Errorf("Expected 0 to be an invalid node ID")
 	}
 
-	for i := uint8(1); i <= 232; i++ {
-		if !IsValidNodeID(i) {
-			t.Errorf("Expected node %d to be valid", i)
+	for i := uint16(1); i <= 232; i++ {
+		if !IsValidNodeID(i) || !IsClassicNodeID(i) || IsLongRangeNodeID(i) {
+			t.Errorf("Expected node %d to be valid classic", i)
 		}
 	}
 
-	for i := 233; i <= 255; i++ {
-		if IsValidNodeID(uint8(i)) {
+	for i := uint16(233); i <= 255; i++ {
+		if IsValidNodeID(i) {
 			t.Errorf("Expected node %d to be invalid", i)
 		}
 	}
+
+	for i := uint16(256); i <= 4000; i++ {
+		if !IsValidNodeID(i) || IsClassicNodeID(i) || !IsLongRangeNodeID(i) {
+			t.Errorf("Expected node %d to be valid Long Range", i)
+		}
+	}
+
+	if IsValidNodeID(4001) {
+		t.Errorf("Expected 4001 to be an invalid node ID")
+	}
 }
 
 func TestEncodeDecodeDuration(t *testing.T) {
@@ -22,26 +22,67 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cybojanek/gozwave/controller"
+	"github.com/cybojanek/gozwave/database"
 	"github.com/cybojanek/gozwave/message"
 	"github.com/cybojanek/gozwave/node"
 	"github.com/cybojanek/gozwave/packet"
+	"github.com/cybojanek/gozwave/provisioning"
+	"github.com/cybojanek/gozwave/trace"
 	"log"
 	"sync"
+	"time"
 )
 
 // Network instance
 type Network struct {
-	DevicePath   string // Path to ZWave controller
-	DebugLogging bool   // Enable debug logging
-
-	mutex                  sync.RWMutex                 // API mutex
-	serialController       *controller.SerialController // Controller
-	callbackChannel        chan *packet.Packet          // Channel for receiving async controller packets
-	stopCallbackHandler    chan int                     // Exit signal channel for callbackHandler
-	stoppedCallbackHandler chan int                     // Exit confirmation channel for callbackHandler
-	nodexMutex             sync.RWMutex                 // Nodes mutex
-	nodes                  map[uint8]*node.Node         // Nodes
-	supportedMessageTypes  []uint8                      // Supported message types
+	DevicePath    string                          // Path to ZWave controller
+	DebugLogging  bool                            // Enable debug logging
+	Database      *database.Database              // Device database for node quirks, can be nil
+	Provisioning  *provisioning.List              // SmartStart provisioning list, can be nil
+	AutoLifeline  bool                            // Setup lifeline association in RefreshNode
+	LongRange     bool                            // Use 16 bit node IDs for Long Range nodes, if supported
+	PollRateLimit time.Duration                   // Minimum time between polls, defaults to 1 second, negative disables
+	PollJitter    float64                         // Random fraction of poll intervals, defaults to 0.1, negative disables
+	OpenPort      func() (controller.Port, error) // Opens the controller connection instead of DevicePath, can be nil
+	Trace         *trace.Writer                   // Records all bytes to and from the controller, can be nil
+
+	openMutex              sync.Mutex                           // Serializes Open and Close
+	mutex                  sync.RWMutex                         // API mutex
+	serialController       *controller.SerialController         // Controller
+	callbackChannel        chan *packet.Packet                  // Channel for receiving async controller packets
+	stateChannel           chan uint8                           // Channel for receiving controller connection states
+	serialAPIStarted       chan *message.SerialAPIStarted       // Most recent controller start notification
+	learnMode              chan *message.ZWSetLearnModeCallback // Learn mode callbacks
+	controllerChange       chan *message.ZWControllerChange     // Controller change callbacks
+	addNodeCallbacks       chan *message.ZWAddNodeToNetwork     // Add node callbacks
+	smartStartMutex        sync.Mutex                           // SmartStart mutex
+	smartStart             bool                                 // Controller listens for SmartStart inclusion requests
+	smartStartBusy         bool                                 // SmartStart inclusion in progress
+	reconnectErr           error                                // Failure of the checks after reconnecting
+	stopCallbackHandler    chan int                             // Exit signal channel for callbackHandler
+	stoppedCallbackHandler chan int                             // Exit confirmation channel for callbackHandler
+	nodexMutex             sync.RWMutex                         // Nodes mutex
+	nodes                  map[uint16]*node.Node                // Nodes
+	supportedMessageTypes  []uint8                              // Supported message types
+	homeID                 uint32                               // HomeID of the network
+	controllerCapabilities *message.ZWGetControllerCapabilities // Role of the controller
+	nodeID                 uint16                               // NodeID of the controller
+	nodeIDTypeMutex        sync.RWMutex                         // Node ID type mutex
+	nodeIDType             uint8                                // Node ID type of frames, 0 before Initialize
+	healMutex              sync.Mutex                           // Heal mutex
+	pendingHeals           map[uint16]bool                      // Sleeping nodes awaiting heal
+	lifelineMutex          sync.Mutex                           // Lifeline mutex
+	lifelines              map[uint16]*LifelineStatus           // Most recent lifeline setup status
+	eventMutex             sync.RWMutex                         // Event subscriptions mutex
+	subscriptions          map[*Subscription]bool               // Event subscriptions
+	valueMutex             sync.RWMutex                         // Value store mutex
+	values                 map[ValueID]*Value                   // Most recent value of each ValueID
+	poller                 poller                               // Polling scheduler
+	healthMutex            sync.Mutex                           // Health mutex
+	health                 map[uint16]*NodeHealth               // Health of each node
+	healthRunning          bool                                 // Health monitor is running
+	stopHealth             chan int                             // Exit signal channel for healthLoop
+	stoppedHealth          chan int                             // Exit confirmation channel for healthLoop
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -53,6 +94,9 @@ func (network *Network) isOpen() bool {
 
 // Open network. goroutine safe.
 func (network *Network) Open() error {
+	network.openMutex.Lock()
+	defer network.openMutex.Unlock()
+
 	network.mutex.Lock()
 	defer network.mutex.Unlock()
 
@@ -62,7 +106,8 @@ func (network *Network) Open() error {
 
 	// Open controller
 	serialController := controller.SerialController{DevicePath: network.DevicePath,
-		DebugLogging: network.DebugLogging}
+		DebugLogging: network.DebugLogging, OpenPort: network.OpenPort,
+		Trace: network.Trace}
 	if err := serialController.Open(); err != nil {
 		return err
 	}
@@ -70,34 +115,55 @@ func (network *Network) Open() error {
 	// Set up callback packet callback handler
 	if network.callbackChannel == nil {
 		network.callbackChannel = make(chan *packet.Packet, 1)
+		network.stateChannel = make(chan uint8, 8)
+		network.serialAPIStarted = make(chan *message.SerialAPIStarted, 1)
+		network.learnMode = make(chan *message.ZWSetLearnModeCallback, 4)
+		network.controllerChange = make(chan *message.ZWControllerChange, 8)
+		network.addNodeCallbacks = make(chan *message.ZWAddNodeToNetwork, 8)
 		network.stopCallbackHandler = make(chan int)
 		network.stoppedCallbackHandler = make(chan int)
 
-		serialController.SetCallbackChannel(network.callbackChannel)
-
-		network.nodes = make(map[uint8]*node.Node)
+		network.nodes = make(map[uint16]*node.Node)
 	}
+	serialController.SetCallbackChannel(network.callbackChannel)
+	serialController.SetStateChannel(network.stateChannel)
+	network.reconnectErr = nil
 
 	// Start callback handler
 	go network.callbackHandler()
 
 	network.serialController = &serialController
+
+	// Start polling scheduler
+	network.startPoller()
+	network.startHealthMonitor()
+
 	return nil
 }
 
 // Close network. goroutine safe.
 func (network *Network) Close() error {
-	network.mutex.Lock()
-	defer network.mutex.Unlock()
+	// Stop polling scheduler and health monitor first, since they acquire the
+	// API lock
+	network.stopPoller()
+	network.stopHealthMonitor()
+
+	network.openMutex.Lock()
+	defer network.openMutex.Unlock()
 
+	network.mutex.Lock()
 	if !network.isOpen() {
+		network.mutex.Unlock()
 		return nil
 	}
 
-	// Close controller
+	// Close controller, and reject new requests
 	err := network.serialController.Close()
+	network.serialController = nil
+	network.mutex.Unlock()
 
-	// Stop callback handler
+	// Stop callback handler without the API lock, which it might be waiting
+	// for
 	network.stopCallbackHandler <- 0
 	<-network.stoppedCallbackHandler
 
@@ -108,12 +174,14 @@ loop:
 		case packet := <-network.callbackChannel:
 			log.Printf("INFO Dropping Close packet: %v", packet)
 
+		case <-network.stateChannel:
+			// Pass and drop
+
 		default:
 			break loop
 		}
 	}
 
-	network.serialController = nil
 	return err
 }
 
@@ -121,6 +189,15 @@ loop:
 
 // DoRequest sends a request and awaits a response
 func (network *Network) DoRequest(request *packet.Packet) (*packet.Packet, error) {
+	response, err := network.doRequest(request)
+	if err == nil {
+		network.recordRequest(request, response)
+	}
+	return response, err
+}
+
+// doRequest sends a request with the API lock
+func (network *Network) doRequest(request *packet.Packet) (*packet.Packet, error) {
 	network.mutex.RLock()
 	defer network.mutex.RUnlock()
 
@@ -128,6 +205,10 @@ func (network *Network) DoRequest(request *packet.Packet) (*packet.Packet, error
 		return nil, errors.New("API is not open")
 	}
 
+	if network.reconnectErr != nil {
+		return nil, network.reconnectErr
+	}
+
 	// Check MessageType is supported
 	found := false
 	if network.supportedMessageTypes != nil {
@@ -169,6 +250,11 @@ func (network *Network) Initialize() error {
 	// Save supported message types
 	network.supportedMessageTypes = capabilities.MessageTypes
 
+	// Node ID type must be set before any frames with node IDs
+	if err := network.initialSetNodeIDType(); err != nil {
+		return err
+	}
+
 	// GetVersion
 	version, err := network.initialGetVersion()
 	if err != nil {
@@ -180,10 +266,12 @@ func (network *Network) Initialize() error {
 	if err != nil {
 		return err
 	}
-	// Check nodeID of controller - should be 0x01
-	if memoryID.NodeID != 0x1 {
-		return fmt.Errorf("Expected Controller node 0x01 not: 0x%02x", memoryID.NodeID)
+	// Check nodeID of controller - 0x01 unless it joined another network
+	if !message.IsValidNodeID(memoryID.NodeID) {
+		return fmt.Errorf("Invalid Controller node: 0x%02x", memoryID.NodeID)
 	}
+	network.homeID = memoryID.HomeID
+	network.nodeID = memoryID.NodeID
 
 	// SerialAPIGetInitData
 	initData, err := network.initialSerialAPIGetInitData()
@@ -191,15 +279,35 @@ func (network *Network) Initialize() error {
 		return err
 	}
 
+	// ZWGetControllerCapabilities
+	controllerCapabilities, err := network.initialZWGetControllerCapabilities()
+	if err != nil {
+		return err
+	}
+	network.controllerCapabilities = controllerCapabilities
+
 	if network.DebugLogging {
 		log.Printf("DEBUG GetVersion: %+v", version)
 		log.Printf("DEBUG GetMemoryID: %+v", memoryID)
 		log.Printf("DEBUG SerialAPIGetCapabilities: %+v", capabilities)
 		log.Printf("DEBUG SerialAPIGetInitData: %+v", initData)
+		log.Printf("DEBUG ZWGetControllerCapabilities: %+v", controllerCapabilities)
 	}
 
 	// Add all known nodes
+	nodeIDs := make([]uint16, 0, len(initData.Nodes))
 	for _, id := range initData.Nodes {
+		nodeIDs = append(nodeIDs, uint16(id))
+	}
+	if network.NodeIDType() == message.NodeIDType16Bit &&
+		network.isSupportedMessageType(message.MessageTypeSerialAPIGetLRNodes) {
+		lrNodeIDs, err := network.initialSerialAPIGetLRNodes()
+		if err != nil {
+			return err
+		}
+		nodeIDs = append(nodeIDs, lrNodeIDs...)
+	}
+	for _, id := range nodeIDs {
 		// Don't add controller
 		if id == memoryID.NodeID {
 			continue
@@ -209,6 +317,31 @@ func (network *Network) Initialize() error {
 			n = node.MakeNode(id, network)
 			network.nodes[id] = n
 		}
+		n.SetDatabase(network.Database)
+	}
+
+	// Listening and FLiRS nodes in the failed node list start out dead. The
+	// controller also lists sleeping nodes, which only missed a wake up.
+	if network.isSupportedMessageType(message.MessageTypeZWIsFailedNode) {
+		for id := range network.nodes {
+			isFailedNode, err := network.initialZWIsFailedNode(id)
+			if err != nil {
+				return err
+			}
+			if !isFailedNode.Failed {
+				continue
+			}
+			protocolInfo, err := network.initialZWGetNodeProtocolInfo(id)
+			if err != nil {
+				return err
+			}
+			if protocolInfo.Capabilities.Listening ||
+				protocolInfo.Capabilities.FrequentListening != message.FrequentListeningNone {
+				network.markFailed(id)
+			} else {
+				network.setControllerFailed(id, true)
+			}
+		}
 	}
 
 	// TODO: remove dead nodes...
@@ -262,7 +395,7 @@ func (network *Network) initialSerialAPIGetInitData() (*message.SerialAPIGetInit
 
 // zWGetControllerCapabilities gets the message.ZWGetControllerCapabilities
 // information
-// Assumption: called only from Initialize
+// Assumption: called only with API lock
 func (network *Network) initialZWGetControllerCapabilities() (*message.ZWGetControllerCapabilities, error) {
 	requestPacket := message.ZWGetControllerCapabilitiesRequest()
 	responsePacket, err := network.serialController.DoRequest(requestPacket)
@@ -272,10 +405,125 @@ func (network *Network) initialZWGetControllerCapabilities() (*message.ZWGetCont
 	return message.ZWGetControllerCapabilitiesResponse(responsePacket)
 }
 
+// initialZWIsFailedNode gets the message.ZWIsFailedNode information
+// Assumption: called only from Initialize
+func (network *Network) initialZWIsFailedNode(nodeID uint16) (*message.ZWIsFailedNode, error) {
+	requestPacket, err := message.ZWIsFailedNodeRequest(nodeID, network.NodeIDType())
+	if err != nil {
+		return nil, err
+	}
+	responsePacket, err := network.serialController.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	return message.ZWIsFailedNodeResponse(responsePacket)
+}
+
+// initialZWGetNodeProtocolInfo gets the message.ZWGetNodeProtocolInfo
+// information
+// Assumption: called only from Initialize
+func (network *Network) initialZWGetNodeProtocolInfo(nodeID uint16) (*message.ZWGetNodeProtocolInfo, error) {
+	requestPacket, err := message.ZWGetNodeProtocolInfoRequest(nodeID, network.NodeIDType())
+	if err != nil {
+		return nil, err
+	}
+	responsePacket, err := network.serialController.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	return message.ZWGetNodeProtocolInfoResponse(responsePacket)
+}
+
+// initialSetNodeIDType switches the controller to 16 bit node IDs if
+// LongRange is set, or back to 8 bit node IDs. Controllers without support
+// for the node ID type use 8 bit node IDs.
+// Assumption: called only with API lock
+func (network *Network) initialSetNodeIDType() error {
+	nodeIDType := uint8(message.NodeIDType8Bit)
+	if network.LongRange {
+		nodeIDType = message.NodeIDType16Bit
+	}
+
+	if network.isSupportedMessageType(message.MessageTypeSerialAPISetup) {
+		requestPacket, err := message.SerialAPISetupSetNodeIDTypeRequest(nodeIDType)
+		if err != nil {
+			return err
+		}
+		responsePacket, err := network.serialController.DoRequest(requestPacket)
+		if err != nil {
+			return err
+		}
+		setup, err := message.SerialAPISetupResponse(responsePacket)
+		if err != nil || !setup.Success {
+			log.Printf("INFO initialSetNodeIDType node ID type 0x%02x not supported: %v",
+				nodeIDType, err)
+			nodeIDType = message.NodeIDType8Bit
+		}
+	} else {
+		nodeIDType = message.NodeIDType8Bit
+	}
+
+	if network.LongRange && nodeIDType != message.NodeIDType16Bit {
+		log.Printf("INFO initialSetNodeIDType Long Range is not supported")
+	}
+
+	network.nodeIDTypeMutex.Lock()
+	network.nodeIDType = nodeIDType
+	network.nodeIDTypeMutex.Unlock()
+	network.serialController.SetNodeIDType(nodeIDType)
+
+	return nil
+}
+
+// initialSerialAPIGetLRNodes gets the IDs of all Long Range nodes
+// Assumption: called only from Initialize
+func (network *Network) initialSerialAPIGetLRNodes() ([]uint16, error) {
+	var nodeIDs []uint16
+	for segment := uint8(0); ; segment++ {
+		responsePacket, err := network.serialController.DoRequest(
+			message.SerialAPIGetLRNodesRequest(segment))
+		if err != nil {
+			return nil, err
+		}
+		lrNodes, err := message.SerialAPIGetLRNodesResponse(responsePacket)
+		if err != nil {
+			return nil, err
+		}
+		nodeIDs = append(nodeIDs, lrNodes.Nodes...)
+
+		if !lrNodes.More {
+			return nodeIDs, nil
+		}
+	}
+}
+
+// NodeIDType returns the node ID type of the frames, which is
+// message.NodeIDType16Bit if LongRange is set and supported. goroutine safe.
+func (network *Network) NodeIDType() uint8 {
+	network.nodeIDTypeMutex.RLock()
+	defer network.nodeIDTypeMutex.RUnlock()
+
+	if network.nodeIDType == 0 {
+		return message.NodeIDType8Bit
+	}
+	return network.nodeIDType
+}
+
+// isSupportedMessageType checks if the controller supports the MessageType,
+// must be called with API lock
+func (network *Network) isSupportedMessageType(messageType uint8) bool {
+	for _, x := range network.supportedMessageTypes {
+		if x == messageType {
+			return true
+		}
+	}
+	return false
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 // GetNode returns the node or nil if doesn't exist. goroutine safe.
-func (network *Network) GetNode(nodeID uint8) *node.Node {
+func (network *Network) GetNode(nodeID uint16) *node.Node {
 	network.mutex.RLock()
 	defer network.mutex.RUnlock()
 
@@ -314,43 +562,61 @@ func (network *Network) callbackHandler() {
 				log.Printf("DEBUG callbackHandler received packet: %s", packet)
 			}
 
-			// Route based on Message Type
-			switch packet.MessageType {
+			nodeIDType := network.NodeIDType()
 
-			case message.MessageTypeApplicationCommand:
-				if response, err := message.ApplicationCommandResponse(packet); err != nil {
-					log.Printf("ERROR callbackHandler decoding ApplicationCommand: %v", err)
+			decoded, err := message.Decode(packet, nodeIDType)
+			if err != nil {
+				log.Printf("ERROR callbackHandler decoding: %v", err)
+				continue
+			}
+
+			// Route based on the decoded message
+			switch response := decoded.(type) {
+
+			case *message.ApplicationCommand:
+				if isReplicationCommand(response) {
+					network.completeReplicationCommand(response)
 				} else if node := network.GetNode(response.NodeID); node == nil {
 					log.Printf("INFO callbackHandler ApplicationCommand no node: %d for %+v",
 						response.NodeID, response)
 				} else {
+					// Decode the report once for the node and the events
+					report := network.decodeReport(node, response)
 					go func() {
-						node.ApplicationCommandHandler(response)
+						node.ApplicationCommandReportHandler(response, report)
 					}()
+					network.healOnWakeUp(response)
+					network.recordContact(node.ID, 0)
+					network.publishApplicationCommand(node, response, report)
 				}
 
-			case message.MessageTypeZWApplicationUpdate:
-				if response, err := message.ZWApplicationUpdateResponse(packet); err != nil {
-					log.Printf("ERROR callbackHandler decoding ZWApplicationUpdate: %v", err)
-				} else if response.Status == message.ZWApplicationUpdateStateReceived {
-					if node := network.GetNode(response.NodeID); node == nil {
-						log.Printf("INFO callbackHandler ZWApplicationUpdate no node: %d for %+v",
-							response.NodeID, response)
-					} else {
-						go func() {
-							node.ApplicationUpdateHandler(response)
-						}()
-					}
-				} else {
-					log.Printf("INFO callbackHandler ZWApplicationUpdate non State Received: %d for %+v",
-						response.NodeID, response)
-				}
+			case *message.ZWApplicationUpdateSmartStart:
+				network.handleSmartStart(response)
+
+			case *message.ZWApplicationUpdate:
+				network.handleApplicationUpdate(response)
+
+			case *message.ZWSetLearnModeCallback:
+				network.notifyLearnMode(response)
+
+			case *message.ZWControllerChange:
+				network.notifyControllerChange(response)
+
+			case *message.ZWAddNodeToNetwork:
+				network.notifyAddNode(response)
+
+			case *message.SerialAPIStarted:
+				log.Printf("INFO callbackHandler controller started: %+v", response)
+				network.notifySerialAPIStarted(response)
 
 			default:
-				log.Printf("INFO callbackHandler unhandled MessageType: 0x%02x",
-					packet.MessageType)
+				log.Printf("INFO callbackHandler unhandled MessageType: %s",
+					message.MessageTypeName(packet.MessageType))
 			}
 
+		case state := <-network.stateChannel:
+			network.handleConnectionState(state)
+
 		case <-network.stopCallbackHandler:
 			network.stoppedCallbackHandler <- 0
 			return
@@ -0,0 +1,104 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"time"
+)
+
+// handleApplicationUpdate publishes the update, and updates the nodes when
+// another controller includes or excludes a node. Unknown nodes which send
+// their node information are added and interviewed.
+// Assumptions: called only from callbackHandler
+func (network *Network) handleApplicationUpdate(update *message.ZWApplicationUpdate) {
+	switch update.Status {
+	case message.ZWApplicationUpdateStateNewIDAssigned:
+		network.discoverNode(update.NodeID)
+
+	case message.ZWApplicationUpdateStateDeleteDone:
+		network.removeNode(update.NodeID)
+
+	// Included SmartStart nodes send their node info when powered up
+	case message.ZWApplicationUpdateStateReceived,
+		message.ZWApplicationUpdateStateIncludedNodeInfoReceived:
+		n := network.GetNode(update.NodeID)
+		if n == nil {
+			if n = network.discoverNode(update.NodeID); n == nil {
+				log.Printf("INFO handleApplicationUpdate no node: %d for %+v",
+					update.NodeID, update)
+				return
+			}
+		}
+		go func() {
+			n.ApplicationUpdateHandler(update)
+		}()
+		network.recordContact(n.ID, 0)
+
+	case message.ZWApplicationUpdateStateSUCID,
+		message.ZWApplicationUpdateStateRoutePending,
+		message.ZWApplicationUpdateStateRequestFailed:
+		// Only published
+
+	default:
+		log.Printf("INFO handleApplicationUpdate unhandled State: 0x%02x for %+v",
+			update.Status, update)
+		return
+	}
+
+	network.publishApplicationUpdate(update)
+}
+
+// discoverNode adds the node, if it is not the controller, publishes an
+// EventTypeNodeAdded event, and interviews it in the background. Returns the
+// node, or nil if it is the controller or not valid.
+func (network *Network) discoverNode(nodeID uint16) *node.Node {
+	network.mutex.RLock()
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	if !message.IsValidNodeID(nodeID) || nodeID == controllerNodeID {
+		return nil
+	}
+
+	n, added := network.addNode(nodeID)
+	if !added {
+		return n
+	}
+
+	log.Printf("INFO discoverNode new node: %d", nodeID)
+	network.publish(&Event{Type: EventTypeNodeAdded, NodeID: nodeID, Time: time.Now()})
+
+	go func() {
+		if err := network.RefreshNode(nodeID); err != nil {
+			log.Printf("ERROR discoverNode node: %d failed to refresh: %v", nodeID, err)
+		}
+	}()
+
+	return n
+}
+
+// removeNode removes the node, and forgets its state
+func (network *Network) removeNode(nodeID uint16) {
+	network.mutex.Lock()
+	delete(network.nodes, nodeID)
+	network.mutex.Unlock()
+
+	network.forgetNode(nodeID)
+}
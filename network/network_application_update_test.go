@@ -0,0 +1,105 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"reflect"
+	"testing"
+)
+
+func TestHandleApplicationUpdate(t *testing.T) {
+	network := Network{nodes: make(map[uint16]*node.Node), nodeID: 1}
+	network.nodes[5] = node.MakeNode(5, nil)
+	network.pendingHeals = map[uint16]bool{5: true}
+	network.lifelines = map[uint16]*LifelineStatus{5: {NodeID: 5, Group: 1}}
+
+	subscription := network.Subscribe(SubscribeOptions{})
+	defer subscription.Close()
+
+	expectEvent := func(eventType uint8, nodeID uint16) *Event {
+		select {
+		case event := <-subscription.Events():
+			if event.Type != eventType || event.NodeID != nodeID {
+				t.Errorf("Unexpected event: %+v", event)
+			}
+			return event
+		default:
+			t.Errorf("Expected an event of type: 0x%02x", eventType)
+			return nil
+		}
+	}
+
+	// Excluded by another controller
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateDeleteDone, NodeID: 5})
+	if network.GetNode(5) != nil {
+		t.Errorf("Expected node 5 to be removed")
+	}
+	if len(network.pendingHeals) != 0 || len(network.lifelines) != 0 {
+		t.Errorf("Expected node 5 heal and lifeline state to be removed")
+	}
+	expectEvent(EventTypeNodeRemoved, 5)
+
+	// Included by another controller
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateNewIDAssigned, NodeID: 9,
+		Body: []uint8{0x04, 0x10, 0x01}})
+	if network.GetNode(9) == nil {
+		t.Errorf("Expected node 9 to be added")
+	}
+	expectEvent(EventTypeNodeAdded, 9)
+
+	// Controller is never added
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateNewIDAssigned, NodeID: 1})
+	if network.GetNode(1) != nil {
+		t.Errorf("Unexpected controller node")
+	}
+
+	// Known node sends its node info
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateReceived, NodeID: 9,
+		Body: []uint8{0x04, 0x10, 0x01, 0x25, 0x86, node.CommandClassMark, 0x20}})
+	if event := expectEvent(EventTypeNodeInfo, 9); event != nil {
+		if info := event.NodeInfo; info == nil || info.DeviceClass.Generic != 0x10 ||
+			!reflect.DeepEqual(info.CommandClasses, []uint8{0x25, 0x86}) ||
+			!reflect.DeepEqual(info.ControlCommandClasses, []uint8{0x20}) {
+			t.Errorf("Unexpected NodeInfo: %+v", info)
+		}
+	}
+
+	// Unknown node sends its node info
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateReceived, NodeID: 12,
+		Body: []uint8{0x04, 0x10, 0x01}})
+	if network.GetNode(12) == nil {
+		t.Errorf("Expected node 12 to be added")
+	}
+	expectEvent(EventTypeNodeAdded, 12)
+	expectEvent(EventTypeNodeInfo, 12)
+
+	for status, eventType := range map[uint8]uint8{
+		message.ZWApplicationUpdateStateSUCID:         EventTypeSUCChanged,
+		message.ZWApplicationUpdateStateRoutePending:  EventTypeRoutePending,
+		message.ZWApplicationUpdateStateRequestFailed: EventTypeNodeInfoFailed,
+	} {
+		network.handleApplicationUpdate(&message.ZWApplicationUpdate{Status: status,
+			NodeID: 9})
+		expectEvent(eventType, 9)
+	}
+}
@@ -0,0 +1,82 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/controller"
+	"log"
+	"time"
+)
+
+// handleConnectionState publishes a controller connection state, and checks
+// the controller after it reconnects
+// Assumptions: called only from callbackHandler
+func (network *Network) handleConnectionState(state uint8) {
+	if state != controller.ConnectionStateConnected {
+		network.publish(&Event{Type: EventTypeConnection, Time: time.Now(),
+			Connection: &ConnectionEvent{State: state}})
+		return
+	}
+
+	// Checks issue requests, which must not block the callback handler
+	go func() {
+		err := network.checkReconnect()
+		if err != nil {
+			log.Printf("ERROR handleConnectionState reconnect check failed: %v", err)
+		}
+		network.publish(&Event{Type: EventTypeConnection, Time: time.Now(),
+			Connection: &ConnectionEvent{State: state, Err: err}})
+	}()
+}
+
+// checkReconnect checks that the reconnected controller is still the
+// controller of the initialized network. Requests are refused until the
+// network is closed, if it is not. goroutine safe.
+func (network *Network) checkReconnect() error {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if !network.isOpen() {
+		return errors.New("API is not open")
+	}
+
+	// Nothing to compare against before Initialize
+	if network.homeID == 0 {
+		return nil
+	}
+
+	// The controller may have restarted with 8 bit node IDs
+	if err := network.initialSetNodeIDType(); err != nil {
+		return err
+	}
+
+	memoryID, err := network.initialGetMemoryID()
+	if err != nil {
+		return err
+	}
+
+	if memoryID.HomeID != network.homeID || memoryID.NodeID != network.nodeID {
+		network.reconnectErr = fmt.Errorf("Controller changed after reconnect, "+
+			"expected HomeID: 0x%08x NodeID: 0x%02x got HomeID: 0x%08x NodeID: 0x%02x",
+			network.homeID, network.nodeID, memoryID.HomeID, memoryID.NodeID)
+		return network.reconnectErr
+	}
+
+	return nil
+}
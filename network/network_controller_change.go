@@ -0,0 +1,196 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"log"
+	"time"
+)
+
+// TransferPrimaryRole hands the primary controller role to another
+// controller, which must enter its learn mode within the timeout. Afterwards
+// this controller is a secondary controller in the same network. Publishes
+// EventTypeControllerChange events. goroutine safe.
+func (network *Network) TransferPrimaryRole(timeout time.Duration) error {
+	var messageType uint8
+	network.mutex.RLock()
+	switch {
+	case !network.isOpen():
+		network.mutex.RUnlock()
+		return errors.New("API is not open")
+	case network.isSupportedMessageType(message.MessageTypeZWControllerChange):
+		messageType = message.MessageTypeZWControllerChange
+	case network.isSupportedMessageType(message.MessageTypeZWNewController):
+		messageType = message.MessageTypeZWNewController
+	default:
+		network.mutex.RUnlock()
+		return errors.New("Controller does not support controller change")
+	}
+	network.mutex.RUnlock()
+
+	// Drop stale callbacks
+loop:
+	for {
+		select {
+		case <-network.controllerChange:
+		default:
+			break loop
+		}
+	}
+
+	startID, err := network.doControllerChange(messageType, message.ControllerChangeStart)
+	if err != nil {
+		return err
+	}
+	stopID := startID
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case callback := <-network.controllerChange:
+			if callback.CallbackID != startID && callback.CallbackID != stopID {
+				continue
+			}
+
+			switch callback.Status {
+			case message.ControllerChangeStatusLearnReady,
+				message.ControllerChangeStatusNodeFound,
+				message.ControllerChangeStatusAddingController:
+				network.publishControllerChange(callback.NodeID, callback.Status, false)
+
+			case message.ControllerChangeStatusProtocolDone:
+				network.publishControllerChange(callback.NodeID, callback.Status, false)
+				// Stopping completes the handover with a Done callback
+				if stopID, err = network.doControllerChange(messageType,
+					message.ControllerChangeStop); err != nil {
+					return err
+				}
+
+			case message.ControllerChangeStatusDone:
+				log.Printf("INFO TransferPrimaryRole new primary: %d", callback.NodeID)
+				if err := network.refreshControllerCapabilities(); err != nil {
+					return err
+				}
+				network.publishControllerChange(callback.NodeID, callback.Status,
+					network.isPrimary())
+				return nil
+
+			default:
+				network.publishControllerChange(callback.NodeID, callback.Status, false)
+				network.stopControllerChange(messageType, message.ControllerChangeStopFailed)
+				return fmt.Errorf("TransferPrimaryRole failed: 0x%02x", callback.Status)
+			}
+
+		case <-deadline:
+			network.stopControllerChange(messageType, message.ControllerChangeStop)
+			return errors.New("TransferPrimaryRole timed out")
+		}
+	}
+}
+
+// ReceivePrimaryRole enters the learn mode, so that the primary controller of
+// the network can hand the primary role to this controller. The primary must
+// start its controller change within the timeout. The nodes are kept, unless
+// the learn mode moved the controller to another network. Publishes an
+// EventTypeControllerChange event. goroutine safe.
+func (network *Network) ReceivePrimaryRole(timeout time.Duration) error {
+	if err := network.runLearnMode("ReceivePrimaryRole", message.LearnModeClassic,
+		timeout); err != nil {
+		return err
+	}
+
+	changed, err := network.learnModeChangedNetwork()
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := network.reinitialize(); err != nil {
+			return err
+		}
+	} else if err := network.refreshControllerCapabilities(); err != nil {
+		return err
+	}
+
+	network.mutex.RLock()
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	primary := network.isPrimary()
+	network.publishControllerChange(controllerNodeID,
+		message.ControllerChangeStatusDone, primary)
+	if !primary {
+		return errors.New("ReceivePrimaryRole did not become primary")
+	}
+
+	return nil
+}
+
+// isPrimary checks if the controller is the primary controller
+func (network *Network) isPrimary() bool {
+	capabilities := network.GetControllerCapabilities()
+	return capabilities != nil && !capabilities.Secondary
+}
+
+// doControllerChange sends a ZWControllerChange or ZWNewController request,
+// and returns the callback id of its callbacks
+func (network *Network) doControllerChange(messageType uint8, mode uint8) (uint8, error) {
+	var requestPacket *packet.Packet
+	var err error
+	if messageType == message.MessageTypeZWNewController {
+		requestPacket, err = message.ZWNewControllerRequest(mode)
+	} else {
+		requestPacket, err = message.ZWControllerChangeRequest(mode)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err := network.DoRequest(requestPacket); err != nil {
+		return 0, err
+	}
+
+	// The SerialController appended the callback id to the request
+	return requestPacket.Body[len(requestPacket.Body)-1], nil
+}
+
+// stopControllerChange stops the controller change
+func (network *Network) stopControllerChange(messageType uint8, mode uint8) {
+	if _, err := network.doControllerChange(messageType, mode); err != nil {
+		log.Printf("ERROR stopControllerChange failed: %v", err)
+	}
+}
+
+// publishControllerChange publishes an EventTypeControllerChange event
+func (network *Network) publishControllerChange(nodeID uint16, status uint8, primary bool) {
+	network.publish(&Event{Type: EventTypeControllerChange, NodeID: nodeID,
+		Time: time.Now(), ControllerChange: &ControllerChangeEvent{Status: status,
+			Primary: primary}})
+}
+
+// notifyControllerChange passes the controller change callback to
+// TransferPrimaryRole
+// Assumptions: called only from callbackHandler
+func (network *Network) notifyControllerChange(callback *message.ZWControllerChange) {
+	select {
+	case network.controllerChange <- callback:
+	default:
+		log.Printf("INFO notifyControllerChange dropping callback: %+v", callback)
+	}
+}
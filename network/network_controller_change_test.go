@@ -0,0 +1,239 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testReceivePrimaryRole initializes a secondary controller with nodes 2 and
+// 3, receives the primary role, and returns the published events
+func testReceivePrimaryRole(t *testing.T, homeID uint32) (*Network, []*Event) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3})
+	c.handle(message.MessageTypeZWGetControllerCapabilities, testResponseHandler(0x01))
+	network := openTestNetwork(t, c)
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The learn mode makes the controller primary in the HomeID. Handlers are
+	// called with c.mutex held.
+	c.handle(message.MessageTypeZWSetLearnMode,
+		func(request *packet.Packet) []*packet.Packet {
+			c.handlers[message.MessageTypeZWGetControllerCapabilities] = testResponseHandler(0x08)
+			c.handlers[message.MessageTypeMemoryGetID] = testResponseHandler(uint8(homeID>>24),
+				uint8(homeID>>16), uint8(homeID>>8), uint8(homeID), 0x01)
+			return []*packet.Packet{
+				testFrame(packet.PacketTypeResponse, request.MessageType, 0x01),
+				testFrame(packet.PacketTypeRequest, request.MessageType,
+					testCallbackID(request), message.LearnModeStatusStarted, 0x00, 0x00),
+				testFrame(packet.PacketTypeRequest, request.MessageType,
+					testCallbackID(request), message.LearnModeStatusDone, 0x01, 0x00)}
+		})
+
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeNodeRemoved, EventTypeControllerChange}}})
+	defer subscription.Close()
+
+	if err := network.ReceivePrimaryRole(time.Second); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	var events []*Event
+	for len(subscription.Events()) > 0 {
+		events = append(events, <-subscription.Events())
+	}
+	return network, events
+}
+
+func TestReceivePrimaryRole(t *testing.T) {
+	network, events := testReceivePrimaryRole(t, 0x01020304)
+	defer network.Close()
+
+	// The nodes are kept
+	if n := len(network.GetNodes()); n != 2 {
+		t.Errorf("Expected 2 nodes got %d", n)
+	}
+	if len(events) != 1 || events[0].Type != EventTypeControllerChange ||
+		!events[0].ControllerChange.Primary {
+		t.Errorf("Expected primary controller change event: %v", events)
+	}
+}
+
+func TestReceivePrimaryRoleOtherNetwork(t *testing.T) {
+	network, events := testReceivePrimaryRole(t, 0x0a0b0c0d)
+	defer network.Close()
+
+	// The network is initialized again
+	network.mutex.RLock()
+	homeID := network.homeID
+	network.mutex.RUnlock()
+	if homeID != 0x0a0b0c0d {
+		t.Errorf("Expected new HomeID got 0x%08x", homeID)
+	}
+	removed := 0
+	for _, event := range events {
+		if event.Type == EventTypeNodeRemoved {
+			removed++
+		}
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 removed nodes: %v", events)
+	}
+}
+
+// testTransferPrimaryRole initializes primary controller node 1 with nodes 2
+// and 3, which supports the messageType for the controller change. Stopping
+// the controller change reports Done, and makes this controller secondary.
+func testTransferPrimaryRole(t *testing.T, messageType uint8) (*testController, *Network) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3}, messageType)
+	network := openTestNetwork(t, c)
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// Handlers are called with c.mutex held
+	c.handle(messageType, func(request *packet.Packet) []*packet.Packet {
+		if request.Body[0] != message.ControllerChangeStop {
+			return nil
+		}
+		c.handlers[message.MessageTypeZWGetControllerCapabilities] = testResponseHandler(0x01)
+		return []*packet.Packet{testFrame(packet.PacketTypeRequest, request.MessageType,
+			testCallbackID(request), message.ControllerChangeStatusDone, 0x04, 0x00)}
+	})
+
+	return c, network
+}
+
+// testControllerChange runs TransferPrimaryRole, and sends the callbacks of
+// the statuses one by one, since callbacks are not delivered in order. Returns
+// the published events and error.
+func testControllerChange(t *testing.T, c *testController, network *Network,
+	messageType uint8, statuses ...uint8) ([]*Event, error) {
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeControllerChange}}})
+	defer subscription.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- network.TransferPrimaryRole(time.Second)
+	}()
+
+	var events []*Event
+	start := c.waitRequests(messageType, 1)[0]
+	for _, status := range statuses {
+		c.send(testFrame(packet.PacketTypeRequest, messageType, testCallbackID(start),
+			status, 0x04, 0x00))
+
+		select {
+		case event := <-subscription.Events():
+			events = append(events, event)
+		case <-time.After(testRequestTimeout):
+			t.Fatalf("Timed out waiting for the event of status 0x%02x", status)
+		}
+	}
+
+	err := <-done
+	for len(subscription.Events()) > 0 {
+		events = append(events, <-subscription.Events())
+	}
+	return events, err
+}
+
+// testControllerChangeModes returns the modes of the controller change
+// requests
+func testControllerChangeModes(c *testController, messageType uint8) []uint8 {
+	var modes []uint8
+	for _, request := range c.getRequests(messageType) {
+		modes = append(modes, request.Body[0])
+	}
+	return modes
+}
+
+func TestTransferPrimaryRole(t *testing.T) {
+	c, network := testTransferPrimaryRole(t, message.MessageTypeZWControllerChange)
+	defer network.Close()
+
+	events, err := testControllerChange(t, c, network, message.MessageTypeZWControllerChange,
+		message.ControllerChangeStatusLearnReady, message.ControllerChangeStatusNodeFound,
+		message.ControllerChangeStatusAddingController,
+		message.ControllerChangeStatusProtocolDone)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The controller change is stopped after the protocol is done
+	if modes := testControllerChangeModes(c, message.MessageTypeZWControllerChange); !reflect.DeepEqual(modes,
+		[]uint8{message.ControllerChangeStart, message.ControllerChangeStop}) {
+		t.Errorf("Expected controller change start and stop: %v", modes)
+	}
+
+	// The capabilities are refreshed after Done
+	if n := len(c.getRequests(message.MessageTypeZWGetControllerCapabilities)); n != 2 {
+		t.Errorf("Expected 2 ZWGetControllerCapabilities requests got %d", n)
+	}
+	if capabilities := network.GetControllerCapabilities(); capabilities == nil ||
+		!capabilities.Secondary {
+		t.Errorf("Expected secondary controller: %+v", capabilities)
+	}
+
+	var statuses []uint8
+	for _, event := range events {
+		statuses = append(statuses, event.ControllerChange.Status)
+		if event.NodeID != 4 || event.ControllerChange.Primary {
+			t.Errorf("Expected secondary controller change event of node 4: %+v %+v",
+				event, event.ControllerChange)
+		}
+	}
+	if !reflect.DeepEqual(statuses, []uint8{message.ControllerChangeStatusLearnReady,
+		message.ControllerChangeStatusNodeFound,
+		message.ControllerChangeStatusAddingController,
+		message.ControllerChangeStatusProtocolDone,
+		message.ControllerChangeStatusDone}) {
+		t.Errorf("Unexpected controller change statuses: %v", statuses)
+	}
+}
+
+func TestTransferPrimaryRoleFailed(t *testing.T) {
+	// Older controllers only support ZWNewController
+	c, network := testTransferPrimaryRole(t, message.MessageTypeZWNewController)
+	defer network.Close()
+
+	events, err := testControllerChange(t, c, network, message.MessageTypeZWNewController,
+		message.ControllerChangeStatusLearnReady, message.ControllerChangeStatusFailed)
+	if err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	if modes := testControllerChangeModes(c, message.MessageTypeZWNewController); !reflect.DeepEqual(modes,
+		[]uint8{message.ControllerChangeStart, message.ControllerChangeStopFailed}) {
+		t.Errorf("Expected controller change start and stop failed: %v", modes)
+	}
+	if n := len(c.getRequests(message.MessageTypeZWGetControllerCapabilities)); n != 1 {
+		t.Errorf("Expected 1 ZWGetControllerCapabilities request got %d", n)
+	}
+	if len(events) != 2 {
+		t.Errorf("Expected 2 controller change events: %v", events)
+	}
+}
@@ -0,0 +1,443 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"sync"
+	"time"
+)
+
+// Event Type
+const (
+	EventTypeSwitch           uint8 = 0x01 // Binary or multi level switch report
+	EventTypeMeter                  = 0x02 // Meter reading
+	EventTypeSensor                 = 0x03 // Multi level sensor value
+	EventTypeBinarySensor           = 0x04 // Binary sensor state
+	EventTypeNotification           = 0x05 // Alarm or notification
+	EventTypeBattery                = 0x06 // Battery level
+	EventTypeNodeAdded              = 0x07 // Node added to the network
+	EventTypeNodeRemoved            = 0x08 // Node removed from the network
+	EventTypeNodeAwake              = 0x09 // Sleeping node woke up
+	EventTypeNodeAsleep             = 0x0a // Sleeping node was sent back to sleep
+	EventTypeValueChanged           = 0x0b // Value in the value store changed
+	EventTypeNodeDead               = 0x0c // Listening node stopped responding
+	EventTypeNodeAlive              = 0x0d // Dead node responded again
+	EventTypeConnection             = 0x0e // Controller connection state changed
+	EventTypeControllerChange       = 0x0f // Primary controller handover progressed
+	EventTypeSUCChanged             = 0x10 // SUC node changed, NodeID is the new SUC or 0
+	EventTypeNodeInfo               = 0x11 // Node information frame received
+	EventTypeNodeInfoFailed         = 0x12 // Node information request failed
+	EventTypeRoutePending           = 0x13 // Node is not responding, routing pending
+)
+
+// Drop Policy. Events are published from the callback handler, so delivery
+// never blocks on a slow subscriber.
+const (
+	DropPolicyNewest uint8 = 0x00 // Drop the new event if the buffer is full
+	DropPolicyOldest       = 0x01 // Drop the oldest buffered event if full
+)
+
+// Default number of buffered events per subscription
+const defaultEventBufferSize = 64
+
+// SwitchEvent information
+type SwitchEvent struct {
+	CommandClass uint8 // CommandClassBinarySwitch or CommandClassMultiLevelSwitch
+	On           bool
+	Level        uint8 // Multi level switch level, or 0x00 / 0xff for binary
+}
+
+// BinarySensorEvent information
+type BinarySensorEvent struct {
+	SensorType uint8
+	Active     bool
+}
+
+// NotificationEvent information
+type NotificationEvent struct {
+	AlarmType uint8
+	Active    bool
+}
+
+// BatteryEvent information
+type BatteryEvent struct {
+	Level uint8 // Percent
+	Low   bool
+}
+
+// ConnectionEvent information
+type ConnectionEvent struct {
+	State uint8 // One of controller.ConnectionState
+	Err   error // Failure of the checks after reconnecting
+}
+
+// ControllerChangeEvent information
+type ControllerChangeEvent struct {
+	Status  uint8 // One of message.ControllerChangeStatus
+	Primary bool  // Controller is the primary, set once Status is Done
+}
+
+// Event information. Only the field of the Type is set.
+type Event struct {
+	Type   uint8     // One of EventType
+	NodeID uint16    // Source node
+	Time   time.Time // Time the event was received
+
+	Switch           *SwitchEvent
+	Meter            *node.MeterResult
+	Sensor           *node.MultiLevelSensorResult
+	BinarySensor     *BinarySensorEvent
+	Notification     *NotificationEvent
+	Battery          *BatteryEvent
+	ValueChange      *ValueChange
+	Connection       *ConnectionEvent
+	ControllerChange *ControllerChangeEvent
+	NodeInfo         *node.NodeInfo
+}
+
+// EventFilter information. Empty lists match everything.
+type EventFilter struct {
+	Types   []uint8  // Event types to deliver
+	NodeIDs []uint16 // Source nodes to deliver
+}
+
+// SubscribeOptions information
+type SubscribeOptions struct {
+	Filter     EventFilter
+	BufferSize int   // Number of buffered events, defaults to 64
+	DropPolicy uint8 // One of DropPolicy, defaults to DropPolicyNewest
+}
+
+// Subscription to network events
+type Subscription struct {
+	network    *Network
+	filter     EventFilter
+	dropPolicy uint8
+	mutex      sync.Mutex  // Serializes delivery and Close
+	events     chan *Event // Buffered events
+	closeOnce  sync.Once   // Close once
+	closed     bool        // Events channel is closed
+	dropped    uint64      // Number of dropped events
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// matches checks if the event passes the filter
+func (filter *EventFilter) matches(event *Event) bool {
+	if len(filter.Types) > 0 {
+		found := false
+		for _, x := range filter.Types {
+			if x == event.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(filter.NodeIDs) > 0 {
+		found := false
+		for _, x := range filter.NodeIDs {
+			if x == event.NodeID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subscribe to typed events of all nodes. Events are delivered in order, and
+// the Events channel is closed after Close. goroutine safe.
+func (network *Network) Subscribe(options SubscribeOptions) *Subscription {
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultEventBufferSize
+	}
+
+	subscription := Subscription{network: network, filter: options.Filter,
+		dropPolicy: options.DropPolicy,
+		events:     make(chan *Event, bufferSize)}
+
+	network.eventMutex.Lock()
+	if network.subscriptions == nil {
+		network.subscriptions = make(map[*Subscription]bool)
+	}
+	network.subscriptions[&subscription] = true
+	network.eventMutex.Unlock()
+
+	return &subscription
+}
+
+// Events returns the channel of delivered events
+func (subscription *Subscription) Events() <-chan *Event {
+	return subscription.events
+}
+
+// Dropped returns the number of events dropped due to a full buffer.
+// goroutine safe.
+func (subscription *Subscription) Dropped() uint64 {
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	return subscription.dropped
+}
+
+// Close the subscription and its Events channel. goroutine safe.
+func (subscription *Subscription) Close() {
+	subscription.closeOnce.Do(func() {
+		network := subscription.network
+		network.eventMutex.Lock()
+		delete(network.subscriptions, subscription)
+		network.eventMutex.Unlock()
+
+		subscription.mutex.Lock()
+		subscription.closed = true
+		close(subscription.events)
+		subscription.mutex.Unlock()
+	})
+}
+
+// deliver the event according to the drop policy
+func (subscription *Subscription) deliver(event *Event) {
+	if !subscription.filter.matches(event) {
+		return
+	}
+
+	subscription.mutex.Lock()
+	defer subscription.mutex.Unlock()
+
+	if subscription.closed {
+		return
+	}
+
+	switch subscription.dropPolicy {
+	case DropPolicyOldest:
+		for {
+			select {
+			case subscription.events <- event:
+				return
+			default:
+			}
+			// Full, so drop the oldest, unless the subscriber just drained it
+			select {
+			case <-subscription.events:
+				subscription.dropped++
+			default:
+			}
+		}
+
+	default:
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped++
+		}
+	}
+}
+
+// publish the event to all subscriptions
+func (network *Network) publish(event *Event) {
+	network.eventMutex.RLock()
+	subscriptions := make([]*Subscription, 0, len(network.subscriptions))
+	for subscription := range network.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	network.eventMutex.RUnlock()
+
+	for _, subscription := range subscriptions {
+		subscription.deliver(event)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// decodeReport decodes the typed report of the command, or returns nil if it
+// is not a report, or is ignored for the device
+func (network *Network) decodeReport(n *node.Node, command *message.ApplicationCommand) *node.Report {
+	data := applicationCommandData(n, command)
+	if data == nil {
+		return nil
+	}
+
+	report, err := node.DecodeReport(n, data)
+	if err != nil {
+		log.Printf("ERROR decodeReport node: %d failed to decode: %v", n.ID, err)
+		return nil
+	}
+	return report
+}
+
+// publishApplicationCommand publishes the report of the command, and the
+// value changes of the command
+func (network *Network) publishApplicationCommand(n *node.Node, command *message.ApplicationCommand,
+	report *node.Report) {
+	if applicationCommandData(n, command) == nil {
+		return
+	}
+
+	event := decodeEvent(report)
+
+	// Update values first, so that subscribers see them in the value store
+	for _, value := range decodeValues(report, event) {
+		if change := network.updateValue(value); change != nil {
+			network.publish(&Event{Type: EventTypeValueChanged, NodeID: n.ID,
+				Time: value.Time, ValueChange: change})
+		}
+	}
+
+	if event != nil {
+		network.publish(event)
+	}
+}
+
+// applicationCommandData returns the command as node.ApplicationCommandData,
+// or nil if it is too short, or is ignored for the device
+func applicationCommandData(n *node.Node, command *message.ApplicationCommand) *node.ApplicationCommandData {
+	if len(command.Body) < 2 {
+		return nil
+	}
+
+	// Same filtering as the node
+	if device := n.GetDevice(); device != nil &&
+		device.IsReportIgnored(command.Body[0], command.Body[1]) {
+		return nil
+	}
+
+	data := node.ApplicationCommandData{Status: command.Status, NodeID: command.NodeID}
+	data.Command.ClassID = command.Body[0]
+	data.Command.ID = command.Body[1]
+	data.Command.Data = command.Body[2:]
+
+	return &data
+}
+
+// publishApplicationUpdate publishes SUC, routing and node information updates
+func (network *Network) publishApplicationUpdate(update *message.ZWApplicationUpdate) {
+	event := Event{NodeID: update.NodeID, Time: time.Now()}
+
+	switch update.Status {
+	case message.ZWApplicationUpdateStateSUCID:
+		event.Type = EventTypeSUCChanged
+
+	case message.ZWApplicationUpdateStateRoutePending:
+		event.Type = EventTypeRoutePending
+
+	case message.ZWApplicationUpdateStateRequestFailed:
+		event.Type = EventTypeNodeInfoFailed
+
+	case message.ZWApplicationUpdateStateReceived,
+		message.ZWApplicationUpdateStateIncludedNodeInfoReceived:
+		info, err := node.ParseNodeInfo(update.Body)
+		if err != nil {
+			log.Printf("ERROR publishApplicationUpdate node: %d: %v", update.NodeID, err)
+			return
+		}
+		event.Type = EventTypeNodeInfo
+		event.NodeInfo = info
+
+	default:
+		return
+	}
+
+	network.publish(&event)
+}
+
+// decodeEvent converts the report into an event, or nil if the report is not
+// an event
+func decodeEvent(report *node.Report) *Event {
+	if report == nil {
+		return nil
+	}
+
+	event := Event{NodeID: report.NodeID, Time: report.Time}
+
+	switch value := report.Value.(type) {
+	case *node.BinarySwitchReport:
+		event.Type = EventTypeSwitch
+		event.Switch = &SwitchEvent{CommandClass: report.CommandClass, On: value.On,
+			Level: value.Value}
+
+	case *node.MultiLevelSwitchReport:
+		event.Type = EventTypeSwitch
+		event.Switch = &SwitchEvent{CommandClass: report.CommandClass, On: value.On,
+			Level: value.Level}
+
+	case *node.MeterResult:
+		event.Type = EventTypeMeter
+		event.Meter = value
+
+	case *node.MultiLevelSensorResult:
+		event.Type = EventTypeSensor
+		event.Sensor = value
+
+	case *node.BinarySensorReport:
+		event.Type = EventTypeBinarySensor
+		event.BinarySensor = &BinarySensorEvent{SensorType: value.SensorType,
+			Active: value.Active}
+
+	case *node.AlarmReport:
+		event.Type = EventTypeNotification
+		event.Notification = &NotificationEvent{AlarmType: value.AlarmType,
+			Active: value.Active}
+
+	case *node.BatteryReport:
+		event.Type = EventTypeBattery
+		event.Battery = &BatteryEvent{Level: value.Level, Low: value.Low}
+
+	case *node.WakeUpNotification:
+		event.Type = EventTypeNodeAwake
+
+	default:
+		return nil
+	}
+
+	return &event
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SendToSleep tells the awake node that it can go back to sleep, and publishes
+// EventTypeNodeAsleep. goroutine safe.
+func (network *Network) SendToSleep(nodeID uint16) error {
+	n := network.GetNode(nodeID)
+	if n == nil {
+		return node.ErrNodeNotFound
+	}
+
+	wakeUp := n.GetWakeUp()
+	if wakeUp == nil {
+		return fmt.Errorf("Node %d does not support WakeUp", nodeID)
+	}
+
+	if err := wakeUp.NoMoreInformation(); err != nil {
+		return err
+	}
+
+	network.publish(&Event{Type: EventTypeNodeAsleep, NodeID: nodeID, Time: time.Now()})
+
+	return nil
+}
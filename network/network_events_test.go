@@ -0,0 +1,173 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"testing"
+	"time"
+)
+
+func TestSubscribeFilter(t *testing.T) {
+	network := Network{}
+
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeBattery}, NodeIDs: []uint16{3}}})
+	defer subscription.Close()
+
+	network.publish(&Event{Type: EventTypeBattery, NodeID: 2})
+	network.publish(&Event{Type: EventTypeMeter, NodeID: 3})
+	network.publish(&Event{Type: EventTypeBattery, NodeID: 3})
+
+	select {
+	case event := <-subscription.Events():
+		if event.Type != EventTypeBattery || event.NodeID != 3 {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+	default:
+		t.Errorf("Expected an event")
+	}
+
+	select {
+	case event := <-subscription.Events():
+		t.Errorf("Unexpected event: %+v", event)
+	default:
+	}
+}
+
+func TestSubscribeDropPolicy(t *testing.T) {
+	network := Network{}
+
+	newest := network.Subscribe(SubscribeOptions{BufferSize: 2})
+	oldest := network.Subscribe(SubscribeOptions{BufferSize: 2, DropPolicy: DropPolicyOldest})
+	defer newest.Close()
+	defer oldest.Close()
+
+	for i := uint16(1); i <= 4; i++ {
+		network.publish(&Event{Type: EventTypeNodeAwake, NodeID: i})
+	}
+
+	for _, x := range []struct {
+		subscription *Subscription
+		nodeIDs      []uint16
+	}{{newest, []uint16{1, 2}}, {oldest, []uint16{3, 4}}} {
+		if dropped := x.subscription.Dropped(); dropped != 2 {
+			t.Errorf("Expected 2 dropped got: %d", dropped)
+		}
+		for _, nodeID := range x.nodeIDs {
+			if event := <-x.subscription.Events(); event.NodeID != nodeID {
+				t.Errorf("Expected node: %d got: %d", nodeID, event.NodeID)
+			}
+		}
+	}
+}
+
+func TestSubscribeClose(t *testing.T) {
+	network := Network{}
+
+	subscription := network.Subscribe(SubscribeOptions{BufferSize: 1})
+	network.publish(&Event{Type: EventTypeNodeAwake, NodeID: 1})
+
+	// A full buffer does not block publish
+	published := make(chan int)
+	go func() {
+		network.publish(&Event{Type: EventTypeNodeAwake, NodeID: 2})
+		published <- 0
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected publish not to block")
+	}
+
+	// Close closes the channel, and later events are not delivered
+	subscription.Close()
+	subscription.Close()
+	network.publish(&Event{Type: EventTypeNodeAwake, NodeID: 3})
+
+	var nodeIDs []uint16
+	for event := range subscription.Events() {
+		nodeIDs = append(nodeIDs, event.NodeID)
+	}
+	if len(nodeIDs) != 1 || nodeIDs[0] != 1 {
+		t.Errorf("Expected node 1 event got: %v", nodeIDs)
+	}
+	if dropped := subscription.Dropped(); dropped != 1 {
+		t.Errorf("Expected 1 dropped got: %d", dropped)
+	}
+
+	if len(network.subscriptions) != 0 {
+		t.Errorf("Expected no subscriptions")
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	n := node.MakeNode(5, nil)
+
+	for _, x := range []struct {
+		body      []uint8
+		eventType uint8
+	}{
+		{[]uint8{node.CommandClassBinarySwitch, 0x03, 0xff}, EventTypeSwitch},
+		{[]uint8{node.CommandClassMultiLevelSwitch, 0x03, 0x20}, EventTypeSwitch},
+		{[]uint8{node.CommandClassBattery, 0x03, 0xff}, EventTypeBattery},
+		{[]uint8{node.CommandClassWakeup, 0x07}, EventTypeNodeAwake},
+		{[]uint8{node.CommandClassBinarySwitch, 0x01, 0xff}, 0},
+		{[]uint8{node.CommandClassVersion, 0x12}, 0},
+	} {
+		report := node.ApplicationCommandData{NodeID: 5}
+		report.Command.ClassID = x.body[0]
+		report.Command.ID = x.body[1]
+		report.Command.Data = x.body[2:]
+
+		decoded, err := node.DecodeReport(n, &report)
+		if err != nil {
+			t.Errorf("Expected nil error: %v", err)
+			continue
+		}
+		event := decodeEvent(decoded)
+
+		if x.eventType == 0 {
+			if event != nil {
+				t.Errorf("Expected nil event: %+v", event)
+			}
+			continue
+		}
+
+		if event == nil || event.Type != x.eventType || event.NodeID != 5 {
+			t.Errorf("Unexpected event: %+v for %v", event, x.body)
+		}
+	}
+
+	// Battery low
+	report := node.ApplicationCommandData{NodeID: 5}
+	report.Command.ClassID = node.CommandClassBattery
+	report.Command.ID = 0x03
+	report.Command.Data = []uint8{0xff}
+	decoded, err := node.DecodeReport(n, &report)
+	if event := decodeEvent(decoded); err != nil || !event.Battery.Low {
+		t.Errorf("Unexpected event: %+v %v", event, err)
+	}
+
+	// Bad report
+	command := message.ApplicationCommand{NodeID: 5,
+		Body: []uint8{node.CommandClassBattery, 0x03}}
+	network := Network{}
+	if report := network.decodeReport(n, &command); report != nil {
+		t.Errorf("Expected nil report: %+v", report)
+	}
+}
@@ -0,0 +1,228 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"sort"
+	"strings"
+)
+
+// Multicast and broadcast frames are not acknowledged, nor routed
+const multicastTransmitOptions = uint8(0x00)
+
+// Group of nodes, which are sent the same command in one multicast frame
+type Group struct {
+	network *Network
+	nodeIDs []uint16
+
+	// FollowUp sends a singlecast of the command to each node after the
+	// multicast, to verify that it was received
+	FollowUp bool
+}
+
+// GroupError contains the nodes which failed the singlecast follow up
+type GroupError struct {
+	Failed map[uint16]error
+}
+
+func (err *GroupError) Error() string {
+	nodeIDs := make([]int, 0, len(err.Failed))
+	for nodeID := range err.Failed {
+		nodeIDs = append(nodeIDs, int(nodeID))
+	}
+	sort.Ints(nodeIDs)
+
+	failures := make([]string, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		failures[i] = fmt.Sprintf("%d: %v", nodeID, err.Failed[uint16(nodeID)])
+	}
+	return fmt.Sprintf("Follow up failed for nodes: %s", strings.Join(failures, ", "))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewGroup creates a group of the unique nodeIDs
+func (network *Network) NewGroup(nodeIDs ...uint16) (*Group, error) {
+	unique := make(map[uint16]bool)
+	for _, nodeID := range nodeIDs {
+		if !message.IsValidNodeID(nodeID) {
+			return nil, fmt.Errorf("Invalid nodeID: 0x%02x", nodeID)
+		}
+		unique[nodeID] = true
+	}
+
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("Group must have at least one node")
+	}
+
+	group := Group{network: network}
+	for nodeID := range unique {
+		group.nodeIDs = append(group.nodeIDs, nodeID)
+	}
+	sort.Slice(group.nodeIDs, func(i, j int) bool {
+		return group.nodeIDs[i] < group.nodeIDs[j]
+	})
+
+	return &group, nil
+}
+
+// NodeIDs returns the sorted IDs of the nodes in the group
+func (group *Group) NodeIDs() []uint16 {
+	nodeIDs := make([]uint16, len(group.nodeIDs))
+	copy(nodeIDs, group.nodeIDs)
+	return nodeIDs
+}
+
+// Send the command class payload to all nodes in the group, using as few
+// multicast frames as possible, and then optionally singlecast follow ups.
+// Long Range nodes can't be multicast, so they are always sent singlecasts.
+// Returns a *GroupError if any of the follow ups fail. goroutine safe.
+func (group *Group) Send(commandClass uint8, payload []uint8) error {
+	var classicNodeIDs []uint8
+	var longRangeNodeIDs []uint16
+	for _, nodeID := range group.nodeIDs {
+		if message.IsClassicNodeID(nodeID) {
+			classicNodeIDs = append(classicNodeIDs, uint8(nodeID))
+		} else {
+			longRangeNodeIDs = append(longRangeNodeIDs, nodeID)
+		}
+	}
+
+	for start := 0; start < len(classicNodeIDs); start += message.MaxMulticastNodes {
+		end := start + message.MaxMulticastNodes
+		if end > len(classicNodeIDs) {
+			end = len(classicNodeIDs)
+		}
+		if err := group.network.zWSendDataMulti(classicNodeIDs[start:end],
+			commandClass, payload); err != nil {
+			return err
+		}
+	}
+
+	singlecastNodeIDs := longRangeNodeIDs
+	if group.FollowUp {
+		singlecastNodeIDs = group.nodeIDs
+	}
+
+	groupError := GroupError{Failed: make(map[uint16]error)}
+	for _, nodeID := range singlecastNodeIDs {
+		if err := group.network.zWSendData(nodeID, commandClass, payload); err != nil {
+			groupError.Failed[nodeID] = err
+		}
+	}
+
+	if len(groupError.Failed) > 0 {
+		return &groupError
+	}
+
+	return nil
+}
+
+// BinarySwitchSet turns the binary switches in the group on or off
+func (group *Group) BinarySwitchSet(on bool) error {
+	return group.Send(node.CommandClassBinarySwitch, node.BinarySwitchSetPayload(on))
+}
+
+// MultiLevelSwitchSet sets the level of the multi level switches in the group
+// to the requested value, which must be in the range of [0, 99] or 0xff, where
+// 255 is the most recent non-zero level
+func (group *Group) MultiLevelSwitchSet(value uint8) error {
+	payload, err := node.MultiLevelSwitchSetPayload(value)
+	if err != nil {
+		return err
+	}
+	return group.Send(node.CommandClassMultiLevelSwitch, payload)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Broadcast the command class payload to all nodes in one frame. goroutine
+// safe.
+func (network *Network) Broadcast(commandClass uint8, payload []uint8) error {
+	requestPacket, err := message.ZWSendDataBroadcastRequest(network.NodeIDType(),
+		commandClass, payload,
+		multicastTransmitOptions)
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWSendDataResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWSendData broadcast failed: 0x%02x", responseMessage.Status)
+	}
+
+	return nil
+}
+
+// zWSendDataMulti sends the command class payload to the nodes in one frame
+func (network *Network) zWSendDataMulti(nodeIDs []uint8, commandClass uint8,
+	payload []uint8) error {
+	requestPacket, err := message.ZWSendDataMultiRequest(nodeIDs, commandClass,
+		payload, multicastTransmitOptions)
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWSendDataMultiResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWSendDataMulti failed: 0x%02x", responseMessage.Status)
+	}
+
+	return nil
+}
+
+// zWSendData sends the command class payload to one node
+func (network *Network) zWSendData(nodeID uint16, commandClass uint8,
+	payload []uint8) error {
+	requestPacket, err := message.ZWSendDataRequest(nodeID, network.NodeIDType(),
+		commandClass, payload, node.DefaultTransmitOptions, 0x00)
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWSendDataResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWSendData failed to contact node: 0x%02x",
+			responseMessage.Status)
+	}
+
+	return nil
+}
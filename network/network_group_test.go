@@ -0,0 +1,52 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewGroup(t *testing.T) {
+	network := Network{}
+
+	group, err := network.NewGroup(5, 3, 5, 9)
+	if group == nil || err != nil {
+		t.Errorf("Expected non nil group and nil error: %v %v", group, err)
+		t.FailNow()
+	}
+	if expected := []uint16{3, 5, 9}; !reflect.DeepEqual(expected, group.NodeIDs()) {
+		t.Errorf("Expected NodeIDs: %v got: %v", expected, group.NodeIDs())
+	}
+
+	for _, nodeIDs := range [][]uint16{{}, {3, 0}, {3, 0xff}, {3, 4001}} {
+		if group, err := network.NewGroup(nodeIDs...); group != nil || err == nil {
+			t.Errorf("Expected nil group and non nil error: %v %v", group, err)
+		}
+	}
+}
+
+func TestGroupError(t *testing.T) {
+	err := GroupError{Failed: map[uint16]error{
+		9: errors.New("b"),
+		3: errors.New("a"),
+	}}
+	if expected := "Follow up failed for nodes: 3: a, 9: b"; err.Error() != expected {
+		t.Errorf("Expected: %s got: %s", expected, err.Error())
+	}
+}
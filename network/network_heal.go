@@ -0,0 +1,300 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"sort"
+)
+
+// Heal Status
+const (
+	HealStatusStarted  uint8 = 0x01
+	HealStatusDone           = 0x02
+	HealStatusFailed         = 0x03
+	HealStatusDeferred       = 0x04 // Node is asleep, healed on next wake up
+)
+
+// WakeUp command class notification sent by sleeping nodes
+const wakeUpCommandNotification uint8 = 0x07
+
+// HealProgress information
+type HealProgress struct {
+	NodeID uint16 // Node being healed
+	Status uint8  // One of HealStatus
+	Err    error  // Failure reason for HealStatusFailed
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Heal updates the neighbors and return routes of all nodes, one at a time.
+// Sleeping nodes are deferred until their next wake up notification. Progress
+// is sent to the optional progress channel, which must be drained by the
+// caller. goroutine safe.
+func (network *Network) Heal(progress chan *HealProgress) error {
+	network.mutex.RLock()
+	if !network.isOpen() {
+		network.mutex.RUnlock()
+		return errors.New("API is not open")
+	}
+	network.mutex.RUnlock()
+
+	nodes := network.GetNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	report := func(nodeID uint16, status uint8, err error) {
+		if progress != nil {
+			progress <- &HealProgress{NodeID: nodeID, Status: status, Err: err}
+		}
+	}
+
+	for _, n := range nodes {
+		if !n.IsReachable() {
+			network.healMutex.Lock()
+			if network.pendingHeals == nil {
+				network.pendingHeals = make(map[uint16]bool)
+			}
+			network.pendingHeals[n.ID] = true
+			network.healMutex.Unlock()
+
+			report(n.ID, HealStatusDeferred, nil)
+			continue
+		}
+
+		report(n.ID, HealStatusStarted, nil)
+		if err := network.HealNode(n.ID); err != nil {
+			report(n.ID, HealStatusFailed, err)
+		} else {
+			report(n.ID, HealStatusDone, nil)
+		}
+	}
+
+	return nil
+}
+
+// HealNode updates the neighbors of the node, and reassigns its return routes
+// to the controller, the SUC and its association targets. The node must be
+// awake. Long Range nodes talk directly to the controller, and have nothing to
+// heal. goroutine safe.
+func (network *Network) HealNode(nodeID uint16) error {
+	n := network.GetNode(nodeID)
+	if n == nil {
+		return node.ErrNodeNotFound
+	}
+
+	if message.IsLongRangeNodeID(nodeID) {
+		return nil
+	}
+
+	network.mutex.RLock()
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	// Neighbors first, since return routes are computed from them
+	if err := network.zWRequestNodeNeighborUpdate(nodeID); err != nil {
+		return err
+	}
+
+	if err := network.zWDeleteReturnRoute(nodeID); err != nil {
+		return err
+	}
+
+	if err := network.zWAssignReturnRoute(nodeID, controllerNodeID); err != nil {
+		return err
+	}
+
+	// Association targets, which are not the controller
+	for _, targetID := range network.getAssociationTargets(n) {
+		if targetID == controllerNodeID || targetID == nodeID {
+			continue
+		}
+		if err := network.zWAssignReturnRoute(nodeID, targetID); err != nil {
+			return err
+		}
+	}
+
+	// Fails on networks without a SUC, which is not an error
+	if err := network.zWAssignSUCReturnRoute(nodeID); err != nil {
+		log.Printf("INFO HealNode node: %d no SUC return route: %v", nodeID, err)
+	}
+
+	return nil
+}
+
+// getAssociationTargets returns the sorted unique node IDs in the association
+// groups of the node. Groups are taken from the device database, or group 1
+// if the device is unknown.
+func (network *Network) getAssociationTargets(n *node.Node) []uint16 {
+	association := n.GetAssociation()
+	if association == nil {
+		return nil
+	}
+
+	groups := []uint8{1}
+	if device := n.GetDevice(); device != nil && len(device.Groups) > 0 {
+		groups = groups[:0]
+		for _, group := range device.Groups {
+			groups = append(groups, group.Index)
+		}
+	}
+
+	targets := make(map[uint16]bool)
+	for _, group := range groups {
+		_, nodes, err := association.Get(group)
+		if err != nil {
+			log.Printf("INFO HealNode node: %d failed to get association group %d: %v",
+				n.ID, group, err)
+			continue
+		}
+		for _, x := range nodes {
+			targets[uint16(x)] = true
+		}
+	}
+
+	var targetList []uint16
+	for x := range targets {
+		targetList = append(targetList, x)
+	}
+	sort.Slice(targetList, func(i, j int) bool { return targetList[i] < targetList[j] })
+
+	return targetList
+}
+
+// healOnWakeUp heals a deferred node after its wake up notification
+func (network *Network) healOnWakeUp(command *message.ApplicationCommand) {
+	if len(command.Body) < 2 || command.Body[0] != node.CommandClassWakeup ||
+		command.Body[1] != wakeUpCommandNotification {
+		return
+	}
+
+	network.healMutex.Lock()
+	pending := network.pendingHeals[command.NodeID]
+	delete(network.pendingHeals, command.NodeID)
+	network.healMutex.Unlock()
+
+	if !pending {
+		return
+	}
+
+	go func() {
+		if err := network.HealNode(command.NodeID); err != nil {
+			log.Printf("ERROR healOnWakeUp node: %d failed: %v", command.NodeID, err)
+		} else {
+			log.Printf("INFO healOnWakeUp node: %d healed", command.NodeID)
+		}
+	}()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// zWRequestNodeNeighborUpdate asks the node to discover its neighbors
+func (network *Network) zWRequestNodeNeighborUpdate(nodeID uint16) error {
+	requestPacket, err := message.ZWRequestNodeNeighborUpdateRequest(nodeID,
+		network.NodeIDType())
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWRequestNodeNeighborUpdateResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.NeighborUpdateDone {
+		return fmt.Errorf("ZWRequestNodeNeighborUpdate failed: 0x%02x",
+			responseMessage.Status)
+	}
+
+	return nil
+}
+
+// zWDeleteReturnRoute deletes all return routes of the node
+func (network *Network) zWDeleteReturnRoute(nodeID uint16) error {
+	requestPacket, err := message.ZWDeleteReturnRouteRequest(nodeID,
+		network.NodeIDType())
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWDeleteReturnRouteResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWDeleteReturnRoute failed: 0x%02x", responseMessage.Status)
+	}
+
+	return nil
+}
+
+// zWAssignReturnRoute assigns the node a return route to the destination
+func (network *Network) zWAssignReturnRoute(nodeID uint16, destinationNodeID uint16) error {
+	requestPacket, err := message.ZWAssignReturnRouteRequest(nodeID, destinationNodeID,
+		network.NodeIDType())
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWAssignReturnRouteResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWAssignReturnRoute to %d failed: 0x%02x",
+			destinationNodeID, responseMessage.Status)
+	}
+
+	return nil
+}
+
+// zWAssignSUCReturnRoute assigns the node a return route to the SUC
+func (network *Network) zWAssignSUCReturnRoute(nodeID uint16) error {
+	requestPacket, err := message.ZWAssignSUCReturnRouteRequest(nodeID,
+		network.NodeIDType())
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWAssignSUCReturnRouteResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.TransmitCompleteOK {
+		return fmt.Errorf("ZWAssignSUCReturnRoute failed: 0x%02x", responseMessage.Status)
+	}
+
+	return nil
+}
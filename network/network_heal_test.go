@@ -0,0 +1,165 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"testing"
+)
+
+// testHealController answers heal requests. Node 2 is associated with nodes 1
+// and 3, and there is no SUC.
+func testHealController(t *testing.T) *testController {
+	c := newTestController(t)
+
+	c.handle(message.MessageTypeZWRequestNodeNeighborUpdate,
+		func(request *packet.Packet) []*packet.Packet {
+			return []*packet.Packet{
+				testFrame(packet.PacketTypeRequest, request.MessageType,
+					testCallbackID(request), message.NeighborUpdateStarted),
+				testFrame(packet.PacketTypeRequest, request.MessageType,
+					testCallbackID(request), message.NeighborUpdateDone)}
+		})
+	c.handle(message.MessageTypeZWDeleteReturnRoute,
+		testCallbackHandler(true, message.TransmitCompleteOK))
+	c.handle(message.MessageTypeZWAssignReturnRoute,
+		testCallbackHandler(true, message.TransmitCompleteOK))
+	c.handle(message.MessageTypeZWAssignSUCReturnRoute,
+		testCallbackHandler(true, message.TransmitCompleteNoACK))
+	c.handle(message.MessageTypeZWSendData, testSendDataHandler(
+		func(nodeID uint16, frame []uint8) []uint8 {
+			// Association Get of group 1
+			if nodeID == 2 && frame[0] == node.CommandClassAssociation && frame[1] == 0x02 {
+				return []uint8{node.CommandClassAssociation, 0x03, frame[2], 5, 0, 1, 3}
+			}
+			return nil
+		}))
+
+	return c
+}
+
+// testReturnRoutes returns the node and destination of ZWAssignReturnRoute
+// requests
+func testReturnRoutes(requests []*packet.Packet) [][2]uint8 {
+	var routes [][2]uint8
+	for _, request := range requests {
+		routes = append(routes, [2]uint8{request.Body[0], request.Body[1]})
+	}
+	return routes
+}
+
+func TestHeal(t *testing.T) {
+	c := testHealController(t)
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	associated := node.MakeNode(2, network)
+	associated.Listening = true
+	associated.CommandClasses = []uint8{node.CommandClassAssociation}
+	listening := node.MakeNode(3, network)
+	listening.Listening = true
+	network.nodes[2] = associated
+	network.nodes[3] = listening
+	network.nodes[4] = node.MakeNode(4, network)
+	network.nodeID = 1
+
+	progress := make(chan *HealProgress, 16)
+	if err := network.Heal(progress); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	close(progress)
+
+	expected := []HealProgress{{2, HealStatusStarted, nil}, {2, HealStatusDone, nil},
+		{3, HealStatusStarted, nil}, {3, HealStatusDone, nil}, {4, HealStatusDeferred, nil}}
+	var actual []HealProgress
+	for x := range progress {
+		if x.Err != nil {
+			t.Errorf("Expected nil error: %v", x.Err)
+		}
+		actual = append(actual, HealProgress{x.NodeID, x.Status, nil})
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected progress %v got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected progress %v got %v", expected, actual)
+			break
+		}
+	}
+
+	// Node 2 gets routes to the controller and its association target
+	routes := testReturnRoutes(c.getRequests(message.MessageTypeZWAssignReturnRoute))
+	expectedRoutes := [][2]uint8{{2, 1}, {2, 3}, {3, 1}}
+	if len(routes) != len(expectedRoutes) {
+		t.Fatalf("Expected routes %v got %v", expectedRoutes, routes)
+	}
+	for i := range routes {
+		if routes[i] != expectedRoutes[i] {
+			t.Errorf("Expected routes %v got %v", expectedRoutes, routes)
+			break
+		}
+	}
+
+	if n := len(c.getRequests(message.MessageTypeZWRequestNodeNeighborUpdate)); n != 2 {
+		t.Errorf("Expected 2 neighbor updates got %d", n)
+	}
+
+	// Node 4 is healed once after its wake up notification
+	c.send(testApplicationCommand(4, node.CommandClassWakeup, wakeUpCommandNotification))
+	requests := c.waitRequests(message.MessageTypeZWAssignSUCReturnRoute, 3)
+	if requests[2].Body[0] != 4 {
+		t.Errorf("Expected SUC return route of node 4: %v", requests[2])
+	}
+
+	c.send(testApplicationCommand(4, node.CommandClassWakeup, wakeUpCommandNotification))
+	c.send(testApplicationCommand(4, node.CommandClassWakeup, wakeUpCommandNotification))
+	if n := len(c.waitRequests(message.MessageTypeZWSendData, 1)); n != 1 {
+		t.Errorf("Expected 1 ZWSendData got %d", n)
+	}
+	network.healMutex.Lock()
+	pending := len(network.pendingHeals)
+	network.healMutex.Unlock()
+	if pending != 0 {
+		t.Errorf("Expected no pending heals: %d", pending)
+	}
+}
+
+func TestHealNodeFailure(t *testing.T) {
+	c := testHealController(t)
+	c.handle(message.MessageTypeZWDeleteReturnRoute,
+		testCallbackHandler(true, message.TransmitCompleteNoACK))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	n := node.MakeNode(3, network)
+	n.Listening = true
+	network.nodes[3] = n
+
+	if err := network.HealNode(3); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+	if n := len(c.getRequests(message.MessageTypeZWAssignReturnRoute)); n != 0 {
+		t.Errorf("Expected no return routes after a failure: %d", n)
+	}
+
+	if err := network.HealNode(9); err != node.ErrNodeNotFound {
+		t.Errorf("Expected ErrNodeNotFound: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"log"
+	"sort"
+	"time"
+)
+
+const (
+	maxHealthFailures   = 3                     // Consecutive failures of a dead node
+	healthCheckInterval = 10 * time.Second      // Time between checks for due pings
+	minPingBackOff      = 30 * time.Second      // Initial time between pings
+	maxPingBackOff      = 30 * time.Minute      // Maximum time between pings
+	transmitTimeUnit    = 10 * time.Millisecond // Unit of ZWSendData.TransmitTime
+)
+
+// NodeHealth information
+type NodeHealth struct {
+	NodeID              uint16
+	Dead                bool          // Listening node failed too many times
+	ConsecutiveFailures int           // Failed transmits since the last contact
+	LastContact         time.Time     // Most recent successful transmit or report
+	LastFailure         time.Time     // Most recent failed transmit
+	LastTransmitTime    time.Duration // Duration of the most recent transmit
+	ControllerFailed    bool          // Controller lists the node as failed
+
+	pingBackOff time.Duration // Time between pings of a dead node
+	nextPing    time.Time     // Time of the next ping of a dead node
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetNodeHealth returns a copy of the health of the node. goroutine safe.
+func (network *Network) GetNodeHealth(nodeID uint16) *NodeHealth {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	health := NodeHealth{NodeID: nodeID}
+	if x, ok := network.health[nodeID]; ok {
+		health = *x
+	}
+	return &health
+}
+
+// GetHealth returns a copy of the health of all nodes, sorted by node ID.
+// goroutine safe.
+func (network *Network) GetHealth() []*NodeHealth {
+	var healths []*NodeHealth
+	for _, n := range network.GetNodes() {
+		healths = append(healths, network.GetNodeHealth(n.ID))
+	}
+	sort.Slice(healths, func(i, j int) bool { return healths[i].NodeID < healths[j].NodeID })
+	return healths
+}
+
+// Ping the node with a NoOperation command. goroutine safe.
+func (network *Network) Ping(nodeID uint16) error {
+	return network.zWSendData(nodeID, node.CommandClassNoOperation, []uint8{})
+}
+
+// IsFailedNode checks if the controller lists the node as failed. goroutine
+// safe.
+func (network *Network) IsFailedNode(nodeID uint16) (bool, error) {
+	requestPacket, err := message.ZWIsFailedNodeRequest(nodeID, network.NodeIDType())
+	if err != nil {
+		return false, err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return false, err
+	}
+	responseMessage, err := message.ZWIsFailedNodeResponse(responsePacket)
+	if err != nil {
+		return false, err
+	}
+
+	return responseMessage.Failed, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// getHealth returns the health of the node, must be called with health lock
+func (network *Network) getHealth(nodeID uint16) *NodeHealth {
+	if network.health == nil {
+		network.health = make(map[uint16]*NodeHealth)
+	}
+	health, ok := network.health[nodeID]
+	if !ok {
+		health = &NodeHealth{NodeID: nodeID}
+		network.health[nodeID] = health
+	}
+	return health
+}
+
+// recordRequest records the transmit status of ZWSendData requests
+func (network *Network) recordRequest(request *packet.Packet, response *packet.Packet) {
+	if request.MessageType != message.MessageTypeZWSendData || response == nil {
+		return
+	}
+
+	nodeID, err := message.ZWSendDataRequestNodeID(request, network.NodeIDType())
+	if err != nil || nodeID == uint16(message.NodeIDBroadcast) {
+		return
+	}
+
+	responseMessage, err := message.ZWSendDataResponse(response)
+	if err != nil {
+		return
+	}
+
+	if responseMessage.Status == message.TransmitCompleteOK {
+		network.recordContact(nodeID,
+			time.Duration(responseMessage.TransmitTime)*transmitTimeUnit)
+	} else {
+		network.recordFailure(nodeID)
+	}
+}
+
+// recordContact marks the node as alive, with the optional transmit time
+func (network *Network) recordContact(nodeID uint16, transmitTime time.Duration) {
+	network.healthMutex.Lock()
+	health := network.getHealth(nodeID)
+	wasDead := health.Dead
+	health.Dead = false
+	health.ControllerFailed = false
+	health.ConsecutiveFailures = 0
+	health.LastContact = time.Now()
+	if transmitTime > 0 {
+		health.LastTransmitTime = transmitTime
+	}
+	network.healthMutex.Unlock()
+
+	if wasDead {
+		log.Printf("INFO recordContact node: %d is alive", nodeID)
+		network.publish(&Event{Type: EventTypeNodeAlive, NodeID: nodeID, Time: time.Now()})
+	}
+}
+
+// recordFailure counts the failure, and marks listening and FLiRS nodes as dead
+// after too many consecutive failures
+func (network *Network) recordFailure(nodeID uint16) {
+	reachable := network.isNodeReachable(nodeID)
+
+	network.healthMutex.Lock()
+	health := network.getHealth(nodeID)
+	health.ConsecutiveFailures++
+	health.LastFailure = time.Now()
+
+	becameDead := false
+	if reachable && !health.Dead && health.ConsecutiveFailures >= maxHealthFailures {
+		health.Dead = true
+		health.pingBackOff = minPingBackOff
+		health.nextPing = health.LastFailure.Add(health.pingBackOff)
+		becameDead = true
+	}
+	network.healthMutex.Unlock()
+
+	if becameDead {
+		log.Printf("INFO recordFailure node: %d is dead", nodeID)
+		network.publish(&Event{Type: EventTypeNodeDead, NodeID: nodeID, Time: time.Now()})
+	}
+}
+
+// isNodeDead checks if the node is dead
+func (network *Network) isNodeDead(nodeID uint16) bool {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	health, ok := network.health[nodeID]
+	return ok && health.Dead
+}
+
+// duePings returns the dead nodes due for a ping, and backs off their next ping
+func (network *Network) duePings(now time.Time) []uint16 {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	var nodeIDs []uint16
+	for nodeID, health := range network.health {
+		if !health.Dead || now.Before(health.nextPing) {
+			continue
+		}
+		nodeIDs = append(nodeIDs, nodeID)
+
+		health.pingBackOff *= 2
+		if health.pingBackOff > maxPingBackOff {
+			health.pingBackOff = maxPingBackOff
+		}
+		health.nextPing = now.Add(health.pingBackOff)
+	}
+	sort.Slice(nodeIDs, func(i, j int) bool { return nodeIDs[i] < nodeIDs[j] })
+
+	return nodeIDs
+}
+
+// markFailed marks the node in the failed node list of the controller as
+// dead, and due for a ping
+func (network *Network) markFailed(nodeID uint16) {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	health := network.getHealth(nodeID)
+	health.Dead = true
+	health.ControllerFailed = true
+	health.pingBackOff = minPingBackOff
+	health.nextPing = time.Now()
+}
+
+// setControllerFailed records the failed node list status of the node
+func (network *Network) setControllerFailed(nodeID uint16, failed bool) {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	network.getHealth(nodeID).ControllerFailed = failed
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// startHealthMonitor starts pinging dead nodes
+func (network *Network) startHealthMonitor() {
+	network.healthMutex.Lock()
+	defer network.healthMutex.Unlock()
+
+	if network.healthRunning {
+		return
+	}
+
+	network.healthRunning = true
+	network.stopHealth = make(chan int)
+	network.stoppedHealth = make(chan int)
+	go network.healthLoop(network.stopHealth, network.stoppedHealth)
+}
+
+// stopHealthMonitor stops pinging dead nodes. Must not be called with the API
+// lock, since pings acquire it.
+func (network *Network) stopHealthMonitor() {
+	network.healthMutex.Lock()
+	if !network.healthRunning {
+		network.healthMutex.Unlock()
+		return
+	}
+	network.healthRunning = false
+	stop, stopped := network.stopHealth, network.stoppedHealth
+	network.healthMutex.Unlock()
+
+	stop <- 0
+	<-stopped
+}
+
+// healthLoop pings dead nodes with a back off, and consults the failed node
+// list of the controller if they do not respond
+func (network *Network) healthLoop(stop chan int, stopped chan int) {
+	for {
+		select {
+		case <-time.After(healthCheckInterval):
+
+		case <-stop:
+			stopped <- 0
+			return
+		}
+
+		for _, nodeID := range network.duePings(time.Now()) {
+			if err := network.Ping(nodeID); err == nil {
+				continue
+			}
+
+			if failed, err := network.IsFailedNode(nodeID); err != nil {
+				log.Printf("ERROR healthLoop node: %d IsFailedNode: %v", nodeID, err)
+			} else {
+				network.setControllerFailed(nodeID, failed)
+			}
+		}
+	}
+}
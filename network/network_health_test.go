@@ -0,0 +1,173 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"testing"
+	"time"
+)
+
+func makeSendDataPackets(t *testing.T, nodeID uint16, status uint8) (*packet.Packet, *packet.Packet) {
+	// NO_OPERATION command class
+	request, err := message.ZWSendDataRequest(nodeID, message.NodeIDType8Bit, 0x00,
+		[]uint8{}, node.DefaultTransmitOptions, 0x00)
+	if err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	response := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeRequest, MessageType: message.MessageTypeZWSendData,
+		Body: []uint8{0x12, status, 0x00, 0x05}}
+	if err := response.Update(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	return request, &response
+}
+
+func TestNodeHealth(t *testing.T) {
+	network := Network{nodes: make(map[uint16]*node.Node)}
+	listening := node.MakeNode(4, nil)
+	listening.Listening = true
+	network.nodes[4] = listening
+	network.nodes[5] = node.MakeNode(5, nil)
+
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeNodeDead, EventTypeNodeAlive}}})
+	defer subscription.Close()
+
+	request, response := makeSendDataPackets(t, 4, message.TransmitCompleteOK)
+	network.recordRequest(request, response)
+
+	health := network.GetNodeHealth(4)
+	if health.Dead || health.LastContact.IsZero() || health.LastTransmitTime != 50*time.Millisecond {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+
+	for _, nodeID := range []uint16{4, 5} {
+		request, response := makeSendDataPackets(t, nodeID, message.TransmitCompleteNoACK)
+		for i := 0; i < maxHealthFailures; i++ {
+			network.recordRequest(request, response)
+		}
+	}
+
+	// Only listening nodes die
+	if health := network.GetNodeHealth(4); !health.Dead ||
+		health.ConsecutiveFailures != maxHealthFailures {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+	if health := network.GetNodeHealth(5); health.Dead ||
+		health.ConsecutiveFailures != maxHealthFailures {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+
+	if event := <-subscription.Events(); event.Type != EventTypeNodeDead || event.NodeID != 4 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	// Pings back off
+	now := time.Now()
+	if nodeIDs := network.duePings(now); len(nodeIDs) != 0 {
+		t.Errorf("Expected no due pings: %v", nodeIDs)
+	}
+	if nodeIDs := network.duePings(now.Add(minPingBackOff)); len(nodeIDs) != 1 || nodeIDs[0] != 4 {
+		t.Errorf("Expected due ping of node 4: %v", nodeIDs)
+	}
+	if nodeIDs := network.duePings(now.Add(2 * minPingBackOff)); len(nodeIDs) != 0 {
+		t.Errorf("Expected no due pings: %v", nodeIDs)
+	}
+
+	// Any report revives the node
+	network.recordContact(4, 0)
+	if health := network.GetNodeHealth(4); health.Dead || health.ConsecutiveFailures != 0 {
+		t.Errorf("Unexpected health: %+v", health)
+	}
+	if event := <-subscription.Events(); event.Type != EventTypeNodeAlive || event.NodeID != 4 {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+
+	if healths := network.GetHealth(); len(healths) != 2 || healths[0].NodeID != 4 {
+		t.Errorf("Unexpected health: %+v", healths)
+	}
+}
+
+func TestInitializeFailedNodes(t *testing.T) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3, 4}, message.MessageTypeZWIsFailedNode,
+		message.MessageTypeZWSendData)
+	c.handle(message.MessageTypeZWIsFailedNode,
+		func(request *packet.Packet) []*packet.Packet {
+			failed := uint8(0x00)
+			if request.Body[0] != 4 {
+				failed = 0x01
+			}
+			return []*packet.Packet{testFrame(packet.PacketTypeResponse,
+				request.MessageType, failed)}
+		})
+	c.handle(message.MessageTypeZWGetNodeProtocolInfo,
+		func(request *packet.Packet) []*packet.Packet {
+			// Node 2 is listening, and node 3 is sleeping
+			capability := uint8(0x80)
+			if request.Body[0] == 3 {
+				capability = 0x00
+			}
+			return []*packet.Packet{testFrame(packet.PacketTypeResponse,
+				request.MessageType, capability, 0x00, 0x00, 0x04, 0x10, 0x01)}
+		})
+	// Pings of dead nodes fail
+	c.handle(message.MessageTypeZWSendData,
+		testCallbackHandler(true, message.TransmitCompleteNoACK))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	if health := network.GetNodeHealth(2); !health.Dead || !health.ControllerFailed {
+		t.Errorf("Expected dead listening node: %+v", health)
+	}
+	if health := network.GetNodeHealth(3); health.Dead || !health.ControllerFailed {
+		t.Errorf("Expected sleeping node not to be dead: %+v", health)
+	}
+	if health := network.GetNodeHealth(4); health.Dead || health.ControllerFailed {
+		t.Errorf("Expected healthy node: %+v", health)
+	}
+
+	// Pings are NO_OPERATION frames
+	if err := network.Ping(2); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+	pings := c.getRequests(message.MessageTypeZWSendData)
+	if len(pings) == 0 {
+		t.Fatalf("Expected pings")
+	}
+	for _, ping := range pings {
+		if len(ping.Body) < 3 || ping.Body[1] != 0x01 || ping.Body[2] != 0x00 {
+			t.Errorf("Expected NO_OPERATION ping: %v", ping.Body)
+		}
+	}
+
+	// Protocol info is only needed for failed nodes
+	if n := len(c.getRequests(message.MessageTypeZWGetNodeProtocolInfo)); n != 2 {
+		t.Errorf("Expected 2 ZWGetNodeProtocolInfo got %d", n)
+	}
+}
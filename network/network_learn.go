@@ -0,0 +1,176 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"time"
+)
+
+// JoinNetwork enters the learn mode, so that the primary controller of
+// another network can include this controller as a secondary controller. The
+// primary must start its inclusion within the timeout. Afterwards the network
+// is initialized again with the new HomeID and node list. goroutine safe.
+func (network *Network) JoinNetwork(networkWide bool, timeout time.Duration) error {
+	mode := uint8(message.LearnModeClassic)
+	if networkWide {
+		mode = message.LearnModeNetworkWideInclusion
+	}
+
+	if err := network.runLearnMode("JoinNetwork", mode, timeout); err != nil {
+		return err
+	}
+
+	return network.reinitialize()
+}
+
+// runLearnMode enters the learn mode, and waits until it is done. The name of
+// the caller prefixes logs and errors.
+func (network *Network) runLearnMode(name string, mode uint8, timeout time.Duration) error {
+	// Drop stale callbacks
+loop:
+	for {
+		select {
+		case <-network.learnMode:
+		default:
+			break loop
+		}
+	}
+
+	callbackID, err := network.setLearnMode(mode)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case callback := <-network.learnMode:
+			if callback.CallbackID != callbackID {
+				continue
+			}
+
+			switch callback.Status {
+			case message.LearnModeStatusStarted:
+				log.Printf("INFO %s learn mode started", name)
+
+			case message.LearnModeStatusDone:
+				// The learn mode must be left on success as well
+				network.disableLearnMode()
+				log.Printf("INFO %s joined as node: %d", name, callback.NodeID)
+				return nil
+
+			default:
+				network.disableLearnMode()
+				return fmt.Errorf("%s failed: 0x%02x", name, callback.Status)
+			}
+
+		case <-deadline:
+			network.disableLearnMode()
+			return fmt.Errorf("%s timed out", name)
+		}
+	}
+}
+
+// learnModeChangedNetwork checks if the learn mode moved the controller to
+// another HomeID or node ID
+func (network *Network) learnModeChangedNetwork() (bool, error) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if !network.isOpen() {
+		return false, errors.New("API is not open")
+	}
+
+	memoryID, err := network.initialGetMemoryID()
+	if err != nil {
+		return false, err
+	}
+
+	return memoryID.HomeID != network.homeID || memoryID.NodeID != network.nodeID, nil
+}
+
+// setLearnMode sets the learn mode, and returns the callback id of the learn
+// mode callbacks
+func (network *Network) setLearnMode(mode uint8) (uint8, error) {
+	requestPacket, err := message.ZWSetLearnModeRequest(mode)
+	if err != nil {
+		return 0, err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return 0, err
+	}
+	responseMessage, err := message.ZWSetLearnModeResponse(responsePacket)
+	if err != nil {
+		return 0, err
+	}
+
+	if !responseMessage.Accepted {
+		return 0, errors.New("ZWSetLearnMode rejected by controller")
+	}
+
+	// The SerialController appended the callback id to the request
+	return requestPacket.Body[len(requestPacket.Body)-1], nil
+}
+
+// disableLearnMode leaves the learn mode
+func (network *Network) disableLearnMode() {
+	if _, err := network.setLearnMode(message.LearnModeDisable); err != nil {
+		log.Printf("ERROR disableLearnMode failed: %v", err)
+	}
+}
+
+// notifyLearnMode passes the learn mode callback to runLearnMode
+// Assumptions: called only from callbackHandler
+func (network *Network) notifyLearnMode(callback *message.ZWSetLearnModeCallback) {
+	select {
+	case network.learnMode <- callback:
+	default:
+		log.Printf("INFO notifyLearnMode dropping callback: %+v", callback)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// isReplicationCommand checks if the command is a controller replication
+// command, sent by the primary controller while it includes this controller
+func isReplicationCommand(command *message.ApplicationCommand) bool {
+	return len(command.Body) > 0 && command.Body[0] == node.CommandClassControllerReplication
+}
+
+// completeReplicationCommand acknowledges the replication command, so that
+// the primary controller continues the transfer. The group and scene data is
+// not used.
+// Assumptions: called only from callbackHandler
+func (network *Network) completeReplicationCommand(command *message.ApplicationCommand) {
+	if network.DebugLogging {
+		log.Printf("DEBUG completeReplicationCommand node: %d body: %v",
+			command.NodeID, command.Body)
+	}
+
+	// Requests must not block the callback handler
+	go func() {
+		if _, err := network.DoRequest(message.ZWReplicationCommandCompleteRequest()); err != nil {
+			log.Printf("ERROR completeReplicationCommand failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,147 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testLearnModeController initializes controller node 1 in the HomeID
+// 0x01020304 with nodes 2 and 3. The learn mode reports the statuses, and
+// Done moves the controller to node 5 in the HomeID 0x0a0b0c0d with node 1.
+func testLearnModeController(t *testing.T, statuses ...uint8) (*testController, *Network) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3})
+	network := openTestNetwork(t, c)
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// Handlers are called with c.mutex held
+	c.handle(message.MessageTypeZWSetLearnMode,
+		func(request *packet.Packet) []*packet.Packet {
+			frames := []*packet.Packet{
+				testFrame(packet.PacketTypeResponse, request.MessageType, 0x01)}
+			if request.Body[0] == message.LearnModeDisable {
+				return frames
+			}
+
+			for _, status := range statuses {
+				nodeID := uint8(0x00)
+				if status == message.LearnModeStatusDone {
+					nodeID = 0x05
+					c.handlers[message.MessageTypeMemoryGetID] = testResponseHandler(
+						0x0a, 0x0b, 0x0c, 0x0d, nodeID)
+					c.handlers[message.MessageTypeSerialAPIGetInitData] = testResponseHandler(
+						append(append([]uint8{0x05, 0x08, 29}, testBitmask(29, 1, 5)...),
+							0x00, 0x00)...)
+				}
+				frames = append(frames, testFrame(packet.PacketTypeRequest,
+					request.MessageType, testCallbackID(request), status, nodeID, 0x00))
+			}
+			return frames
+		})
+
+	return c, network
+}
+
+// testLearnModes returns the modes of the learn mode requests
+func testLearnModes(c *testController) []uint8 {
+	var modes []uint8
+	for _, request := range c.getRequests(message.MessageTypeZWSetLearnMode) {
+		modes = append(modes, request.Body[0])
+	}
+	return modes
+}
+
+func TestJoinNetwork(t *testing.T) {
+	c, network := testLearnModeController(t, message.LearnModeStatusStarted,
+		message.LearnModeStatusDone)
+	defer network.Close()
+
+	if err := network.JoinNetwork(false, time.Second); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The learn mode is left after it is done
+	if modes := testLearnModes(c); !reflect.DeepEqual(modes,
+		[]uint8{message.LearnModeClassic, message.LearnModeDisable}) {
+		t.Errorf("Expected classic learn mode and disable: %v", modes)
+	}
+
+	// The network is initialized again with the new HomeID
+	network.mutex.RLock()
+	homeID, nodeID := network.homeID, network.nodeID
+	network.mutex.RUnlock()
+	if homeID != 0x0a0b0c0d || nodeID != 5 {
+		t.Errorf("Expected HomeID 0x0a0b0c0d node 5 got: 0x%08x %d", homeID, nodeID)
+	}
+	if nodes := network.GetNodes(); len(nodes) != 1 || nodes[0].ID != 1 {
+		t.Errorf("Expected node 1: %v", nodes)
+	}
+}
+
+func TestJoinNetworkFailed(t *testing.T) {
+	c, network := testLearnModeController(t, message.LearnModeStatusStarted,
+		message.LearnModeStatusFailed)
+	defer network.Close()
+
+	if err := network.JoinNetwork(true, time.Second); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	if modes := testLearnModes(c); !reflect.DeepEqual(modes,
+		[]uint8{message.LearnModeNetworkWideInclusion, message.LearnModeDisable}) {
+		t.Errorf("Expected network wide learn mode and disable: %v", modes)
+	}
+
+	// The network is not changed
+	network.mutex.RLock()
+	homeID := network.homeID
+	network.mutex.RUnlock()
+	if homeID != 0x01020304 {
+		t.Errorf("Expected HomeID 0x01020304 got: 0x%08x", homeID)
+	}
+	if n := len(network.GetNodes()); n != 2 {
+		t.Errorf("Expected 2 nodes got %d", n)
+	}
+}
+
+func TestJoinNetworkTimeout(t *testing.T) {
+	c, network := testLearnModeController(t, message.LearnModeStatusStarted)
+	defer network.Close()
+
+	if err := network.JoinNetwork(false, 50*time.Millisecond); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	// The learn mode is left after the timeout
+	if modes := testLearnModes(c); !reflect.DeepEqual(modes,
+		[]uint8{message.LearnModeClassic, message.LearnModeDisable}) {
+		t.Errorf("Expected classic learn mode and disable: %v", modes)
+	}
+	network.mutex.RLock()
+	homeID := network.homeID
+	network.mutex.RUnlock()
+	if homeID != 0x01020304 {
+		t.Errorf("Expected HomeID 0x01020304 got: 0x%08x", homeID)
+	}
+}
@@ -0,0 +1,252 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Lifeline Method
+const (
+	LifelineMethodAGI      uint8 = 0x01 // Association group information profile
+	LifelineMethodDatabase       = 0x02 // Device database group label
+	LifelineMethodDefault        = 0x03 // Group 1 heuristic
+)
+
+// LifelineStatus information
+type LifelineStatus struct {
+	NodeID uint16
+	Group  uint8 // Lifeline group, or 0 if unknown
+	Method uint8 // One of LifelineMethod
+	Wired  bool  // Controller is verified to be in the lifeline group
+	Err    error // Failure reason if not Wired
+}
+
+// lifelineAssociation abstracts Association and MultiChannelAssociation
+type lifelineAssociation struct {
+	association             *node.Association
+	multiChannelAssociation *node.MultiChannelAssociation
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetupLifeline adds the controller to the lifeline association group of the
+// node, so that the node sends unsolicited reports to the controller, and
+// verifies it. The node must be awake. goroutine safe.
+func (network *Network) SetupLifeline(nodeID uint16) *LifelineStatus {
+	status := LifelineStatus{NodeID: nodeID}
+	status.Err = network.setupLifeline(&status)
+
+	network.lifelineMutex.Lock()
+	if network.lifelines == nil {
+		network.lifelines = make(map[uint16]*LifelineStatus)
+	}
+	network.lifelines[nodeID] = &status
+	network.lifelineMutex.Unlock()
+
+	if status.Err != nil {
+		log.Printf("ERROR SetupLifeline node: %d failed: %v", nodeID, status.Err)
+	}
+
+	return &status
+}
+
+// SetupLifelines sets up the lifeline of all listening and FLiRS nodes, and
+// returns the status of every node. Sleeping nodes are reported as not wired,
+// unless already set up. goroutine safe.
+func (network *Network) SetupLifelines() []*LifelineStatus {
+	for _, n := range network.GetNodes() {
+		if n.IsReachable() {
+			network.SetupLifeline(n.ID)
+		}
+	}
+	return network.GetLifelineReport()
+}
+
+// GetLifelineReport returns the most recent lifeline status of every node,
+// sorted by node ID. goroutine safe.
+func (network *Network) GetLifelineReport() []*LifelineStatus {
+	network.lifelineMutex.Lock()
+	defer network.lifelineMutex.Unlock()
+
+	var report []*LifelineStatus
+	for _, n := range network.GetNodes() {
+		status, ok := network.lifelines[n.ID]
+		if !ok {
+			status = &LifelineStatus{NodeID: n.ID,
+				Err: errors.New("Lifeline was not set up")}
+		}
+		report = append(report, status)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].NodeID < report[j].NodeID })
+
+	return report
+}
+
+// RefreshNode refreshes the node, and if AutoLifeline is set, sets up its
+// lifeline. goroutine safe.
+func (network *Network) RefreshNode(nodeID uint16) error {
+	n := network.GetNode(nodeID)
+	if n == nil {
+		return node.ErrNodeNotFound
+	}
+
+	if err := n.Refresh(); err != nil {
+		return err
+	}
+
+	if network.AutoLifeline {
+		network.SetupLifeline(nodeID)
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// setupLifeline fills in the status of the lifeline setup
+func (network *Network) setupLifeline(status *LifelineStatus) error {
+	n := network.GetNode(status.NodeID)
+	if n == nil {
+		return node.ErrNodeNotFound
+	}
+
+	network.mutex.RLock()
+	nodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	// Association command classes only carry 8 bit node IDs
+	if !message.IsClassicNodeID(nodeID) {
+		return fmt.Errorf("Controller nodeID does not fit associations: %d", nodeID)
+	}
+	controllerNodeID := uint8(nodeID)
+
+	association := lifelineAssociation{
+		association:             n.GetAssociation(),
+		multiChannelAssociation: n.GetMultiChannelAssociation(),
+	}
+	if association.association == nil && association.multiChannelAssociation == nil {
+		return errors.New("Node does not support associations")
+	}
+
+	status.Group, status.Method = association.findLifelineGroup(n)
+
+	wired, err := association.contains(status.Group, controllerNodeID)
+	if err != nil {
+		return err
+	}
+
+	if !wired {
+		if err := association.add(status.Group, controllerNodeID); err != nil {
+			return err
+		}
+		if wired, err = association.contains(status.Group, controllerNodeID); err != nil {
+			return err
+		}
+	}
+
+	if !wired {
+		return fmt.Errorf("Controller not in lifeline group %d after add", status.Group)
+	}
+
+	status.Wired = true
+	return nil
+}
+
+// findLifelineGroup determines the lifeline group using association group
+// information, then the device database, and falls back to group 1
+func (association *lifelineAssociation) findLifelineGroup(n *node.Node) (uint8, uint8) {
+	if agi := n.GetAssociationGroupInformation(); agi != nil {
+		groups, err := association.getGroupings()
+		if err != nil {
+			log.Printf("INFO findLifelineGroup node: %d failed to get groups: %v", n.ID, err)
+		}
+		for group := uint8(1); err == nil && group <= groups && group != 0; group++ {
+			profile, err := agi.GetProfile(group)
+			if err != nil {
+				log.Printf("INFO findLifelineGroup node: %d failed to get group %d profile: %v",
+					n.ID, group, err)
+				break
+			}
+			if profile == node.AssociationGroupProfileGeneralLifeline {
+				return group, LifelineMethodAGI
+			}
+		}
+	}
+
+	if device := n.GetDevice(); device != nil {
+		for _, group := range device.Groups {
+			if strings.Contains(strings.ToLower(group.Label), "lifeline") {
+				return group.Index, LifelineMethodDatabase
+			}
+		}
+	}
+
+	return 1, LifelineMethodDefault
+}
+
+// getGroupings gets the number of supported association groups
+func (association *lifelineAssociation) getGroupings() (uint8, error) {
+	if association.multiChannelAssociation != nil {
+		return association.multiChannelAssociation.GetGroupings()
+	}
+	return association.association.GetGroupings()
+}
+
+// add adds the controller to the group. Multi channel devices are associated
+// to the root endpoint, so that reports include the source endpoint
+func (association *lifelineAssociation) add(group uint8, controllerNodeID uint8) error {
+	if association.multiChannelAssociation != nil {
+		return association.multiChannelAssociation.Add(group, nil,
+			[]node.AssociationEndpoint{{NodeID: controllerNodeID, Endpoint: 0}})
+	}
+	return association.association.Add(group, []uint8{controllerNodeID})
+}
+
+// contains checks if the controller is in the group
+func (association *lifelineAssociation) contains(group uint8, controllerNodeID uint8) (bool, error) {
+	var nodes []uint8
+	var endpoints []node.AssociationEndpoint
+	var err error
+
+	if association.multiChannelAssociation != nil {
+		_, nodes, endpoints, err = association.multiChannelAssociation.Get(group)
+	} else {
+		_, nodes, err = association.association.Get(group)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, x := range nodes {
+		if x == controllerNodeID {
+			return true, nil
+		}
+	}
+	for _, x := range endpoints {
+		if x.NodeID == controllerNodeID && x.Endpoint == 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
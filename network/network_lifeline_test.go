@@ -0,0 +1,152 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/database"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"sync"
+	"testing"
+)
+
+// testLifelineNodes emulates the association groups of nodes, and the
+// association group profiles of nodes with AGI
+type testLifelineNodes struct {
+	mutex    sync.Mutex
+	groups   map[uint16]map[uint8][]uint8
+	profiles map[uint16]map[uint8]uint16
+	sets     [][2]uint8
+}
+
+// reply to Association and AGI commands
+func (nodes *testLifelineNodes) reply(nodeID uint16, frame []uint8) []uint8 {
+	nodes.mutex.Lock()
+	defer nodes.mutex.Unlock()
+
+	groups := nodes.groups[nodeID]
+	switch {
+	case frame[0] == node.CommandClassAssociation && frame[1] == 0x01:
+		nodes.sets = append(nodes.sets, [2]uint8{uint8(nodeID), frame[2]})
+		groups[frame[2]] = append(groups[frame[2]], frame[3:]...)
+	case frame[0] == node.CommandClassAssociation && frame[1] == 0x02:
+		return append([]uint8{node.CommandClassAssociation, 0x03, frame[2], 5, 0},
+			groups[frame[2]]...)
+	case frame[0] == node.CommandClassAssociation && frame[1] == 0x05:
+		return []uint8{node.CommandClassAssociation, 0x06, uint8(len(groups))}
+	case frame[0] == node.CommandClassAssociationGroupInformation && frame[1] == 0x03:
+		profile := nodes.profiles[nodeID][frame[3]]
+		return []uint8{node.CommandClassAssociationGroupInformation, 0x04, 0x01,
+			frame[3], 0x00, uint8(profile >> 8), uint8(profile), 0x00, 0x00, 0x00}
+	}
+	return nil
+}
+
+func TestSetupLifeline(t *testing.T) {
+	nodes := testLifelineNodes{
+		groups: map[uint16]map[uint8][]uint8{
+			2: {1: {}, 2: {}, 3: {}},
+			3: {1: {1}, 2: {}},
+			4: {1: {}, 2: {}, 3: {}},
+			5: {1: {}},
+		},
+		profiles: map[uint16]map[uint8]uint16{
+			2: {2: node.AssociationGroupProfileGeneralLifeline},
+			4: {},
+		},
+	}
+	c := newTestController(t)
+	c.handle(message.MessageTypeZWSendData, testSendDataHandler(nodes.reply))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+	network.nodeID = 1
+
+	// Node 2 has a lifeline AGI profile in group 2
+	agi := node.MakeNode(2, network)
+	agi.Listening = true
+	agi.CommandClasses = []uint8{node.CommandClassAssociation,
+		node.CommandClassAssociationGroupInformation}
+	network.nodes[2] = agi
+
+	// Node 3 has no AGI, and is already wired in group 1
+	wired := node.MakeNode(3, network)
+	wired.Listening = true
+	wired.CommandClasses = []uint8{node.CommandClassAssociation}
+	network.nodes[3] = wired
+
+	// Node 4 has AGI without a lifeline profile, and a database label
+	labeled := node.MakeNode(4, network)
+	labeled.Listening = true
+	labeled.CommandClasses = []uint8{node.CommandClassAssociation,
+		node.CommandClassAssociationGroupInformation}
+	labeled.Device = &database.Device{Groups: []database.AssociationGroup{
+		{Index: 3, Label: "Lifeline"}}}
+	network.nodes[4] = labeled
+
+	// Node 5 has no AGI and no database entry
+	plain := node.MakeNode(5, network)
+	plain.Listening = true
+	plain.CommandClasses = []uint8{node.CommandClassAssociation}
+	network.nodes[5] = plain
+
+	// Node 6 has no associations
+	none := node.MakeNode(6, network)
+	none.Listening = true
+	network.nodes[6] = none
+
+	expected := []LifelineStatus{
+		{NodeID: 2, Group: 2, Method: LifelineMethodAGI, Wired: true},
+		{NodeID: 3, Group: 1, Method: LifelineMethodDefault, Wired: true},
+		{NodeID: 4, Group: 3, Method: LifelineMethodDatabase, Wired: true},
+		{NodeID: 5, Group: 1, Method: LifelineMethodDefault, Wired: true},
+	}
+	for _, x := range expected {
+		status := network.SetupLifeline(x.NodeID)
+		if status.Err != nil {
+			t.Errorf("Expected nil error for node %d: %v", x.NodeID, status.Err)
+			continue
+		}
+		if *status != x {
+			t.Errorf("Expected status %+v got %+v", x, *status)
+		}
+	}
+
+	if status := network.SetupLifeline(6); status.Err == nil || status.Wired {
+		t.Errorf("Expected error for node without associations: %+v", status)
+	}
+	if status := network.SetupLifeline(9); status.Err != node.ErrNodeNotFound {
+		t.Errorf("Expected ErrNodeNotFound got: %v", status.Err)
+	}
+
+	// The wired node is not set again
+	nodes.mutex.Lock()
+	defer nodes.mutex.Unlock()
+	expectedSets := [][2]uint8{{2, 2}, {4, 3}, {5, 1}}
+	if len(nodes.sets) != len(expectedSets) {
+		t.Fatalf("Expected sets %v got %v", expectedSets, nodes.sets)
+	}
+	for i := range expectedSets {
+		if nodes.sets[i] != expectedSets[i] {
+			t.Errorf("Expected sets %v got %v", expectedSets, nodes.sets)
+			break
+		}
+	}
+
+	report := network.GetLifelineReport()
+	if len(report) != 5 {
+		t.Errorf("Expected 5 lifeline statuses got %d", len(report))
+	}
+}
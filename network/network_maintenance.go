@@ -0,0 +1,235 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"log"
+	"time"
+)
+
+// Maximum time to wait for the controller to report that it started after a
+// soft reset
+const serialAPIStartedTimeout = (3 * time.Second)
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SoftReset restarts the controller, and waits for it to report that it
+// started. Older controllers do not report it, so the returned
+// message.SerialAPIStarted is nil if it is not received in time. The node ID
+// type is set again afterwards. goroutine safe.
+func (network *Network) SoftReset() (*message.SerialAPIStarted, error) {
+	// Drop stale notifications
+	select {
+	case <-network.serialAPIStarted:
+	default:
+	}
+
+	if _, err := network.DoRequest(message.SerialAPISoftResetRequest()); err != nil {
+		return nil, err
+	}
+
+	var started *message.SerialAPIStarted
+	select {
+	case started = <-network.serialAPIStarted:
+	case <-time.After(serialAPIStartedTimeout):
+		log.Printf("INFO SoftReset controller did not report start after %v",
+			serialAPIStartedTimeout)
+	}
+
+	// The controller restarted with 8 bit node IDs
+	network.mutex.Lock()
+	err := network.initialSetNodeIDType()
+	network.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return started, nil
+}
+
+// notifySerialAPIStarted passes the start notification to SoftReset, dropping
+// any earlier unclaimed notification
+// Assumptions: called only from callbackHandler
+func (network *Network) notifySerialAPIStarted(started *message.SerialAPIStarted) {
+	select {
+	case <-network.serialAPIStarted:
+	default:
+	}
+	network.serialAPIStarted <- started
+}
+
+// FactoryReset resets the controller to its factory default state, which
+// removes all nodes and creates a new HomeID, and initializes the network
+// again. goroutine safe.
+func (network *Network) FactoryReset() error {
+	responsePacket, err := network.DoRequest(message.ZWSetDefaultRequest())
+	if err != nil {
+		return err
+	}
+	if _, err := message.ZWSetDefaultResponse(responsePacket); err != nil {
+		return err
+	}
+
+	return network.reinitialize()
+}
+
+// reinitialize forgets all nodes, and initializes the network again, after
+// the controller NVM was replaced
+func (network *Network) reinitialize() error {
+	network.mutex.Lock()
+	nodeIDs := make([]uint16, 0, len(network.nodes))
+	for id := range network.nodes {
+		nodeIDs = append(nodeIDs, id)
+	}
+	network.nodes = make(map[uint16]*node.Node)
+	network.homeID = 0
+	network.nodeID = 0
+	network.mutex.Unlock()
+
+	for _, id := range nodeIDs {
+		network.forgetNode(id)
+	}
+
+	return network.Initialize()
+}
+
+// forgetNode removes the state of a node which is no longer in the network
+func (network *Network) forgetNode(nodeID uint16) {
+	for _, target := range network.GetPollTargets() {
+		if target.NodeID == nodeID {
+			network.StopPolling(target)
+		}
+	}
+
+	network.healthMutex.Lock()
+	delete(network.health, nodeID)
+	network.healthMutex.Unlock()
+
+	network.healMutex.Lock()
+	delete(network.pendingHeals, nodeID)
+	network.healMutex.Unlock()
+
+	network.lifelineMutex.Lock()
+	delete(network.lifelines, nodeID)
+	network.lifelineMutex.Unlock()
+
+	network.removeValues(nodeID)
+	network.publish(&Event{Type: EventTypeNodeRemoved, NodeID: nodeID,
+		Time: time.Now()})
+}
+
+// GetRandom returns count random bytes from the controller hardware random
+// number generator, count must be in the range of [1, 32]. goroutine safe.
+func (network *Network) GetRandom(count uint8) ([]uint8, error) {
+	requestPacket, err := message.ZWGetRandomRequest(count)
+	if err != nil {
+		return nil, err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	responseMessage, err := message.ZWGetRandomResponse(responsePacket)
+	if err != nil {
+		return nil, err
+	}
+	return responseMessage.Bytes, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// SetTxStatusReport enables or disables the transmit report in ZWSendData
+// callbacks, which includes the transmit time. goroutine safe.
+func (network *Network) SetTxStatusReport(enable bool) error {
+	return network.serialAPISetupSet(message.SerialAPISetupSetTxStatusReportRequest(enable))
+}
+
+// GetRFRegion returns the RF region of the controller. goroutine safe.
+func (network *Network) GetRFRegion() (uint8, error) {
+	setup, err := network.serialAPISetup(message.SerialAPISetupGetRFRegionRequest())
+	if err != nil {
+		return 0, err
+	}
+	return setup.RFRegion, nil
+}
+
+// SetRFRegion sets the RF region of the controller, which is used after the
+// next SoftReset. goroutine safe.
+func (network *Network) SetRFRegion(region uint8) error {
+	return network.serialAPISetupSet(message.SerialAPISetupSetRFRegionRequest(region))
+}
+
+// GetPowerLevel returns the normal transmit power level, and the measured
+// output power at 0 dBm, both in deci dBm. goroutine safe.
+func (network *Network) GetPowerLevel() (int8, int8, error) {
+	setup, err := network.serialAPISetup(message.SerialAPISetupGetPowerLevelRequest())
+	if err != nil {
+		return 0, 0, err
+	}
+	return setup.PowerLevel.Normal, setup.PowerLevel.Measured0dBm, nil
+}
+
+// SetPowerLevel sets the normal transmit power level, and the measured output
+// power at 0 dBm, both in deci dBm. goroutine safe.
+func (network *Network) SetPowerLevel(normal int8, measured0dBm int8) error {
+	return network.serialAPISetupSet(message.SerialAPISetupSetPowerLevelRequest(normal,
+		measured0dBm))
+}
+
+// GetMaxPayloadSize returns the maximum ZWSendData payload size. goroutine
+// safe.
+func (network *Network) GetMaxPayloadSize() (uint8, error) {
+	setup, err := network.serialAPISetup(message.SerialAPISetupGetMaxPayloadSizeRequest())
+	if err != nil {
+		return 0, err
+	}
+	return setup.MaxPayloadSize, nil
+}
+
+// serialAPISetup issues the SerialAPISetup request
+func (network *Network) serialAPISetup(requestPacket *packet.Packet) (*message.SerialAPISetup, error) {
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	responseMessage, err := message.SerialAPISetupResponse(responsePacket)
+	if err != nil {
+		return nil, err
+	}
+	if responseMessage.Command != requestPacket.Body[0] {
+		return nil, fmt.Errorf("SerialAPISetup expected command: 0x%02x got: 0x%02x",
+			requestPacket.Body[0], responseMessage.Command)
+	}
+	return responseMessage, nil
+}
+
+// serialAPISetupSet issues the SerialAPISetup set request
+func (network *Network) serialAPISetupSet(requestPacket *packet.Packet) error {
+	responseMessage, err := network.serialAPISetup(requestPacket)
+	if err != nil {
+		return err
+	}
+	if !responseMessage.Success {
+		return errors.New("SerialAPISetup rejected by controller")
+	}
+	return nil
+}
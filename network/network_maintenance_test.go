@@ -0,0 +1,192 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"testing"
+	"time"
+)
+
+func TestNotifySerialAPIStarted(t *testing.T) {
+	network := Network{serialAPIStarted: make(chan *message.SerialAPIStarted, 1)}
+
+	// Only the most recent notification is kept, without blocking
+	first := &message.SerialAPIStarted{}
+	second := &message.SerialAPIStarted{}
+	network.notifySerialAPIStarted(first)
+	network.notifySerialAPIStarted(second)
+
+	if started := <-network.serialAPIStarted; started != second {
+		t.Errorf("Expected the second notification: %+v", started)
+	}
+	if n := len(network.serialAPIStarted); n != 0 {
+		t.Errorf("Expected no notifications got %d", n)
+	}
+}
+
+func TestForgetNode(t *testing.T) {
+	network := Network{}
+
+	for _, nodeID := range []uint16{4, 5} {
+		if err := network.PollCommandClass(nodeID, node.CommandClassBattery, time.Hour); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+		network.recordFailure(nodeID)
+		network.updateValue(&Value{ID: ValueID{NodeID: nodeID,
+			CommandClass: node.CommandClassBattery, Property: ValuePropertyLevel},
+			Value: uint8(0x50)})
+	}
+
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeNodeRemoved}}})
+	defer subscription.Close()
+
+	// FactoryReset forgets every node of the old network
+	network.forgetNode(4)
+
+	if targets := network.GetPollTargets(); len(targets) != 1 || targets[0].NodeID != 5 {
+		t.Errorf("Expected poll target of node 5: %v", targets)
+	}
+	if health := network.GetNodeHealth(4); health.ConsecutiveFailures != 0 {
+		t.Errorf("Expected no health of node 4: %+v", health)
+	}
+	if health := network.GetNodeHealth(5); health.ConsecutiveFailures != 1 {
+		t.Errorf("Expected health of node 5: %+v", health)
+	}
+	if values := network.GetValues(4); len(values) != 0 {
+		t.Errorf("Expected no values of node 4: %v", values)
+	}
+	if values := network.GetValues(5); len(values) != 1 {
+		t.Errorf("Expected 1 value of node 5: %v", values)
+	}
+
+	select {
+	case event := <-subscription.Events():
+		if event.Type != EventTypeNodeRemoved || event.NodeID != 4 {
+			t.Errorf("Expected node 4 removed event: %+v", event)
+		}
+	default:
+		t.Errorf("Expected node removed event")
+	}
+}
+
+func TestSoftResetNodeIDType(t *testing.T) {
+	c := newTestController(t)
+	c.handle(message.MessageTypeSerialAPISoftReset, testRequestHandler(
+		message.MessageTypeSerialAPIStarted, 0x00, 0x00, 0x80, 0x02, 0x01, 0x00))
+	c.handle(message.MessageTypeSerialAPISetup, testResponseHandler(
+		message.SerialAPISetupCommandSetNodeIDType, 0x01))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+	network.LongRange = true
+	network.supportedMessageTypes = []uint8{message.MessageTypeSerialAPISoftReset,
+		message.MessageTypeSerialAPISetup}
+
+	started, err := network.SoftReset()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if started == nil || started.DeviceClass.Generic != 0x02 {
+		t.Errorf("Unexpected SerialAPIStarted: %+v", started)
+	}
+
+	// The controller restarted with 8 bit node IDs, so they are set again
+	requests := c.getRequests(message.MessageTypeSerialAPISetup)
+	if len(requests) != 1 || requests[0].Body[1] != message.NodeIDType16Bit {
+		t.Errorf("Expected 16 bit SerialAPISetup request: %v", requests)
+	}
+	if nodeIDType := network.NodeIDType(); nodeIDType != message.NodeIDType16Bit {
+		t.Errorf("Expected 16 bit node ID type got: 0x%02x", nodeIDType)
+	}
+}
+
+func TestSoftResetStaleStart(t *testing.T) {
+	c := newTestController(t)
+	c.handle(message.MessageTypeSerialAPISoftReset, testRequestHandler(
+		message.MessageTypeSerialAPIStarted, 0x00, 0x00, 0x80, 0x02, 0x01, 0x00))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	// A start notification which was not asked for
+	c.send(testFrame(packet.PacketTypeRequest, message.MessageTypeSerialAPIStarted,
+		0x00, 0x00, 0x80, 0x01, 0x01, 0x00))
+	timeout := time.After(testRequestTimeout)
+	for len(network.serialAPIStarted) == 0 {
+		select {
+		case <-time.After(time.Millisecond):
+		case <-timeout:
+			t.Fatalf("Timed out waiting for the start notification")
+		}
+	}
+
+	// SoftReset returns the notification of its own reset
+	started, err := network.SoftReset()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if started == nil || started.DeviceClass.Generic != 0x02 {
+		t.Errorf("Unexpected SerialAPIStarted: %+v", started)
+	}
+}
+
+func TestFactoryReset(t *testing.T) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3})
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The controller forgets the nodes, and creates a new HomeID. Handlers are
+	// called with c.mutex held.
+	c.handle(message.MessageTypeZWSetDefault,
+		func(request *packet.Packet) []*packet.Packet {
+			c.handlers[message.MessageTypeMemoryGetID] = testResponseHandler(
+				0x0a, 0x0b, 0x0c, 0x0d, 0x01)
+			c.handlers[message.MessageTypeSerialAPIGetInitData] = testResponseHandler(
+				append(append([]uint8{0x05, 0x08, 29}, testBitmask(29, 1)...),
+					0x00, 0x00)...)
+			return []*packet.Packet{testFrame(packet.PacketTypeRequest,
+				request.MessageType, testCallbackID(request))}
+		})
+
+	subscription := network.Subscribe(SubscribeOptions{Filter: EventFilter{
+		Types: []uint8{EventTypeNodeRemoved}}})
+	defer subscription.Close()
+
+	if err := network.FactoryReset(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The network is initialized again with the new HomeID
+	network.mutex.RLock()
+	homeID := network.homeID
+	network.mutex.RUnlock()
+	if homeID != 0x0a0b0c0d {
+		t.Errorf("Expected new HomeID got 0x%08x", homeID)
+	}
+	if n := len(network.GetNodes()); n != 0 {
+		t.Errorf("Expected no nodes got %d", n)
+	}
+	if n := len(subscription.Events()); n != 2 {
+		t.Errorf("Expected 2 removed nodes got %d", n)
+	}
+}
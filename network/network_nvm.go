@@ -0,0 +1,478 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/controller"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// NVM image file magic and format version
+var nvmImageMagic = []uint8("GZWNVM")
+
+const nvmImageFormat uint8 = 0x01
+
+// Maximum size of an NVM image, the NVM offsets are 24 bit
+const maxNVMImageSize = 0x1000000
+
+// Bytes read or written per NVM request
+const nvmChunkSize = 64
+
+// NVMImage of the controller non volatile memory, which contains the HomeID
+// and all inclusion data
+type NVMImage struct {
+	HomeID      uint32
+	Version     string // Protocol version, i.e. "Z-Wave 4.54"
+	LibraryType uint8  // One of message.LibraryType
+	Method      uint8  // MessageType used to access the NVM
+	Data        []uint8
+}
+
+// nvmAccess reads and writes the controller NVM
+type nvmAccess interface {
+	open() (uint32, error) // Returns the NVM size
+	read(offset uint32, length int) ([]uint8, error)
+	write(offset uint32, data []uint8) error
+	close() error
+}
+
+// nvmBackupRestore accesses the NVM with NVMBackupRestore requests
+type nvmBackupRestore struct {
+	controller controller.Controller
+}
+
+// nvmExt accesses the NVM with NVMExt requests
+type nvmExt struct {
+	controller controller.Controller
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BackupNVM reads the controller NVM, and writes it as an NVMImage. The NVM
+// is read twice, and the backup fails if the reads differ. goroutine safe.
+func (network *Network) BackupNVM(writer io.Writer) error {
+	image, err := network.readNVMImage()
+	if err != nil {
+		return err
+	}
+	return image.WriteImage(writer)
+}
+
+// RestoreNVM writes an NVMImage to the controller NVM, soft resets the
+// controller, and initializes the network again. Images of other firmware
+// generations are refused. goroutine safe.
+func (network *Network) RestoreNVM(reader io.Reader) error {
+	image, err := ReadNVMImage(reader)
+	if err != nil {
+		return err
+	}
+
+	if err := network.writeNVMImage(image); err != nil {
+		return err
+	}
+
+	// The controller only uses the restored NVM after a restart
+	if _, err := network.SoftReset(); err != nil {
+		return err
+	}
+
+	return network.reinitialize()
+}
+
+// readNVMImage reads the controller NVM with the API lock
+func (network *Network) readNVMImage() (*NVMImage, error) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if !network.isOpen() {
+		return nil, errors.New("API is not open")
+	}
+
+	access, method, err := network.getNVMAccess()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := network.initialGetVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	memoryID, err := network.initialGetMemoryID()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := readNVM(access)
+	if err != nil {
+		return nil, err
+	}
+
+	// The controller might write the NVM during the read
+	verify, err := readNVM(access)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(data, verify) {
+		return nil, errors.New("NVM changed during backup")
+	}
+
+	image := NVMImage{HomeID: memoryID.HomeID, Version: version.Info,
+		LibraryType: version.LibraryType, Method: method, Data: data}
+
+	return &image, nil
+}
+
+// writeNVMImage writes the image to the controller NVM with the API lock
+func (network *Network) writeNVMImage(image *NVMImage) error {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if !network.isOpen() {
+		return errors.New("API is not open")
+	}
+
+	access, method, err := network.getNVMAccess()
+	if err != nil {
+		return err
+	}
+
+	version, err := network.initialGetVersion()
+	if err != nil {
+		return err
+	}
+
+	current := NVMImage{Version: version.Info, LibraryType: version.LibraryType,
+		Method: method}
+	if current.Generation() != image.Generation() {
+		return fmt.Errorf("NVM image generation %s does not match controller %s",
+			image.Generation(), current.Generation())
+	}
+
+	if err := writeNVM(access, image.Data); err != nil {
+		return err
+	}
+
+	verify, err := readNVM(access)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(image.Data, verify) {
+		return errors.New("NVM verification after restore failed")
+	}
+
+	return nil
+}
+
+// getNVMAccess returns the NVM access supported by the controller, and its
+// MessageType, must be called with API lock
+func (network *Network) getNVMAccess() (nvmAccess, uint8, error) {
+	if network.isSupportedMessageType(message.MessageTypeNVMBackupRestore) {
+		return &nvmBackupRestore{controller: network.serialController},
+			message.MessageTypeNVMBackupRestore, nil
+	}
+
+	if network.isSupportedMessageType(message.MessageTypeNVMGetID) &&
+		network.isSupportedMessageType(message.MessageTypeNVMExtReadLongBuffer) &&
+		network.isSupportedMessageType(message.MessageTypeNVMExtWriteLongBuffer) {
+		return &nvmExt{controller: network.serialController},
+			message.MessageTypeNVMExtReadLongBuffer, nil
+	}
+
+	return nil, 0, errors.New("NVM backup not supported by controller")
+}
+
+// readNVM reads the whole NVM
+func readNVM(access nvmAccess) ([]uint8, error) {
+	size, err := access.open()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]uint8, 0, size)
+	for offset := uint32(0); offset < size; {
+		length := nvmChunkSize
+		if remaining := int(size - offset); remaining < length {
+			length = remaining
+		}
+
+		chunk, err := access.read(offset, length)
+		if err != nil {
+			access.close()
+			return nil, err
+		}
+		data = append(data, chunk...)
+		offset += uint32(length)
+	}
+
+	if err := access.close(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// writeNVM writes the whole NVM, which must be the same size as the data
+func writeNVM(access nvmAccess, data []uint8) error {
+	size, err := access.open()
+	if err != nil {
+		return err
+	}
+
+	if int(size) != len(data) {
+		access.close()
+		return fmt.Errorf("NVM image size %d does not match controller %d",
+			len(data), size)
+	}
+
+	for offset := 0; offset < len(data); offset += nvmChunkSize {
+		end := offset + nvmChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		if err := access.write(uint32(offset), data[offset:end]); err != nil {
+			access.close()
+			return err
+		}
+	}
+
+	return access.close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// do issues the request and checks the response status
+func (access *nvmBackupRestore) do(requestPacket *packet.Packet) (*message.NVMBackupRestore, error) {
+	responsePacket, err := access.controller.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	responseMessage, err := message.NVMBackupRestoreResponse(responsePacket)
+	if err != nil {
+		return nil, err
+	}
+
+	if responseMessage.Status != message.NVMBackupRestoreStatusOK &&
+		responseMessage.Status != message.NVMBackupRestoreStatusEndOfFile {
+		return nil, fmt.Errorf("NVMBackupRestore operation 0x%02x failed: 0x%02x",
+			requestPacket.Body[0], responseMessage.Status)
+	}
+
+	return responseMessage, nil
+}
+
+func (access *nvmBackupRestore) open() (uint32, error) {
+	responseMessage, err := access.do(message.NVMBackupRestoreOpenRequest())
+	if err != nil {
+		return 0, err
+	}
+
+	// Size is in the offset field
+	if responseMessage.Offset == 0 {
+		access.close()
+		return 0, errors.New("NVMBackupRestore reported empty NVM")
+	}
+
+	return uint32(responseMessage.Offset), nil
+}
+
+func (access *nvmBackupRestore) read(offset uint32, length int) ([]uint8, error) {
+	responseMessage, err := access.do(message.NVMBackupRestoreReadRequest(uint16(offset),
+		uint8(length)))
+	if err != nil {
+		return nil, err
+	}
+
+	if uint32(responseMessage.Offset) != offset || len(responseMessage.Data) != length {
+		return nil, fmt.Errorf("NVMBackupRestore read expected offset: 0x%04x length: %d "+
+			"got offset: 0x%04x length: %d", offset, length, responseMessage.Offset,
+			len(responseMessage.Data))
+	}
+
+	return responseMessage.Data, nil
+}
+
+func (access *nvmBackupRestore) write(offset uint32, data []uint8) error {
+	requestPacket, err := message.NVMBackupRestoreWriteRequest(uint16(offset), data)
+	if err != nil {
+		return err
+	}
+	_, err = access.do(requestPacket)
+	return err
+}
+
+func (access *nvmBackupRestore) close() error {
+	_, err := access.do(message.NVMBackupRestoreCloseRequest())
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+func (access *nvmExt) open() (uint32, error) {
+	responsePacket, err := access.controller.DoRequest(message.NVMGetIDRequest())
+	if err != nil {
+		return 0, err
+	}
+	responseMessage, err := message.NVMGetIDResponse(responsePacket)
+	if err != nil {
+		return 0, err
+	}
+	return responseMessage.Size, nil
+}
+
+func (access *nvmExt) read(offset uint32, length int) ([]uint8, error) {
+	requestPacket, err := message.NVMExtReadLongBufferRequest(offset, uint16(length))
+	if err != nil {
+		return nil, err
+	}
+	responsePacket, err := access.controller.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	responseMessage, err := message.NVMExtReadLongBufferResponse(responsePacket)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(responseMessage.Data) != length {
+		return nil, fmt.Errorf("NVMExtReadLongBuffer expected length: %d got: %d",
+			length, len(responseMessage.Data))
+	}
+
+	return responseMessage.Data, nil
+}
+
+func (access *nvmExt) write(offset uint32, data []uint8) error {
+	requestPacket, err := message.NVMExtWriteLongBufferRequest(offset, data)
+	if err != nil {
+		return err
+	}
+	responsePacket, err := access.controller.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.NVMExtWriteLongBufferResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if !responseMessage.Success {
+		return fmt.Errorf("NVMExtWriteLongBuffer failed at offset: 0x%06x", offset)
+	}
+
+	return nil
+}
+
+func (access *nvmExt) close() error {
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Generation of the controller firmware, which is the protocol version, the
+// library type and the NVM access method. The NVM layout can change between
+// any two firmware versions, so images can only be restored to the same
+// generation.
+func (image *NVMImage) Generation() string {
+	return fmt.Sprintf("%s/0x%02x/0x%02x", strings.TrimRight(image.Version, "\x00"),
+		image.LibraryType, image.Method)
+}
+
+// WriteImage writes the image header and data
+func (image *NVMImage) WriteImage(writer io.Writer) error {
+	if len(image.Version) > 0xff {
+		return fmt.Errorf("Version is too long: %d", len(image.Version))
+	}
+
+	// Image: | MAGIC | FORMAT | METHOD | LIBRARY_TYPE | HOME_ID (4) |
+	//        | VERSION_LENGTH | VERSION | SIZE (4) | CRC32 (4) | DATA |
+	var header bytes.Buffer
+	header.Write(nvmImageMagic)
+	header.Write([]uint8{nvmImageFormat, image.Method, image.LibraryType})
+	binary.Write(&header, binary.BigEndian, image.HomeID)
+	header.WriteByte(uint8(len(image.Version)))
+	header.WriteString(image.Version)
+	binary.Write(&header, binary.BigEndian, uint32(len(image.Data)))
+	binary.Write(&header, binary.BigEndian, crc32.ChecksumIEEE(image.Data))
+
+	if _, err := writer.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err := writer.Write(image.Data)
+	return err
+}
+
+// ReadNVMImage reads an image written by WriteImage, and verifies its
+// checksum
+func ReadNVMImage(reader io.Reader) (*NVMImage, error) {
+	magic := make([]uint8, len(nvmImageMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, nvmImageMagic) {
+		return nil, errors.New("Not an NVM image")
+	}
+
+	fields := make([]uint8, 8)
+	if _, err := io.ReadFull(reader, fields); err != nil {
+		return nil, err
+	}
+	if fields[0] != nvmImageFormat {
+		return nil, fmt.Errorf("Unsupported NVM image format: %d", fields[0])
+	}
+
+	image := NVMImage{Method: fields[1], LibraryType: fields[2],
+		HomeID: binary.BigEndian.Uint32(fields[3:7])}
+
+	version := make([]uint8, fields[7])
+	if _, err := io.ReadFull(reader, version); err != nil {
+		return nil, err
+	}
+	image.Version = string(version)
+
+	var size, checksum uint32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(reader, binary.BigEndian, &checksum); err != nil {
+		return nil, err
+	}
+	if size > maxNVMImageSize {
+		return nil, fmt.Errorf("NVM image is too large: %d", size)
+	}
+
+	image.Data = make([]uint8, size)
+	if _, err := io.ReadFull(reader, image.Data); err != nil {
+		return nil, err
+	}
+
+	if actual := crc32.ChecksumIEEE(image.Data); actual != checksum {
+		return nil, fmt.Errorf("NVM image checksum mismatch: 0x%08x != 0x%08x",
+			actual, checksum)
+	}
+
+	return &image, nil
+}
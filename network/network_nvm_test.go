@@ -0,0 +1,203 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"strings"
+	"testing"
+)
+
+// testNVMController serves NVM requests from memory
+type testNVMController struct {
+	nvm    []uint8
+	opened bool
+}
+
+func (c *testNVMController) DoRequest(request *packet.Packet) (*packet.Packet, error) {
+	var body []uint8
+	switch request.MessageType {
+	case message.MessageTypeNVMBackupRestore:
+		operation, length := request.Body[0], int(request.Body[1])
+		offset := int(binary.BigEndian.Uint16(request.Body[2:4]))
+		status := message.NVMBackupRestoreStatusOK
+		if operation != message.NVMBackupRestoreOperationOpen && !c.opened {
+			status = message.NVMBackupRestoreStatusOperationMismatch
+		}
+		var data []uint8
+		switch operation {
+		case message.NVMBackupRestoreOperationOpen:
+			c.opened = true
+			offset = len(c.nvm)
+		case message.NVMBackupRestoreOperationRead:
+			data = c.nvm[offset : offset+length]
+		case message.NVMBackupRestoreOperationWrite:
+			copy(c.nvm[offset:], request.Body[4:])
+		case message.NVMBackupRestoreOperationClose:
+			c.opened = false
+		}
+		body = append([]uint8{status, uint8(len(data)), uint8(offset >> 8), uint8(offset)},
+			data...)
+
+	case message.MessageTypeNVMGetID:
+		body = []uint8{0x00, 0xef, 0x01, 0x0a}
+
+	case message.MessageTypeNVMExtReadLongBuffer:
+		offset := int(request.Body[0])<<16 | int(request.Body[1])<<8 | int(request.Body[2])
+		length := int(binary.BigEndian.Uint16(request.Body[3:5]))
+		body = append(body, c.nvm[offset:offset+length]...)
+
+	case message.MessageTypeNVMExtWriteLongBuffer:
+		offset := int(request.Body[0])<<16 | int(request.Body[1])<<8 | int(request.Body[2])
+		copy(c.nvm[offset:], request.Body[5:])
+		body = []uint8{0x01}
+	}
+
+	response := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packet.PacketTypeResponse, MessageType: request.MessageType,
+		Body: body}
+	return &response, response.Update()
+}
+
+func makeTestNVM(size int) []uint8 {
+	nvm := make([]uint8, size)
+	for i := range nvm {
+		nvm[i] = uint8(i * 7)
+	}
+	return nvm
+}
+
+func TestNVMAccess(t *testing.T) {
+	for _, method := range []uint8{message.MessageTypeNVMBackupRestore,
+		message.MessageTypeNVMExtReadLongBuffer} {
+		controller := testNVMController{nvm: makeTestNVM(1024)}
+
+		var access nvmAccess = &nvmExt{controller: &controller}
+		if method == message.MessageTypeNVMBackupRestore {
+			controller.nvm = makeTestNVM(1000)
+			access = &nvmBackupRestore{controller: &controller}
+		}
+
+		data, err := readNVM(access)
+		if err != nil {
+			t.Errorf("Expected nil error: %v", err)
+			t.FailNow()
+		}
+		if !bytes.Equal(data, controller.nvm) {
+			t.Errorf("Method 0x%02x read unexpected data", method)
+		}
+
+		restore := make([]uint8, len(data))
+		if err := writeNVM(access, restore); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+		if !bytes.Equal(restore, controller.nvm) {
+			t.Errorf("Method 0x%02x wrote unexpected data", method)
+		}
+
+		// Size mismatch
+		if err := writeNVM(access, restore[1:]); err == nil {
+			t.Errorf("Expected non nil error")
+		}
+		if controller.opened {
+			t.Errorf("Expected NVM to be closed")
+		}
+	}
+}
+
+func TestNVMImage(t *testing.T) {
+	image := NVMImage{HomeID: 0xc0ffee01, Version: "Z-Wave 4.54",
+		LibraryType: message.LibraryTypeControllerStatic,
+		Method:      message.MessageTypeNVMExtReadLongBuffer, Data: makeTestNVM(300)}
+
+	var buffer bytes.Buffer
+	if err := image.WriteImage(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+	encoded := buffer.Bytes()
+
+	decoded, err := ReadNVMImage(bytes.NewReader(encoded))
+	if err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+	if decoded.HomeID != image.HomeID || decoded.Version != image.Version ||
+		decoded.LibraryType != image.LibraryType || decoded.Method != image.Method ||
+		!bytes.Equal(decoded.Data, image.Data) {
+		t.Errorf("Expected image: %+v got: %+v", image, decoded)
+	}
+
+	if generation := decoded.Generation(); generation != "Z-Wave 4.54/0x01/0x2a" {
+		t.Errorf("Unexpected Generation: %s", generation)
+	}
+
+	// Corrupt data
+	corrupt := append([]uint8(nil), encoded...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if _, err := ReadNVMImage(bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	// Truncated
+	if _, err := ReadNVMImage(bytes.NewReader(encoded[:len(encoded)-1])); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	// Bad magic
+	if _, err := ReadNVMImage(bytes.NewReader(encoded[1:])); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+}
+
+func TestRestoreNVMGeneration(t *testing.T) {
+	c := newTestController(t)
+	c.handle(message.MessageTypeGetVersion, testResponseHandler(
+		append([]uint8("Z-Wave 4.54\x00"), message.LibraryTypeControllerStatic)...))
+	network := openTestNetwork(t, c)
+	defer network.Close()
+	network.supportedMessageTypes = []uint8{message.MessageTypeNVMGetID,
+		message.MessageTypeNVMExtReadLongBuffer, message.MessageTypeNVMExtWriteLongBuffer}
+
+	for _, image := range []NVMImage{
+		{Version: "Z-Wave 4.61", LibraryType: message.LibraryTypeControllerStatic,
+			Method: message.MessageTypeNVMExtReadLongBuffer},
+		{Version: "Z-Wave 4.54", LibraryType: message.LibraryTypeController,
+			Method: message.MessageTypeNVMExtReadLongBuffer},
+		{Version: "Z-Wave 4.54", LibraryType: message.LibraryTypeControllerStatic,
+			Method: message.MessageTypeNVMBackupRestore},
+	} {
+		image.Data = makeTestNVM(16)
+
+		var buffer bytes.Buffer
+		if err := image.WriteImage(&buffer); err != nil {
+			t.Fatalf("Expected nil error: %v", err)
+		}
+		if err := network.RestoreNVM(&buffer); err == nil ||
+			!strings.Contains(err.Error(), "does not match") {
+			t.Errorf("Expected generation mismatch of %s: %v", image.Generation(), err)
+		}
+	}
+
+	// The NVM is not touched
+	if n := len(c.getRequests(message.MessageTypeNVMGetID)); n != 0 {
+		t.Errorf("Expected no NVMGetID requests got %d", n)
+	}
+}
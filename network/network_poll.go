@@ -0,0 +1,380 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollRateLimit = time.Second      // Minimum time between any two polls
+	defaultPollJitter    = 0.1              // Random fraction of the interval
+	maxPollFailures      = 3                // Failed polls before suspending a node
+	pollSuspendDuration  = 10 * time.Minute // Time to suspend a failed node
+	pollAwakeDuration    = 10 * time.Second // Time a sleeping node stays awake
+	pollIdleWait         = time.Minute      // Scheduler wait without any tasks
+)
+
+// PollTarget information
+type PollTarget struct {
+	NodeID       uint16
+	CommandClass uint8
+	Payload      []uint8 // Get command, whose report is handled as usual
+	Interval     time.Duration
+}
+
+// pollTask information
+type pollTask struct {
+	target PollTarget
+	next   time.Time // Time of the next poll
+}
+
+// poller state
+type poller struct {
+	mutex     sync.Mutex
+	tasks     map[string]*pollTask // Tasks by pollTaskKey
+	failures  map[uint16]int       // Consecutive failed polls of a node
+	suspended map[uint16]time.Time // Failed nodes, suspended until
+	awake     map[uint16]time.Time // Sleeping nodes, awake until
+	lastPoll  time.Time            // Time of the most recent poll
+	changed   chan struct{}        // Signals a change of tasks
+	running   bool                 // Scheduler is running
+	stop      chan int             // Exit signal channel for pollLoop
+	stopped   chan int             // Exit confirmation channel for pollLoop
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// pollTaskKey identifies the target
+func pollTaskKey(target *PollTarget) string {
+	return fmt.Sprintf("%d-%d-%x", target.NodeID, target.CommandClass, target.Payload)
+}
+
+// Poll the target periodically, replacing any previous poll of the same node,
+// command class and payload. Reports are delivered through the value store
+// and event subscriptions. Polls of sleeping nodes are sent after their wake
+// up notification, and polls of failed nodes are suspended. goroutine safe.
+func (network *Network) Poll(target PollTarget) error {
+	if !message.IsValidNodeID(target.NodeID) {
+		return fmt.Errorf("Invalid nodeID: 0x%02x", target.NodeID)
+	}
+
+	if target.Interval <= 0 {
+		return errors.New("Interval must be positive")
+	}
+
+	target.Payload = append([]uint8(nil), target.Payload...)
+	network.poller.add(&target, time.Now(), network.pollJitter())
+
+	return nil
+}
+
+// PollCommandClass periodically polls the report of the command class, see
+// node.GetReportPayload. goroutine safe.
+func (network *Network) PollCommandClass(nodeID uint16, commandClass uint8,
+	interval time.Duration) error {
+	payload, err := node.GetReportPayload(commandClass)
+	if err != nil {
+		return err
+	}
+
+	return network.Poll(PollTarget{NodeID: nodeID, CommandClass: commandClass,
+		Payload: payload, Interval: interval})
+}
+
+// StopPolling the target with the node, command class and payload.
+// goroutine safe.
+func (network *Network) StopPolling(target PollTarget) {
+	network.poller.remove(&target)
+}
+
+// GetPollTargets returns the polled targets, sorted by node ID. goroutine safe.
+func (network *Network) GetPollTargets() []PollTarget {
+	network.poller.mutex.Lock()
+	var targets []PollTarget
+	for _, task := range network.poller.tasks {
+		target := task.target
+		target.Payload = append([]uint8(nil), target.Payload...)
+		targets = append(targets, target)
+	}
+	network.poller.mutex.Unlock()
+
+	sort.Slice(targets, func(i, j int) bool {
+		return pollTaskKey(&targets[i]) < pollTaskKey(&targets[j])
+	})
+
+	return targets
+}
+
+// pollRateLimit returns the configured or default rate limit. A negative value
+// disables it.
+func (network *Network) pollRateLimit() time.Duration {
+	switch {
+	case network.PollRateLimit > 0:
+		return network.PollRateLimit
+	case network.PollRateLimit < 0:
+		return 0
+	}
+	return defaultPollRateLimit
+}
+
+// pollJitter returns the configured or default jitter. A negative value
+// disables it.
+func (network *Network) pollJitter() float64 {
+	switch {
+	case network.PollJitter > 0:
+		return network.PollJitter
+	case network.PollJitter < 0:
+		return 0
+	}
+	return defaultPollJitter
+}
+
+// isNodeReachable checks if the node exists and is listening or FLiRS
+func (network *Network) isNodeReachable(nodeID uint16) bool {
+	n := network.GetNode(nodeID)
+	return n != nil && n.IsReachable()
+}
+
+// isNodePollable checks if the node is reachable and not dead
+func (network *Network) isNodePollable(nodeID uint16) bool {
+	return network.isNodeReachable(nodeID) && !network.isNodeDead(nodeID)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// startPoller starts the scheduler
+func (network *Network) startPoller() {
+	p := &network.poller
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.running {
+		return
+	}
+
+	p.init()
+	p.running = true
+	p.stop = make(chan int)
+	p.stopped = make(chan int)
+
+	subscription := network.Subscribe(SubscribeOptions{})
+	go network.pollLoop(subscription, network.pollRateLimit(), network.pollJitter(),
+		p.stop, p.stopped)
+}
+
+// stopPoller stops the scheduler, and waits for the current poll to finish.
+// Must not be called with the API lock, since polls acquire it.
+func (network *Network) stopPoller() {
+	p := &network.poller
+	p.mutex.Lock()
+	if !p.running {
+		p.mutex.Unlock()
+		return
+	}
+	p.running = false
+	stop, stopped := p.stop, p.stopped
+	p.mutex.Unlock()
+
+	stop <- 0
+	<-stopped
+}
+
+// pollLoop runs due polls, one at a time and at most once per rateLimit
+func (network *Network) pollLoop(subscription *Subscription, rateLimit time.Duration,
+	jitter float64, stop chan int, stopped chan int) {
+	defer subscription.Close()
+
+	for {
+		task, wait := network.poller.nextTask(time.Now(), rateLimit,
+			network.isNodePollable)
+
+		if task != nil {
+			target := task.target
+			err := network.zWSendData(target.NodeID, target.CommandClass, target.Payload)
+			if err != nil {
+				log.Printf("ERROR pollLoop node: %d command class: 0x%02x failed: %v",
+					target.NodeID, target.CommandClass, err)
+			}
+			network.poller.complete(task, err, time.Now(), jitter)
+			continue
+		}
+
+		select {
+		case <-time.After(wait):
+
+		case <-network.poller.changed:
+
+		case event := <-subscription.Events():
+			network.poller.handleEvent(event, time.Now())
+
+		case <-stop:
+			stopped <- 0
+			return
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// init lazily makes the maps, must be called with poller lock
+func (p *poller) init() {
+	if p.tasks == nil {
+		p.tasks = make(map[string]*pollTask)
+		p.failures = make(map[uint16]int)
+		p.suspended = make(map[uint16]time.Time)
+		p.awake = make(map[uint16]time.Time)
+		p.changed = make(chan struct{}, 1)
+	}
+}
+
+// signal the scheduler about a change, must be called with poller lock
+func (p *poller) signal() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// add the target, with the first poll spread over the jitter of the interval
+func (p *poller) add(target *PollTarget, now time.Time, jitter float64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.init()
+	offset := time.Duration(rand.Float64() * jitter * float64(target.Interval))
+	p.tasks[pollTaskKey(target)] = &pollTask{target: *target, next: now.Add(offset)}
+	p.signal()
+}
+
+// remove the target
+func (p *poller) remove(target *PollTarget) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.init()
+	delete(p.tasks, pollTaskKey(target))
+	p.signal()
+}
+
+// nextTask returns the earliest due task of an available node, or the time to
+// wait until the next task could be due
+func (p *poller) nextTask(now time.Time, rateLimit time.Duration,
+	isListening func(uint16) bool) (*pollTask, time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.init()
+
+	if wait := p.lastPoll.Add(rateLimit).Sub(now); wait > 0 {
+		return nil, wait
+	}
+
+	var next *pollTask
+	wait := pollIdleWait
+	for _, task := range p.tasks {
+		nodeID := task.target.NodeID
+
+		// Failed nodes are retried after the suspension
+		if until, ok := p.suspended[nodeID]; ok {
+			if now.Before(until) {
+				if until.Sub(now) < wait {
+					wait = until.Sub(now)
+				}
+				continue
+			}
+			delete(p.suspended, nodeID)
+		}
+
+		// Sleeping nodes are only polled while awake
+		if until, ok := p.awake[nodeID]; ok && !now.Before(until) {
+			delete(p.awake, nodeID)
+		}
+		if _, ok := p.awake[nodeID]; !ok && !isListening(nodeID) {
+			continue
+		}
+
+		if task.next.After(now) {
+			if task.next.Sub(now) < wait {
+				wait = task.next.Sub(now)
+			}
+			continue
+		}
+
+		if next == nil || task.next.Before(next.next) {
+			next = task
+		}
+	}
+
+	if next != nil {
+		p.lastPoll = now
+		return next, 0
+	}
+
+	return nil, wait
+}
+
+// complete schedules the next poll of the task, and suspends its node after
+// too many consecutive failures
+func (p *poller) complete(task *pollTask, err error, now time.Time, jitter float64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.init()
+
+	interval := task.target.Interval
+	task.next = now.Add(interval + time.Duration(rand.Float64()*jitter*float64(interval)))
+
+	nodeID := task.target.NodeID
+	if err == nil {
+		delete(p.failures, nodeID)
+		return
+	}
+
+	p.failures[nodeID]++
+	if p.failures[nodeID] >= maxPollFailures {
+		log.Printf("INFO poller node: %d suspended for %v after %d failures",
+			nodeID, pollSuspendDuration, p.failures[nodeID])
+		delete(p.failures, nodeID)
+		p.suspended[nodeID] = now.Add(pollSuspendDuration)
+	}
+}
+
+// handleEvent resumes nodes which are heard from, and tracks sleeping nodes
+func (p *poller) handleEvent(event *Event, now time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.init()
+
+	delete(p.suspended, event.NodeID)
+	delete(p.failures, event.NodeID)
+
+	switch event.Type {
+	case EventTypeNodeAwake:
+		p.awake[event.NodeID] = now.Add(pollAwakeDuration)
+	case EventTypeNodeAsleep:
+		delete(p.awake, event.NodeID)
+	}
+}
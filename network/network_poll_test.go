@@ -0,0 +1,161 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"errors"
+	"github.com/cybojanek/gozwave/node"
+	"testing"
+	"time"
+)
+
+func TestPollTargets(t *testing.T) {
+	network := Network{}
+
+	if err := network.PollCommandClass(3, node.CommandClassMeter, time.Minute); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if err := network.PollCommandClass(2, node.CommandClassBattery, time.Hour); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	// Replaces the previous target
+	if err := network.PollCommandClass(3, node.CommandClassMeter, time.Second); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	for _, err := range []error{
+		network.PollCommandClass(3, node.CommandClassVersion, time.Minute),
+		network.Poll(PollTarget{NodeID: 0, Interval: time.Minute}),
+		network.Poll(PollTarget{NodeID: 3}),
+	} {
+		if err == nil {
+			t.Errorf("Expected non nil error")
+		}
+	}
+
+	targets := network.GetPollTargets()
+	if len(targets) != 2 || targets[0].NodeID != 2 || targets[1].NodeID != 3 ||
+		targets[1].Interval != time.Second {
+		t.Errorf("Unexpected targets: %+v", targets)
+	}
+
+	network.StopPolling(targets[0])
+	if targets := network.GetPollTargets(); len(targets) != 1 {
+		t.Errorf("Unexpected targets: %+v", targets)
+	}
+}
+
+func TestPollSettings(t *testing.T) {
+	network := Network{}
+	if limit := network.pollRateLimit(); limit != defaultPollRateLimit {
+		t.Errorf("Expected default rate limit: %v", limit)
+	}
+	if jitter := network.pollJitter(); jitter != defaultPollJitter {
+		t.Errorf("Expected default jitter: %v", jitter)
+	}
+
+	network.PollRateLimit = 5 * time.Second
+	network.PollJitter = 0.5
+	if limit := network.pollRateLimit(); limit != 5*time.Second {
+		t.Errorf("Expected 5s rate limit: %v", limit)
+	}
+	if jitter := network.pollJitter(); jitter != 0.5 {
+		t.Errorf("Expected 0.5 jitter: %v", jitter)
+	}
+
+	// Negative values disable them
+	network.PollRateLimit = -1
+	network.PollJitter = -1
+	if limit := network.pollRateLimit(); limit != 0 {
+		t.Errorf("Expected disabled rate limit: %v", limit)
+	}
+	if jitter := network.pollJitter(); jitter != 0 {
+		t.Errorf("Expected disabled jitter: %v", jitter)
+	}
+}
+
+func TestPollerSchedule(t *testing.T) {
+	p := poller{}
+	now := time.Now()
+	listening := func(nodeID uint16) bool { return nodeID != 9 }
+
+	p.add(&PollTarget{NodeID: 2, Interval: time.Minute}, now, 0)
+	p.add(&PollTarget{NodeID: 3, Interval: time.Minute}, now.Add(time.Second), 0)
+	p.add(&PollTarget{NodeID: 9, Interval: time.Minute}, now, 0)
+
+	// Earliest due task first
+	task, _ := p.nextTask(now.Add(2*time.Second), 0, listening)
+	if task == nil || task.target.NodeID != 2 {
+		t.Errorf("Unexpected task: %+v", task)
+		t.FailNow()
+	}
+	p.complete(task, nil, now.Add(2*time.Second), 0)
+
+	// Rate limit
+	if task, wait := p.nextTask(now.Add(2*time.Second), time.Second, listening); task != nil ||
+		wait != time.Second {
+		t.Errorf("Expected rate limited wait: %+v %v", task, wait)
+	}
+
+	task, _ = p.nextTask(now.Add(3*time.Second), time.Second, listening)
+	if task == nil || task.target.NodeID != 3 {
+		t.Errorf("Unexpected task: %+v", task)
+		t.FailNow()
+	}
+	p.complete(task, nil, now.Add(3*time.Second), 0)
+
+	// Sleeping node 9 is skipped, until it wakes up
+	if task, wait := p.nextTask(now.Add(5*time.Second), 0, listening); task != nil ||
+		wait != 57*time.Second {
+		t.Errorf("Expected wait for node 2: %+v %v", task, wait)
+	}
+
+	p.handleEvent(&Event{Type: EventTypeNodeAwake, NodeID: 9}, now.Add(5*time.Second))
+	task, _ = p.nextTask(now.Add(5*time.Second), 0, listening)
+	if task == nil || task.target.NodeID != 9 {
+		t.Errorf("Unexpected task: %+v", task)
+		t.FailNow()
+	}
+}
+
+func TestPollerSuspend(t *testing.T) {
+	p := poller{}
+	now := time.Now()
+	listening := func(nodeID uint16) bool { return true }
+
+	p.add(&PollTarget{NodeID: 4, Interval: time.Second}, now, 0)
+
+	for i := 0; i < maxPollFailures; i++ {
+		now = now.Add(time.Second)
+		task, _ := p.nextTask(now, 0, listening)
+		if task == nil {
+			t.Errorf("Expected task at failure: %d", i)
+			t.FailNow()
+		}
+		p.complete(task, errors.New("No ACK"), now, 0)
+	}
+
+	now = now.Add(time.Second)
+	if task, wait := p.nextTask(now, 0, listening); task != nil || wait != pollIdleWait {
+		t.Errorf("Expected suspended node: %+v %v", task, wait)
+	}
+
+	// Any report resumes the node
+	p.handleEvent(&Event{Type: EventTypeBattery, NodeID: 4}, now)
+	if task, _ := p.nextTask(now, 0, listening); task == nil {
+		t.Errorf("Expected resumed node")
+	}
+}
@@ -0,0 +1,275 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/provisioning"
+	"log"
+	"time"
+)
+
+// Maximum duration of one SmartStart inclusion
+const smartStartTimeout = 60 * time.Second
+
+// EnableSmartStart makes the controller listen for SmartStart inclusion
+// requests. Devices with a SmartStart entry in the Provisioning list are
+// included in the background, and published as EventTypeNodeAdded events.
+// Security is not bootstrapped, so devices which require security can't be
+// included: the Provisioning list rejects entries with SecurityClasses.
+// goroutine safe.
+func (network *Network) EnableSmartStart() error {
+	network.mutex.RLock()
+	if !network.isOpen() {
+		network.mutex.RUnlock()
+		return errors.New("API is not open")
+	}
+	supported := network.isSupportedMessageType(message.MessageTypeZWAddNodeToNetwork)
+	network.mutex.RUnlock()
+
+	if !supported {
+		return errors.New("Controller does not support SmartStart")
+	}
+	if network.Provisioning == nil {
+		return errors.New("Provisioning list is nil")
+	}
+
+	network.smartStartMutex.Lock()
+	network.smartStart = true
+	busy := network.smartStartBusy
+	network.smartStartMutex.Unlock()
+
+	// Listening resumes after the inclusion in progress
+	if busy {
+		return nil
+	}
+
+	_, err := network.DoRequest(message.ZWAddNodeToNetworkSmartStartRequest())
+	return err
+}
+
+// DisableSmartStart stops listening for SmartStart inclusion requests.
+// goroutine safe.
+func (network *Network) DisableSmartStart() error {
+	network.smartStartMutex.Lock()
+	network.smartStart = false
+	network.smartStartMutex.Unlock()
+
+	_, err := network.DoRequest(message.ZWAddNodeToNetworkStopRequest())
+	return err
+}
+
+// handleSmartStart starts the inclusion of the requesting device, if it has a
+// SmartStart entry in the Provisioning list
+// Assumptions: called only from callbackHandler
+func (network *Network) handleSmartStart(update *message.ZWApplicationUpdateSmartStart) {
+	if network.DebugLogging {
+		log.Printf("DEBUG handleSmartStart: %+v", update)
+	}
+
+	if update.Status == message.ZWApplicationUpdateStateSmartStartHomeIDReceivedLR {
+		log.Printf("INFO handleSmartStart Long Range inclusion is not supported: 0x%08x",
+			update.NWIHomeID)
+		return
+	}
+
+	if network.Provisioning == nil {
+		return
+	}
+
+	entry := network.Provisioning.LookupNWIHomeID(update.NWIHomeID)
+	if entry == nil {
+		log.Printf("INFO handleSmartStart no provisioning entry for: 0x%08x",
+			update.NWIHomeID)
+		return
+	}
+	if entry.BootMode != provisioning.BootModeSmartStart {
+		log.Printf("INFO handleSmartStart entry is not SmartStart: %s", entry.DSK)
+		return
+	}
+	if entry.NodeID != 0 && network.GetNode(entry.NodeID) != nil {
+		log.Printf("INFO handleSmartStart entry already included as node %d: %s",
+			entry.NodeID, entry.DSK)
+		return
+	}
+
+	network.smartStartMutex.Lock()
+	if network.smartStartBusy {
+		network.smartStartMutex.Unlock()
+		log.Printf("INFO handleSmartStart inclusion in progress, ignoring: %s", entry.DSK)
+		return
+	}
+	network.smartStartBusy = true
+	network.smartStartMutex.Unlock()
+
+	// Requests must not block the callback handler
+	go func() {
+		if err := network.includeSmartStart(entry); err != nil {
+			log.Printf("ERROR handleSmartStart failed to include %s: %v", entry.DSK, err)
+		}
+
+		network.smartStartMutex.Lock()
+		network.smartStartBusy = false
+		resume := network.smartStart
+		network.smartStartMutex.Unlock()
+
+		if resume {
+			if _, err := network.DoRequest(
+				message.ZWAddNodeToNetworkSmartStartRequest()); err != nil {
+				log.Printf("ERROR handleSmartStart failed to resume listening: %v", err)
+			}
+		}
+	}()
+}
+
+// includeSmartStart includes the device of the entry, and then interviews it
+func (network *Network) includeSmartStart(entry *provisioning.Entry) error {
+	// Drop stale callbacks
+loop:
+	for {
+		select {
+		case <-network.addNodeCallbacks:
+		default:
+			break loop
+		}
+	}
+
+	requestPacket := message.ZWAddNodeToNetworkHomeIDRequest(entry.DSK.NWIHomeID(),
+		entry.DSK.AuthHomeID())
+	if _, err := network.DoRequest(requestPacket); err != nil {
+		return err
+	}
+	// The SerialController inserted the callback id after the mode
+	startID := requestPacket.Body[1]
+	stopID := startID
+
+	var nodeID uint16
+	deadline := time.After(smartStartTimeout)
+	for done := false; !done; {
+		select {
+		case callback := <-network.addNodeCallbacks:
+			if callback.CallbackID != startID && callback.CallbackID != stopID {
+				continue
+			}
+
+			switch callback.Status {
+			case message.AddNodeStatusLearnReady, message.AddNodeStatusNodeFound:
+
+			case message.AddNodeStatusAddingSlave, message.AddNodeStatusAddingController:
+				nodeID = callback.NodeID
+
+			case message.AddNodeStatusProtocolDone:
+				// Stopping completes the inclusion with a Done callback
+				stopPacket := message.ZWAddNodeToNetworkStopRequest()
+				if _, err := network.DoRequest(stopPacket); err != nil {
+					return err
+				}
+				stopID = stopPacket.Body[len(stopPacket.Body)-1]
+
+			case message.AddNodeStatusDone:
+				done = true
+
+			default:
+				network.stopAddNode()
+				return fmt.Errorf("ZWAddNodeToNetwork failed: 0x%02x", callback.Status)
+			}
+
+		case <-deadline:
+			network.stopAddNode()
+			return errors.New("SmartStart inclusion timed out")
+		}
+	}
+
+	if !message.IsValidNodeID(nodeID) {
+		return fmt.Errorf("Invalid included node: 0x%02x", nodeID)
+	}
+	log.Printf("INFO includeSmartStart included %s as node: %d", entry.DSK, nodeID)
+
+	if err := network.Provisioning.SetNodeID(entry.DSK, nodeID); err != nil {
+		log.Printf("ERROR includeSmartStart failed to update entry: %v", err)
+	}
+
+	n, _ := network.addNode(nodeID)
+	network.publish(&Event{Type: EventTypeNodeAdded, NodeID: nodeID, Time: time.Now()})
+
+	if err := network.RefreshNode(nodeID); err != nil {
+		return fmt.Errorf("Failed to refresh node %d: %v", nodeID, err)
+	}
+
+	return setNameAndLocation(n, entry.Name, entry.Location)
+}
+
+// stopAddNode stops the inclusion
+func (network *Network) stopAddNode() {
+	if _, err := network.DoRequest(message.ZWAddNodeToNetworkStopRequest()); err != nil {
+		log.Printf("ERROR stopAddNode failed: %v", err)
+	}
+}
+
+// addNode adds the node, if it does not exist, and returns it and whether it
+// was added
+func (network *Network) addNode(nodeID uint16) (*node.Node, bool) {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	n, ok := network.nodes[nodeID]
+	if !ok {
+		n = node.MakeNode(nodeID, network)
+		n.SetDatabase(network.Database)
+		network.nodes[nodeID] = n
+	}
+	return n, !ok
+}
+
+// setNameAndLocation sets the name and location of the node, if they are not
+// empty and the node supports the NamingAndLocation command class
+func setNameAndLocation(n *node.Node, name string, location string) error {
+	if len(name) == 0 && len(location) == 0 {
+		return nil
+	}
+
+	namingAndLocation := n.GetNamingAndLocation()
+	if namingAndLocation == nil {
+		return nil
+	}
+
+	if len(name) > 0 {
+		if err := namingAndLocation.SetName(name); err != nil {
+			return err
+		}
+	}
+	if len(location) > 0 {
+		if err := namingAndLocation.SetLocation(location); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notifyAddNode passes the add node callback to includeSmartStart
+// Assumptions: called only from callbackHandler
+func (network *Network) notifyAddNode(callback *message.ZWAddNodeToNetwork) {
+	select {
+	case network.addNodeCallbacks <- callback:
+	default:
+		log.Printf("INFO notifyAddNode dropping callback: %+v", callback)
+	}
+}
@@ -0,0 +1,72 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"github.com/cybojanek/gozwave/provisioning"
+	"testing"
+)
+
+// testSmartStartRequest returns the SmartStart inclusion request of the DSK
+func testSmartStartRequest(dsk provisioning.DSK) *packet.Packet {
+	homeID := dsk.NWIHomeID()
+	return testFrame(packet.PacketTypeRequest, message.MessageTypeZWApplicationUpdate,
+		message.ZWApplicationUpdateStateSmartStartHomeIDReceived, 0x00, 0x00,
+		uint8(homeID>>24), uint8(homeID>>16), uint8(homeID>>8), uint8(homeID), 0x00)
+}
+
+func TestSmartStartSecurity(t *testing.T) {
+	secure := provisioning.Entry{DSK: provisioning.DSK{0: 1, 11: 1},
+		SecurityClasses: provisioning.SecurityClassS2Authenticated,
+		BootMode:        provisioning.BootModeSmartStart}
+	insecure := provisioning.Entry{DSK: provisioning.DSK{0: 2, 11: 2},
+		BootMode: provisioning.BootModeSmartStart}
+
+	c := newTestController(t)
+	// The inclusion of the insecure entry fails
+	c.handle(message.MessageTypeZWAddNodeToNetwork,
+		func(request *packet.Packet) []*packet.Packet {
+			if len(request.Body) < 3 {
+				return nil
+			}
+			return []*packet.Packet{testFrame(packet.PacketTypeRequest,
+				request.MessageType, request.Body[1], message.AddNodeStatusFailed,
+				0x00, 0x00)}
+		})
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	// The secure entry is refused when it is added
+	network.Provisioning = &provisioning.List{}
+	if err := network.Provisioning.Add(&secure); err != provisioning.ErrSecurityNotSupported {
+		t.Errorf("Expected ErrSecurityNotSupported: %v", err)
+	}
+	if err := network.Provisioning.Add(&insecure); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The secure device is not included
+	c.send(testSmartStartRequest(secure.DSK))
+
+	// The insecure entry is included, and then stopped after the failure
+	c.send(testSmartStartRequest(insecure.DSK))
+	requests := c.waitRequests(message.MessageTypeZWAddNodeToNetwork, 2)
+	if homeID := insecure.DSK.NWIHomeID(); requests[0].Body[2] != uint8(homeID>>24) {
+		t.Errorf("Expected inclusion of 0x%08x: %v", homeID, requests[0])
+	}
+}
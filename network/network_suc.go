@@ -0,0 +1,127 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+)
+
+// GetControllerCapabilities returns the role of the controller in the
+// network, or nil before Initialize. goroutine safe.
+func (network *Network) GetControllerCapabilities() *message.ZWGetControllerCapabilities {
+	network.mutex.RLock()
+	defer network.mutex.RUnlock()
+
+	if network.controllerCapabilities == nil {
+		return nil
+	}
+	capabilities := *network.controllerCapabilities
+	return &capabilities
+}
+
+// GetSUCNodeID returns the node ID of the SUC, or 0 if there is no SUC.
+// goroutine safe.
+func (network *Network) GetSUCNodeID() (uint16, error) {
+	responsePacket, err := network.DoRequest(message.ZWGetSUCNodeIDRequest())
+	if err != nil {
+		return 0, err
+	}
+	responseMessage, err := message.ZWGetSUCNodeIDResponse(responsePacket)
+	if err != nil {
+		return 0, err
+	}
+	return responseMessage.NodeID, nil
+}
+
+// SetSUCNodeID enables or disables the node as the SUC, and optionally as the
+// SIS, which allows other controllers to include nodes. goroutine safe.
+func (network *Network) SetSUCNodeID(nodeID uint16, enable bool, sis bool) error {
+	network.mutex.RLock()
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	// The controller does not send a callback when it sets itself
+	requestPacket, err := message.ZWSetSUCNodeIDRequest(nodeID, network.NodeIDType(), enable, sis,
+		nodeID != controllerNodeID)
+	if err != nil {
+		return err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWSetSUCNodeIDResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.SUCStatusSucceeded {
+		return fmt.Errorf("ZWSetSUCNodeID failed: 0x%02x", responseMessage.Status)
+	}
+
+	return network.refreshControllerCapabilities()
+}
+
+// BecomeSIS makes the controller the SUC and SIS of the network. goroutine
+// safe.
+func (network *Network) BecomeSIS() error {
+	network.mutex.RLock()
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	return network.SetSUCNodeID(controllerNodeID, true, true)
+}
+
+// RequestNetworkUpdate gets the changes of the network from the SUC, which
+// secondary controllers use to learn about added and removed nodes. goroutine
+// safe.
+func (network *Network) RequestNetworkUpdate() error {
+	responsePacket, err := network.DoRequest(message.ZWRequestNetworkUpdateRequest())
+	if err != nil {
+		return err
+	}
+	responseMessage, err := message.ZWRequestNetworkUpdateResponse(responsePacket)
+	if err != nil {
+		return err
+	}
+
+	if responseMessage.Status != message.NetworkUpdateDone {
+		return fmt.Errorf("ZWRequestNetworkUpdate failed: 0x%02x", responseMessage.Status)
+	}
+
+	return nil
+}
+
+// refreshControllerCapabilities updates the role of the controller
+func (network *Network) refreshControllerCapabilities() error {
+	network.mutex.Lock()
+	defer network.mutex.Unlock()
+
+	if !network.isOpen() {
+		return errors.New("API is not open")
+	}
+
+	capabilities, err := network.initialZWGetControllerCapabilities()
+	if err != nil {
+		return err
+	}
+	network.controllerCapabilities = capabilities
+
+	return nil
+}
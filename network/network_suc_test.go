@@ -0,0 +1,148 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"testing"
+)
+
+// testSUCController initializes controller node 1 with nodes 2 and 3, which
+// was primary, and becomes the SUC after a successful ZWSetSUCNodeID
+func testSUCController(t *testing.T) (*testController, *Network) {
+	c := newTestController(t)
+	c.handleInitialize(0x01020304, []uint8{1, 2, 3})
+	network := openTestNetwork(t, c)
+
+	if err := network.Initialize(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	c.handle(message.MessageTypeZWGetControllerCapabilities, testResponseHandler(0x18))
+
+	return c, network
+}
+
+// testSUCHandler answers ZWSetSUCNodeID with the status, in the callback, or
+// in the response when the controller sets itself without a callback
+func testSUCHandler(status uint8) testHandler {
+	return func(request *packet.Packet) []*packet.Packet {
+		if testCallbackID(request) != 0 {
+			return testCallbackHandler(true, status)(request)
+		}
+
+		response := uint8(0x00)
+		if status == message.SUCStatusSucceeded {
+			response = 0x01
+		}
+		return []*packet.Packet{testFrame(packet.PacketTypeResponse,
+			request.MessageType, response)}
+	}
+}
+
+// testCheckSUC checks the last ZWSetSUCNodeID request, and if the controller
+// capabilities were refreshed
+func testCheckSUC(t *testing.T, c *testController, network *Network, body []uint8,
+	refreshed bool) {
+	requests := c.getRequests(message.MessageTypeZWSetSUCNodeID)
+	if len(requests) == 0 {
+		t.Fatalf("Expected ZWSetSUCNodeID request")
+	}
+	request := requests[len(requests)-1]
+	if len(request.Body) != 5 || !reflect.DeepEqual(request.Body[:4], body) {
+		t.Errorf("Expected ZWSetSUCNodeID body: %v got: %v", body, request.Body)
+	}
+
+	// Initialize gets the capabilities once
+	expected := 1
+	if refreshed {
+		expected = 2
+	}
+	if n := len(c.getRequests(message.MessageTypeZWGetControllerCapabilities)); n != expected {
+		t.Errorf("Expected %d ZWGetControllerCapabilities requests got %d", expected, n)
+	}
+	if capabilities := network.GetControllerCapabilities(); capabilities == nil ||
+		capabilities.StaticUpdateController != refreshed {
+		t.Errorf("Unexpected controller capabilities: %+v", capabilities)
+	}
+}
+
+func TestSetSUCNodeID(t *testing.T) {
+	c, network := testSUCController(t)
+	defer network.Close()
+	c.handle(message.MessageTypeZWSetSUCNodeID, testSUCHandler(message.SUCStatusSucceeded))
+
+	if err := network.SetSUCNodeID(2, true, false); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	// Other nodes send a callback
+	testCheckSUC(t, c, network, []uint8{0x02, 0x01, message.TransmitOptionLowPower, 0x00}, true)
+	if request := c.getRequests(message.MessageTypeZWSetSUCNodeID)[0]; testCallbackID(request) == 0 {
+		t.Errorf("Expected callback id: %v", request.Body)
+	}
+}
+
+func TestSetSUCNodeIDFailed(t *testing.T) {
+	c, network := testSUCController(t)
+	defer network.Close()
+	c.handle(message.MessageTypeZWSetSUCNodeID, testSUCHandler(message.SUCStatusFailed))
+
+	if err := network.SetSUCNodeID(2, true, true); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+	testCheckSUC(t, c, network, []uint8{0x02, 0x01, message.TransmitOptionLowPower, 0x01}, false)
+
+	// The controller itself does not send a callback
+	if err := network.SetSUCNodeID(1, false, false); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+	testCheckSUC(t, c, network, []uint8{0x01, 0x00, message.TransmitOptionLowPower, 0x00}, false)
+}
+
+func TestBecomeSIS(t *testing.T) {
+	c, network := testSUCController(t)
+	defer network.Close()
+	c.handle(message.MessageTypeZWSetSUCNodeID, testSUCHandler(message.SUCStatusSucceeded))
+
+	if err := network.BecomeSIS(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	// The controller sets itself without a callback
+	testCheckSUC(t, c, network, []uint8{0x01, 0x01, message.TransmitOptionLowPower, 0x01}, true)
+	if request := c.getRequests(message.MessageTypeZWSetSUCNodeID)[0]; testCallbackID(request) != 0 {
+		t.Errorf("Expected no callback id: %v", request.Body)
+	}
+}
+
+func TestRequestNetworkUpdate(t *testing.T) {
+	c, network := testSUCController(t)
+	defer network.Close()
+
+	c.handle(message.MessageTypeZWRequestNetworkUpdate,
+		testCallbackHandler(true, message.NetworkUpdateDone))
+	if err := network.RequestNetworkUpdate(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	c.handle(message.MessageTypeZWRequestNetworkUpdate,
+		testCallbackHandler(true, message.NetworkUpdateAbort))
+	if err := network.RequestNetworkUpdate(); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+}
@@ -17,6 +17,11 @@ limitations under the License.
 */
 
 import (
+	"github.com/cybojanek/gozwave/controller"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"io"
+	"sync"
 	"testing"
 	"time"
 )
@@ -24,6 +29,271 @@ import (
 // FIXME: mock out or parameterize
 const testDevicePath = "/dev/tty.usbmodem1451"
 
+// Time to wait for the network to send an expected request
+const testRequestTimeout = (2 * time.Second)
+
+// testHandler returns the frames sent by the controller after the ACK of a
+// request
+type testHandler func(request *packet.Packet) []*packet.Packet
+
+// testController emulates a ZWave controller as a controller.Port. Requests
+// are ACKed, and answered by the handler of their MessageType.
+type testController struct {
+	t        *testing.T
+	mutex    sync.Mutex
+	handlers map[uint8]testHandler
+	requests []*packet.Packet // Received requests
+	parser   packet.Parser
+	inFrame  bool
+	pending  []uint8       // Bytes to be read by the host
+	changed  chan struct{} // Closed and replaced when pending or requests change
+}
+
+func newTestController(t *testing.T) *testController {
+	return &testController{t: t, handlers: make(map[uint8]testHandler),
+		changed: make(chan struct{})}
+}
+
+// openTestNetwork opens a network connected to the test controller
+func openTestNetwork(t *testing.T, c *testController) *Network {
+	network := Network{OpenPort: func() (controller.Port, error) { return c, nil }}
+	if err := network.Open(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	return &network
+}
+
+// testFrame returns a SOF packet
+func testFrame(packetType uint8, messageType uint8, body ...uint8) *packet.Packet {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF, PacketType: packetType,
+		MessageType: messageType, Body: body}
+	if err := p.Update(); err != nil {
+		panic(err)
+	}
+	return &p
+}
+
+// testCallbackID returns the callback id, which the controller appends to
+// requests
+func testCallbackID(request *packet.Packet) uint8 {
+	return request.Body[len(request.Body)-1]
+}
+
+// testCallbackHandler responds with an optional one byte response, and a
+// callback with the status
+func testCallbackHandler(response bool, status uint8) testHandler {
+	return func(request *packet.Packet) []*packet.Packet {
+		var frames []*packet.Packet
+		if response {
+			frames = append(frames, testFrame(packet.PacketTypeResponse,
+				request.MessageType, 0x01))
+		}
+		return append(frames, testFrame(packet.PacketTypeRequest, request.MessageType,
+			testCallbackID(request), status))
+	}
+}
+
+// testSendDataHandler transmits ZWSendData requests, and sends the
+// ApplicationCommand reply of the node, if reply returns a command class frame
+func testSendDataHandler(reply func(nodeID uint16, frame []uint8) []uint8) testHandler {
+	return func(request *packet.Packet) []*packet.Packet {
+		nodeID, _ := message.ZWSendDataRequestNodeID(request, message.NodeIDType8Bit)
+		frame := request.Body[2 : 2+int(request.Body[1])]
+
+		frames := []*packet.Packet{
+			testFrame(packet.PacketTypeResponse, message.MessageTypeZWSendData, 0x01),
+			testFrame(packet.PacketTypeRequest, message.MessageTypeZWSendData,
+				testCallbackID(request), message.TransmitCompleteOK, 0x00, 0x02)}
+
+		if reply != nil {
+			if command := reply(nodeID, frame); command != nil {
+				frames = append(frames, testApplicationCommand(nodeID, command...))
+			}
+		}
+
+		return frames
+	}
+}
+
+// testApplicationCommand returns an ApplicationCommand frame of the node
+func testApplicationCommand(nodeID uint16, command ...uint8) *packet.Packet {
+	body := append([]uint8{0x00, uint8(nodeID), uint8(len(command))}, command...)
+	return testFrame(packet.PacketTypeRequest, message.MessageTypeApplicationCommand, body...)
+}
+
+// testResponseHandler responds with the body
+func testResponseHandler(body ...uint8) testHandler {
+	return func(request *packet.Packet) []*packet.Packet {
+		return []*packet.Packet{testFrame(packet.PacketTypeResponse,
+			request.MessageType, body...)}
+	}
+}
+
+// testRequestHandler sends an unsolicited request frame with the body
+func testRequestHandler(messageType uint8, body ...uint8) testHandler {
+	return func(request *packet.Packet) []*packet.Packet {
+		return []*packet.Packet{testFrame(packet.PacketTypeRequest, messageType, body...)}
+	}
+}
+
+// testBitmask returns a bitmask of length bytes, where bit 0 is value 1
+func testBitmask(length int, values ...uint8) []uint8 {
+	bitmask := make([]uint8, length)
+	for _, x := range values {
+		bitmask[(x-1)/8] |= 1 << ((x - 1) % 8)
+	}
+	return bitmask
+}
+
+// handleInitialize answers the Initialize requests of controller node 1 in
+// the home ID, with the nodes and supported optional message types
+func (c *testController) handleInitialize(homeID uint32, nodeIDs []uint8, messageTypes ...uint8) {
+	capabilities := append([]uint8{0x01, 0x00, 0x00, 0x86, 0x00, 0x01, 0x00, 0x5a},
+		testBitmask(32, messageTypes...)...)
+	c.handle(message.MessageTypeSerialAPIGetCapabilities, testResponseHandler(capabilities...))
+	c.handle(message.MessageTypeGetVersion,
+		testResponseHandler(append([]uint8("Z-Wave 6.07\x00"), 0x01)...))
+	c.handle(message.MessageTypeMemoryGetID, testResponseHandler(uint8(homeID>>24),
+		uint8(homeID>>16), uint8(homeID>>8), uint8(homeID), 0x01))
+	initData := append([]uint8{0x05, 0x08, 29}, testBitmask(29, nodeIDs...)...)
+	c.handle(message.MessageTypeSerialAPIGetInitData,
+		testResponseHandler(append(initData, 0x00, 0x00)...))
+	c.handle(message.MessageTypeZWGetControllerCapabilities, testResponseHandler(0x08))
+}
+
+// handle requests of the MessageType
+func (c *testController) handle(messageType uint8, handler testHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.handlers[messageType] = handler
+}
+
+// send frames to the host
+func (c *testController) send(frames ...*packet.Packet) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.queue(frames)
+}
+
+// queue frames to be read
+// NOTE: not goroutine safe, caller must hold c.mutex
+func (c *testController) queue(frames []*packet.Packet) {
+	for _, frame := range frames {
+		b, err := frame.Bytes()
+		if err != nil {
+			c.t.Errorf("Expected nil error: %v", err)
+			continue
+		}
+		c.pending = append(c.pending, b...)
+	}
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// getRequests returns the received requests of the MessageType
+func (c *testController) getRequests(messageType uint8) []*packet.Packet {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var requests []*packet.Packet
+	for _, request := range c.requests {
+		if request.MessageType == messageType {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// waitRequests waits for count requests of the MessageType
+func (c *testController) waitRequests(messageType uint8, count int) []*packet.Packet {
+	timeout := time.After(testRequestTimeout)
+	for {
+		c.mutex.Lock()
+		changed := c.changed
+		c.mutex.Unlock()
+
+		if requests := c.getRequests(messageType); len(requests) >= count {
+			return requests
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			c.t.Fatalf("Timed out waiting for %d requests of %s", count,
+				message.MessageTypeName(messageType))
+		}
+	}
+}
+
+// Read pending bytes, or return io.EOF after a timeout
+func (c *testController) Read(b []byte) (int, error) {
+	timeout := time.After(10 * time.Millisecond)
+	for {
+		c.mutex.Lock()
+		if len(c.pending) > 0 {
+			n := copy(b, c.pending)
+			c.pending = c.pending[n:]
+			c.mutex.Unlock()
+			return n, nil
+		}
+		changed := c.changed
+		c.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return 0, io.EOF
+		}
+	}
+}
+
+// Write parses requests, and queues their ACK and handler frames
+func (c *testController) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, x := range b {
+		// The host terminates frames with a newline
+		if x == '\n' && !c.inFrame {
+			continue
+		}
+
+		p, err := c.parser.Parse(x)
+		c.inFrame = p == nil && err == nil
+		if err != nil {
+			c.t.Errorf("Expected nil error: %v", err)
+		}
+		if p == nil || p.Preamble != packet.PacketPreambleSOF {
+			continue
+		}
+
+		c.requests = append(c.requests, p)
+		frames := []*packet.Packet{{Preamble: packet.PacketPreambleACK}}
+
+		if handler, ok := c.handlers[p.MessageType]; ok {
+			frames = append(frames, handler(p)...)
+		} else {
+			c.t.Errorf("Unexpected request: %v", p)
+		}
+
+		c.queue(frames)
+	}
+
+	return len(b), nil
+}
+
+// Flush does nothing
+func (c *testController) Flush() error {
+	return nil
+}
+
+// Close does nothing, since the controller may be reopened
+func (c *testController) Close() error {
+	return nil
+}
+
 func TestApiOpenClose(t *testing.T) {
 	api := Network{DevicePath: testDevicePath}
 
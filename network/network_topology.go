@@ -0,0 +1,261 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cybojanek/gozwave/message"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+)
+
+// TopologyNode information
+type TopologyNode struct {
+	ID          uint16 `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Controller  bool   `json:"controller"`
+	Listening   bool   `json:"listening"`
+	DeviceClass struct {
+		Basic    uint8 `json:"basic"`
+		Generic  uint8 `json:"generic"`
+		Specific uint8 `json:"specific"`
+	} `json:"device_class"`
+	Neighbors []uint16 `json:"neighbors"`
+	Unknown   bool     `json:"unknown,omitempty"`    // Routing info not available
+	LongRange bool     `json:"long_range,omitempty"` // Long Range node, linked only to the controller
+}
+
+// TopologyLink between two nodes. A link is asymmetric if only one of the
+// nodes reports the other as a neighbor, which indicates a weak link.
+type TopologyLink struct {
+	A          uint16 `json:"a"`
+	B          uint16 `json:"b"`
+	Asymmetric bool   `json:"asymmetric"`
+}
+
+// Topology of the network neighbor graph
+type Topology struct {
+	Nodes []*TopologyNode `json:"nodes"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetTopology queries the controller for the neighbors of every node and
+// returns the neighbor graph, including the controller. A node whose routing
+// info can't be read is marked as unknown. Long Range nodes are not part of
+// the mesh, so they are linked only to the controller. goroutine safe.
+func (network *Network) GetTopology() (*Topology, error) {
+	network.mutex.RLock()
+	if !network.isOpen() {
+		network.mutex.RUnlock()
+		return nil, errors.New("API is not open")
+	}
+	controllerNodeID := network.nodeID
+	network.mutex.RUnlock()
+
+	topology := Topology{}
+
+	controllerNode := TopologyNode{ID: controllerNodeID, Controller: true,
+		Listening: true}
+	topology.Nodes = append(topology.Nodes, &controllerNode)
+
+	for _, n := range network.GetNodes() {
+		topologyNode := TopologyNode{ID: n.ID, Listening: n.IsListening()}
+		topologyNode.Name, topologyNode.Location = n.GetNameAndLocation()
+		topologyNode.DeviceClass.Basic, topologyNode.DeviceClass.Generic,
+			topologyNode.DeviceClass.Specific = n.GetDeviceClass()
+		topology.Nodes = append(topology.Nodes, &topologyNode)
+	}
+
+	sort.Slice(topology.Nodes, func(i, j int) bool {
+		return topology.Nodes[i].ID < topology.Nodes[j].ID
+	})
+
+	var longRangeNodeIDs []uint16
+	for _, topologyNode := range topology.Nodes {
+		// Long Range nodes only talk directly to the controller
+		if message.IsLongRangeNodeID(topologyNode.ID) {
+			topologyNode.LongRange = true
+			topologyNode.Neighbors = []uint16{controllerNodeID}
+			longRangeNodeIDs = append(longRangeNodeIDs, topologyNode.ID)
+			continue
+		}
+		routingInfo, err := network.zWGetRoutingInfo(topologyNode.ID)
+		if err != nil {
+			log.Printf("ERROR GetTopology: failed to get routing info of node %d: %v",
+				topologyNode.ID, err)
+			topologyNode.Unknown = true
+			continue
+		}
+		for _, neighbor := range routingInfo.Neighbors {
+			topologyNode.Neighbors = append(topologyNode.Neighbors, uint16(neighbor))
+		}
+	}
+
+	// Routing info of the controller only covers the mesh
+	controllerNode.Neighbors = append(controllerNode.Neighbors, longRangeNodeIDs...)
+
+	return &topology, nil
+}
+
+// zWGetRoutingInfo gets the message.ZWGetRoutingInfo information
+func (network *Network) zWGetRoutingInfo(nodeID uint16) (*message.ZWGetRoutingInfo, error) {
+	requestPacket, err := message.ZWGetRoutingInfoRequest(nodeID, network.NodeIDType(),
+		false, false)
+	if err != nil {
+		return nil, err
+	}
+	responsePacket, err := network.DoRequest(requestPacket)
+	if err != nil {
+		return nil, err
+	}
+	return message.ZWGetRoutingInfoResponse(responsePacket)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetNode returns the node or nil if doesn't exist
+func (topology *Topology) GetNode(nodeID uint16) *TopologyNode {
+	for _, topologyNode := range topology.Nodes {
+		if topologyNode.ID == nodeID {
+			return topologyNode
+		}
+	}
+	return nil
+}
+
+// Links returns the sorted unique links between nodes in the topology
+func (topology *Topology) Links() []TopologyLink {
+	reported := make(map[[2]uint16]int)
+	for _, topologyNode := range topology.Nodes {
+		for _, neighbor := range topologyNode.Neighbors {
+			if topology.GetNode(neighbor) == nil || neighbor == topologyNode.ID {
+				continue
+			}
+			key := [2]uint16{topologyNode.ID, neighbor}
+			if neighbor < topologyNode.ID {
+				key = [2]uint16{neighbor, topologyNode.ID}
+			}
+			reported[key]++
+		}
+	}
+
+	links := make([]TopologyLink, 0, len(reported))
+	for key, count := range reported {
+		links = append(links, TopologyLink{A: key[0], B: key[1], Asymmetric: count < 2})
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].A != links[j].A {
+			return links[i].A < links[j].A
+		}
+		return links[i].B < links[j].B
+	})
+
+	return links
+}
+
+// Isolated returns the IDs of nodes without any links. Unknown nodes are not
+// isolated, since their neighbors are not known.
+func (topology *Topology) Isolated() []uint16 {
+	linked := make(map[uint16]bool)
+	for _, link := range topology.Links() {
+		linked[link.A] = true
+		linked[link.B] = true
+	}
+
+	isolated := []uint16{}
+	for _, topologyNode := range topology.Nodes {
+		if !linked[topologyNode.ID] && !topologyNode.Unknown {
+			isolated = append(isolated, topologyNode.ID)
+		}
+	}
+	return isolated
+}
+
+// WriteJSON writes the topology nodes, links and isolated nodes as JSON
+func (topology *Topology) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Nodes    []*TopologyNode `json:"nodes"`
+		Links    []TopologyLink  `json:"links"`
+		Isolated []uint16        `json:"isolated"`
+	}{topology.Nodes, topology.Links(), topology.Isolated()})
+}
+
+// WriteDOT writes the topology as an undirected Graphviz DOT graph. The
+// controller is drawn as a double circle, sleeping nodes are dashed, isolated
+// nodes are red, unknown nodes are gray, Long Range nodes are boxes, and
+// asymmetric links are dashed.
+func (topology *Topology) WriteDOT(writer io.Writer) error {
+	isolated := make(map[uint16]bool)
+	for _, nodeID := range topology.Isolated() {
+		isolated[nodeID] = true
+	}
+
+	w := bufio.NewWriter(writer)
+
+	fmt.Fprintf(w, "graph zwave {\n")
+	for _, topologyNode := range topology.Nodes {
+		label := fmt.Sprintf("%d", topologyNode.ID)
+		if len(topologyNode.Name) > 0 {
+			label += "\n" + topologyNode.Name
+		}
+		if len(topologyNode.Location) > 0 {
+			label += "\n" + topologyNode.Location
+		}
+		if !topologyNode.Controller {
+			label += fmt.Sprintf("\n0x%02x/0x%02x", topologyNode.DeviceClass.Generic,
+				topologyNode.DeviceClass.Specific)
+		}
+
+		attributes := "label=" + strconv.Quote(label)
+		if topologyNode.Controller {
+			attributes += " shape=doublecircle"
+		}
+		if topologyNode.LongRange {
+			attributes += " shape=box"
+		}
+		if !topologyNode.Listening {
+			attributes += " style=dashed"
+		}
+		if isolated[topologyNode.ID] {
+			attributes += " color=red"
+		}
+		if topologyNode.Unknown {
+			attributes += " color=gray"
+		}
+		fmt.Fprintf(w, "  n%d [%s];\n", topologyNode.ID, attributes)
+	}
+
+	for _, link := range topology.Links() {
+		if link.Asymmetric {
+			fmt.Fprintf(w, "  n%d -- n%d [style=dashed];\n", link.A, link.B)
+		} else {
+			fmt.Fprintf(w, "  n%d -- n%d;\n", link.A, link.B)
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+
+	return w.Flush()
+}
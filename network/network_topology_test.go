@@ -0,0 +1,167 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func makeTestTopology() *Topology {
+	topology := Topology{Nodes: []*TopologyNode{
+		{ID: 1, Controller: true, Listening: true, Neighbors: []uint16{2, 3}},
+		{ID: 2, Name: "Lamp", Listening: true, Neighbors: []uint16{1, 3}},
+		{ID: 3, Listening: true, Neighbors: []uint16{2}},
+		{ID: 4, Neighbors: []uint16{}},
+	}}
+	return &topology
+}
+
+func TestTopologyLinks(t *testing.T) {
+	topology := makeTestTopology()
+
+	expectedLinks := []TopologyLink{{1, 2, false}, {1, 3, true}, {2, 3, false}}
+	if links := topology.Links(); !reflect.DeepEqual(expectedLinks, links) {
+		t.Errorf("Expected Links: %v got: %v", expectedLinks, links)
+	}
+
+	expectedIsolated := []uint16{4}
+	if isolated := topology.Isolated(); !reflect.DeepEqual(expectedIsolated, isolated) {
+		t.Errorf("Expected Isolated: %v got: %v", expectedIsolated, isolated)
+	}
+}
+
+func TestTopologyWriteDOT(t *testing.T) {
+	topology := makeTestTopology()
+
+	var buffer bytes.Buffer
+	if err := topology.WriteDOT(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	expected := "graph zwave {\n" +
+		"  n1 [label=\"1\" shape=doublecircle];\n" +
+		"  n2 [label=\"2\\nLamp\\n0x00/0x00\"];\n" +
+		"  n3 [label=\"3\\n0x00/0x00\"];\n" +
+		"  n4 [label=\"4\\n0x00/0x00\" style=dashed color=red];\n" +
+		"  n1 -- n2;\n" +
+		"  n1 -- n3 [style=dashed];\n" +
+		"  n2 -- n3;\n" +
+		"}\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected DOT:\n%s\ngot:\n%s", expected, buffer.String())
+	}
+}
+
+func TestTopologyWriteJSON(t *testing.T) {
+	topology := makeTestTopology()
+
+	var buffer bytes.Buffer
+	if err := topology.WriteJSON(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	var decoded struct {
+		Nodes    []*TopologyNode
+		Links    []TopologyLink
+		Isolated []uint16
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	if !reflect.DeepEqual(topology.Nodes, decoded.Nodes) {
+		t.Errorf("Expected Nodes: %v got: %v", topology.Nodes, decoded.Nodes)
+	}
+	if len(decoded.Links) != 3 || !reflect.DeepEqual([]uint16{4}, decoded.Isolated) {
+		t.Errorf("Unexpected JSON: %s", buffer.String())
+	}
+}
+
+func TestGetTopologyUnknown(t *testing.T) {
+	c := newTestController(t)
+	c.handle(message.MessageTypeZWGetRoutingInfo,
+		func(request *packet.Packet) []*packet.Packet {
+			body := make([]uint8, 29)
+			switch request.Body[0] {
+			case 1:
+				body[0] = 0x02
+			case 2:
+				body[0] = 0x01
+			default:
+				// Node 3 fails with a bad response
+				body = body[:1]
+			}
+			return []*packet.Packet{testFrame(packet.PacketTypeResponse,
+				request.MessageType, body...)}
+		})
+	network := openTestNetwork(t, c)
+	defer network.Close()
+
+	network.nodes[2] = node.MakeNode(2, network)
+	network.nodes[3] = node.MakeNode(3, network)
+	network.nodes[256] = node.MakeNode(256, network)
+	network.nodeID = 1
+
+	topology, err := network.GetTopology()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if len(topology.Nodes) != 4 {
+		t.Fatalf("Expected 4 nodes, got: %d", len(topology.Nodes))
+	}
+	if !reflect.DeepEqual([]uint16{2, 256}, topology.GetNode(1).Neighbors) {
+		t.Errorf("Unexpected controller: %+v", topology.GetNode(1))
+	}
+	if longRange := topology.GetNode(256); !longRange.LongRange || longRange.Unknown ||
+		!reflect.DeepEqual([]uint16{1}, longRange.Neighbors) {
+		t.Errorf("Unexpected node 256: %+v", longRange)
+	}
+	expectedLinks := []TopologyLink{{1, 2, false}, {1, 256, false}}
+	if links := topology.Links(); !reflect.DeepEqual(expectedLinks, links) {
+		t.Errorf("Expected Links: %v got: %v", expectedLinks, links)
+	}
+	if topology.GetNode(2).Unknown || !reflect.DeepEqual([]uint16{1}, topology.GetNode(2).Neighbors) {
+		t.Errorf("Unexpected node 2: %+v", topology.GetNode(2))
+	}
+	if !topology.GetNode(3).Unknown || len(topology.GetNode(3).Neighbors) != 0 {
+		t.Errorf("Unexpected node 3: %+v", topology.GetNode(3))
+	}
+	if isolated := topology.Isolated(); len(isolated) != 0 {
+		t.Errorf("Expected no isolated nodes, got: %v", isolated)
+	}
+
+	var buffer bytes.Buffer
+	if err := topology.WriteDOT(&buffer); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if !strings.Contains(buffer.String(), "  n3 [label=\"3\\n0x00/0x00\" style=dashed color=gray];\n") {
+		t.Errorf("Unexpected DOT:\n%s", buffer.String())
+	}
+	if !strings.Contains(buffer.String(), "  n256 [label=\"256\\n0x00/0x00\" shape=box style=dashed];\n") {
+		t.Errorf("Unexpected DOT:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,304 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"fmt"
+	"github.com/cybojanek/gozwave/node"
+	"sort"
+	"time"
+)
+
+// Value Property
+const (
+	ValuePropertyCurrentValue = "currentValue" // Basic current value, uint8
+	ValuePropertyTargetValue  = "targetValue"  // Basic V2 target value, uint8
+	ValuePropertyOn           = "on"           // Binary switch state, bool
+	ValuePropertyLevel        = "level"        // Multi level switch or battery level, uint8
+	ValuePropertyValue        = "value"        // Meter or multi level sensor value, float32
+	ValuePropertyActive       = "active"       // Binary sensor or alarm state, bool
+	ValuePropertyLow          = "low"          // Battery is low, bool
+)
+
+// ValueID uniquely identifies a value. Key distinguishes values with the same
+// property, and is the binary sensor type, alarm type, multi level sensor
+// type << 8 | scale, or meter type << 8 | scale.
+type ValueID struct {
+	NodeID       uint16
+	Endpoint     uint8 // 0 is the root device
+	CommandClass uint8
+	Property     string // One of ValueProperty
+	Key          uint16
+}
+
+// Value information
+type Value struct {
+	ID    ValueID
+	Value interface{} // bool, uint8 or float32, depending on the ValueProperty
+	Unit  string      // Unit of the value, or empty if unitless
+	Time  time.Time   // Time of the most recent report
+}
+
+// ValueChange information
+type ValueChange struct {
+	Previous *Value // Previous value, or nil if this is the first report
+	Current  *Value
+}
+
+// Meter units by type and scale
+var meterUnits = map[uint8]map[uint8]string{
+	node.MeterTypeElectric: {
+		node.MeterScaleElectricKWH:         "kWh",
+		node.MeterScaleElectricKVAH:        "kVAh",
+		node.MeterScaleElectricW:           "W",
+		node.MeterScaleElectricPulseCount:  "pulses",
+		node.MeterScaleElectricV:           "V",
+		node.MeterScaleElectricA:           "A",
+		node.MeterScaleElectricPowerFactor: "PF",
+	},
+	node.MeterTypeGas: {
+		node.MeterScaleGasCubicMeters: "m³",
+		node.MeterScaleGasCubicFeet:   "ft³",
+		node.MeterScaleGasPulseCount:  "pulses",
+	},
+	node.MeterTypeWater: {
+		node.MeterScaleWaterCubicMeters:     "m³",
+		node.MeterScaleWaterCubicFeet:       "ft³",
+		node.MeterScaleWaterCubicUSGallons:  "gal",
+		node.MeterScaleWaterCubicPulseCount: "pulses",
+	},
+	node.MeterTypeHeating: {node.MeterScaleHeatingKWH: "kWh"},
+	node.MeterTypeCooling: {node.MeterScaleCoolingKWH: "kWh"},
+}
+
+// Multi level sensor units by type and scale
+var sensorUnits = map[uint8]map[uint8]string{
+	node.MultiLevelSensorTypeTemperature: {
+		node.MultiLevelSensorScaleTemperatureCelcius:    "°C",
+		node.MultiLevelSensorScaleTemperatureFahrenheit: "°F",
+	},
+	node.MultiLevelSensorTypeGeneral: {
+		node.MultiLevelSensorScaleGeneralPercentage: "%",
+	},
+	node.MultiLevelSensorTypeLuminance: {
+		node.MultiLevelSensorScaleLuminancePercentage: "%",
+		node.MultiLevelSensorScaleLuminanceLUX:        "lux",
+	},
+	node.MultiLevelSensorTypePower: {
+		node.MultiLevelSensorScalePowerWatts:      "W",
+		node.MultiLevelSensorScalePowerBTUPerHour: "BTU/h",
+	},
+	node.MultiLevelSensorTypeRelativeHumidity: {
+		node.MultiLevelSensorScaleRelativeHumidityPercentage: "%",
+		node.MultiLevelSensorScaleRelativeHumidityAbsolute:   "g/m³",
+	},
+	node.MultiLevelSensorTypeVelocity: {
+		node.MultiLevelSensorScaleVelocityMetersPerSecond: "m/s",
+		node.MultiLevelSensorScaleVelocityMilesPerHour:    "mph",
+	},
+	node.MultiLevelSensorTypeAtmosphericPressure: {
+		node.MultiLevelSensorScaleAtmosphericPressureKiloPascals:     "kPa",
+		node.MultiLevelSensorScaleAtmosphericPressureInchesOfMercury: "inHg",
+	},
+	node.MultiLevelSensorTypeBarometricPressure: {
+		node.MultiLevelSensorScaleAtmosphericPressureKiloPascals:     "kPa",
+		node.MultiLevelSensorScaleAtmosphericPressureInchesOfMercury: "inHg",
+	},
+	node.MultiLevelSensorTypeDewPoint: {
+		node.MultiLevelSensorScaleDewPointCelcius:    "°C",
+		node.MultiLevelSensorScaleDewPointFahrenheit: "°F",
+	},
+	node.MultiLevelSensorTypeVoltage: {
+		node.MultiLevelSensorScaleVoltageVolts:      "V",
+		node.MultiLevelSensorScaleVoltageMilliVolts: "mV",
+	},
+	node.MultiLevelSensorTypeCurrent: {
+		node.MultiLevelSensorScaleCurrentAmps:      "A",
+		node.MultiLevelSensorScaleCurrentMilliAmps: "mA",
+	},
+	node.MultiLevelSensorTypeCO2: {
+		node.MultiLevelSensorScaleCO2PartsPerMillion: "ppm",
+	},
+	node.MultiLevelSensorTypeWaterTemperature: {
+		node.MultiLevelSensorScaleWaterTemperatureCelcius:    "°C",
+		node.MultiLevelSensorScaleWaterTemperatureFahrenheit: "°F",
+	},
+	node.MultiLevelSensorTypeSoilTemperature: {
+		node.MultiLevelSensorScaleSoilTemperatureCelcius:    "°C",
+		node.MultiLevelSensorScaleSoilTemperatureFahrenheit: "°F",
+	},
+	node.MultiLevelSensorTypeLoudness: {
+		node.MultiLevelSensorScaleLoudnessDecibel:          "dB",
+		node.MultiLevelSensorScaleLoudnessDecibelWeighting: "dBA",
+	},
+	node.MultiLevelSensorTypeMoisture: {
+		node.MultiLevelSensorScaleMoisturePercentage: "%",
+	},
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// String of the ValueID, stable across restarts
+func (id ValueID) String() string {
+	return fmt.Sprintf("%d-%d-0x%02x-%s-%d", id.NodeID, id.Endpoint, id.CommandClass,
+		id.Property, id.Key)
+}
+
+// GetValue returns a copy of the most recent value, or nil if it was never
+// reported. goroutine safe.
+func (network *Network) GetValue(id ValueID) *Value {
+	network.valueMutex.RLock()
+	defer network.valueMutex.RUnlock()
+
+	if value, ok := network.values[id]; ok {
+		valueCopy := *value
+		return &valueCopy
+	}
+
+	return nil
+}
+
+// GetValues returns copies of the most recent values of the node, sorted by
+// ValueID. goroutine safe.
+func (network *Network) GetValues(nodeID uint16) []*Value {
+	network.valueMutex.RLock()
+	var values []*Value
+	for id, value := range network.values {
+		if id.NodeID == nodeID {
+			valueCopy := *value
+			values = append(values, &valueCopy)
+		}
+	}
+	network.valueMutex.RUnlock()
+
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i].ID, values[j].ID
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		if a.CommandClass != b.CommandClass {
+			return a.CommandClass < b.CommandClass
+		}
+		if a.Property != b.Property {
+			return a.Property < b.Property
+		}
+		return a.Key < b.Key
+	})
+
+	return values
+}
+
+// updateValue stores the value, and returns the change or nil if the value
+// did not change
+func (network *Network) updateValue(value *Value) *ValueChange {
+	network.valueMutex.Lock()
+	defer network.valueMutex.Unlock()
+
+	if network.values == nil {
+		network.values = make(map[ValueID]*Value)
+	}
+
+	previous, ok := network.values[value.ID]
+	stored := *value
+	network.values[value.ID] = &stored
+
+	if !ok {
+		return &ValueChange{Current: value}
+	}
+
+	if previous.Value == value.Value && previous.Unit == value.Unit {
+		return nil
+	}
+
+	previousCopy := *previous
+	return &ValueChange{Previous: &previousCopy, Current: value}
+}
+
+// removeValues forgets all values of the node
+func (network *Network) removeValues(nodeID uint16) {
+	network.valueMutex.Lock()
+	defer network.valueMutex.Unlock()
+
+	for id := range network.values {
+		if id.NodeID == nodeID {
+			delete(network.values, id)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// decodeValues converts the decoded event, or the Basic report, into values
+func decodeValues(report *node.Report, event *Event) []*Value {
+	if report == nil {
+		return nil
+	}
+
+	makeValue := func(property string, key uint16, value interface{}, unit string) *Value {
+		return &Value{ID: ValueID{NodeID: report.NodeID,
+			CommandClass: report.CommandClass, Property: property, Key: key},
+			Value: value, Unit: unit, Time: report.Time}
+	}
+
+	// Basic is not an event, since it duplicates the switch reports. V1 reports
+	// have a TargetValue of CurrentValue.
+	if basic, ok := report.Value.(*node.BasicReport); ok {
+		return []*Value{
+			makeValue(ValuePropertyCurrentValue, 0, basic.CurrentValue, ""),
+			makeValue(ValuePropertyTargetValue, 0, basic.TargetValue, ""),
+		}
+	}
+
+	if event == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case EventTypeSwitch:
+		if event.Switch.CommandClass == node.CommandClassBinarySwitch {
+			return []*Value{makeValue(ValuePropertyOn, 0, event.Switch.On, "")}
+		}
+		return []*Value{makeValue(ValuePropertyLevel, 0, event.Switch.Level, "")}
+
+	case EventTypeMeter:
+		meter := event.Meter
+		key := uint16(meter.MeterType)<<8 | uint16(meter.MeterScale)
+		return []*Value{makeValue(ValuePropertyValue, key, meter.Value,
+			meterUnits[meter.MeterType][meter.MeterScale])}
+
+	case EventTypeSensor:
+		sensor := event.Sensor
+		key := uint16(sensor.SensorType)<<8 | uint16(sensor.SensorScale)
+		return []*Value{makeValue(ValuePropertyValue, key, sensor.Value,
+			sensorUnits[sensor.SensorType][sensor.SensorScale])}
+
+	case EventTypeBinarySensor:
+		return []*Value{makeValue(ValuePropertyActive,
+			uint16(event.BinarySensor.SensorType), event.BinarySensor.Active, "")}
+
+	case EventTypeNotification:
+		return []*Value{makeValue(ValuePropertyActive,
+			uint16(event.Notification.AlarmType), event.Notification.Active, "")}
+
+	case EventTypeBattery:
+		return []*Value{
+			makeValue(ValuePropertyLevel, 0, event.Battery.Level, "%"),
+			makeValue(ValuePropertyLow, 0, event.Battery.Low, ""),
+		}
+	}
+
+	return nil
+}
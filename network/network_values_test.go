@@ -0,0 +1,116 @@
+package network
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/node"
+	"testing"
+)
+
+func TestValueStore(t *testing.T) {
+	network := Network{}
+	n := node.MakeNode(7, nil)
+
+	subscription := network.Subscribe(SubscribeOptions{
+		Filter: EventFilter{Types: []uint8{EventTypeValueChanged}}})
+	defer subscription.Close()
+
+	for _, body := range [][]uint8{
+		{node.CommandClassMultiLevelSwitch, 0x03, 0x20},
+		{node.CommandClassMultiLevelSwitch, 0x03, 0x20},
+		{node.CommandClassMultiLevelSwitch, 0x03, 0x30},
+		{node.CommandClassBattery, 0x03, 0x50},
+		{node.CommandClassBasic, 0x03, 0x10},
+	} {
+		command := message.ApplicationCommand{NodeID: 7, Body: body}
+		network.publishApplicationCommand(n, &command, network.decodeReport(n, &command))
+	}
+
+	levelID := ValueID{NodeID: 7, CommandClass: node.CommandClassMultiLevelSwitch,
+		Property: ValuePropertyLevel}
+	if value := network.GetValue(levelID); value == nil || value.Value != uint8(0x30) {
+		t.Errorf("Unexpected value: %+v", value)
+	}
+
+	if value := network.GetValue(ValueID{NodeID: 8}); value != nil {
+		t.Errorf("Expected nil value: %+v", value)
+	}
+
+	values := network.GetValues(7)
+	expected := []string{
+		"7-0-0x20-currentValue-0",
+		"7-0-0x20-targetValue-0",
+		"7-0-0x26-level-0",
+		"7-0-0x80-level-0",
+		"7-0-0x80-low-0",
+	}
+	if len(values) != len(expected) {
+		t.Errorf("Expected %d values got: %d", len(expected), len(values))
+		t.FailNow()
+	}
+	for i, value := range values {
+		if value.ID.String() != expected[i] {
+			t.Errorf("Expected ValueID: %s got: %s", expected[i], value.ID)
+		}
+	}
+	if values[1].Value != uint8(0x10) {
+		t.Errorf("Unexpected basic target value: %+v", values[1])
+	}
+	if values[3].Unit != "%" || values[3].Value != uint8(0x50) {
+		t.Errorf("Unexpected battery value: %+v", values[3])
+	}
+
+	// First level, changed level, battery level and low, basic current and
+	// target
+	var changes []*ValueChange
+	for len(subscription.Events()) > 0 {
+		changes = append(changes, (<-subscription.Events()).ValueChange)
+	}
+	if len(changes) != 6 {
+		t.Errorf("Expected 6 changes got: %d", len(changes))
+		t.FailNow()
+	}
+	if changes[0].Previous != nil || changes[1].Previous == nil ||
+		changes[1].Previous.Value != uint8(0x20) || changes[1].Current.Value != uint8(0x30) {
+		t.Errorf("Unexpected changes: %+v %+v", changes[0], changes[1])
+	}
+
+	// Removed nodes forget their values
+	network.handleApplicationUpdate(&message.ZWApplicationUpdate{
+		Status: message.ZWApplicationUpdateStateDeleteDone, NodeID: 7})
+	if values := network.GetValues(7); len(values) != 0 {
+		t.Errorf("Expected no values: %v", values)
+	}
+}
+
+func TestValueUnits(t *testing.T) {
+	network := Network{}
+	n := node.MakeNode(3, nil)
+
+	// Temperature 21.5 °C: type, precision 1 | scale 0 | size 2, value
+	command := message.ApplicationCommand{NodeID: 3,
+		Body: []uint8{node.CommandClassMultiLevelSensor, 0x05,
+			node.MultiLevelSensorTypeTemperature, 0x22, 0x00, 0xd7}}
+	network.publishApplicationCommand(n, &command, network.decodeReport(n, &command))
+
+	id := ValueID{NodeID: 3, CommandClass: node.CommandClassMultiLevelSensor,
+		Property: ValuePropertyValue, Key: uint16(node.MultiLevelSensorTypeTemperature) << 8}
+	if value := network.GetValue(id); value == nil || value.Unit != "°C" ||
+		value.Value != float32(21.5) {
+		t.Errorf("Unexpected value: %+v", value)
+	}
+}
@@ -56,8 +56,9 @@ const (
 
 // Command Class
 const (
-	CommandClassNoOperation                 uint8 = 0x01
+	CommandClassNoOperation                 uint8 = 0x00
 	CommandClassBasic                             = 0x20
+	CommandClassControllerReplication             = 0x21
 	CommandClassBinarySwitch                      = 0x25
 	CommandClassMultiLevelSwitch                  = 0x26
 	CommandClassAllSwitch                         = 0x27
@@ -78,5 +79,34 @@ const (
 	CommandClassWakeup                            = 0x84
 	CommandClassAssociation                       = 0x85
 	CommandClassVersion                           = 0x86
+	CommandClassMultiChannelAssociation           = 0x8e
 	CommandClassMark                              = 0xef
 )
+
+// CommandClassNames of the Command Class constants
+var CommandClassNames = map[uint8]string{
+	CommandClassNoOperation:                 "NoOperation",
+	CommandClassBasic:                       "Basic",
+	CommandClassControllerReplication:       "ControllerReplication",
+	CommandClassBinarySwitch:                "BinarySwitch",
+	CommandClassMultiLevelSwitch:            "MultiLevelSwitch",
+	CommandClassAllSwitch:                   "AllSwitch",
+	CommandClassBinarySensor:                "BinarySensor",
+	CommandClassMultiLevelSensor:            "MultiLevelSensor",
+	CommandClassMeter:                       "Meter",
+	CommandClassColorSwitch:                 "ColorSwitch",
+	CommandClassAssociationGroupInformation: "AssociationGroupInformation",
+	CommandClassZwavePlusInfo:               "ZwavePlusInfo",
+	CommandClassConfiguration:               "Configuration",
+	CommandClassAlarm:                       "Alarm",
+	CommandClassManufacturerSpecific:        "ManufacturerSpecific",
+	CommandClassFirmwareUpdateMetadata:      "FirmwareUpdateMetadata",
+	CommandClassNodeNamingAndLocation:       "NodeNamingAndLocation",
+	CommandClassBattery:                     "Battery",
+	CommandClassClock:                       "Clock",
+	CommandClassWakeup:                      "Wakeup",
+	CommandClassAssociation:                 "Association",
+	CommandClassVersion:                     "Version",
+	CommandClassMultiChannelAssociation:     "MultiChannelAssociation",
+	CommandClassMark:                        "Mark",
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/cybojanek/gozwave/controller"
+	"github.com/cybojanek/gozwave/database"
 	"github.com/cybojanek/gozwave/message"
 	"log"
 	"sync"
@@ -30,6 +31,9 @@ import (
 var (
 	// ErrNodeNotFound is returned in case of node not found
 	ErrNodeNotFound = errors.New("Node not found")
+	// ErrNodeAsleep is returned when sending to a sleeping node, which is
+	// neither listening nor FLiRS, outside of its wake up
+	ErrNodeAsleep = errors.New("Node is asleep")
 	// DefaultTransmitOptions for ZW Send Data commands
 	DefaultTransmitOptions = (message.TransmitOptionACK |
 		message.TransmitOptionAutoRoute | message.TransmitOptionExplore)
@@ -37,13 +41,21 @@ var (
 
 const responseTimeout = (10 * time.Second)
 
+// Extra time for a response of a FLiRS node, which the controller first wakes
+// up with a beam of up to 1 second
+const beamTimeout = (1 * time.Second)
+
+// Time a sleeping node stays awake after its wake up notification
+const awakeTimeout = (10 * time.Second)
+
 // Node information
 type Node struct {
-	ID uint8
+	ID uint16
 
-	CommandClasses        []uint8 // List of supported command classes
-	ControlCommandClasses []uint8 // List of control command classes
-	Listening             bool    // Is node actively listening
+	CommandClasses        []uint8                       // List of supported command classes
+	ControlCommandClasses []uint8                       // List of control command classes
+	Listening             bool                          // Is node actively listening
+	ProtocolInfo          message.ZWGetNodeProtocolInfo // Protocol information from Refresh
 	DeviceClass           struct {
 		Basic    uint8 // Basic Device Class
 		Generic  uint8 // Generic Device Class
@@ -56,19 +68,28 @@ type Node struct {
 		ID   uint16 // Product ID
 		Type uint16 // Product Type
 	}
+	DeviceID *DeviceID        // Manufacturer Specific V2 device ID or nil
+	Device   *database.Device // Device database entry or nil if unknown
+	Name     string           // Name from NamingAndLocation
+	Location string           // Location from NamingAndLocation
 
-	network controller.Controller // Reference to parent network
-	mutex   sync.RWMutex          // Node mutex
+	network         controller.Controller // Reference to parent network
+	deviceDatabase  *database.Database    // Device database or nil
+	mutex           sync.RWMutex          // Node mutex
+	hasProtocolInfo bool                  // ProtocolInfo is from Refresh
+	awakeUntil      time.Time             // Sleeping node is awake until
 
 	keyCallbacks                map[uint16]map[chan *ApplicationCommandData]chan *ApplicationCommandData
 	applicationCommandCallbacks map[chan *ApplicationCommandData]chan *ApplicationCommandData
 	applicationUpdateCallbacks  map[chan *ApplicationUpdateData]chan *ApplicationUpdateData
+	reportCallbacks             map[chan *Report]chan *Report
+	reports                     map[uint8]*Report // Most recent report of each command class
 }
 
 // ApplicationCommandData information
 type ApplicationCommandData struct {
-	Status  uint8 // ??
-	NodeID  uint8 // Source NodeID
+	Status  uint8  // ??
+	NodeID  uint16 // Source NodeID
 	Command struct {
 		ClassID uint8   // Command Class ID
 		ID      uint8   // Command Class Subcommand ID
@@ -79,23 +100,49 @@ type ApplicationCommandData struct {
 // ApplicationUpdateData information
 type ApplicationUpdateData struct {
 	Status uint8   // One of message.ZWApplicationUpdateState
-	NodeID uint8   // Source NodeID
+	NodeID uint16  // Source NodeID
 	Data   []uint8 // Update data
 }
 
+// NodeInfo information from a node information frame
+type NodeInfo struct {
+	DeviceClass struct {
+		Basic    uint8 // Basic Device Class
+		Generic  uint8 // Generic Device Class
+		Specific uint8 // Specific Device Class
+	}
+	CommandClasses        []uint8 // List of supported command classes
+	ControlCommandClasses []uint8 // List of control command classes
+}
+
 type applicationCallbackFilter func(response *ApplicationCommandData) bool
 
+// nodeIDTyper is implemented by controllers, whose frames may use 16 bit node
+// IDs
+type nodeIDTyper interface {
+	NodeIDType() uint8
+}
+
 // MakeNode makes a new node
-func MakeNode(nodeID uint8, controller controller.Controller) *Node {
+func MakeNode(nodeID uint16, controller controller.Controller) *Node {
 	return &Node{ID: nodeID, network: controller}
 }
 
+// nodeIDType returns the node ID type of the frames of the controller
+func (node *Node) nodeIDType() uint8 {
+	if typer, ok := node.network.(nodeIDTyper); ok {
+		return typer.NodeIDType()
+	}
+	return message.NodeIDType8Bit
+}
+
 // commandClassIDsToMapKey returns the 16 bit key to use for callback maps
 func commandClassIDsToMapKey(commandClassID uint8, commandID uint8) uint16 {
 	return (uint16(commandClassID) << 8) | (uint16(commandID))
 }
 
-// Refresh the node information: Listening, DeviceClass, CommandClasses.
+// Refresh the node information: ProtocolInfo, Listening, DeviceClass,
+// CommandClasses.
 func (node *Node) Refresh() error {
 	// Acquire exclusive lock, since we'll be updating fields
 	node.mutex.Lock()
@@ -108,13 +155,15 @@ func (node *Node) Refresh() error {
 	}
 
 	// Update fields
+	node.ProtocolInfo = *nodeProtocolInfo
+	node.hasProtocolInfo = true
 	node.Listening = nodeProtocolInfo.Capabilities.Listening
 	node.DeviceClass.Basic = nodeProtocolInfo.DeviceClass.Basic
 	node.DeviceClass.Generic = nodeProtocolInfo.DeviceClass.Generic
 	node.DeviceClass.Specific = nodeProtocolInfo.DeviceClass.Specific
 
-	// If it's a listening device, we can issue more commands to it
-	if node.Listening {
+	// If it's a listening or FLiRS device, we can issue more commands to it
+	if node.isReachable() {
 		node.mutex.Unlock()
 		channel := make(chan *ApplicationUpdateData, 1)
 		node.AddApplicationUpdateCallbackChannel(channel)
@@ -127,9 +176,10 @@ func (node *Node) Refresh() error {
 			return err
 		}
 
+		timeout := node.responseTimeout()
 		node.mutex.Unlock()
 
-		end := time.Now().Add(responseTimeout)
+		end := time.Now().Add(timeout)
 	outer:
 		for {
 			now := time.Now()
@@ -145,10 +195,9 @@ func (node *Node) Refresh() error {
 					continue
 				}
 
-				data := response.Data
-				if len(data) < 3 {
-					return fmt.Errorf("ZWApplicationUpdateStateReceived too short: %d < 3",
-						len(response.Data))
+				info, err := ParseNodeInfo(response.Data)
+				if err != nil {
+					return err
 				}
 
 				// Lock again because we're updating
@@ -157,27 +206,15 @@ func (node *Node) Refresh() error {
 				// NOTE: zWGetNodeProtocolInfo also does device class, but does not do
 				//       command classes
 				// Update DeviceClass
-				node.DeviceClass.Basic = data[0]
-				node.DeviceClass.Generic = data[1]
-				node.DeviceClass.Specific = data[2]
-
-				// Update CommandClasses
-				node.CommandClasses = []uint8{}
-				node.ControlCommandClasses = []uint8{}
-
-				// NOTE: CommandClasses before CommandClassMark are those supported by
-				//       the Node, while the CommandClasses after CommandClassMark are
-				//       those which the Node can control
-				afterMark := false
-				for _, x := range data[3:] {
-					if !afterMark && x == CommandClassMark {
-						afterMark = true
-					} else if !afterMark {
-						node.CommandClasses = append(node.CommandClasses, x)
-					} else { // afterMark
-						node.ControlCommandClasses = append(node.ControlCommandClasses, x)
-					}
-				}
+				node.DeviceClass.Basic = info.DeviceClass.Basic
+				node.DeviceClass.Generic = info.DeviceClass.Generic
+				node.DeviceClass.Specific = info.DeviceClass.Specific
+
+				// Update CommandClasses. RefreshWithIDs removes those the
+				// device advertises, but does not support.
+				node.CommandClasses = info.CommandClasses
+				node.removeUnsupportedCommandClasses()
+				node.ControlCommandClasses = info.ControlCommandClasses
 
 				node.mutex.Unlock()
 
@@ -194,11 +231,48 @@ func (node *Node) Refresh() error {
 			if err != nil {
 				return err
 			}
-			node.mutex.Lock()
-			node.Manufacturer.ID = manufacturerID
-			node.Product.Type = productType
-			node.Product.ID = productID
-			node.mutex.Unlock()
+			if err := node.RefreshWithIDs(manufacturerID, productID, productType); err != nil {
+				return err
+			}
+
+			// V2 adds the serial number, or some other unique device ID
+			if version := node.GetVersion(); version != nil {
+				if v, err := version.GetCommandClass(CommandClassManufacturerSpecific); err != nil {
+					log.Printf("INFO Refresh: node: %d failed to get ManufacturerSpecific "+
+						"version: %v", node.ID, err)
+				} else if v >= 2 {
+					deviceID, err := manuf.GetDeviceID(DeviceIDTypeSerialNumber)
+					if err != nil {
+						log.Printf("INFO Refresh: node: %d failed to get device ID: %v",
+							node.ID, err)
+					} else {
+						node.mutex.Lock()
+						node.DeviceID = deviceID
+						node.mutex.Unlock()
+					}
+				}
+			}
+		}
+
+		// Check if we can get the name and location. They are optional, so a
+		// failure does not fail the refresh
+		if naming := node.GetNamingAndLocation(); naming != nil {
+			if name, err := naming.GetName(); err != nil {
+				log.Printf("INFO Refresh: node: %d failed to get name: %v",
+					node.ID, err)
+			} else {
+				node.mutex.Lock()
+				node.Name = name
+				node.mutex.Unlock()
+			}
+			if location, err := naming.GetLocation(); err != nil {
+				log.Printf("INFO Refresh: node: %d failed to get location: %v",
+					node.ID, err)
+			} else {
+				node.mutex.Lock()
+				node.Location = location
+				node.mutex.Unlock()
+			}
 		}
 	} else {
 		// Can't fill anything in
@@ -208,14 +282,160 @@ func (node *Node) Refresh() error {
 	return nil
 }
 
-// RefreshWithIDs using the manufacturer information and local database
+// RefreshWithIDs using the manufacturer information and local database, and
+// remove the command classes the device advertises, but does not support
 func (node *Node) RefreshWithIDs(manufacturerID uint16, productID uint16, typeID uint16) error {
-	// TODO: implement
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.Manufacturer.ID = manufacturerID
+	node.Product.Type = typeID
+	node.Product.ID = productID
+
+	node.Device = nil
+	if node.deviceDatabase != nil {
+		node.Device = node.deviceDatabase.Lookup(manufacturerID, typeID, productID)
+	}
+	node.removeUnsupportedCommandClasses()
+
 	return nil
 }
 
+// removeUnsupportedCommandClasses removes the command classes the device
+// advertises, but does not support, must be called with node lock
+func (node *Node) removeUnsupportedCommandClasses() {
+	if node.Device == nil {
+		return
+	}
+
+	commandClasses := []uint8{}
+	for _, x := range node.CommandClasses {
+		if !node.Device.IsCommandClassRemoved(x) {
+			commandClasses = append(commandClasses, x)
+		}
+	}
+	node.CommandClasses = commandClasses
+}
+
+// SetDatabase sets the device database used by RefreshWithIDs, can be nil.
+// goroutine safe.
+func (node *Node) SetDatabase(deviceDatabase *database.Database) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	node.deviceDatabase = deviceDatabase
+}
+
+// IsListening checks if the node is actively listening. goroutine safe.
+func (node *Node) IsListening() bool {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.Listening
+}
+
+// IsFrequentListening checks if the node is a FLiRS node, which the controller
+// wakes up with a beam before each frame. goroutine safe.
+func (node *Node) IsFrequentListening() bool {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.ProtocolInfo.Capabilities.FrequentListening != message.FrequentListeningNone
+}
+
+// IsReachable checks if the node can be sent commands at any time, because
+// it is listening or a FLiRS node. Other nodes must be sent commands after
+// their wake up notification. goroutine safe.
+func (node *Node) IsReachable() bool {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.isReachable()
+}
+
+// isReachable checks if the node is listening or a FLiRS node, must be called
+// with node lock
+func (node *Node) isReachable() bool {
+	return node.Listening ||
+		node.ProtocolInfo.Capabilities.FrequentListening != message.FrequentListeningNone
+}
+
+// isAsleep checks if the node is known to be a sleeping node, which is not
+// awake, must be called with node lock
+func (node *Node) isAsleep() bool {
+	return node.hasProtocolInfo && !node.isReachable() &&
+		!time.Now().Before(node.awakeUntil)
+}
+
+// responseTimeout returns the time to wait for a response of the node, must be
+// called with node lock
+func (node *Node) responseTimeout() time.Duration {
+	if node.ProtocolInfo.Capabilities.FrequentListening != message.FrequentListeningNone {
+		return responseTimeout + beamTimeout
+	}
+	return responseTimeout
+}
+
+// GetProtocolInfo returns the protocol information from the last Refresh.
+// goroutine safe.
+func (node *Node) GetProtocolInfo() message.ZWGetNodeProtocolInfo {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.ProtocolInfo
+}
+
+// GetNameAndLocation returns the name and location from the last Refresh.
+// goroutine safe.
+func (node *Node) GetNameAndLocation() (name string, location string) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.Name, node.Location
+}
+
+// GetDeviceClass returns the basic, generic and specific device class.
+// goroutine safe.
+func (node *Node) GetDeviceClass() (basic uint8, generic uint8, specific uint8) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.DeviceClass.Basic, node.DeviceClass.Generic, node.DeviceClass.Specific
+}
+
+// GetDevice returns the device database entry or nil if unknown. goroutine
+// safe.
+func (node *Node) GetDevice() *database.Device {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.Device
+}
+
 // ApplicationCommandHandler function
 func (node *Node) ApplicationCommandHandler(command *message.ApplicationCommand) {
+	var report *Report
+	if len(command.Body) >= 2 {
+		data := ApplicationCommandData{Status: command.Status, NodeID: command.NodeID}
+		data.Command.ClassID = command.Body[0]
+		data.Command.ID = command.Body[1]
+		data.Command.Data = command.Body[2:]
+
+		var err error
+		if report, err = DecodeReport(node, &data); err != nil {
+			log.Printf("ERROR ApplicationCommandHandler: node: %d failed to decode "+
+				"0x%02x 0x%02x: %v", node.ID, data.Command.ClassID, data.Command.ID, err)
+		}
+	}
+
+	node.ApplicationCommandReportHandler(command, report)
+}
+
+// ApplicationCommandReportHandler handles the command like
+// ApplicationCommandHandler, with its report already decoded by DecodeReport,
+// or nil if it is not a report
+func (node *Node) ApplicationCommandReportHandler(command *message.ApplicationCommand,
+	report *Report) {
 	log.Printf("DEBUG ApplicationCommandHandler: node: %d command: %+v", node.ID, command)
 
 	node.mutex.Lock()
@@ -231,6 +451,18 @@ func (node *Node) ApplicationCommandHandler(command *message.ApplicationCommand)
 	commandID := command.Body[1]
 	commandData := command.Body[2:len(command.Body)]
 
+	// Drop reports the device is known to send incorrectly
+	if node.Device != nil && node.Device.IsReportIgnored(commandClassID, commandID) {
+		log.Printf("INFO ApplicationCommandHandler: node: %d ignoring report "+
+			"0x%02x 0x%02x", node.ID, commandClassID, commandID)
+		return
+	}
+
+	// Sleeping nodes can be sent commands for a while after they wake up
+	if commandClassID == CommandClassWakeup && commandID == wakeUpCommandNotification {
+		node.awakeUntil = time.Now().Add(awakeTimeout)
+	}
+
 	// Compute lookup key
 	key := commandClassIDsToMapKey(commandClassID, commandID)
 
@@ -271,6 +503,11 @@ func (node *Node) ApplicationCommandHandler(command *message.ApplicationCommand)
 			}()
 		}
 	}
+
+	// Save reports, whether or not a caller is waiting for them
+	if report != nil {
+		node.handleReport(report)
+	}
 }
 
 // ApplicationUpdateHandler function
@@ -292,6 +529,59 @@ func (node *Node) ApplicationUpdateHandler(update *message.ZWApplicationUpdate)
 	}
 }
 
+// ParseNodeInfo parses the data of a ZWApplicationUpdateStateReceived update
+func ParseNodeInfo(data []uint8) (*NodeInfo, error) {
+	// Data: | BASIC | GENERIC | SPECIFIC | COMMAND_CLASSES |
+	if len(data) < 3 {
+		return nil, fmt.Errorf("ZWApplicationUpdateStateReceived too short: %d < 3",
+			len(data))
+	}
+
+	info := NodeInfo{CommandClasses: []uint8{}, ControlCommandClasses: []uint8{}}
+	info.DeviceClass.Basic = data[0]
+	info.DeviceClass.Generic = data[1]
+	info.DeviceClass.Specific = data[2]
+
+	// NOTE: CommandClasses before CommandClassMark are those supported by
+	//       the Node, while the CommandClasses after CommandClassMark are
+	//       those which the Node can control
+	afterMark := false
+	for _, x := range data[3:] {
+		if !afterMark && x == CommandClassMark {
+			afterMark = true
+		} else if !afterMark {
+			info.CommandClasses = append(info.CommandClasses, x)
+		} else { // afterMark
+			info.ControlCommandClasses = append(info.ControlCommandClasses, x)
+		}
+	}
+
+	return &info, nil
+}
+
+// GetReportPayload returns the payload of the parameterless Get command of the
+// command class, whose report is handled like an unsolicited report
+func GetReportPayload(commandClass uint8) ([]uint8, error) {
+	switch commandClass {
+	case CommandClassBasic:
+		return []uint8{basicCommandGet}, nil
+	case CommandClassBinarySwitch:
+		return []uint8{binarySwitchCommandGet}, nil
+	case CommandClassMultiLevelSwitch:
+		return []uint8{multiLevelSwitchCommandGet}, nil
+	case CommandClassBinarySensor:
+		return []uint8{binarySensorCommandGet}, nil
+	case CommandClassMultiLevelSensor:
+		return []uint8{multiLevelSensorCommandGet}, nil
+	case CommandClassMeter:
+		return []uint8{meterCommandGet}, nil
+	case CommandClassBattery:
+		return []uint8{batteryCommandGet}, nil
+	default:
+		return nil, fmt.Errorf("Command class 0x%02x has no report Get", commandClass)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 
 // Check if node supports a command class.
@@ -397,7 +687,7 @@ func (node *Node) RemoveApplicationUpdateCallbackChannel(channel chan *Applicati
 // for a requested node. Returns ErrNodeNotFound if the request node could not
 // be found by the controller.
 func (node *Node) zWGetNodeProtocolInfo() (*message.ZWGetNodeProtocolInfo, error) {
-	requestPacket, err := message.ZWGetNodeProtocolInfoRequest(node.ID)
+	requestPacket, err := message.ZWGetNodeProtocolInfoRequest(node.ID, node.nodeIDType())
 	if err != nil {
 		return nil, err
 	}
@@ -420,7 +710,7 @@ func (node *Node) zWGetNodeProtocolInfo() (*message.ZWGetNodeProtocolInfo, error
 // zWRequestNodeInfo gets the message.ZWRequestNodeInfo information for a
 // requested node.
 func (node *Node) zWRequestNodeInfo() error {
-	requestPacket, err := message.ZWRequestNodeInfoRequest(node.ID)
+	requestPacket, err := message.ZWRequestNodeInfoRequest(node.ID, node.nodeIDType())
 	if err != nil {
 		return err
 	}
@@ -440,10 +730,18 @@ func (node *Node) zWRequestNodeInfo() error {
 	return nil
 }
 
-// zWSendData sends the ZWSendData request to a given node
+// zWSendData sends the ZWSendData request to a given node. The controller
+// beams to FLiRS nodes by itself. Sleeping nodes are not queued for their wake
+// up: sending to them fails with ErrNodeAsleep, unless they sent a wake up
+// notification in the last awakeTimeout.
+// NOTE: not goroutine safe, caller must hold node.mutex
 func (node *Node) zWSendData(commandClass uint8, payload []uint8) error {
-	requestPacket, err := message.ZWSendDataRequest(node.ID, commandClass, payload,
-		DefaultTransmitOptions, 0x00)
+	if node.isAsleep() {
+		return ErrNodeAsleep
+	}
+
+	requestPacket, err := message.ZWSendDataRequest(node.ID, node.nodeIDType(),
+		commandClass, payload, DefaultTransmitOptions, 0x00)
 	if err != nil {
 		return err
 	}
@@ -492,9 +790,10 @@ func (node *Node) zwSendDataWaitForResponse(commandClass uint8,
 		node.mutex.Unlock()
 		return nil, err
 	}
+	timeout := node.responseTimeout()
 	node.mutex.Unlock()
 
-	end := time.Now().Add(responseTimeout)
+	end := time.Now().Add(timeout)
 	for {
 		now := time.Now()
 		timeLeft := end.Sub(now)
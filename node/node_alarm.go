@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 )
 
 const (
@@ -67,14 +68,23 @@ func (node *Node) GetAlarm() *Alarm {
 
 // Activate turns the alarm on
 func (node *Alarm) Activate(alarmType uint8) error {
-	return node.zwSendDataRequest(CommandClassAlarm,
-		[]uint8{binarySwitchCommandSet, alarmType, 0xff})
+	return node.set(alarmType, commandclass.AlarmSetV2ZWaveAlarmStatusOn)
 }
 
 // Deactivate turns the alarm off
 func (node *Alarm) Deactivate(alarmType uint8) error {
-	return node.zwSendDataRequest(CommandClassAlarm,
-		[]uint8{binarySwitchCommandSet, alarmType, 0x00})
+	return node.set(alarmType, commandclass.AlarmSetV2ZWaveAlarmStatusOff)
+}
+
+// set the status of an alarm type
+func (node *Alarm) set(alarmType uint8, status uint8) error {
+	payload, err := (&commandclass.AlarmSetV2{ZWaveAlarmType: alarmType,
+		ZWaveAlarmStatus: status}).Encode()
+	if err != nil {
+		return err
+	}
+
+	return node.zwSendDataRequest(CommandClassAlarm, payload)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -130,7 +140,8 @@ func (node *Alarm) ParseReport(report *ApplicationCommandData) (isActive bool, a
 	// TODO: Better V2/Notification support
 	if len(data) > 2 {
 		if len(data) < 7 {
-			err = fmt.Errorf("Bad Report Data length %d > 2 but %d < 7", len(data))
+			err = fmt.Errorf("Bad Report Data length %d > 2 but %d < 7",
+				len(data), len(data))
 			return
 		}
 
@@ -177,3 +188,29 @@ func (node *Alarm) GetSupportedAlarmTypes() (notificationOnly bool, alarmTypes [
 
 	return
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// AlarmReport information
+type AlarmReport struct {
+	AlarmType uint8
+	Active    bool
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassAlarm, decodeAlarmReport)
+}
+
+// decodeAlarmReport decodes a report into an *AlarmReport
+func decodeAlarmReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := Alarm{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	active, alarmType, err := wrapper.ParseReport(report)
+	if err != nil {
+		return nil, err
+	}
+	return &AlarmReport{AlarmType: alarmType, Active: active}, nil
+}
@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/database"
 )
 
 const (
@@ -96,7 +97,7 @@ func (node *Association) Get(association uint8) (maxNodes uint8, nodes []uint8,
 	}
 
 	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassAssociation, []uint8{associationCommandGet},
+		CommandClassAssociation, []uint8{associationCommandGet, association},
 		associationCommandReport, filter); err != nil {
 		return
 	}
@@ -120,18 +121,22 @@ func (node *Association) Get(association uint8) (maxNodes uint8, nodes []uint8,
 
 ////////////////////////////////////////////////////////////////////////////////
 
-// GetSupported gets the number of supported association groups
+// GetSupported gets the number of supported association groups.
+//
+// Deprecated: Association Groupings Get is not sent for a group, so the
+// association argument is ignored. Use GetGroupings.
 func (node *Association) GetSupported(association uint8) (uint8, error) {
+	return node.GetGroupings()
+}
+
+// GetGroupings gets the number of supported association groups
+func (node *Association) GetGroupings() (uint8, error) {
 	var response *ApplicationCommandData
 	var err error
 
-	filter := func(response *ApplicationCommandData) bool {
-		return len(response.Command.Data) > 0 && response.Command.Data[0] == association
-	}
-
 	if response, err = node.zwSendDataWaitForResponse(
 		CommandClassAssociation, []uint8{associationCommandGroupingsGet},
-		associationCommandGroupingsReport, filter); err != nil {
+		associationCommandGroupingsReport, nil); err != nil {
 		return 0, err
 	}
 
@@ -142,3 +147,14 @@ func (node *Association) GetSupported(association uint8) (uint8, error) {
 
 	return data[0], nil
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetGroupInfo returns the device database metadata of the association group
+// or nil if unknown
+func (node *Association) GetGroupInfo(association uint8) *database.AssociationGroup {
+	if device := node.GetDevice(); device != nil {
+		return device.GetGroup(association)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	associationGroupInformationCommandNameGet           uint8 = 0x01
+	associationGroupInformationCommandNameReport              = 0x02
+	associationGroupInformationCommandInfoGet                 = 0x03
+	associationGroupInformationCommandInfoReport              = 0x04
+	associationGroupInformationCommandCommandListGet          = 0x05
+	associationGroupInformationCommandCommandListReport       = 0x06
+)
+
+// Association Group Profile
+const (
+	AssociationGroupProfileGeneralNA       uint16 = 0x0000
+	AssociationGroupProfileGeneralLifeline        = 0x0001
+)
+
+// AssociationGroupInformation information
+type AssociationGroupInformation struct {
+	*Node
+}
+
+// GetAssociationGroupInformation returns a AssociationGroupInformation or nil
+// object
+func (node *Node) GetAssociationGroupInformation() *AssociationGroupInformation {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if node.supportsCommandClass(CommandClassAssociationGroupInformation) {
+		return &AssociationGroupInformation{node}
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetName gets the name of the association group
+func (node *AssociationGroupInformation) GetName(association uint8) (string, error) {
+	var response *ApplicationCommandData
+	var err error
+
+	filter := func(response *ApplicationCommandData) bool {
+		return len(response.Command.Data) > 0 && response.Command.Data[0] == association
+	}
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassAssociationGroupInformation,
+		[]uint8{associationGroupInformationCommandNameGet, association},
+		associationGroupInformationCommandNameReport, filter); err != nil {
+		return "", err
+	}
+
+	// Data: | GROUP | LENGTH | NAME |
+	data := response.Command.Data
+	if len(data) < 2 {
+		return "", fmt.Errorf("Response is too short %d < 2", len(data))
+	}
+
+	if int(data[1]) != len(data)-2 {
+		return "", fmt.Errorf("Bad name length %d != %d", data[1], len(data)-2)
+	}
+
+	return string(data[2:]), nil
+}
+
+// GetProfile gets the profile of the association group, which is one of
+// AssociationGroupProfile
+func (node *AssociationGroupInformation) GetProfile(association uint8) (uint16, error) {
+	var response *ApplicationCommandData
+	var err error
+
+	filter := func(response *ApplicationCommandData) bool {
+		return len(response.Command.Data) > 1 && response.Command.Data[1] == association
+	}
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassAssociationGroupInformation,
+		[]uint8{associationGroupInformationCommandInfoGet, 0x00, association},
+		associationGroupInformationCommandInfoReport, filter); err != nil {
+		return 0, err
+	}
+
+	// Data: | PROPERTIES | followed by GROUP_COUNT of
+	//       | GROUP | MODE | PROFILE_MSB | PROFILE_LSB | RESERVED |
+	//       | EVENT_CODE_MSB | EVENT_CODE_LSB |
+	// PROPERTIES: bit 7 is LIST_MODE, bit 6 is DYNAMIC, bits 0-5 are
+	//             GROUP_COUNT
+	data := response.Command.Data
+	if len(data) < 8 {
+		return 0, fmt.Errorf("Response is too short %d < 8", len(data))
+	}
+
+	if groupCount := data[0] & 0x3f; groupCount != 1 {
+		return 0, fmt.Errorf("Bad group count %d != 1", groupCount)
+	}
+
+	return binary.BigEndian.Uint16(data[3:5]), nil
+}
+
+// GetCommands gets the command class and command pairs sent to the
+// association group
+func (node *AssociationGroupInformation) GetCommands(association uint8) ([][2]uint8, error) {
+	var response *ApplicationCommandData
+	var err error
+
+	filter := func(response *ApplicationCommandData) bool {
+		return len(response.Command.Data) > 0 && response.Command.Data[0] == association
+	}
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassAssociationGroupInformation,
+		[]uint8{associationGroupInformationCommandCommandListGet, 0x00, association},
+		associationGroupInformationCommandCommandListReport, filter); err != nil {
+		return nil, err
+	}
+
+	// Data: | GROUP | LENGTH | COMMAND_CLASS | COMMAND | ... |
+	data := response.Command.Data
+	if len(data) < 2 {
+		return nil, fmt.Errorf("Response is too short %d < 2", len(data))
+	}
+
+	if int(data[1]) != len(data)-2 || data[1]%2 != 0 {
+		return nil, fmt.Errorf("Bad command list length %d", data[1])
+	}
+
+	// NOTE: extended command classes are not supported
+	commands := make([][2]uint8, 0, data[1]/2)
+	for i := 2; i < len(data); i += 2 {
+		commands = append(commands, [2]uint8{data[i], data[i+1]})
+	}
+
+	return commands, nil
+}
@@ -138,3 +138,39 @@ func (node *Basic) ParseReportV2(report *ApplicationCommandData) (currentValue u
 
 	return
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BasicReport information
+type BasicReport struct {
+	CurrentValue uint8
+	TargetValue  uint8         // Same as CurrentValue in V1 reports
+	Duration     time.Duration // Time to reach TargetValue, 0 in V1 reports
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassBasic, decodeBasicReport)
+}
+
+// decodeBasicReport decodes a V1 or V2 report into a *BasicReport
+func decodeBasicReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := Basic{node}
+	switch {
+	case wrapper.IsReport(report):
+		value, err := wrapper.ParseReport(report)
+		if err != nil {
+			return nil, err
+		}
+		return &BasicReport{CurrentValue: value, TargetValue: value}, nil
+
+	case wrapper.IsReportV2(report):
+		currentValue, targetValue, duration, err := wrapper.ParseReportV2(report)
+		if err != nil {
+			return nil, err
+		}
+		return &BasicReport{CurrentValue: currentValue, TargetValue: targetValue,
+			Duration: duration}, nil
+	}
+
+	return nil, nil
+}
@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 )
 
 const (
@@ -74,17 +75,43 @@ func (node *Battery) ParseReport(report *ApplicationCommandData) (isLow bool, le
 			report.Command.ID, batteryCommandReport)
 	}
 
-	data := report.Command.Data
-	if len(data) != 1 {
-		return false, 0, fmt.Errorf("Bad Report Data length %d != 1", len(data))
+	var command commandclass.BatteryReportV1
+	if err = command.Decode(report.Command.Data); err != nil {
+		return
 	}
 
 	// Level is 0-100 or 255
-	level = data[0]
-	isLow = data[0] == 0xff
+	level = command.BatteryLevel
+	isLow = command.BatteryLevel == 0xff
 	if isLow {
 		level = 0
 	}
 
 	return
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BatteryReport information
+type BatteryReport struct {
+	Level uint8 // Percent
+	Low   bool
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassBattery, decodeBatteryReport)
+}
+
+// decodeBatteryReport decodes a report into a *BatteryReport
+func decodeBatteryReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := Battery{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	low, level, err := wrapper.ParseReport(report)
+	if err != nil {
+		return nil, err
+	}
+	return &BatteryReport{Level: level, Low: low}, nil
+}
@@ -130,3 +130,29 @@ func (node *BinarySensor) IsActiveV2(sensorType uint8) (bool, error) {
 	isActive, _, err := node.ParseReport(response)
 	return isActive, err
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BinarySensorReport information
+type BinarySensorReport struct {
+	SensorType uint8
+	Active     bool
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassBinarySensor, decodeBinarySensorReport)
+}
+
+// decodeBinarySensorReport decodes a report into a *BinarySensorReport
+func decodeBinarySensorReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := BinarySensor{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	active, sensorType, err := wrapper.ParseReport(report)
+	if err != nil {
+		return nil, err
+	}
+	return &BinarySensorReport{SensorType: sensorType, Active: active}, nil
+}
@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 )
 
 const (
@@ -45,16 +46,30 @@ func (node *Node) GetBinarySwitch() *BinarySwitch {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// BinarySwitchSetPayload returns the CommandClassBinarySwitch payload to turn
+// a switch on or off, for use in multicast and broadcast sends
+func BinarySwitchSetPayload(on bool) []uint8 {
+	command := commandclass.SwitchBinarySetV1{
+		SwitchValue: commandclass.SwitchBinarySetV1SwitchValueOffDisable}
+	if on {
+		command.SwitchValue = commandclass.SwitchBinarySetV1SwitchValueOnEnable
+	}
+
+	// Encoding a single byte parameter never fails
+	payload, _ := command.Encode()
+	return payload
+}
+
 // On turns the switch on
 func (node *BinarySwitch) On() error {
 	return node.zwSendDataRequest(CommandClassBinarySwitch,
-		[]uint8{binarySwitchCommandSet, 0xff})
+		BinarySwitchSetPayload(true))
 }
 
 // Off turns the switch off
 func (node *BinarySwitch) Off() error {
 	return node.zwSendDataRequest(CommandClassBinarySwitch,
-		[]uint8{binarySwitchCommandSet, 0x00})
+		BinarySwitchSetPayload(false))
 }
 
 // IsOn queries the switch to check current status
@@ -88,10 +103,36 @@ func (node *BinarySwitch) ParseReport(report *ApplicationCommandData) (bool, err
 			report.Command.ID, binarySwitchCommandReport)
 	}
 
-	data := report.Command.Data
-	if len(data) != 1 {
-		return false, fmt.Errorf("Bad Report Data length %d != 1", len(data))
+	var command commandclass.SwitchBinaryReportV1
+	if err := command.Decode(report.Command.Data); err != nil {
+		return false, err
+	}
+
+	return command.Value != commandclass.SwitchBinaryReportV1ValueOffDisable, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// BinarySwitchReport information
+type BinarySwitchReport struct {
+	On    bool
+	Value uint8 // Reported value, 0x00 or 0xff
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassBinarySwitch, decodeBinarySwitchReport)
+}
+
+// decodeBinarySwitchReport decodes a report into a *BinarySwitchReport
+func decodeBinarySwitchReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := BinarySwitch{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
 	}
 
-	return data[0] != 0, nil
+	on, err := wrapper.ParseReport(report)
+	if err != nil {
+		return nil, err
+	}
+	return &BinarySwitchReport{On: on, Value: report.Command.Data[0]}, nil
 }
@@ -21,6 +21,7 @@ limitations under the License.
 import (
 	"encoding/binary"
 	"fmt"
+	"github.com/cybojanek/gozwave/database"
 )
 
 const (
@@ -163,3 +164,63 @@ func (node *Configuration) SetInt(parameter uint8, value uint32) error {
 			uint8((value >> 16) & (0xff)), uint8((value >> 8) & (0xff)),
 			uint8(value & 0xff)})
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetParameterInfo returns the device database metadata of the parameter or
+// nil if unknown
+func (node *Configuration) GetParameterInfo(parameter uint8) *database.Parameter {
+	if device := node.GetDevice(); device != nil {
+		return device.GetParameter(parameter)
+	}
+	return nil
+}
+
+// Get returns the signed value of the parameter, using the size from the
+// device database
+func (node *Configuration) Get(parameter uint8) (int32, error) {
+	info := node.GetParameterInfo(parameter)
+	if info == nil {
+		return 0, fmt.Errorf("Parameter %d not in device database", parameter)
+	}
+
+	var value []uint8
+	var err error
+
+	if value, err = node.getValue(parameter, info.Size); err != nil {
+		return 0, err
+	}
+
+	switch info.Size {
+	case 1:
+		return int32(int8(value[0])), nil
+	case 2:
+		return int32(int16(binary.BigEndian.Uint16(value))), nil
+	default:
+		return int32(binary.BigEndian.Uint32(value)), nil
+	}
+}
+
+// Set sets the signed value of the parameter, using the size and range from
+// the device database
+func (node *Configuration) Set(parameter uint8, value int32) error {
+	info := node.GetParameterInfo(parameter)
+	if info == nil {
+		return fmt.Errorf("Parameter %d not in device database", parameter)
+	}
+
+	if value < info.Min || value > info.Max {
+		return fmt.Errorf("Value %d not in range [%d, %d]", value, info.Min, info.Max)
+	}
+
+	switch info.Size {
+	case 1:
+		return node.SetByte(parameter, uint8(value))
+	case 2:
+		return node.SetShort(parameter, uint16(value))
+	case 4:
+		return node.SetInt(parameter, uint32(value))
+	default:
+		return fmt.Errorf("Bad parameter size: %d", info.Size)
+	}
+}
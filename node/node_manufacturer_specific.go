@@ -18,14 +18,47 @@ limitations under the License.
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 const (
-	manufacturerSpecificCommandGet    uint8 = 0x04
-	manufacturerSpecificCommandReport       = 0x05
+	manufacturerSpecificCommandGet                  uint8 = 0x04
+	manufacturerSpecificCommandReport                     = 0x05
+	manufacturerSpecificCommandDeviceSpecificGet          = 0x06
+	manufacturerSpecificCommandDeviceSpecificReport       = 0x07
 )
 
+// Device ID Type
+const (
+	DeviceIDTypeFactoryDefault uint8 = 0x00
+	DeviceIDTypeSerialNumber         = 0x01
+	DeviceIDTypePseudoRandom         = 0x02
+)
+
+// Device ID Data Format
+const (
+	DeviceIDFormatUTF8   uint8 = 0x00
+	DeviceIDFormatBinary       = 0x01
+)
+
+// DeviceID information
+type DeviceID struct {
+	Type   uint8   // One of DeviceIDType
+	Format uint8   // One of DeviceIDFormat
+	Data   []uint8 // Raw device ID
+}
+
+// String representation of the device ID. Binary IDs are hex encoded with a
+// h' prefix, as recommended by the specification.
+func (id *DeviceID) String() string {
+	if id.Format == DeviceIDFormatUTF8 {
+		return string(id.Data)
+	}
+	return "h'" + strings.ToUpper(hex.EncodeToString(id.Data))
+}
+
 // ManufacturerSpecific information
 type ManufacturerSpecific struct {
 	*Node
@@ -66,3 +99,61 @@ func (node *ManufacturerSpecific) Get() (manufacturerID uint16, productType uint
 	productID = binary.BigEndian.Uint16(data[4:6])
 	return
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetDeviceID queries the V2 device ID of the requested type. Devices which
+// do not support the type reply with their factory default ID.
+func (node *ManufacturerSpecific) GetDeviceID(deviceIDType uint8) (*DeviceID, error) {
+	if (deviceIDType & 0x7) != deviceIDType {
+		return nil, fmt.Errorf("Device ID type out of range [0, 7]")
+	}
+
+	var response *ApplicationCommandData
+	var err error
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassManufacturerSpecific,
+		[]uint8{manufacturerSpecificCommandDeviceSpecificGet, deviceIDType},
+		manufacturerSpecificCommandDeviceSpecificReport, nil); err != nil {
+		return nil, err
+	}
+
+	return node.ParseDeviceIDReport(response)
+}
+
+// ParseDeviceIDReport of device ID
+func (node *ManufacturerSpecific) ParseDeviceIDReport(report *ApplicationCommandData) (*DeviceID, error) {
+	if report.Command.ClassID != CommandClassManufacturerSpecific {
+		return nil, fmt.Errorf("Bad Report Command Class ID: 0x%02x != 0x%02x",
+			report.Command.ClassID, CommandClassManufacturerSpecific)
+	}
+
+	if report.Command.ID != manufacturerSpecificCommandDeviceSpecificReport {
+		return nil, fmt.Errorf("Bad Report Command ID 0x%02x != 0x%02x",
+			report.Command.ID, manufacturerSpecificCommandDeviceSpecificReport)
+	}
+
+	data := report.Command.Data
+	if len(data) < 2 {
+		return nil, fmt.Errorf("Bad Report Data length %d < 2", len(data))
+	}
+
+	id := DeviceID{Type: data[0] & 0x7, Format: (data[1] >> 5) & 0x7}
+	switch id.Format {
+	case DeviceIDFormatUTF8, DeviceIDFormatBinary:
+		// Pass
+	default:
+		return nil, fmt.Errorf("Unknown device ID format: 0x%02x", id.Format)
+	}
+
+	length := int(data[1] & 0x1f)
+	if len(data) != 2+length {
+		return nil, fmt.Errorf("Bad Report Data length %d != %d", len(data), 2+length)
+	}
+
+	id.Data = make([]uint8, length)
+	copy(id.Data, data[2:])
+
+	return &id, nil
+}
@@ -0,0 +1,89 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testDeviceIDReport returns a Device Specific Report
+func testDeviceIDReport(data ...uint8) *ApplicationCommandData {
+	report := ApplicationCommandData{NodeID: 2}
+	report.Command.ClassID = CommandClassManufacturerSpecific
+	report.Command.ID = manufacturerSpecificCommandDeviceSpecificReport
+	report.Command.Data = data
+	return &report
+}
+
+func TestParseDeviceIDReport(t *testing.T) {
+	wrapper := ManufacturerSpecific{MakeNode(2, nil)}
+
+	// UTF-8 serial number, with reserved bits set
+	id, err := wrapper.ParseDeviceIDReport(testDeviceIDReport(0xf9, 0x03, 'A', 'B', 'C'))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if id.Type != DeviceIDTypeSerialNumber || id.Format != DeviceIDFormatUTF8 ||
+		!bytes.Equal(id.Data, []uint8{'A', 'B', 'C'}) || id.String() != "ABC" {
+		t.Errorf("Unexpected device ID: %+v %s", id, id.String())
+	}
+
+	// Binary pseudo random ID
+	id, err = wrapper.ParseDeviceIDReport(testDeviceIDReport(0x02, 0x22, 0x0a, 0xbc))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if id.Type != DeviceIDTypePseudoRandom || id.Format != DeviceIDFormatBinary ||
+		!bytes.Equal(id.Data, []uint8{0x0a, 0xbc}) || id.String() != "h'0ABC" {
+		t.Errorf("Unexpected device ID: %+v %s", id, id.String())
+	}
+
+	// Empty ID
+	id, err = wrapper.ParseDeviceIDReport(testDeviceIDReport(0x00, 0x00))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if id.Type != DeviceIDTypeFactoryDefault || len(id.Data) != 0 || id.String() != "" {
+		t.Errorf("Unexpected device ID: %+v %s", id, id.String())
+	}
+
+	// Bad format
+	if id, err := wrapper.ParseDeviceIDReport(testDeviceIDReport(0x01, 0x41, 0x00)); id != nil || err == nil {
+		t.Errorf("Expected nil id: %v and non nil error: %v", id, err)
+	}
+
+	// Bad lengths
+	for _, data := range [][]uint8{{}, {0x01}, {0x01, 0x03, 'A', 'B'}, {0x01, 0x01, 'A', 'B'}} {
+		if id, err := wrapper.ParseDeviceIDReport(testDeviceIDReport(data...)); id != nil || err == nil {
+			t.Errorf("Expected nil id: %v and non nil error: %v for %v", id, err, data)
+		}
+	}
+
+	// Bad command class and command
+	report := testDeviceIDReport(0x01, 0x01, 'A')
+	report.Command.ClassID = CommandClassVersion
+	if id, err := wrapper.ParseDeviceIDReport(report); id != nil || err == nil {
+		t.Errorf("Expected nil id: %v and non nil error: %v", id, err)
+	}
+
+	report = testDeviceIDReport(0x01, 0x01, 'A')
+	report.Command.ID = manufacturerSpecificCommandReport
+	if id, err := wrapper.ParseDeviceIDReport(report); id != nil || err == nil {
+		t.Errorf("Expected nil id: %v and non nil error: %v", id, err)
+	}
+}
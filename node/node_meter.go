@@ -17,8 +17,8 @@ limitations under the License.
 */
 
 import (
-	"encoding/binary"
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 	"github.com/cybojanek/gozwave/message"
 )
 
@@ -150,12 +150,32 @@ func (node *Meter) ParseReport(report *ApplicationCommandData) (*MeterResult, er
 
 	data := report.Command.Data
 	if len(data) < 3 {
-		return nil, fmt.Errorf("Bad response, data too short %d < %d", len(data))
+		return nil, fmt.Errorf("Bad response, data too short %d < %d", len(data), 3)
+	}
+
+	// The Previous Meter Value is left out when the Delta Time is 0, and V4
+	// appends a Scale 2 byte, neither of which the generated V3 report knows
+	// about, so only the V3 part of the report is decoded with it
+	size := int(data[1] & 0x7)
+	end := 2 + size
+	if len(data) > end {
+		end += 2
+		if len(data) >= end && (data[end-2] != 0 || data[end-1] != 0) {
+			end += size
+		}
+	}
+	if end > len(data) {
+		end = len(data)
 	}
 
-	// Process MeterType
 	// NOTE: this handles both V1, V2, V3
-	meterType := data[0] & 0x1f
+	var command commandclass.MeterReportV3
+	if err = command.Decode(data[:end]); err != nil {
+		return nil, err
+	}
+
+	// Process MeterType
+	meterType := command.MeterType
 	switch meterType {
 	case MeterTypeElectric, MeterTypeGas, MeterTypeWater, MeterTypeHeating, MeterTypeCooling:
 		result.MeterType = meterType
@@ -165,7 +185,7 @@ func (node *Meter) ParseReport(report *ApplicationCommandData) (*MeterResult, er
 
 	// Process RateType
 	// NOTE: this will be 0 for V1
-	rateType := (data[0] >> 5) & 0x3
+	rateType := command.RateType
 	switch rateType {
 	case RateTypeNone, RateTypeImport, RateTypeExport:
 		result.RateType = rateType
@@ -173,65 +193,32 @@ func (node *Meter) ParseReport(report *ApplicationCommandData) (*MeterResult, er
 		return nil, fmt.Errorf("Unknown rate type: 0x%02x", rateType)
 	}
 
-	// Number of decimal points
-	precision := uint8((data[1] >> 5) & 0x7)
-
 	// What is it reporting KWH, KVAH, etc...
-	scale := uint8((data[1] >> 3) & 0x3)
+	scale := command.ScaleBits10
 	// Handle V3
-	if (data[0] & 0x80) != 0 {
+	if command.ScaleBit2 {
 		scale |= 4
 	}
 
-	// Bytes of data
-	size := uint8(data[1] & 0x7)
-	if len(data) < int(size)+2 {
-		return nil, fmt.Errorf("Bad size %d < %d", len(data), size+2)
-	}
-
-	// Offset into array
-	offset := 2
-
 	// Decode Value
-	var value float32
-	if value, err = message.DecodeFloat(data[offset:offset+int(size)], precision); err != nil {
+	if result.Value, err = message.DecodeFloat(command.MeterValue,
+		command.Precision); err != nil {
 		return nil, err
 	}
-	result.Value = float32(value)
-	offset += int(size)
 
-	// If we have more bytes, check for DeltaTime and PreviousValue
-	if len(data) > offset {
-
-		// Check we have enough for DeltaTime
-		if len(data) < offset+2 {
-			return nil, fmt.Errorf("Expected Delta Time, bad size: %d < %d",
-				len(data), offset+2)
-		}
-		// Decode DeltaTime
-		result.DeltaTime = binary.BigEndian.Uint16(data[offset : offset+2])
-		offset += 2
-	}
-
-	// Check for PreviousValue
-	if result.DeltaTime > 0 && len(data) > offset {
-		// If we have more bytes, try to decode previous value
-		if len(data) < offset+int(size) {
-			return nil, fmt.Errorf("Expected Previous Value, bad size: %d < %d",
-				len(data), offset+int(size))
-		}
-		if value, err = message.DecodeFloat(data[offset:offset+int(size)], precision); err != nil {
+	// Decode DeltaTime and PreviousValue
+	result.DeltaTime = command.DeltaTime
+	if len(command.PreviousMeterValue) > 0 {
+		if result.PreviousValue, err = message.DecodeFloat(
+			command.PreviousMeterValue, command.Precision); err != nil {
 			return nil, err
 		}
-		result.PreviousValue = value
-		offset += int(size)
 	}
 
 	// Handle V4
-	if len(data) > offset && scale == 0x7 {
+	if len(data) > end && scale == 0x7 {
 		// Actual scale is in Scale2 byte
-		scale = data[offset]
-		offset++
+		scale = data[end]
 	}
 	result.MeterScale = scale
 
@@ -281,13 +268,14 @@ func (node *Meter) ParseReport(report *ApplicationCommandData) (*MeterResult, er
 
 // GetV2 the current value in the requested scale
 func (node *Meter) GetV2(scaleType uint8) (*MeterResult, error) {
-	if (scaleType & 0x3) != scaleType {
-		return nil, fmt.Errorf("Scale out of range [0, 3]")
-	}
-
 	var response *ApplicationCommandData
 	var err error
 
+	payload, err := (&commandclass.MeterGetV2{Scale: scaleType}).Encode()
+	if err != nil {
+		return nil, err
+	}
+
 	filter := func(response *ApplicationCommandData) bool {
 		if result, err := node.ParseReport(response); err == nil && result.MeterScale == scaleType {
 			return true
@@ -296,8 +284,7 @@ func (node *Meter) GetV2(scaleType uint8) (*MeterResult, error) {
 	}
 
 	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassMeter, []uint8{meterCommandGet, scaleType << 3},
-		meterCommandReport, filter); err != nil {
+		CommandClassMeter, payload, meterCommandReport, filter); err != nil {
 		return nil, err
 	}
 
@@ -315,20 +302,20 @@ func (node *Meter) GetSupported() (canReset bool, rateType uint8, meterType uint
 	}
 
 	data := response.Command.Data
-	if len(data) < 2 {
-		err = fmt.Errorf("Response size mismatch %d < 2", len(data))
+	var command commandclass.MeterSupportedReportV3
+	if err = command.Decode(data); err != nil {
 		return
 	}
 
-	canReset = (data[0] & 0x80) != 0
-	// Rate Type is V4 extension
-	rateType = (data[0] >> 5) & 0x3
-	meterType = (data[0] & 0x1f)
+	canReset = command.MeterReset
+	// Rate Type is V4 extension, in the Reserved bits of V3
+	rateType = command.Reserved
+	meterType = command.MeterType
 
 	// Get first 7 bits
 	scaleType := uint8(0)
 	for i := uint32(0); i < 7; i++ {
-		if (data[1] & (1 << i)) != 0 {
+		if (command.ScaleSupported & (1 << i)) != 0 {
 			scales = append(scales, scaleType)
 		}
 		scaleType++
@@ -336,8 +323,8 @@ func (node *Meter) GetSupported() (canReset bool, rateType uint8, meterType uint
 
 	// FIXME: looks like there are still trom trailing bytes in V4?
 
-	// Handle V4 extension
-	if (data[1] & 0x80) != 0 {
+	// Handle V4 extension, which the generated V3 report doesn't know about
+	if (command.ScaleSupported & 0x80) != 0 {
 		scaleBytes := data[2:]
 		if len(scaleBytes) == 0 {
 			err = fmt.Errorf("Scales bytes missing")
@@ -374,13 +361,14 @@ func (node *Meter) Reset() error {
 
 // GetV3 the current value in the requested scale
 func (node *Meter) GetV3(scaleType uint8) (*MeterResult, error) {
-	if (scaleType & 0x7) != scaleType {
-		return nil, fmt.Errorf("Scale out of range [0, 7]")
-	}
-
 	var response *ApplicationCommandData
 	var err error
 
+	payload, err := (&commandclass.MeterGetV3{Scale: scaleType}).Encode()
+	if err != nil {
+		return nil, err
+	}
+
 	filter := func(response *ApplicationCommandData) bool {
 		if result, err := node.ParseReport(response); err == nil && result.MeterScale == scaleType {
 			return true
@@ -389,8 +377,7 @@ func (node *Meter) GetV3(scaleType uint8) (*MeterResult, error) {
 	}
 
 	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassMeter, []uint8{meterCommandGet, scaleType << 3},
-		meterCommandReport, filter); err != nil {
+		CommandClassMeter, payload, meterCommandReport, filter); err != nil {
 		return nil, err
 	}
 
@@ -399,6 +386,9 @@ func (node *Meter) GetV3(scaleType uint8) (*MeterResult, error) {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// NOTE: the generated commandclass stops at version 3 of the meter, so the
+// payload of GetV4 is built by hand
+
 // GetV4 the current value in the requested scale
 func (node *Meter) GetV4(scaleType uint8, rateType uint8) (*MeterResult, error) {
 	if (rateType & 0x3) != rateType {
@@ -431,3 +421,19 @@ func (node *Meter) GetV4(scaleType uint8, rateType uint8) (*MeterResult, error)
 
 	return node.ParseReport(response)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+func init() {
+	RegisterReportDecoder(CommandClassMeter, decodeMeterReport)
+}
+
+// decodeMeterReport decodes a report into a *MeterResult
+func decodeMeterReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := Meter{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	return wrapper.ParseReport(report)
+}
@@ -0,0 +1,82 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"testing"
+)
+
+// testMeterReport returns a Meter Report
+func testMeterReport(data ...uint8) *ApplicationCommandData {
+	report := ApplicationCommandData{NodeID: 2}
+	report.Command.ClassID = CommandClassMeter
+	report.Command.ID = meterCommandReport
+	report.Command.Data = data
+	return &report
+}
+
+func TestMeterParseReport(t *testing.T) {
+	wrapper := Meter{MakeNode(2, nil)}
+
+	for _, test := range []struct {
+		data     []uint8
+		expected MeterResult
+	}{
+		// V1, 12.3 kWh
+		{[]uint8{0x01, 0x22, 0x00, 0x7b},
+			MeterResult{MeterType: MeterTypeElectric, Value: 12.3}},
+		// V2 import of 12.3 W, 10.0 W 60 seconds ago
+		{[]uint8{0x21, 0x32, 0x00, 0x7b, 0x00, 0x3c, 0x00, 0x64},
+			MeterResult{MeterType: MeterTypeElectric, MeterScale: MeterScaleElectricW,
+				RateType: RateTypeImport, Value: 12.3, DeltaTime: 60, PreviousValue: 10}},
+		// V2 without a Previous Meter Value, since the Delta Time is 0
+		{[]uint8{0x01, 0x22, 0x00, 0x7b, 0x00, 0x00},
+			MeterResult{MeterType: MeterTypeElectric, Value: 12.3}},
+		// V3 scale
+		{[]uint8{0x81, 0x21, 0x7b},
+			MeterResult{MeterType: MeterTypeElectric, MeterScale: MeterScaleElectricV,
+				Value: 12.3}},
+		// V4 Scale 2, after a Delta Time of 0
+		{[]uint8{0x81, 0x3a, 0x00, 0x7b, 0x00, 0x00, 0x00},
+			MeterResult{MeterType: MeterTypeElectric, Value: 12.3}},
+		// V4 Scale 2, after a Previous Meter Value
+		{[]uint8{0x81, 0x3a, 0x00, 0x7b, 0x00, 0x3c, 0x00, 0x64, 0x01},
+			MeterResult{MeterType: MeterTypeElectric, MeterScale: MeterScaleElectricKVAH,
+				Value: 12.3, DeltaTime: 60, PreviousValue: 10}},
+	} {
+		result, err := wrapper.ParseReport(testMeterReport(test.data...))
+		if err != nil {
+			t.Errorf("Expected nil error: %v for %v", err, test.data)
+		} else if *result != test.expected {
+			t.Errorf("Unexpected result: %+v != %+v for %v", *result,
+				test.expected, test.data)
+		}
+	}
+
+	// Bad reports
+	for _, data := range [][]uint8{
+		// Too short, or a truncated value, Delta Time or Previous Meter Value
+		{0x01, 0x22}, {0x01, 0x22, 0x00}, {0x01, 0x22, 0x00, 0x7b, 0x00},
+		{0x01, 0x22, 0x00, 0x7b, 0x00, 0x3c, 0x00},
+		// Unknown meter type, rate type and scale
+		{0x06, 0x22, 0x00, 0x7b}, {0x61, 0x22, 0x00, 0x7b}, {0x04, 0x0a, 0x00, 0x7b},
+	} {
+		if result, err := wrapper.ParseReport(testMeterReport(data...)); result != nil || err == nil {
+			t.Errorf("Expected nil result: %v and non nil error: %v for %v", result, err, data)
+		}
+	}
+}
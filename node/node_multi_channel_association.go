@@ -0,0 +1,159 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"fmt"
+)
+
+const (
+	multiChannelAssociationCommandSet             uint8 = 0x01
+	multiChannelAssociationCommandGet                   = 0x02
+	multiChannelAssociationCommandReport                = 0x03
+	multiChannelAssociationCommandRemove                = 0x04
+	multiChannelAssociationCommandGroupingsGet          = 0x05
+	multiChannelAssociationCommandGroupingsReport       = 0x06
+)
+
+// Separates node IDs from endpoint pairs
+const multiChannelAssociationMarker uint8 = 0x00
+
+// MultiChannelAssociation information
+type MultiChannelAssociation struct {
+	*Node
+}
+
+// AssociationEndpoint information
+type AssociationEndpoint struct {
+	NodeID   uint8
+	Endpoint uint8 // 0 is the root device
+}
+
+// GetMultiChannelAssociation returns a MultiChannelAssociation or nil object
+func (node *Node) GetMultiChannelAssociation() *MultiChannelAssociation {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if node.supportsCommandClass(CommandClassMultiChannelAssociation) {
+		return &MultiChannelAssociation{node}
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// encodeMultiChannelAssociation encodes the command for the nodes and
+// endpoints of the association group
+func encodeMultiChannelAssociation(command uint8, association uint8, nodes []uint8,
+	endpoints []AssociationEndpoint) []uint8 {
+	// Data: | COMMAND | GROUP | NODE_IDS | MARKER | NODE_ID | ENDPOINT | ... |
+	data := []uint8{command, association}
+	data = append(data, nodes...)
+	if len(endpoints) > 0 {
+		data = append(data, multiChannelAssociationMarker)
+		for _, endpoint := range endpoints {
+			data = append(data, endpoint.NodeID, endpoint.Endpoint)
+		}
+	}
+	return data
+}
+
+// Add adds the nodes and endpoints to the association group
+func (node *MultiChannelAssociation) Add(association uint8, nodes []uint8,
+	endpoints []AssociationEndpoint) error {
+	return node.zwSendDataRequest(CommandClassMultiChannelAssociation,
+		encodeMultiChannelAssociation(multiChannelAssociationCommandSet,
+			association, nodes, endpoints))
+}
+
+// Remove removes the nodes and endpoints from the association group
+func (node *MultiChannelAssociation) Remove(association uint8, nodes []uint8,
+	endpoints []AssociationEndpoint) error {
+	return node.zwSendDataRequest(CommandClassMultiChannelAssociation,
+		encodeMultiChannelAssociation(multiChannelAssociationCommandRemove,
+			association, nodes, endpoints))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Get gets the nodes and endpoints in the association group
+func (node *MultiChannelAssociation) Get(association uint8) (maxNodes uint8,
+	nodes []uint8, endpoints []AssociationEndpoint, err error) {
+	var response *ApplicationCommandData
+
+	filter := func(response *ApplicationCommandData) bool {
+		return len(response.Command.Data) > 0 && response.Command.Data[0] == association
+	}
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassMultiChannelAssociation,
+		[]uint8{multiChannelAssociationCommandGet, association},
+		multiChannelAssociationCommandReport, filter); err != nil {
+		return
+	}
+
+	// Data: | GROUP | MAX_NODES | REPORTS_TO_FOLLOW | NODE_IDS | MARKER |
+	//       | NODE_ID | ENDPOINT | ... |
+	data := response.Command.Data
+	if len(data) < 3 {
+		err = fmt.Errorf("Response is too short %d < 3", len(data))
+		return
+	}
+
+	maxNodes = data[1]
+	// TODO: add support for reports to follow data[2]
+	nodes = []uint8{}
+	endpoints = []AssociationEndpoint{}
+
+	i := 3
+	for ; i < len(data) && data[i] != multiChannelAssociationMarker; i++ {
+		nodes = append(nodes, data[i])
+	}
+
+	// Skip marker
+	i++
+	if i < len(data) && (len(data)-i)%2 != 0 {
+		err = fmt.Errorf("Bad endpoint list length %d", len(data)-i)
+		return
+	}
+	for ; i+1 < len(data); i += 2 {
+		endpoints = append(endpoints, AssociationEndpoint{NodeID: data[i],
+			Endpoint: data[i+1]})
+	}
+
+	return
+}
+
+// GetGroupings gets the number of supported association groups
+func (node *MultiChannelAssociation) GetGroupings() (uint8, error) {
+	var response *ApplicationCommandData
+	var err error
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassMultiChannelAssociation,
+		[]uint8{multiChannelAssociationCommandGroupingsGet},
+		multiChannelAssociationCommandGroupingsReport, nil); err != nil {
+		return 0, err
+	}
+
+	data := response.Command.Data
+	if len(data) != 1 {
+		return 0, fmt.Errorf("Response has bad length %d != 1", len(data))
+	}
+
+	return data[0], nil
+}
@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 	"github.com/cybojanek/gozwave/message"
 )
 
@@ -192,32 +193,20 @@ func (node *MultiLevelSensor) ParseReport(report *ApplicationCommandData) (*Mult
 			report.Command.ID, multiLevelSensorCommandReport)
 	}
 
-	data := report.Command.Data
-	if len(data) < 3 {
-		return nil, fmt.Errorf("Bad Report Data length %d < 1", len(data))
+	// NOTE: the report of V1-4 has the same layout as V5
+	var command commandclass.SensorMultilevelReportV5
+	if err = command.Decode(report.Command.Data); err != nil {
+		return nil, err
 	}
 
-	// Sensor Type
-	sensorType := data[0]
-	result.SensorType = sensorType
-
-	// Precision, Scale, Size
-	precision := (data[1] >> 5) & 0x7
-	scale := (data[1] >> 3) & 0x3
-	size := data[1] & 0x7
-
-	result.SensorScale = scale
-
-	// Offset into array
-	offset := 2
+	result.SensorType = command.SensorType
+	result.SensorScale = command.Scale
 
 	// Decode Value
-	var value float32
-	if value, err = message.DecodeFloat(data[offset:offset+int(size)], precision); err != nil {
+	if result.Value, err = message.DecodeFloat(command.SensorValue,
+		command.Precision); err != nil {
 		return nil, err
 	}
-	result.Value = float32(value)
-	offset += int(size)
 
 	return &result, nil
 }
@@ -235,10 +224,15 @@ func (node *MultiLevelSensor) GetSupportedSensorTypes() ([]uint8, error) {
 		return nil, err
 	}
 
+	var command commandclass.SensorMultilevelSupportedSensorReportV5
+	if err = command.Decode(response.Command.Data); err != nil {
+		return nil, err
+	}
+
 	// Loop over bit mask
 	var sensors []uint8
 	sensorType := uint8(1)
-	for _, b := range response.Command.Data {
+	for _, b := range command.BitMask {
 		for i := uint32(0); i < 8; i++ {
 			if (b & (1 << i)) != 0 {
 				sensors = append(sensors, sensorType)
@@ -260,20 +254,26 @@ func (node *MultiLevelSensor) GetSupportedScaleTypes(sensorType uint8) ([]uint8,
 		return len(response.Command.Data) > 0 && response.Command.Data[0] == sensorType
 	}
 
+	payload, err := (&commandclass.SensorMultilevelSupportedGetScaleV5{
+		SensorType: sensorType}).Encode()
+	if err != nil {
+		return nil, err
+	}
+
 	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassMultiLevelSensor, []uint8{multiLevelSensorCommandGetScaleTypes},
+		CommandClassMultiLevelSensor, payload,
 		multiLevelSensorCommandReportScaleTypes, filter); err != nil {
 		return nil, err
 	}
 
-	data := response.Command.Data
-	if len(data) != 2 {
-		return nil, fmt.Errorf("Response size mismatch %d != 2", len(data))
+	var command commandclass.SensorMultilevelSupportedScaleReportV5
+	if err = command.Decode(response.Command.Data); err != nil {
+		return nil, err
 	}
 
 	var scaleIndices []uint8
 	for i := uint8(0); i < 4; i++ {
-		if ((1 << i) & data[1]) != 0 {
+		if ((1 << i) & command.ScaleBitMask) != 0 {
 			scaleIndices = append(scaleIndices, i)
 		}
 	}
@@ -292,11 +292,33 @@ func (node *MultiLevelSensor) GetV5(sensorType uint8) (*MultiLevelSensorResult,
 		return len(response.Command.Data) > 0 && response.Command.Data[0] == sensorType
 	}
 
+	payload, err := (&commandclass.SensorMultilevelGetV5{
+		SensorType: sensorType}).Encode()
+	if err != nil {
+		return nil, err
+	}
+
 	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassMultiLevelSensor, []uint8{multiLevelSensorCommandGet, sensorType},
+		CommandClassMultiLevelSensor, payload,
 		multiLevelSensorCommandReport, filter); err != nil {
 		return nil, err
 	}
 
 	return node.ParseReport(response)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+func init() {
+	RegisterReportDecoder(CommandClassMultiLevelSensor, decodeMultiLevelSensorReport)
+}
+
+// decodeMultiLevelSensorReport decodes a report into a *MultiLevelSensorResult
+func decodeMultiLevelSensorReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := MultiLevelSensor{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	return wrapper.ParseReport(report)
+}
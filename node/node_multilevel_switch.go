@@ -18,6 +18,7 @@ limitations under the License.
 
 import (
 	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
 	"github.com/cybojanek/gozwave/message"
 	"time"
 )
@@ -51,28 +52,22 @@ func (node *Node) GetMultiLevelSwitch() *MultiLevelSwitch {
 
 // On turns the switch on to the most recent non-zero level
 func (node *MultiLevelSwitch) On() error {
-	return node.zwSendDataRequest(CommandClassMultiLevelSwitch,
-		[]uint8{multiLevelSwitchCommandSet, 0xff})
+	return node.Set(0xff)
 }
 
 // Off turns the switch off
 func (node *MultiLevelSwitch) Off() error {
-	return node.zwSendDataRequest(CommandClassMultiLevelSwitch,
-		[]uint8{multiLevelSwitchCommandSet, 0x00})
+	return node.Set(0x00)
 }
 
 // IsOn queries the switch to check current status
 func (node *MultiLevelSwitch) IsOn() (bool, error) {
-	var response *ApplicationCommandData
-	var err error
-
-	if response, err = node.zwSendDataWaitForResponse(
-		CommandClassMultiLevelSwitch, []uint8{multiLevelSwitchCommandGet},
-		multiLevelSwitchCommandReport, nil); err != nil {
+	level, err := node.Get()
+	if err != nil {
 		return false, err
 	}
 
-	return node.ParseReport(response)
+	return level != 0, nil
 }
 
 // IsReport checks if the report is a ParseReport
@@ -82,52 +77,78 @@ func (node *MultiLevelSwitch) IsReport(report *ApplicationCommandData) bool {
 
 // ParseReport of status
 func (node *MultiLevelSwitch) ParseReport(report *ApplicationCommandData) (bool, error) {
+	level, err := node.parseLevel(report)
+	if err != nil {
+		return false, err
+	}
+
+	return level != 0, nil
+}
+
+// parseLevel returns the level of a report
+func (node *MultiLevelSwitch) parseLevel(report *ApplicationCommandData) (uint8, error) {
 	if report.Command.ClassID != CommandClassMultiLevelSwitch {
-		return false, fmt.Errorf("Bad Report Command Class ID: 0x%02x != 0x%02x",
+		return 0, fmt.Errorf("Bad Report Command Class ID: 0x%02x != 0x%02x",
 			report.Command.ClassID, CommandClassMultiLevelSwitch)
 	}
 
 	if report.Command.ID != multiLevelSwitchCommandReport {
-		return false, fmt.Errorf("Bad Report Command ID 0x%02x != 0x%02x",
+		return 0, fmt.Errorf("Bad Report Command ID 0x%02x != 0x%02x",
 			report.Command.ID, multiLevelSwitchCommandReport)
 	}
 
-	data := report.Command.Data
-	if len(data) != 1 {
-		return false, fmt.Errorf("Bad Report Data length %d != 1", len(data))
+	var command commandclass.SwitchMultilevelReportV1
+	if err := command.Decode(report.Command.Data); err != nil {
+		return 0, err
 	}
 
-	return data[0] != 0, nil
+	return command.Value, nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
 // Get queries the switch to check current value
 func (node *MultiLevelSwitch) Get() (uint8, error) {
-	if response, err := node.zwSendDataWaitForResponse(
+	var response *ApplicationCommandData
+	var err error
+
+	if response, err = node.zwSendDataWaitForResponse(
 		CommandClassMultiLevelSwitch, []uint8{multiLevelSwitchCommandGet},
 		multiLevelSwitchCommandReport, nil); err != nil {
 		return 0, err
-	} else if len(response.Command.Data) != 1 {
-		return 0, fmt.Errorf("Bad response length %d != 1", len(response.Command.Data))
-	} else {
-		return response.Command.Data[0], nil
 	}
+
+	return node.parseLevel(response)
+}
+
+// MultiLevelSwitchSetPayload returns the CommandClassMultiLevelSwitch payload
+// to set the level, for use in multicast and broadcast sends. The value must
+// be in the range of [0, 99] or 0xff, where 255 is the most recent non-zero
+// level
+func MultiLevelSwitchSetPayload(value uint8) ([]uint8, error) {
+	if value > 99 && value < 0xff {
+		return nil, fmt.Errorf("Value must be in range [0, 99] or 255")
+	}
+	return (&commandclass.SwitchMultilevelSetV1{Value: value}).Encode()
 }
 
 // Set sets the level to the requested value, which must be in the range
 // of [0, 99] or 0xff, where 255 is the most recent non-zero level
 func (node *MultiLevelSwitch) Set(value uint8) error {
-	if value > 99 && value < 0xff {
-		return fmt.Errorf("Value must be in range [0, 99] or 255")
+	payload, err := MultiLevelSwitchSetPayload(value)
+	if err != nil {
+		return err
 	}
-	return node.zwSendDataRequest(CommandClassMultiLevelSwitch,
-		[]uint8{multiLevelSwitchCommandSet, value})
+	return node.zwSendDataRequest(CommandClassMultiLevelSwitch, payload)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
 
 // Start a level change
+//
+// NOTE: the payload is not built with
+// commandclass.SwitchMultilevelStartLevelChangeV1, because its definition has
+// the Up/Down flag in bit 7, while Start has always sent it in bit 6
 func (node *MultiLevelSwitch) Start(up bool, ignoreStart bool, start uint8) error {
 	if start > 99 && start < 0xff {
 		return fmt.Errorf("Start must be in range [0, 99] or 255")
@@ -151,6 +172,9 @@ func (node *MultiLevelSwitch) Stop() error {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// NOTE: the generated commandclass only has version 1 of the multilevel
+// switch, so the payloads of the V2 commands are built by hand
+
 // SetV2 sets the level to the requested value, which must be in the range
 // of [0, 99] or 0xff, where 255 is the most recent non-zero level, and duration
 // must be either [0, 127] seconds or [1, 127] minutes
@@ -193,3 +217,29 @@ func (node *MultiLevelSwitch) StartV2(up bool, ignoreStart bool, start uint8, du
 	return node.zwSendDataRequest(CommandClassMultiLevelSwitch,
 		[]uint8{multiLevelSwitchCommandStartLevelChange, flags, start, durationByte})
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MultiLevelSwitchReport information
+type MultiLevelSwitchReport struct {
+	On    bool
+	Level uint8 // [0, 99] or 0xff
+}
+
+func init() {
+	RegisterReportDecoder(CommandClassMultiLevelSwitch, decodeMultiLevelSwitchReport)
+}
+
+// decodeMultiLevelSwitchReport decodes a report into a *MultiLevelSwitchReport
+func decodeMultiLevelSwitchReport(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := MultiLevelSwitch{node}
+	if !wrapper.IsReport(report) {
+		return nil, nil
+	}
+
+	level, err := wrapper.parseLevel(report)
+	if err != nil {
+		return nil, err
+	}
+	return &MultiLevelSwitchReport{On: level != 0, Level: level}, nil
+}
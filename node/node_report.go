@@ -0,0 +1,129 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ReportDecoder decodes a frame of a command class into a typed report, or
+// returns nil if the frame is not a report. Decoders are called without the
+// node lock, before the frame is handled by the node, and the node may be a
+// placeholder that is not part of a network. They must only parse the frame,
+// and must not send requests to the node.
+type ReportDecoder func(node *Node, report *ApplicationCommandData) (interface{}, error)
+
+// Report is a typed report, decoded from a frame without a waiting caller
+type Report struct {
+	NodeID       uint16      // Source NodeID
+	CommandClass uint8       // Command Class ID
+	Command      uint8       // Command Class Subcommand ID
+	Time         time.Time   // Time the report was received
+	Value        interface{} // Typed report of the ReportDecoder
+}
+
+// Report decoders of each command class, registered by the wrapper of the
+// command class
+var reportDecodersMutex sync.RWMutex
+var reportDecoders = make(map[uint8]ReportDecoder)
+
+// RegisterReportDecoder registers the decoder of the command class, replacing
+// any registered decoder, or removing it if decoder is nil. goroutine safe.
+func RegisterReportDecoder(commandClass uint8, decoder ReportDecoder) {
+	reportDecodersMutex.Lock()
+	defer reportDecodersMutex.Unlock()
+
+	if decoder == nil {
+		delete(reportDecoders, commandClass)
+	} else {
+		reportDecoders[commandClass] = decoder
+	}
+}
+
+// DecodeReport decodes the frame with the decoder of its command class.
+// Returns nil if there is no decoder, or the frame is not a report.
+// goroutine safe.
+func DecodeReport(node *Node, report *ApplicationCommandData) (*Report, error) {
+	reportDecodersMutex.RLock()
+	decoder, ok := reportDecoders[report.Command.ClassID]
+	reportDecodersMutex.RUnlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	value, err := decoder(node, report)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	return &Report{NodeID: report.NodeID, CommandClass: report.Command.ClassID,
+		Command: report.Command.ID, Time: time.Now(), Value: value}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetReport returns the most recent report of the command class, or nil.
+// goroutine safe.
+func (node *Node) GetReport(commandClass uint8) *Report {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	return node.reports[commandClass]
+}
+
+// AddReportCallbackChannel adds a channel, which is sent every typed report
+// of the node. Reports are dropped if the channel is full, so it should be
+// buffered. goroutine safe.
+func (node *Node) AddReportCallbackChannel(channel chan *Report) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if node.reportCallbacks == nil {
+		node.reportCallbacks = make(map[chan *Report]chan *Report)
+	}
+
+	node.reportCallbacks[channel] = channel
+}
+
+// RemoveReportCallbackChannel removes a channel. goroutine safe.
+func (node *Node) RemoveReportCallbackChannel(channel chan *Report) {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	delete(node.reportCallbacks, channel)
+}
+
+// handleReport saves the typed report, and sends it to the report callbacks,
+// without blocking on full channels. Must be called with node lock.
+func (node *Node) handleReport(report *Report) {
+	if node.reports == nil {
+		node.reports = make(map[uint8]*Report)
+	}
+	node.reports[report.CommandClass] = report
+
+	for _, channel := range node.reportCallbacks {
+		select {
+		case channel <- report:
+		default:
+			log.Printf("ERROR handleReport: node: %d dropped report 0x%02x 0x%02x, "+
+				"callback channel is full", node.ID, report.CommandClass, report.Command)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/message"
+	"testing"
+	"time"
+)
+
+func TestReportDecoders(t *testing.T) {
+	// Each wrapper registers its decoder
+	for _, commandClass := range []uint8{CommandClassBasic, CommandClassBinarySwitch,
+		CommandClassMultiLevelSwitch, CommandClassMeter, CommandClassMultiLevelSensor,
+		CommandClassBinarySensor, CommandClassAlarm, CommandClassBattery,
+		CommandClassWakeup} {
+		reportDecodersMutex.RLock()
+		_, ok := reportDecoders[commandClass]
+		reportDecodersMutex.RUnlock()
+		if !ok {
+			t.Errorf("Expected decoder of command class 0x%02x", commandClass)
+		}
+	}
+}
+
+func TestNodeReports(t *testing.T) {
+	n := MakeNode(5, nil)
+	channel := make(chan *Report, 1)
+	n.AddReportCallbackChannel(channel)
+	defer n.RemoveReportCallbackChannel(channel)
+
+	n.ApplicationCommandHandler(&message.ApplicationCommand{NodeID: 5,
+		Body: []uint8{CommandClassBinarySwitch, 0x03, 0xff}})
+
+	select {
+	case report := <-channel:
+		if value, ok := report.Value.(*BinarySwitchReport); !ok || !value.On ||
+			value.Value != 0xff || report.NodeID != 5 ||
+			report.CommandClass != CommandClassBinarySwitch {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Expected a report")
+	}
+
+	if report := n.GetReport(CommandClassBinarySwitch); report == nil {
+		t.Errorf("Expected most recent report")
+	}
+
+	// A decoded report is saved as is
+	decoded := Report{NodeID: 5, CommandClass: CommandClassBattery, Command: 0x03,
+		Time: time.Now(), Value: &BatteryReport{Level: 50}}
+	n.ApplicationCommandReportHandler(&message.ApplicationCommand{NodeID: 5,
+		Body: []uint8{CommandClassBattery, 0x03, 0x32}}, &decoded)
+	if report := n.GetReport(CommandClassBattery); report != &decoded {
+		t.Errorf("Expected decoded report: %+v", report)
+	}
+	<-channel
+
+	// Reports to a full channel are dropped, without blocking the handler
+	for i := 0; i < 2; i++ {
+		n.ApplicationCommandHandler(&message.ApplicationCommand{NodeID: 5,
+			Body: []uint8{CommandClassBinarySwitch, 0x03, uint8(i)}})
+	}
+	if report := <-channel; report.Value.(*BinarySwitchReport).Value != 0x00 {
+		t.Errorf("Expected the first report: %+v", report.Value)
+	}
+	select {
+	case report := <-channel:
+		t.Errorf("Unexpected report: %+v", report)
+	default:
+	}
+
+	// Bad reports are not saved
+	n.ApplicationCommandHandler(&message.ApplicationCommand{NodeID: 5,
+		Body: []uint8{CommandClassMultiLevelSwitch, 0x03}})
+	if report := n.GetReport(CommandClassMultiLevelSwitch); report != nil {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+
+	// Command classes without a built in decoder
+	RegisterReportDecoder(CommandClassVersion,
+		func(n *Node, report *ApplicationCommandData) (interface{}, error) {
+			return report.Command.Data[0], nil
+		})
+	defer RegisterReportDecoder(CommandClassVersion, nil)
+
+	report := ApplicationCommandData{NodeID: 5}
+	report.Command.ClassID = CommandClassVersion
+	report.Command.ID = 0x12
+	report.Command.Data = []uint8{0x63}
+	if decoded, err := DecodeReport(n, &report); err != nil || decoded == nil ||
+		decoded.Value != uint8(0x63) {
+		t.Errorf("Unexpected report: %+v %v", decoded, err)
+	}
+}
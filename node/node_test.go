@@ -0,0 +1,187 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"github.com/cybojanek/gozwave/database"
+	"github.com/cybojanek/gozwave/message"
+	"github.com/cybojanek/gozwave/packet"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testController accepts every ZWSendData request, and sends the command
+// class reply of the node, if reply returns one
+type testController struct {
+	requests int
+	node     *Node
+	reply    func(frame []uint8) []uint8
+}
+
+func (controller *testController) DoRequest(request *packet.Packet) (*packet.Packet, error) {
+	controller.requests++
+
+	if controller.reply != nil {
+		// Body: | NODE_ID | LENGTH | FRAME | TX_OPTIONS | CALLBACK_ID |
+		frame := request.Body[2 : 2+int(request.Body[1])]
+		if command := controller.reply(frame); command != nil {
+			// The caller holds the node lock until it waits for the reply
+			go controller.node.ApplicationCommandReportHandler(
+				&message.ApplicationCommand{NodeID: controller.node.ID,
+					Body: command}, nil)
+		}
+	}
+
+	return &packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType:  packet.PacketTypeResponse,
+		MessageType: request.MessageType,
+		Body:        []uint8{0x00, message.TransmitCompleteOK, 0x00, 0x00}}, nil
+}
+
+func TestRefreshWithIDsRemovedCommandClasses(t *testing.T) {
+	device := &database.Device{Key: database.DeviceKey{ManufacturerID: 0x86,
+		ProductType: 0x02, ProductID: 0x64}}
+	device.Quirks.RemovedCommandClasses = []uint8{CommandClassBasic}
+
+	devices := &database.Database{}
+	devices.Add(device)
+
+	n := MakeNode(5, nil)
+	n.SetDatabase(devices)
+	n.CommandClasses = []uint8{CommandClassBasic, CommandClassBinarySwitch}
+
+	// Unknown devices keep every command class
+	if err := n.RefreshWithIDs(0x86, 0x65, 0x02); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if n.Device != nil || len(n.CommandClasses) != 2 {
+		t.Fatalf("Bad device: %v command classes: %v", n.Device, n.CommandClasses)
+	}
+
+	// The first lookup of the device removes the command class
+	if err := n.RefreshWithIDs(0x86, 0x64, 0x02); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if n.Device != device ||
+		!reflect.DeepEqual(n.CommandClasses, []uint8{CommandClassBinarySwitch}) {
+		t.Fatalf("Bad device: %v command classes: %v", n.Device, n.CommandClasses)
+	}
+}
+
+func TestSendDataAsleep(t *testing.T) {
+	controller := &testController{}
+	n := MakeNode(5, controller)
+
+	// Nodes without protocol information are assumed to be reachable
+	if err := n.zwSendDataRequest(CommandClassBasic, []uint8{0x02}); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	n.hasProtocolInfo = true
+	if err := n.zwSendDataRequest(CommandClassBasic, []uint8{0x02}); err != ErrNodeAsleep {
+		t.Errorf("Expected ErrNodeAsleep: %v", err)
+	}
+	if controller.requests != 1 {
+		t.Errorf("Expected 1 request: %d", controller.requests)
+	}
+
+	// Awake after the wake up notification, until told to go back to sleep
+	n.ApplicationCommandReportHandler(&message.ApplicationCommand{NodeID: 5,
+		Body: []uint8{CommandClassWakeup, wakeUpCommandNotification}}, nil)
+	if err := n.zwSendDataRequest(CommandClassBasic, []uint8{0x02}); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	wakeUp := WakeUp{n}
+	if err := wakeUp.NoMoreInformation(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if err := n.zwSendDataRequest(CommandClassBasic, []uint8{0x02}); err != ErrNodeAsleep {
+		t.Errorf("Expected ErrNodeAsleep: %v", err)
+	}
+
+	// FLiRS nodes are woken up by the controller, and take longer to respond
+	n.ProtocolInfo.Capabilities.FrequentListening = message.FrequentListening1000ms
+	if err := n.zwSendDataRequest(CommandClassBasic, []uint8{0x02}); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if timeout := n.responseTimeout(); timeout != responseTimeout+time.Second {
+		t.Errorf("Expected longer FLiRS timeout: %v", timeout)
+	}
+}
+
+// testAssociationController replies to Association Get and Groupings Get, and
+// records the sent frames
+func testAssociationController(frames *[][]uint8) *Association {
+	controller := &testController{}
+	n := MakeNode(5, controller)
+	controller.node = n
+
+	controller.reply = func(frame []uint8) []uint8 {
+		*frames = append(*frames, append([]uint8(nil), frame...))
+		switch frame[1] {
+		case associationCommandGet:
+			return []uint8{CommandClassAssociation, associationCommandReport,
+				frame[len(frame)-1], 0x05, 0x00, 0x01}
+		case associationCommandGroupingsGet:
+			return []uint8{CommandClassAssociation, associationCommandGroupingsReport,
+				0x03}
+		}
+		return nil
+	}
+
+	return &Association{n}
+}
+
+func TestAssociationGetGroupings(t *testing.T) {
+	var frames [][]uint8
+	association := testAssociationController(&frames)
+
+	// The deprecated GetSupported ignores the group
+	for _, get := range []func() (uint8, error){association.GetGroupings,
+		func() (uint8, error) { return association.GetSupported(7) }} {
+		if groups, err := get(); err != nil || groups != 3 {
+			t.Errorf("Expected 3 groups: %d and nil error: %v", groups, err)
+		}
+	}
+
+	for _, frame := range frames {
+		if !reflect.DeepEqual(frame, []uint8{CommandClassAssociation,
+			associationCommandGroupingsGet}) {
+			t.Errorf("Bad Association Groupings Get: %v", frame)
+		}
+	}
+}
+
+func TestAssociationGet(t *testing.T) {
+	var frames [][]uint8
+	association := testAssociationController(&frames)
+
+	maxNodes, nodes, err := association.Get(2)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	// The group is sent in the request
+	if len(frames) != 1 || !reflect.DeepEqual(frames[0], []uint8{
+		CommandClassAssociation, associationCommandGet, 0x02}) {
+		t.Errorf("Bad Association Get: %v", frames)
+	}
+	if maxNodes != 5 || !reflect.DeepEqual(nodes, []uint8{0x01}) {
+		t.Errorf("Bad report: %d %v", maxNodes, nodes)
+	}
+}
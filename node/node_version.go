@@ -72,10 +72,20 @@ func (node *Version) Get() (library uint8, protocol uint16, application uint16,
 
 ////////////////////////////////////////////////////////////////////////////////
 
-// GetCommandClass version for a given command class
+// GetCommandClass version for a given command class. Versions forced by the
+// device database are returned without querying the node.
 func (node *Version) GetCommandClass(commandClass uint8) (uint8, error) {
+	if device := node.GetDevice(); device != nil {
+		if version, ok := device.CommandClassVersion(commandClass); ok {
+			return version, nil
+		}
+	}
+
 	// Fail early to avoid long timeout errors
-	if !node.supportsCommandClass(commandClass) {
+	node.mutex.Lock()
+	supported := node.supportsCommandClass(commandClass)
+	node.mutex.Unlock()
+	if !supported {
 		return 0, fmt.Errorf("Node does not support command class")
 	}
 
@@ -0,0 +1,126 @@
+package node
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+import (
+	"fmt"
+	"github.com/cybojanek/gozwave/commandclass"
+	"time"
+)
+
+const (
+	wakeUpCommandIntervalSet       uint8 = 0x04
+	wakeUpCommandIntervalGet             = 0x05
+	wakeUpCommandIntervalReport          = 0x06
+	wakeUpCommandNotification            = 0x07
+	wakeUpCommandNoMoreInformation       = 0x08
+)
+
+// WakeUp information
+type WakeUp struct {
+	*Node
+}
+
+// GetWakeUp returns a WakeUp or nil object
+func (node *Node) GetWakeUp() *WakeUp {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if node.supportsCommandClass(CommandClassWakeup) {
+		return &WakeUp{node}
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// IsNotification checks if the report is a wake up notification
+func (node *WakeUp) IsNotification(report *ApplicationCommandData) bool {
+	return report.Command.ClassID == CommandClassWakeup &&
+		report.Command.ID == wakeUpCommandNotification
+}
+
+// NoMoreInformation tells the awake node that it can go back to sleep
+func (node *WakeUp) NoMoreInformation() error {
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if err := node.zWSendData(CommandClassWakeup,
+		[]uint8{wakeUpCommandNoMoreInformation}); err != nil {
+		return err
+	}
+
+	node.awakeUntil = time.Time{}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// GetInterval gets the wake up interval, and the node which is notified
+func (node *WakeUp) GetInterval() (time.Duration, uint8, error) {
+	var response *ApplicationCommandData
+	var err error
+
+	if response, err = node.zwSendDataWaitForResponse(
+		CommandClassWakeup, []uint8{wakeUpCommandIntervalGet},
+		wakeUpCommandIntervalReport, nil); err != nil {
+		return 0, 0, err
+	}
+
+	var report commandclass.WakeUpIntervalReportV2
+	if err = report.Decode(response.Command.Data); err != nil {
+		return 0, 0, err
+	}
+
+	return time.Duration(report.Seconds) * time.Second, report.NodeID, nil
+}
+
+// SetInterval sets the wake up interval, which must be less than 2^24
+// seconds, and the node which is notified
+func (node *WakeUp) SetInterval(interval time.Duration, nodeID uint8) error {
+	seconds := uint32(interval.Seconds())
+	if interval < 0 || seconds >= (1<<24) {
+		return fmt.Errorf("Interval must be in range [0, %d] seconds", (1<<24)-1)
+	}
+
+	payload, err := (&commandclass.WakeUpIntervalSetV2{Seconds: seconds,
+		NodeID: nodeID}).Encode()
+	if err != nil {
+		return err
+	}
+
+	return node.zwSendDataRequest(CommandClassWakeup, payload)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// WakeUpNotification information
+type WakeUpNotification struct{}
+
+func init() {
+	RegisterReportDecoder(CommandClassWakeup, decodeWakeUpNotification)
+}
+
+// decodeWakeUpNotification decodes a notification into a *WakeUpNotification
+func decodeWakeUpNotification(node *Node, report *ApplicationCommandData) (interface{}, error) {
+	wrapper := WakeUp{node}
+	if !wrapper.IsNotification(report) {
+		return nil, nil
+	}
+
+	return &WakeUpNotification{}, nil
+}
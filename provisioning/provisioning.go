@@ -0,0 +1,257 @@
+package provisioning
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrSecurityNotSupported is returned for entries which request security
+// classes, since security is not bootstrapped when a device is included
+var ErrSecurityNotSupported = errors.New("Security bootstrapping is not supported")
+
+// Security classes requested by a device
+const (
+	SecurityClassS2Unauthenticated uint8 = 0x01
+	SecurityClassS2Authenticated         = 0x02
+	SecurityClassS2AccessControl         = 0x04
+	SecurityClassS0                      = 0x80
+)
+
+// Boot mode of a device
+const (
+	BootModeS2         uint8 = 0x00 // Included manually by the user
+	BootModeSmartStart       = 0x01 // Included automatically when it powers up
+)
+
+// DSK is the device specific key, which is printed on the device and in its
+// QR code
+type DSK [16]uint8
+
+// Entry of the provisioning list
+type Entry struct {
+	DSK             DSK    `json:"dsk"`
+	SecurityClasses uint8  `json:"security_classes"` // Bit mask of SecurityClass, must be 0
+	BootMode        uint8  `json:"boot_mode"`        // One of BootMode
+	Name            string `json:"name,omitempty"`
+	Location        string `json:"location,omitempty"`
+	NodeID          uint16 `json:"node_id,omitempty"` // Node of the included device, or 0
+}
+
+// List of devices, which may join the network
+type List struct {
+	mutex   sync.RWMutex   // List mutex
+	entries map[DSK]*Entry // Entries
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// ParseDSK parses the DSK from eight dash separated decimal blocks, like
+// 12345-12345-12345-12345-12345-12345-12345-12345
+func ParseDSK(text string) (DSK, error) {
+	var dsk DSK
+
+	blocks := strings.Split(text, "-")
+	if len(blocks) != 8 {
+		return dsk, fmt.Errorf("Bad DSK block count: %d", len(blocks))
+	}
+
+	for i, block := range blocks {
+		if len(block) != 5 {
+			return dsk, fmt.Errorf("Bad DSK block: %q", block)
+		}
+		value, err := strconv.ParseUint(block, 10, 16)
+		if err != nil {
+			return dsk, fmt.Errorf("Bad DSK block: %q", block)
+		}
+		binary.BigEndian.PutUint16(dsk[2*i:], uint16(value))
+	}
+
+	return dsk, nil
+}
+
+// String returns the DSK as eight dash separated decimal blocks
+func (dsk DSK) String() string {
+	blocks := make([]string, 8)
+	for i := range blocks {
+		blocks[i] = fmt.Sprintf("%05d", binary.BigEndian.Uint16(dsk[2*i:]))
+	}
+	return strings.Join(blocks, "-")
+}
+
+// MarshalText encodes the DSK as a string
+func (dsk DSK) MarshalText() ([]byte, error) {
+	return []byte(dsk.String()), nil
+}
+
+// UnmarshalText decodes the DSK from a string
+func (dsk *DSK) UnmarshalText(text []byte) error {
+	parsed, err := ParseDSK(string(text))
+	if err != nil {
+		return err
+	}
+	*dsk = parsed
+	return nil
+}
+
+// NWIHomeID returns the HomeID, which the device uses in its SmartStart
+// inclusion requests
+func (dsk DSK) NWIHomeID() uint32 {
+	return (binary.BigEndian.Uint32(dsk[8:12]) | 0xc0000000) &^ 0x01
+}
+
+// AuthHomeID returns the HomeID, which the device uses after it verified
+// the inclusion
+func (dsk DSK) AuthHomeID() uint32 {
+	return (binary.BigEndian.Uint32(dsk[12:16]) &^ 0xc0000000) | 0x01
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// validate checks the entry fields
+func (entry *Entry) validate() error {
+	if entry.BootMode != BootModeS2 && entry.BootMode != BootModeSmartStart {
+		return fmt.Errorf("Bad boot mode: 0x%02x", entry.BootMode)
+	}
+	// Including the device without the security it requests would downgrade it
+	if entry.SecurityClasses != 0 {
+		return ErrSecurityNotSupported
+	}
+	return nil
+}
+
+// Add an entry, replacing any previous entry with the same DSK. Returns
+// ErrSecurityNotSupported if the entry requests security classes. goroutine
+// safe.
+func (list *List) Add(entry *Entry) error {
+	if err := entry.validate(); err != nil {
+		return err
+	}
+
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if list.entries == nil {
+		list.entries = make(map[DSK]*Entry)
+	}
+
+	copied := *entry
+	list.entries[entry.DSK] = &copied
+
+	return nil
+}
+
+// Remove the entry, and return true if it existed. goroutine safe.
+func (list *List) Remove(dsk DSK) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	_, ok := list.entries[dsk]
+	delete(list.entries, dsk)
+	return ok
+}
+
+// Get returns a copy of the entry or nil if doesn't exist. goroutine safe.
+func (list *List) Get(dsk DSK) *Entry {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	if entry, ok := list.entries[dsk]; ok {
+		copied := *entry
+		return &copied
+	}
+	return nil
+}
+
+// GetEntries returns copies of all entries, sorted by DSK. goroutine safe.
+func (list *List) GetEntries() []*Entry {
+	list.mutex.RLock()
+	entries := make([]*Entry, 0, len(list.entries))
+	for _, entry := range list.entries {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	list.mutex.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DSK.String() < entries[j].DSK.String()
+	})
+	return entries
+}
+
+// LookupNWIHomeID returns a copy of the entry, whose DSK matches the HomeID
+// of a SmartStart inclusion request, or nil if doesn't exist. goroutine safe.
+func (list *List) LookupNWIHomeID(homeID uint32) *Entry {
+	list.mutex.RLock()
+	defer list.mutex.RUnlock()
+
+	for _, entry := range list.entries {
+		if entry.DSK.NWIHomeID() == homeID {
+			copied := *entry
+			return &copied
+		}
+	}
+	return nil
+}
+
+// SetNodeID records the node of the included device. goroutine safe.
+func (list *List) SetNodeID(dsk DSK, nodeID uint16) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	entry, ok := list.entries[dsk]
+	if !ok {
+		return fmt.Errorf("No entry for DSK: %s", dsk)
+	}
+	entry.NodeID = nodeID
+	return nil
+}
+
+// LoadJSON adds all entries from a JSON list of entries. goroutine safe.
+func (list *List) LoadJSON(reader io.Reader) error {
+	var entries []*Entry
+	if err := json.NewDecoder(reader).Decode(&entries); err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		if err := entry.validate(); err != nil {
+			return fmt.Errorf("Bad entry %d: %v", i, err)
+		}
+	}
+
+	for _, entry := range entries {
+		list.Add(entry)
+	}
+
+	return nil
+}
+
+// WriteJSON writes all entries as a JSON list of entries. goroutine safe.
+func (list *List) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list.GetEntries())
+}
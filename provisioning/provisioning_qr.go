@@ -0,0 +1,257 @@
+package provisioning
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// QR code version
+const (
+	QRVersionS2         uint8 = 0x00
+	QRVersionSmartStart       = 0x01
+)
+
+// QR code TLV types
+const (
+	qrTypeProductType                 uint8 = 0x00
+	qrTypeProductID                         = 0x01
+	qrTypeMaxInclusionRequestInterval       = 0x02
+	qrTypeUUID16                            = 0x03
+)
+
+// Length of the QR code fields before the TLVs, in digits:
+// | LEAD_IN (2) | VERSION (2) | CHECKSUM (5) | SECURITY_CLASSES (3) | DSK (40) |
+const qrHeaderLength = 52
+
+// QRProductType information
+type QRProductType struct {
+	GenericDeviceClass  uint8
+	SpecificDeviceClass uint8
+	InstallerIconType   uint16
+}
+
+// QRProductID information
+type QRProductID struct {
+	ManufacturerID     uint16
+	ProductType        uint16
+	ProductID          uint16
+	ApplicationVersion uint16 // Major in the high byte, minor in the low byte
+}
+
+// QRUUID16 information
+type QRUUID16 struct {
+	Format uint8 // Presentation format
+	UUID   [16]uint8
+}
+
+// QRCode information of a Z-Wave QR code. Optional fields are nil or zero
+// when they are not in the code.
+type QRCode struct {
+	Version                     uint8 // One of QRVersion
+	SecurityClasses             uint8 // Bit mask of SecurityClass
+	DSK                         DSK
+	ProductType                 *QRProductType
+	ProductID                   *QRProductID
+	MaxInclusionRequestInterval time.Duration
+	UUID16                      *QRUUID16
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// parseDigits parses count decimal digits at start in text, which must
+// not exceed max
+func parseDigits(text string, start int, count int, max uint64) (uint64, error) {
+	if start+count > len(text) {
+		return 0, fmt.Errorf("QR code too short: %d", len(text))
+	}
+	digits := text[start : start+count]
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("Bad QR code digits: %q", digits)
+		}
+	}
+	value, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil || value > max {
+		return 0, fmt.Errorf("Bad QR code value: %q", digits)
+	}
+	return value, nil
+}
+
+// parseUint16Blocks parses len(dst)/2 blocks of five decimal digits
+func parseUint16Blocks(text string, start int, dst []uint8) error {
+	for i := 0; i < len(dst)/2; i++ {
+		value, err := parseDigits(text, start+5*i, 5, 0xffff)
+		if err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint16(dst[2*i:], uint16(value))
+	}
+	return nil
+}
+
+// ParseQRCode parses the numeric payload of a Z-Wave QR code
+func ParseQRCode(text string) (*QRCode, error) {
+	if len(text) < qrHeaderLength {
+		return nil, fmt.Errorf("QR code too short: %d", len(text))
+	}
+	if text[0:2] != "90" {
+		return nil, fmt.Errorf("Bad QR code lead in: %q", text[0:2])
+	}
+
+	version, err := parseDigits(text, 2, 2, uint64(QRVersionSmartStart))
+	if err != nil {
+		return nil, err
+	}
+
+	// Checksum is the first two bytes of the SHA-1 of the rest of the text
+	checksum, err := parseDigits(text, 4, 5, 0xffff)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha1.Sum([]byte(text[9:]))
+	if expected := binary.BigEndian.Uint16(hash[:2]); uint16(checksum) != expected {
+		return nil, fmt.Errorf("Bad QR code checksum: %05d expected: %05d",
+			checksum, expected)
+	}
+
+	securityClasses, err := parseDigits(text, 9, 3, 0xff)
+	if err != nil {
+		return nil, err
+	}
+
+	code := QRCode{Version: uint8(version), SecurityClasses: uint8(securityClasses)}
+	if err := parseUint16Blocks(text, 12, code.DSK[:]); err != nil {
+		return nil, err
+	}
+
+	// TLV: | TYPE << 1 | CRITICAL (2) | LENGTH (2) | VALUE (LENGTH) |
+	for offset := qrHeaderLength; offset < len(text); {
+		typeCritical, err := parseDigits(text, offset, 2, 99)
+		if err != nil {
+			return nil, err
+		}
+		length, err := parseDigits(text, offset+2, 2, 99)
+		if err != nil {
+			return nil, err
+		}
+		start := offset + 4
+		if start+int(length) > len(text) {
+			return nil, fmt.Errorf("Bad QR code TLV length: %d", length)
+		}
+		offset = start + int(length)
+
+		tlvType := uint8(typeCritical >> 1)
+		critical := typeCritical&0x01 != 0
+
+		if err := code.parseTLV(tlvType, critical, text[:offset], start,
+			int(length)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &code, nil
+}
+
+// parseTLV parses the TLV value of length digits at start in text
+func (code *QRCode) parseTLV(tlvType uint8, critical bool, text string, start int,
+	length int) error {
+	expectedLength := map[uint8]int{
+		qrTypeProductType:                 10,
+		qrTypeProductID:                   20,
+		qrTypeMaxInclusionRequestInterval: 3,
+		qrTypeUUID16:                      42,
+	}
+
+	if expected, ok := expectedLength[tlvType]; !ok {
+		if critical {
+			return fmt.Errorf("Unsupported critical QR code TLV: 0x%02x", tlvType)
+		}
+		return nil
+	} else if length != expected {
+		return fmt.Errorf("Bad QR code TLV 0x%02x length: %d", tlvType, length)
+	}
+
+	switch tlvType {
+	case qrTypeProductType:
+		// | DEVICE_CLASS (5) | INSTALLER_ICON_TYPE (5) |
+		values := make([]uint8, 4)
+		if err := parseUint16Blocks(text, start, values); err != nil {
+			return err
+		}
+		code.ProductType = &QRProductType{GenericDeviceClass: values[0],
+			SpecificDeviceClass: values[1],
+			InstallerIconType:   binary.BigEndian.Uint16(values[2:])}
+
+	case qrTypeProductID:
+		// | MANUFACTURER_ID (5) | PRODUCT_TYPE (5) | PRODUCT_ID (5) |
+		// | APPLICATION_VERSION (5) |
+		values := make([]uint8, 8)
+		if err := parseUint16Blocks(text, start, values); err != nil {
+			return err
+		}
+		code.ProductID = &QRProductID{
+			ManufacturerID:     binary.BigEndian.Uint16(values[0:]),
+			ProductType:        binary.BigEndian.Uint16(values[2:]),
+			ProductID:          binary.BigEndian.Uint16(values[4:]),
+			ApplicationVersion: binary.BigEndian.Uint16(values[6:])}
+
+	case qrTypeMaxInclusionRequestInterval:
+		// | INTERVAL (3) | in steps of 128 seconds
+		interval, err := parseDigits(text, start, 3, 99)
+		if err != nil {
+			return err
+		}
+		if interval < 5 {
+			return fmt.Errorf("Bad QR code max inclusion request interval: %d", interval)
+		}
+		code.MaxInclusionRequestInterval = time.Duration(interval) * 128 * time.Second
+
+	case qrTypeUUID16:
+		// | FORMAT (2) | UUID (40) |
+		format, err := parseDigits(text, start, 2, 99)
+		if err != nil {
+			return err
+		}
+		uuid := QRUUID16{Format: uint8(format)}
+		if err := parseUint16Blocks(text, start+2, uuid.UUID[:]); err != nil {
+			return err
+		}
+		code.UUID16 = &uuid
+	}
+
+	return nil
+}
+
+// Entry returns a provisioning list entry of the device. SmartStart codes
+// are included automatically. Returns ErrSecurityNotSupported if the device
+// requests security classes.
+func (code *QRCode) Entry() (*Entry, error) {
+	entry := Entry{DSK: code.DSK, SecurityClasses: code.SecurityClasses,
+		BootMode: BootModeS2}
+	if code.Version == QRVersionSmartStart {
+		entry.BootMode = BootModeSmartStart
+	}
+	if err := entry.validate(); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
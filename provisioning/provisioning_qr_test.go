@@ -0,0 +1,115 @@
+package provisioning
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+const testQRCode = "9001327820035152535455414243444531323334352122232425" +
+	"00100435301537" + "022065520001000000300578"
+
+func TestParseQRCode(t *testing.T) {
+	code, err := ParseQRCode(testQRCode)
+	if code == nil || err != nil {
+		t.Errorf("Expected non nil code and nil error: %v %v", code, err)
+		t.FailNow()
+	}
+
+	if code.Version != QRVersionSmartStart ||
+		code.SecurityClasses != SecurityClassS2Unauthenticated|SecurityClassS2Authenticated {
+		t.Errorf("Unexpected code: %+v", code)
+	}
+	if dsk := code.DSK.String(); dsk != "51525-35455-41424-34445-31323-33435-21222-32425" {
+		t.Errorf("Unexpected DSK: %s", dsk)
+	}
+	if code.ProductType == nil || *code.ProductType != (QRProductType{
+		GenericDeviceClass: 0x11, SpecificDeviceClass: 0x01, InstallerIconType: 0x0601}) {
+		t.Errorf("Unexpected ProductType: %+v", code.ProductType)
+	}
+	if code.ProductID == nil || *code.ProductID != (QRProductID{ManufacturerID: 0xfff0,
+		ProductType: 0x0064, ProductID: 0x0003, ApplicationVersion: 0x0242}) {
+		t.Errorf("Unexpected ProductID: %+v", code.ProductID)
+	}
+	if code.MaxInclusionRequestInterval != 0 || code.UUID16 != nil {
+		t.Errorf("Unexpected optional fields: %+v", code)
+	}
+
+	// The code requests S2 security, which is not bootstrapped
+	if entry, err := code.Entry(); entry != nil || err != ErrSecurityNotSupported {
+		t.Errorf("Expected ErrSecurityNotSupported: %+v %v", entry, err)
+	}
+
+	code.SecurityClasses = 0
+	entry, err := code.Entry()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if entry.DSK != code.DSK || entry.BootMode != BootModeSmartStart ||
+		entry.SecurityClasses != 0 {
+		t.Errorf("Unexpected Entry: %+v", entry)
+	}
+}
+
+func TestParseQRCodeOptional(t *testing.T) {
+	code, err := ParseQRCode("9001348820035152535455414243444531323334352122232425" +
+		"0403010" + "0642030000100001000010000100001000010000100001")
+	if code == nil || err != nil {
+		t.Errorf("Expected non nil code and nil error: %v %v", code, err)
+		t.FailNow()
+	}
+
+	if code.MaxInclusionRequestInterval != 1280*time.Second {
+		t.Errorf("Unexpected MaxInclusionRequestInterval: %v",
+			code.MaxInclusionRequestInterval)
+	}
+	if code.UUID16 == nil || code.UUID16.Format != 3 || !bytes.Equal(code.UUID16.UUID[:],
+		[]uint8{0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1}) {
+		t.Errorf("Unexpected UUID16: %+v", code.UUID16)
+	}
+
+	// Unknown non critical TLV is skipped
+	if code, err := ParseQRCode("9001388320035152535455414243444531323334352122232425" +
+		"9803123"); code == nil || err != nil {
+		t.Errorf("Expected non nil code and nil error: %v %v", code, err)
+	}
+}
+
+func TestParseQRCodeErrors(t *testing.T) {
+	for _, text := range []string{
+		// Too short
+		testQRCode[:51],
+		// Bad lead in
+		"8" + testQRCode[1:],
+		// Bad checksum
+		testQRCode[:len(testQRCode)-1] + "9",
+		// Non digit
+		testQRCode[:len(testQRCode)-1] + "a",
+		// Truncated TLV
+		testQRCode[:len(testQRCode)-1],
+		// Unknown critical TLV
+		"9001080460035152535455414243444531323334352122232425" + "0403010" + "9903123",
+		// Max inclusion request interval too small
+		"9001288340035152535455414243444531323334352122232425" + "0403004",
+	} {
+		if code, err := ParseQRCode(text); code != nil || err == nil {
+			t.Errorf("Expected nil code and non nil error for %q: %v %v", text, code, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package provisioning
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"testing"
+)
+
+const testDSK = "51525-35455-41424-34445-31323-33435-21222-32425"
+
+func TestDSK(t *testing.T) {
+	dsk, err := ParseDSK(testDSK)
+	if err != nil {
+		t.Errorf("Expected nil error: %v", err)
+		t.FailNow()
+	}
+
+	if !bytes.Equal(dsk[:4], []uint8{0xc9, 0x45, 0x8a, 0x7f}) {
+		t.Errorf("Unexpected DSK: %v", dsk)
+	}
+	if dsk.String() != testDSK {
+		t.Errorf("Unexpected String: %s", dsk.String())
+	}
+	if homeID := dsk.NWIHomeID(); homeID != 0xfa5b829a {
+		t.Errorf("Unexpected NWIHomeID: 0x%08x", homeID)
+	}
+	if homeID := dsk.AuthHomeID(); homeID != 0x12e67ea9 {
+		t.Errorf("Unexpected AuthHomeID: 0x%08x", homeID)
+	}
+
+	for _, text := range []string{"", testDSK[:46], testDSK + "-12345",
+		"65536" + testDSK[5:], "1234a" + testDSK[5:]} {
+		if _, err := ParseDSK(text); err == nil {
+			t.Errorf("Expected non nil error for %q", text)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	dsk, _ := ParseDSK(testDSK)
+
+	list := List{}
+	if err := list.Add(&Entry{DSK: dsk, BootMode: 0x02}); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+	if err := list.Add(&Entry{DSK: dsk, BootMode: BootModeSmartStart,
+		SecurityClasses: SecurityClassS2Authenticated}); err != ErrSecurityNotSupported {
+		t.Errorf("Expected ErrSecurityNotSupported: %v", err)
+	}
+	if err := list.Add(&Entry{DSK: dsk, BootMode: BootModeSmartStart,
+		Name: "Lamp"}); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	if entry := list.LookupNWIHomeID(dsk.NWIHomeID()); entry == nil || entry.Name != "Lamp" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry := list.LookupNWIHomeID(0xc0000000); entry != nil {
+		t.Errorf("Expected nil entry: %+v", entry)
+	}
+
+	if err := list.SetNodeID(dsk, 0x05); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if err := list.SetNodeID(DSK{}, 0x05); err == nil {
+		t.Errorf("Expected non nil error")
+	}
+
+	var buffer bytes.Buffer
+	if err := list.WriteJSON(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	loaded := List{}
+	if err := loaded.LoadJSON(&buffer); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if entry := loaded.Get(dsk); entry == nil || entry.NodeID != 0x05 ||
+		entry.BootMode != BootModeSmartStart || entry.Name != "Lamp" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if !list.Remove(dsk) || list.Remove(dsk) || len(list.GetEntries()) != 0 {
+		t.Errorf("Unexpected Remove")
+	}
+}
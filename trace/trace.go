@@ -0,0 +1,213 @@
+// Package trace records the bytes exchanged with a ZWave controller in a
+// compact file format, and replays recorded traces. A trace starts with a
+// header of | MAGIC | VERSION | START_TIME |, followed by records of
+// | DIRECTION | TIME_DELTA | LENGTH | DATA |, where TIME_DELTA, in
+// microseconds since the previous record, and LENGTH are unsigned varints.
+package trace
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Direction of recorded bytes
+const (
+	DirectionRead  uint8 = 0x01 // Controller to host
+	DirectionWrite       = 0x02 // Host to controller
+)
+
+// Magic bytes at the start of a trace
+var traceMagic = []uint8{'Z', 'W', 'T', 'R', 'A', 'C', 'E'}
+
+// Version of the trace format
+const traceVersion uint8 = 0x01
+
+// Maximum length of recorded data, to reject corrupt traces
+const maxRecordLength = 1 << 16
+
+// Record of bytes read from or written to the controller
+type Record struct {
+	Time      time.Time
+	Direction uint8 // One of Direction
+	Data      []uint8
+}
+
+// Writer records bytes to a trace. Records are written through to the
+// underlying writer, so that a trace survives a crash.
+type Writer struct {
+	mutex sync.Mutex
+	w     io.Writer
+	last  time.Time // Time of the previous record
+	err   error     // First write error
+}
+
+// Reader reads records of a trace
+type Reader struct {
+	r    *bufio.Reader
+	last time.Time // Time of the previous record
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewWriter writes the trace header to w, and returns a Writer for records
+func NewWriter(w io.Writer) (*Writer, error) {
+	now := time.Now()
+
+	header := append([]uint8{}, traceMagic...)
+	header = append(header, traceVersion)
+	header = append(header, make([]uint8, 8)...)
+	binary.BigEndian.PutUint64(header[len(header)-8:], uint64(now.UnixNano()))
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, last: now}, nil
+}
+
+// Record the bytes of the direction. After the first write error, all records
+// are dropped and the error is returned. goroutine safe.
+func (writer *Writer) Record(direction uint8, data []uint8) error {
+	if direction != DirectionRead && direction != DirectionWrite {
+		return fmt.Errorf("Bad Direction: 0x%02x", direction)
+	}
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if writer.err != nil {
+		return writer.err
+	}
+
+	now := time.Now()
+	delta := now.Sub(writer.last).Truncate(time.Microsecond)
+	if delta < 0 {
+		delta = 0
+	}
+	writer.last = writer.last.Add(delta)
+
+	record := make([]uint8, 1+2*binary.MaxVarintLen64, 1+2*binary.MaxVarintLen64+len(data))
+	record[0] = direction
+	n := 1
+	n += binary.PutUvarint(record[n:], uint64(delta/time.Microsecond))
+	n += binary.PutUvarint(record[n:], uint64(len(data)))
+	record = append(record[:n], data...)
+
+	if _, err := writer.w.Write(record); err != nil {
+		writer.err = err
+		return err
+	}
+
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewReader reads the trace header from r, and returns a Reader for records
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+
+	header := make([]uint8, len(traceMagic)+1+8)
+	if _, err := io.ReadFull(reader.r, header); err != nil {
+		return nil, fmt.Errorf("Bad trace header: %v", err)
+	}
+
+	if !bytes.Equal(header[:len(traceMagic)], traceMagic) {
+		return nil, fmt.Errorf("Bad trace magic: %x", header[:len(traceMagic)])
+	}
+
+	if version := header[len(traceMagic)]; version != traceVersion {
+		return nil, fmt.Errorf("Bad trace version: %d != %d", version, traceVersion)
+	}
+
+	start := int64(binary.BigEndian.Uint64(header[len(traceMagic)+1:]))
+	reader.last = time.Unix(0, start)
+
+	return reader, nil
+}
+
+// Next returns the next record, io.EOF at the end of the trace, or
+// io.ErrUnexpectedEOF if the trace ends within a record
+func (reader *Reader) Next() (*Record, error) {
+	direction, err := reader.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if direction != DirectionRead && direction != DirectionWrite {
+		return nil, fmt.Errorf("Bad Direction: 0x%02x", direction)
+	}
+
+	delta, err := binary.ReadUvarint(reader.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	length, err := binary.ReadUvarint(reader.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	if length > maxRecordLength {
+		return nil, fmt.Errorf("Bad record length: %d > %d", length, maxRecordLength)
+	}
+
+	record := Record{Direction: direction, Data: make([]uint8, length)}
+	if _, err := io.ReadFull(reader.r, record.Data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	reader.last = reader.last.Add(time.Duration(delta) * time.Microsecond)
+	record.Time = reader.last
+
+	return &record, nil
+}
+
+// ReadAll reads all records of a trace. A trace cut short within a record, for
+// example by a crash, returns the complete records with io.ErrUnexpectedEOF.
+func ReadAll(r io.Reader) ([]*Record, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// unexpectedEOF converts io.EOF within a record to io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,282 @@
+package trace
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"github.com/cybojanek/gozwave/packet"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Time Read waits for the next recorded bytes, before reporting a timeout
+// with io.EOF, like a serial port
+const replayReadTimeout = (100 * time.Millisecond)
+
+// Replay plays back the controller side of a trace, as a controller.Port.
+// Recorded reads are returned by Read once the host wrote all the bytes
+// recorded before them, so responses follow their requests. Written bytes are
+// not compared with the trace, except for callback ids: the host picks random
+// callback ids, so recorded callbacks are rewritten to the ids of the replayed
+// requests. Recorded timing is ignored. goroutine safe.
+type Replay struct {
+	mutex       sync.Mutex
+	records     []*Record
+	read        int                       // Index of the record being read
+	readOffset  int                       // Offset into the record being read
+	write       int                       // Index of the next record to write
+	frame       replayFrame               // State of the frame being read
+	callbackIDs map[uint8]map[uint8]uint8 // MessageType: recorded: replayed
+	changed     chan struct{}             // Closed and replaced on progress
+	done        chan struct{}             // Closed when all records are replayed
+	closed      bool
+}
+
+// replayFrame tracks the position within a SOF frame being read, to rewrite
+// its callback id and checksum
+type replayFrame struct {
+	position    int   // 0 outside of a frame, 1 after SOF, ...
+	length      uint8 // Frame length
+	packetType  uint8
+	messageType uint8
+	checksum    uint8 // XOR of the original and rewritten bytes
+}
+
+// NewReplay of recorded records
+func NewReplay(records []*Record) *Replay {
+	replay := Replay{records: records,
+		callbackIDs: make(map[uint8]map[uint8]uint8),
+		changed:     make(chan struct{}),
+		done:        make(chan struct{})}
+
+	replay.read = replay.nextRecord(0, DirectionRead)
+	replay.write = replay.nextRecord(0, DirectionWrite)
+	replay.checkDone()
+
+	return &replay
+}
+
+// Done returns a channel, which is closed after all recorded bytes were read,
+// and all recorded writes were written
+func (replay *Replay) Done() <-chan struct{} {
+	return replay.done
+}
+
+// nextRecord returns the index of the first record of the direction starting
+// at index, or len(records) if there is none
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) nextRecord(index int, direction uint8) int {
+	for ; index < len(replay.records); index++ {
+		if replay.records[index].Direction == direction {
+			break
+		}
+	}
+	return index
+}
+
+// progress wakes up waiting readers, and checks if the replay is done
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) progress() {
+	close(replay.changed)
+	replay.changed = make(chan struct{})
+	replay.checkDone()
+}
+
+// checkDone closes the done channel after all records are replayed
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) checkDone() {
+	if replay.read < len(replay.records) || replay.write < len(replay.records) {
+		return
+	}
+
+	select {
+	case <-replay.done:
+	default:
+		close(replay.done)
+	}
+}
+
+// Read recorded bytes of the controller. Returns io.EOF if no bytes are
+// available before a timeout, and os.ErrClosed after Close.
+func (replay *Replay) Read(b []byte) (int, error) {
+	timeout := time.After(replayReadTimeout)
+
+	for {
+		replay.mutex.Lock()
+		if replay.closed {
+			replay.mutex.Unlock()
+			return 0, os.ErrClosed
+		}
+
+		// Wait for all writes recorded before the read
+		if replay.read < len(replay.records) && replay.write > replay.read {
+			n := replay.readRecord(b)
+			replay.mutex.Unlock()
+			return n, nil
+		}
+
+		changed := replay.changed
+		replay.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return 0, io.EOF
+		}
+	}
+}
+
+// readRecord copies bytes of the current read record into b
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) readRecord(b []byte) int {
+	data := replay.records[replay.read].Data[replay.readOffset:]
+
+	n := copy(b, data)
+	for i := range b[:n] {
+		b[i] = replay.rewrite(b[i])
+	}
+
+	replay.readOffset += n
+	if replay.readOffset >= len(replay.records[replay.read].Data) {
+		replay.readOffset = 0
+		replay.read = replay.nextRecord(replay.read+1, DirectionRead)
+		replay.progress()
+	}
+
+	return n
+}
+
+// rewrite a byte of a read frame. The callback id is the first body byte of
+// callback requests, and the XOR checksum is the last byte of the frame.
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) rewrite(b uint8) uint8 {
+	frame := &replay.frame
+
+	switch {
+	case frame.position == 0:
+		if b == packet.PacketPreambleSOF {
+			*frame = replayFrame{position: 1}
+		}
+		return b
+	case frame.position == 1:
+		frame.length = b
+	case frame.position == 2:
+		frame.packetType = b
+	case frame.position == 3:
+		frame.messageType = b
+	case frame.position == int(frame.length)+1:
+		// Checksum
+		frame.position = 0
+		return b ^ frame.checksum
+	case frame.position == 4 && frame.packetType == packet.PacketTypeRequest:
+		if replayed, ok := replay.callbackIDs[frame.messageType][b]; ok {
+			frame.checksum ^= b ^ replayed
+			b = replayed
+		}
+	}
+
+	frame.position++
+	if frame.length < 3 {
+		// Bad length, which the packet.Parser rejects
+		frame.position = 0
+	}
+	return b
+}
+
+// Write consumes the next recorded write. Any bytes are accepted, since the
+// host decides what to write. Returns os.ErrClosed after Close.
+func (replay *Replay) Write(b []byte) (int, error) {
+	replay.mutex.Lock()
+	defer replay.mutex.Unlock()
+
+	if replay.closed {
+		return 0, os.ErrClosed
+	}
+
+	if replay.write >= len(replay.records) {
+		return len(b), nil
+	}
+
+	replay.mapCallbackID(replay.records[replay.write].Data, b)
+
+	replay.write = replay.nextRecord(replay.write+1, DirectionWrite)
+	replay.progress()
+
+	return len(b), nil
+}
+
+// mapCallbackID compares a recorded and a replayed request frame, which
+// differ in a single body byte: the callback id
+// NOTE: not goroutine safe, caller must hold replay.mutex
+func (replay *Replay) mapCallbackID(recorded []uint8, replayed []uint8) {
+	if len(recorded) != len(replayed) || len(recorded) < 6 ||
+		recorded[0] != packet.PacketPreambleSOF || replayed[0] != packet.PacketPreambleSOF {
+		return
+	}
+
+	// | SOF | LENGTH | TYPE | MESSAGE_TYPE | BODY | CHECKSUM | (NEWLINE) |
+	length := int(recorded[1])
+	if length < 3 || length+2 > len(recorded) {
+		return
+	}
+
+	for i := 0; i < 4; i++ {
+		if recorded[i] != replayed[i] {
+			return
+		}
+	}
+
+	differences := 0
+	var from, to uint8
+	for i := 4; i < length+1; i++ {
+		if recorded[i] != replayed[i] {
+			differences++
+			from, to = recorded[i], replayed[i]
+		}
+	}
+
+	if differences != 1 {
+		return
+	}
+
+	messageType := recorded[3]
+	if replay.callbackIDs[messageType] == nil {
+		replay.callbackIDs[messageType] = make(map[uint8]uint8)
+	}
+	replay.callbackIDs[messageType][from] = to
+}
+
+// Flush does nothing, since there is no buffered input
+func (replay *Replay) Flush() error {
+	return nil
+}
+
+// Close the replay, further reads and writes return os.ErrClosed, so that a
+// controller reading it sees a fatal error instead of a timeout
+func (replay *Replay) Close() error {
+	replay.mutex.Lock()
+	defer replay.mutex.Unlock()
+
+	if !replay.closed {
+		replay.closed = true
+		// Wake up waiting readers
+		close(replay.changed)
+		replay.changed = make(chan struct{})
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package trace
+
+/*
+Copyright (C) 2017 Jan Kasiak
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"bytes"
+	"github.com/cybojanek/gozwave/packet"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// testFrame returns the bytes of a SOF packet
+func testFrame(t *testing.T, packetType uint8, messageType uint8, body ...uint8) []uint8 {
+	p := packet.Packet{Preamble: packet.PacketPreambleSOF,
+		PacketType: packetType, MessageType: messageType, Body: body}
+	if err := p.Update(); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	b, err := p.Bytes()
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	return b
+}
+
+func TestTrace(t *testing.T) {
+	var buffer bytes.Buffer
+
+	writer, err := NewWriter(&buffer)
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	expected := []*Record{
+		{Direction: DirectionWrite, Data: []uint8{0x15, '\n'}},
+		{Direction: DirectionRead, Data: []uint8{0x06}},
+		{Direction: DirectionRead, Data: []uint8{}},
+		{Direction: DirectionRead, Data: make([]uint8, 300)},
+	}
+
+	// Length of the trace after each record
+	boundaries := map[int]int{buffer.Len(): 0}
+
+	start := time.Now()
+	for i, record := range expected {
+		if err := writer.Record(record.Direction, record.Data); err != nil {
+			t.Errorf("Expected nil error: %v", err)
+		}
+		boundaries[buffer.Len()] = i + 1
+	}
+	end := time.Now()
+
+	if err := writer.Record(0x03, []uint8{0x06}); err == nil {
+		t.Errorf("Expected non nil error for bad Direction")
+	}
+
+	records, err := ReadAll(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records got %d", len(expected), len(records))
+	}
+
+	last := start.Add(-time.Microsecond)
+	for i, record := range records {
+		if record.Direction != expected[i].Direction {
+			t.Errorf("Expected Direction 0x%02x got 0x%02x",
+				expected[i].Direction, record.Direction)
+		}
+		if !bytes.Equal(record.Data, expected[i].Data) {
+			t.Errorf("Expected Data %v got %v", expected[i].Data, record.Data)
+		}
+		if record.Time.Before(last) || record.Time.After(end) {
+			t.Errorf("Unexpected Time %v not within %v and %v", record.Time, last, end)
+		}
+		last = record.Time
+	}
+
+	// Truncated records
+	complete := 0
+	for i := len(traceMagic) + 9; i < buffer.Len(); i++ {
+		records, err := ReadAll(bytes.NewReader(buffer.Bytes()[:i]))
+		if n, ok := boundaries[i]; ok {
+			complete = n
+			if err != nil {
+				t.Errorf("Expected nil error for length %d: %v", i, err)
+			}
+		} else if err != io.ErrUnexpectedEOF {
+			t.Errorf("Expected io.ErrUnexpectedEOF for length %d: %v", i, err)
+		}
+		if len(records) != complete {
+			t.Errorf("Expected %d records for length %d got %d", complete, i, len(records))
+		}
+	}
+}
+
+func TestTraceBadHeader(t *testing.T) {
+	var buffer bytes.Buffer
+
+	if _, err := NewWriter(&buffer); err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	header := buffer.Bytes()
+
+	badMagic := append([]uint8{}, header...)
+	badMagic[0] = 'X'
+
+	badVersion := append([]uint8{}, header...)
+	badVersion[len(traceMagic)] = traceVersion + 1
+
+	for _, b := range [][]uint8{badMagic, badVersion, header[:len(header)-1], {}} {
+		if _, err := NewReader(bytes.NewReader(b)); err == nil {
+			t.Errorf("Expected non nil error for header %v", b)
+		}
+	}
+
+	reader, err := NewReader(bytes.NewReader(header))
+	if err != nil {
+		t.Fatalf("Expected nil error: %v", err)
+	}
+	if record, err := reader.Next(); record != nil || err != io.EOF {
+		t.Errorf("Expected nil record: %v and io.EOF: %v", record, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	// ZWSendData to node 2 with recorded callback id 0x20
+	recordedRequest := testFrame(t, packet.PacketTypeRequest, 0x13,
+		0x02, 0x02, 0x25, 0x02, 0x25, 0x20)
+	replayedRequest := testFrame(t, packet.PacketTypeRequest, 0x13,
+		0x02, 0x02, 0x25, 0x02, 0x25, 0x42)
+	recordedCallback := testFrame(t, packet.PacketTypeRequest, 0x13, 0x20, 0x00)
+	expectedCallback := testFrame(t, packet.PacketTypeRequest, 0x13, 0x42, 0x00)
+
+	replay := NewReplay([]*Record{
+		{Direction: DirectionWrite, Data: append(recordedRequest, '\n')},
+		{Direction: DirectionRead, Data: []uint8{0x06}},
+		{Direction: DirectionRead, Data: recordedCallback},
+		{Direction: DirectionWrite, Data: []uint8{0x06, '\n'}},
+	})
+
+	buffer := make([]uint8, 64)
+
+	// Reads wait for the request
+	if n, err := replay.Read(buffer); n != 0 || err != io.EOF {
+		t.Errorf("Expected 0 bytes: %d and io.EOF: %v", n, err)
+	}
+
+	if n, err := replay.Write(append(replayedRequest, '\n')); err != nil ||
+		n != len(replayedRequest)+1 {
+		t.Errorf("Expected %d bytes: %d and nil error: %v",
+			len(replayedRequest)+1, n, err)
+	}
+
+	var actual []uint8
+	for len(actual) < 1+len(expectedCallback) {
+		// Read one byte at a time, to rewrite frames split across reads
+		n, err := replay.Read(buffer[:1])
+		if err != nil {
+			t.Fatalf("Expected nil error: %v", err)
+		}
+		actual = append(actual, buffer[:n]...)
+	}
+
+	if expected := append([]uint8{0x06}, expectedCallback...); !bytes.Equal(expected, actual) {
+		t.Errorf("Expected %v got %v", expected, actual)
+	}
+
+	select {
+	case <-replay.Done():
+		t.Errorf("Expected replay not done")
+	default:
+	}
+
+	if _, err := replay.Write([]uint8{0x06, '\n'}); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	select {
+	case <-replay.Done():
+	case <-time.After(time.Second):
+		t.Errorf("Expected replay done")
+	}
+
+	if err := replay.Close(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+	if n, err := replay.Read(buffer); n != 0 || err != os.ErrClosed {
+		t.Errorf("Expected 0 bytes: %d and os.ErrClosed: %v", n, err)
+	}
+	if n, err := replay.Write([]uint8{0x06}); n != 0 || err != os.ErrClosed {
+		t.Errorf("Expected 0 bytes: %d and os.ErrClosed: %v", n, err)
+	}
+}
+
+func TestReplayCloseWakesRead(t *testing.T) {
+	replay := NewReplay([]*Record{
+		{Direction: DirectionWrite, Data: []uint8{0x06}},
+		{Direction: DirectionRead, Data: []uint8{0x06}},
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := replay.Read(make([]uint8, 1))
+		result <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if err := replay.Close(); err != nil {
+		t.Errorf("Expected nil error: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != os.ErrClosed {
+			t.Errorf("Expected os.ErrClosed: %v", err)
+		}
+	case <-time.After(replayReadTimeout / 2):
+		t.Errorf("Expected Read to return after Close")
+	}
+}